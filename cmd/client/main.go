@@ -12,11 +12,14 @@ import (
 	"github.com/denistakeda/mpass/internal/grpc_client"
 	"github.com/denistakeda/mpass/internal/printer"
 	"github.com/denistakeda/mpass/internal/scanner"
+	"github.com/denistakeda/mpass/internal/tls_config"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
 )
 
 func main() {
 	homeFolder := fmt.Sprintf("%s/.mpass/", os.Getenv("HOME"))
-	statePath := fmt.Sprintf("%s/state.gob", homeFolder)
+	statePath := fmt.Sprintf("%s/state.db", homeFolder)
 	configPath := fmt.Sprintf("%s/config.json", homeFolder)
 
 	conf, err := config.ParseClientCfg(configPath)
@@ -24,16 +27,22 @@ func main() {
 		log.Fatal(err)
 	}
 
-	grpcClient := grpc_client.New(conf.Address)
-	defer grpcClient.Close()
-
-	clientStorage := client_storage.NewInMemory(statePath)
-	defer clientStorage.Close()
+	creds, err := transportCredentials(conf)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	clientService := client_service.New(clientStorage, grpcClient)
+	grpcClient := grpc_client.New(conf.Address, creds)
+	defer grpcClient.Close()
 
 	printer := printer.New(os.Stdout, os.Stderr)
 	scanner := scanner.New(os.Stdin)
+	masterPassword := client.NewMasterPasswordReader(printer, scanner)
+
+	clientStorage := client_storage.NewWithSQLite(statePath, masterPassword)
+	defer clientStorage.Close()
+
+	clientService := client_service.New(clientStorage, grpcClient, masterPassword)
 
 	c := client.New(client.NewClientParams{
 		Printer:       printer,
@@ -45,3 +54,22 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+func transportCredentials(conf config.ClientCfg) (credentials.TransportCredentials, error) {
+	if conf.Insecure {
+		return insecure.NewCredentials(), nil
+	}
+
+	tlsConf, err := tls_config.ClientConfig(tls_config.ClientParams{
+		CAFile:      conf.CACert,
+		Fingerprint: conf.CertFingerprint,
+		ServerName:  conf.ServerName,
+		CertFile:    conf.ClientCert,
+		KeyFile:     conf.ClientKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return credentials.NewTLS(tlsConf), nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/denistakeda/mpass/internal/config"
+	"github.com/denistakeda/mpass/internal/logging"
+	"github.com/pkg/errors"
+)
+
+// backup writes the users and their vaults to the archive, either to the file or to the backup directory:
+//
+//	mpass-server backup -config config/default.json -out mpass.tar.gz
+func backup(args []string) error {
+	flags := flag.NewFlagSet("backup", flag.ContinueOnError)
+	out := flags.String("out", "", "File to write the backup to, \"-\" for stdout, the backup directory is used by default")
+	conf, err := config.ParseServerCfg(flags, args)
+	if err != nil {
+		return err
+	}
+
+	service := newBackupService(conf)
+	ctx := context.Background()
+
+	if *out == "" {
+		path, summary, err := service.BackupToDir(ctx)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "%d users with %d records were backed up to %q\n", summary.Users, summary.Records, path)
+		return nil
+	}
+
+	var w io.WriteCloser = os.Stdout
+	if *out != "-" {
+		// the existing backups are never overwritten
+		w, err = os.OpenFile(*out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create backup %q", *out)
+		}
+	}
+
+	summary, err := service.Backup(ctx, w)
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		if *out != "-" {
+			os.Remove(*out)
+		}
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "%d users with %d records were backed up to %q\n", summary.Users, summary.Records, *out)
+
+	return nil
+}
+
+// restore replaces the vaults of the users found in the backup, the other users are not touched.
+// It is better to stop the server while the backup is restored:
+//
+//	mpass-server restore -config config/default.json mpass.tar.gz
+func restore(args []string) error {
+	flags := flag.NewFlagSet("restore", flag.ContinueOnError)
+	yes := flags.Bool("yes", false, "Do not ask for the confirmation")
+	conf, err := config.ParseServerCfg(flags, args)
+	if err != nil {
+		return err
+	}
+
+	path := flags.Arg(0)
+	if path == "" {
+		return errors.New("the backup file was not provided")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open backup %q", path)
+	}
+	defer file.Close()
+
+	if !*yes {
+		fmt.Fprintf(os.Stderr, "the vaults of the users from %q will be replaced, the changes made since the backup are lost, continue? [y/N]: ", path)
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			return errors.New("restore was cancelled")
+		}
+	}
+
+	summary, err := newBackupService(conf).Restore(context.Background(), file)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "%d users with %d records were restored from the backup made at %s\n",
+		summary.Users, summary.Records, summary.CreatedAt.Format("2006-01-02 15:04:05 MST"))
+
+	return nil
+}
+
+func newBackupService(conf config.Config) backupService {
+	logService := logging.New()
+	stores := makeStores(logService.ComponentLogger("main"), conf, false)
+
+	return buildBackupService(conf, logService, stores)
+}
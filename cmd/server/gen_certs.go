@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/denistakeda/mpass/internal/tls_config"
+)
+
+// genCerts generates the self-signed certificates for development setups:
+//
+//	mpass-server gen-certs -dir certs -hosts localhost,127.0.0.1
+func genCerts(args []string) error {
+	flags := flag.NewFlagSet("gen-certs", flag.ContinueOnError)
+	dir := flags.String("dir", "certs", "Directory to write the certificates to")
+	hosts := flags.String("hosts", "localhost,127.0.0.1", "Comma separated host names and IP addresses of the server")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	certs, err := tls_config.GenerateCerts(*dir, strings.Split(*hosts, ","))
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Certificates were written to %q\n\n", *dir)
+	fmt.Printf("Server configuration:\n")
+	fmt.Printf("  \"tls_cert\": %q,\n  \"tls_key\": %q,\n  \"tls_client_ca\": %q\n\n", certs.ServerCert, certs.ServerKey, certs.CACert)
+	fmt.Printf("Client configuration:\n")
+	fmt.Printf("  \"ca_cert\": %q,\n  \"client_cert\": %q,\n  \"client_key\": %q\n\n", certs.CACert, certs.ClientCert, certs.ClientKey)
+	fmt.Printf("Server certificate fingerprint (for \"cert_fingerprint\"):\n  %s\n", certs.ServerFingerprint)
+
+	return nil
+}
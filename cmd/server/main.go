@@ -1,20 +1,33 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"io"
+	"log"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/denistakeda/mpass/internal/auth_service"
+	"github.com/denistakeda/mpass/internal/backup_service"
+	"github.com/denistakeda/mpass/internal/blob_store"
 	"github.com/denistakeda/mpass/internal/config"
 	"github.com/denistakeda/mpass/internal/db"
 	"github.com/denistakeda/mpass/internal/logging"
+	"github.com/denistakeda/mpass/internal/password_hasher"
 	"github.com/denistakeda/mpass/internal/ports"
+	"github.com/denistakeda/mpass/internal/rate_limiter"
 	"github.com/denistakeda/mpass/internal/record_service"
 	"github.com/denistakeda/mpass/internal/record_store"
 	"github.com/denistakeda/mpass/internal/server"
+	"github.com/denistakeda/mpass/internal/tls_config"
+	"github.com/denistakeda/mpass/internal/token_store"
+	"github.com/denistakeda/mpass/internal/upload_store"
 	"github.com/denistakeda/mpass/internal/user_store"
 	"github.com/rs/zerolog"
+	"google.golang.org/grpc/credentials"
 )
 
 type (
@@ -23,13 +36,42 @@ type (
 		Stop()
 		Host() string
 	}
+
+	backupService interface {
+		Backup(ctx context.Context, w io.Writer) (backup_service.Summary, error)
+		Restore(ctx context.Context, r io.ReadSeeker) (backup_service.Summary, error)
+		BackupToDir(ctx context.Context) (string, backup_service.Summary, error)
+		Run(ctx context.Context, interval time.Duration)
+	}
 )
 
 func main() {
+	var command string
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
+
+	var err error
+	switch command {
+	case "gen-certs":
+		err = genCerts(os.Args[2:])
+	case "backup":
+		err = backup(os.Args[2:])
+	case "restore":
+		err = restore(os.Args[2:])
+	default:
+		serve()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func serve() {
 	logService := logging.New()
 	logger := logService.ComponentLogger("main")
 
-	conf, err := config.ParseServerCfg()
+	conf, err := config.ParseServerCfg(flag.CommandLine, os.Args[1:])
 	if err != nil {
 		logger.Fatal().Err(err).Msg("failed to read the configuration")
 	}
@@ -37,13 +79,24 @@ func main() {
 
 	interruptChan := handleInterrupt()
 
+	stores := makeStores(logger, conf, false)
+
 	srv := buildServer(buildParams{
 		conf:       conf,
 		logService: logService,
+		stores:     &stores,
 	})
 	serverErrors := srv.Start()
 	defer srv.Stop()
 
+	if conf.BackupInterval.Duration > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go buildBackupService(conf, logService, stores).Run(ctx, conf.BackupInterval.Duration)
+		logger.Info().Msgf("the server is backed up to %q every %s", conf.BackupDir, conf.BackupInterval.Duration)
+	}
+
 	select {
 	case serverError := <-serverErrors:
 		logger.Error().Err(serverError).Msg("server error")
@@ -56,45 +109,164 @@ type buildParams struct {
 	conf                config.Config
 	logService          ports.LogService
 	useInMemoryStorages bool
+	// stores are made from the configuration unless they are provided
+	stores *stores
 }
 
 func buildServer(params buildParams) srv {
 	logger := params.logService.ComponentLogger("buildServer")
 
 	// Stores
-	userStore, recordStore := makeStores(logger, params.conf.DatabaseURI, params.useInMemoryStorages)
+	var stores stores
+	if params.stores != nil {
+		stores = *params.stores
+	} else {
+		stores = makeStores(logger, params.conf, params.useInMemoryStorages)
+	}
 
 	// Services
+	passwordHasher, err := password_hasher.New(password_hasher.Options{
+		Algorithm: params.conf.PasswordHash,
+		Argon2id: password_hasher.Argon2idParams{
+			Time:    params.conf.Argon2Time,
+			Memory:  params.conf.Argon2Memory,
+			Threads: params.conf.Argon2Threads,
+		},
+		BcryptCost: params.conf.BcryptCost,
+	})
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to configure password hashing")
+	}
+
 	authService := auth_service.New(auth_service.NewAuthServiceParams{
-		Secret: params.conf.Secret,
+		Secret:          params.conf.Secret,
+		AccessTokenTTL:  params.conf.AccessTokenTTL.Duration,
+		RefreshTokenTTL: params.conf.RefreshTokenTTL.Duration,
+		Lockout: auth_service.Lockout{
+			Threshold:   params.conf.LockoutThreshold,
+			Duration:    params.conf.LockoutDuration.Duration,
+			MaxDuration: params.conf.MaxLockoutDuration.Duration,
+		},
 
-		LogService: params.logService,
-		UserStore:  userStore,
+		LogService:     params.logService,
+		UserStore:      stores.user,
+		TokenStore:     stores.token,
+		PasswordHasher: passwordHasher,
 	})
 
-	recordService := record_service.New(params.logService, recordStore)
+	recordService := record_service.New(
+		params.logService,
+		stores.record,
+		stores.upload,
+		stores.user,
+		params.conf.TombstoneRetention.Duration,
+		params.conf.HistoryRetention,
+	)
+
+	creds, err := transportCredentials(params.conf)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to configure TLS")
+	}
 
 	// One ring to rule them all
 	s := server.New(server.NewServerParams{
 		Host:          params.conf.Host,
+		Credentials:   creds,
 		LogService:    params.logService,
 		AuthService:   authService,
 		RecordService: recordService,
+
+		SignInLoginLimiter: rate_limiter.New(params.conf.SignInRatePerLogin.Count, params.conf.SignInRatePerLogin.Per),
+		SignInIPLimiter:    rate_limiter.New(params.conf.SignInRatePerIP.Count, params.conf.SignInRatePerIP.Per),
+		SignUpIPLimiter:    rate_limiter.New(params.conf.SignUpRatePerIP.Count, params.conf.SignUpRatePerIP.Per),
 	})
 
 	return s
 }
 
-func makeStores(logger zerolog.Logger, databaseURI string, inMemory bool) (ports.UserStore, ports.RecordStore) {
+func buildBackupService(conf config.Config, logService ports.LogService, stores stores) backupService {
+	return backup_service.New(backup_service.NewBackupServiceParams{
+		Dir:       conf.BackupDir,
+		Retention: conf.BackupRetention,
+
+		LogService:  logService,
+		UserStore:   stores.user,
+		RecordStore: stores.record,
+	})
+}
+
+func transportCredentials(conf config.Config) (credentials.TransportCredentials, error) {
+	if conf.TLSCert == "" && conf.TLSKey == "" {
+		return nil, nil
+	}
+
+	tlsConf, err := tls_config.ServerConfig(tls_config.ServerParams{
+		CertFile:     conf.TLSCert,
+		KeyFile:      conf.TLSKey,
+		ClientCAFile: conf.TLSClientCA,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return credentials.NewTLS(tlsConf), nil
+}
+
+type stores struct {
+	user   ports.UserStore
+	token  ports.TokenStore
+	record ports.RecordStore
+	upload ports.UploadStore
+}
+
+func makeStores(logger zerolog.Logger, conf config.Config, inMemory bool) stores {
 	if inMemory {
-		return user_store.NewInMemory(), record_store.NewInMemory()
-	} else {
-		db, err := db.NewDB(databaseURI, "file://migrations")
+		return stores{
+			user:   user_store.NewInMemory(),
+			token:  token_store.NewInMemory(),
+			record: record_store.NewInMemory(),
+			upload: upload_store.NewInMemory(),
+		}
+	}
+
+	dialect, err := db.Dialect(conf.DatabaseURI)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to initiate database")
+	}
+
+	blobStorage := conf.BlobStorage
+	if blobStorage == "" {
+		blobStorage = "blobs"
+	}
+	blobs, err := blob_store.New(blobStorage, conf.S3AccessKey, conf.S3SecretKey)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to initiate blob storage")
+	}
+
+	if dialect == db.SQLite {
+		db, err := db.NewSQLite(conf.DatabaseURI, "file://migrations/sqlite")
 		if err != nil {
 			logger.Fatal().Err(err).Msg("failed to initiate database")
 		}
 
-		return user_store.NewWithDB(db), record_store.NewWithDb(db)
+		return stores{
+			user:   user_store.NewWithDB(db),
+			token:  token_store.NewWithDB(db),
+			record: record_store.NewWithSQLite(db, blobs),
+			upload: upload_store.NewWithDB(db),
+		}
+	}
+
+	db, err := db.NewDB(conf.DatabaseURI, "file://migrations")
+	if err != nil {
+		logger.Fatal().Err(err).Msg("failed to initiate database")
+	}
+
+	return stores{
+		user:   user_store.NewWithDB(db),
+		token:  token_store.NewWithDB(db),
+		record: record_store.NewWithDb(db, blobs),
+		upload: upload_store.NewWithDB(db),
 	}
 }
 
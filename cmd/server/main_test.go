@@ -2,22 +2,30 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
 	"fmt"
+	"hash/crc32"
+	"io"
 	"testing"
 	"time"
 
 	"github.com/denistakeda/mpass/internal/config"
 	"github.com/denistakeda/mpass/internal/domain/record"
 	"github.com/denistakeda/mpass/internal/logging"
+	"github.com/denistakeda/mpass/internal/tls_config"
 	"github.com/denistakeda/mpass/proto"
 	empty "github.com/golang/protobuf/ptypes/empty"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	protobuf "google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -38,8 +46,8 @@ var (
 		Id:             "2",
 		LastUpdateDate: timestamppb.Now(),
 
-		Record: &proto.Record_TextRecord{
-			TextRecord: &proto.TextRecord{Text: "just a text"},
+		Record: &proto.Record_EncryptedRecord{
+			EncryptedRecord: &proto.EncryptedRecord{Payload: []byte("text ciphertext")},
 		},
 	}
 
@@ -47,8 +55,17 @@ var (
 		Id:             "3",
 		LastUpdateDate: timestamppb.Now(),
 
-		Record: &proto.Record_BinaryRecord{
-			BinaryRecord: &proto.BinaryRecord{Binary: []byte("binary text")},
+		Record: &proto.Record_EncryptedRecord{
+			EncryptedRecord: &proto.EncryptedRecord{Payload: []byte("binary ciphertext")},
+		},
+	}
+
+	encryptedRecord = &proto.Record{
+		Id:             "5",
+		LastUpdateDate: timestamppb.Now(),
+
+		Record: &proto.Record_EncryptedRecord{
+			EncryptedRecord: &proto.EncryptedRecord{Payload: []byte("ciphertext")},
 		},
 	}
 
@@ -112,6 +129,34 @@ func Test_SignIn(t *testing.T) {
 	})
 }
 
+func Test_RefreshToken(t *testing.T) {
+	serverTest(t, "refresh and logout", func(t *testing.T, c proto.MpassServiceClient) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		signUpResp, err := c.SignUp(ctx, &proto.SignUpRequest{Login: "login", Password: "password", Device: "laptop"})
+		require.NoError(t, err, "should successfully create a user")
+		require.NotEmpty(t, signUpResp.RefreshToken)
+
+		refreshResp, err := c.RefreshToken(ctx, &proto.RefreshTokenRequest{RefreshToken: signUpResp.RefreshToken})
+		require.NoError(t, err, "should successfully refresh the token")
+		assert.NotEmpty(t, refreshResp.Token)
+
+		md := metadata.New(map[string]string{"authorization": fmt.Sprintf("Bearer %s", refreshResp.Token)})
+		_, err = c.GetVaultKey(metadata.NewOutgoingContext(ctx, md), &empty.Empty{})
+		assert.NoError(t, err, "refreshed token should be accepted")
+
+		_, err = c.RefreshToken(ctx, &proto.RefreshTokenRequest{RefreshToken: signUpResp.RefreshToken})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err), "used refresh token should be rejected")
+
+		_, err = c.Logout(ctx, &proto.LogoutRequest{RefreshToken: refreshResp.RefreshToken})
+		require.NoError(t, err, "should successfully logout")
+
+		_, err = c.RefreshToken(ctx, &proto.RefreshTokenRequest{RefreshToken: refreshResp.RefreshToken})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err), "revoked refresh token should be rejected")
+	})
+}
+
 func Test_AddRecords(t *testing.T) {
 	serverTest(t, "should require authentication", func(t *testing.T, c proto.MpassServiceClient) {
 		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
@@ -136,12 +181,48 @@ func Test_AddRecords(t *testing.T) {
 
 		_, err := c.AddRecords(ctx, &proto.AddRecordsRequest{
 			Records: []*proto.Record{
-				loginPasswordRecord, textRecord, binaryRecord, bankCardRecord,
+				textRecord, binaryRecord, encryptedRecord,
 			},
 		})
 
 		assert.NoError(t, err)
 	})
+
+	serverTest(t, "reject plaintext records", func(t *testing.T, c proto.MpassServiceClient) {
+		ctx := authorisedContext(t, c, "login", "password")
+
+		for _, rec := range []*proto.Record{loginPasswordRecord, bankCardRecord} {
+			_, err := c.AddRecords(ctx, &proto.AddRecordsRequest{Records: []*proto.Record{textRecord, rec}})
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		}
+
+		all, err := c.AllRecords(ctx, &empty.Empty{})
+		require.NoError(t, err)
+		assert.Empty(t, all.Records, "nothing is stored from the rejected request")
+	})
+
+	serverTest(t, "report concurrent edits", func(t *testing.T, c proto.MpassServiceClient) {
+		ctx := authorisedContext(t, c, "login", "password")
+
+		resp, err := c.AddRecords(ctx, &proto.AddRecordsRequest{Records: []*proto.Record{textRecord}})
+		require.NoError(t, err)
+		assert.Empty(t, resp.Conflicts)
+
+		changes, err := c.GetChangesSince(ctx, &proto.GetChangesSinceRequest{})
+		require.NoError(t, err)
+		require.Len(t, changes.Records, 1)
+
+		edit := protobuf.Clone(textRecord).(*proto.Record)
+		edit.Revision = changes.Records[0].Revision
+		resp, err = c.AddRecords(ctx, &proto.AddRecordsRequest{Records: []*proto.Record{edit}})
+		require.NoError(t, err)
+		assert.Empty(t, resp.Conflicts, "edit of the latest revision should be accepted")
+
+		// the same revision is stale now
+		resp, err = c.AddRecords(ctx, &proto.AddRecordsRequest{Records: []*proto.Record{edit}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{textRecord.Id}, resp.Conflicts)
+	})
 }
 
 func Test_AllRecords(t *testing.T) {
@@ -165,7 +246,7 @@ func Test_AllRecords(t *testing.T) {
 		ctx := authorisedContext(t, c, "login", "password")
 
 		records := []*proto.Record{
-			loginPasswordRecord, textRecord, binaryRecord, bankCardRecord,
+			textRecord, binaryRecord, encryptedRecord,
 		}
 
 		_, err := c.AddRecords(ctx, &proto.AddRecordsRequest{
@@ -182,11 +263,380 @@ func Test_AllRecords(t *testing.T) {
 	})
 }
 
+func Test_GetChangesSince(t *testing.T) {
+	serverTest(t, "should require authentication", func(t *testing.T, c proto.MpassServiceClient) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		_, err := c.GetChangesSince(ctx, &proto.GetChangesSinceRequest{})
+
+		assert.Error(t, err)
+		if err != nil {
+			e, ok := status.FromError(err)
+			assert.True(t, ok, "should return error with a status")
+			if ok {
+				assert.Equalf(t, codes.Unauthenticated, e.Code(), "should return Unauthenticated status code")
+			}
+		}
+	})
+
+	serverTest(t, "get only the changed records", func(t *testing.T, c proto.MpassServiceClient) {
+		ctx := authorisedContext(t, c, "login", "password")
+
+		_, err := c.AddRecords(ctx, &proto.AddRecordsRequest{
+			Records: []*proto.Record{textRecord},
+		})
+		assert.NoError(t, err)
+
+		resp, err := c.GetChangesSince(ctx, &proto.GetChangesSinceRequest{Revision: 0})
+		assert.NoError(t, err)
+		assert.Len(t, resp.Records, 1)
+
+		revision := resp.Revision
+
+		_, err = c.AddRecords(ctx, &proto.AddRecordsRequest{
+			Records: []*proto.Record{binaryRecord},
+		})
+		assert.NoError(t, err)
+
+		_, err = c.DeleteRecords(ctx, &proto.DeleteRecordsRequest{
+			Tombstones: []*proto.Tombstone{{Id: textRecord.Id, DeletionDate: timestamppb.Now()}},
+		})
+		assert.NoError(t, err)
+
+		resp, err = c.GetChangesSince(ctx, &proto.GetChangesSinceRequest{Revision: revision})
+		assert.NoError(t, err)
+		if assert.Len(t, resp.Records, 1) {
+			assert.Equal(t, binaryRecord.Id, resp.Records[0].Id)
+		}
+		if assert.Len(t, resp.Tombstones, 1) {
+			assert.Equal(t, textRecord.Id, resp.Tombstones[0].Id)
+		}
+		assert.Greater(t, resp.Revision, revision)
+	})
+}
+
+func Test_DeleteRecords(t *testing.T) {
+	serverTest(t, "should require authentication", func(t *testing.T, c proto.MpassServiceClient) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		_, err := c.DeleteRecords(ctx, &proto.DeleteRecordsRequest{})
+
+		assert.Error(t, err)
+		if err != nil {
+			e, ok := status.FromError(err)
+			assert.True(t, ok, "should return error with a status")
+			if ok {
+				assert.Equalf(t, codes.Unauthenticated, e.Code(), "should return Unauthenticated status code")
+			}
+		}
+	})
+
+	serverTest(t, "delete a record", func(t *testing.T, c proto.MpassServiceClient) {
+		ctx := authorisedContext(t, c, "login", "password")
+
+		_, err := c.AddRecords(ctx, &proto.AddRecordsRequest{
+			Records: []*proto.Record{textRecord, binaryRecord},
+		})
+		assert.NoError(t, err)
+
+		_, err = c.DeleteRecords(ctx, &proto.DeleteRecordsRequest{
+			Tombstones: []*proto.Tombstone{{Id: textRecord.Id, DeletionDate: timestamppb.Now()}},
+		})
+		assert.NoError(t, err)
+
+		resp, err := c.AllRecords(ctx, &empty.Empty{})
+		assert.NoError(t, err)
+		if assert.Len(t, resp.Records, 1) {
+			assert.Equal(t, binaryRecord.Id, resp.Records[0].Id)
+		}
+	})
+
+	serverTest(t, "purge a record with its history", func(t *testing.T, c proto.MpassServiceClient) {
+		ctx := authorisedContext(t, c, "login", "password")
+
+		_, err := c.AddRecords(ctx, &proto.AddRecordsRequest{
+			Records: []*proto.Record{textRecord, binaryRecord},
+		})
+		require.NoError(t, err)
+
+		_, err = c.DeleteRecords(ctx, &proto.DeleteRecordsRequest{
+			Tombstones: []*proto.Tombstone{{Id: textRecord.Id, DeletionDate: timestamppb.Now()}},
+			Purge:      true,
+		})
+		require.NoError(t, err)
+
+		resp, err := c.GetChangesSince(ctx, &proto.GetChangesSinceRequest{})
+		require.NoError(t, err)
+		if assert.Len(t, resp.Records, 1) {
+			assert.Equal(t, binaryRecord.Id, resp.Records[0].Id)
+		}
+		assert.Empty(t, resp.Tombstones, "the purged record leaves no tombstone")
+
+		versions, err := c.ListRecordVersions(ctx, &proto.ListRecordVersionsRequest{Id: textRecord.Id})
+		require.NoError(t, err)
+		assert.Empty(t, versions.Versions)
+	})
+}
+
+func Test_RecordVersions(t *testing.T) {
+	serverTest(t, "should require authentication", func(t *testing.T, c proto.MpassServiceClient) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		_, err := c.ListRecordVersions(ctx, &proto.ListRecordVersionsRequest{Id: textRecord.Id})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	serverTest(t, "restore overwritten record", func(t *testing.T, c proto.MpassServiceClient) {
+		ctx := authorisedContext(t, c, "login", "password")
+
+		_, err := c.AddRecords(ctx, &proto.AddRecordsRequest{Records: []*proto.Record{textRecord}})
+		require.NoError(t, err)
+
+		all, err := c.AllRecords(ctx, &empty.Empty{})
+		require.NoError(t, err)
+		require.Len(t, all.Records, 1)
+		revision := all.Records[0].Revision
+
+		updated := protobuf.Clone(textRecord).(*proto.Record)
+		updated.Revision = revision
+		updated.Record = &proto.Record_EncryptedRecord{EncryptedRecord: &proto.EncryptedRecord{Payload: []byte("overwritten")}}
+		_, err = c.AddRecords(ctx, &proto.AddRecordsRequest{Records: []*proto.Record{updated}})
+		require.NoError(t, err)
+
+		resp, err := c.ListRecordVersions(ctx, &proto.ListRecordVersionsRequest{Id: textRecord.Id})
+		require.NoError(t, err)
+		if assert.Len(t, resp.Versions, 1) {
+			assert.Equal(t, revision, resp.Versions[0].Version)
+		}
+
+		rec, err := c.GetRecordVersion(ctx, &proto.GetRecordVersionRequest{Id: textRecord.Id, Version: revision})
+		require.NoError(t, err)
+		assert.Equal(t, textRecord.GetEncryptedRecord().Payload, rec.GetEncryptedRecord().Payload)
+
+		_, err = c.GetRecordVersion(ctx, &proto.GetRecordVersionRequest{Id: textRecord.Id, Version: revision + 100})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
+
+func Test_VaultKey(t *testing.T) {
+	serverTest(t, "should require authentication", func(t *testing.T, c proto.MpassServiceClient) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		_, err := c.GetVaultKey(ctx, &empty.Empty{})
+
+		assert.Error(t, err)
+		if err != nil {
+			e, ok := status.FromError(err)
+			assert.True(t, ok, "should return error with a status")
+			if ok {
+				assert.Equalf(t, codes.Unauthenticated, e.Code(), "should return Unauthenticated status code")
+			}
+		}
+	})
+
+	serverTest(t, "init the vault key only once", func(t *testing.T, c proto.MpassServiceClient) {
+		ctx := authorisedContext(t, c, "login", "password")
+
+		resp, err := c.GetVaultKey(ctx, &empty.Empty{})
+		assert.NoError(t, err)
+		assert.Empty(t, resp.WrappedKey, "vault key should not be initiated yet")
+
+		_, err = c.InitVaultKey(ctx, &proto.VaultKey{WrappedKey: []byte("wrapped key")})
+		assert.NoError(t, err)
+
+		_, err = c.InitVaultKey(ctx, &proto.VaultKey{WrappedKey: []byte("another wrapped key")})
+		assert.Error(t, err, "should not overwrite the vault key")
+
+		resp, err = c.GetVaultKey(ctx, &empty.Empty{})
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("wrapped key"), resp.WrappedKey)
+	})
+}
+
+func Test_UploadDownloadRecord(t *testing.T) {
+	// bigger than the default message limit of gRPC
+	payload := make([]byte, 5<<20)
+	_, err := rand.Read(payload)
+	require.NoError(t, err)
+	checksum := sha256.Sum256(payload)
+
+	startUpload := func(t *testing.T, ctx context.Context, c proto.MpassServiceClient) *proto.StartUploadResponse {
+		resp, err := c.StartUpload(ctx, &proto.StartUploadRequest{
+			Id:             "scan.pdf",
+			LastUpdateDate: timestamppb.Now(),
+			Size:           int64(len(payload)),
+			Checksum:       checksum[:],
+		})
+		require.NoError(t, err)
+
+		return resp
+	}
+
+	sendChunks := func(t *testing.T, ctx context.Context, c proto.MpassServiceClient, uploadID string, from, to int) (*proto.AddRecordsResponse, error) {
+		stream, err := c.UploadRecord(ctx)
+		require.NoError(t, err)
+
+		for offset := from; offset < to; offset += 1 << 20 {
+			end := offset + 1<<20
+			if end > to {
+				end = to
+			}
+
+			data := payload[offset:end]
+			err := stream.Send(&proto.RecordChunk{
+				UploadId: uploadID,
+				Offset:   int64(offset),
+				Data:     data,
+				Checksum: crc32.Checksum(data, crc32.MakeTable(crc32.Castagnoli)),
+			})
+			require.NoError(t, err)
+		}
+
+		return stream.CloseAndRecv()
+	}
+
+	download := func(t *testing.T, ctx context.Context, c proto.MpassServiceClient, offset int64) []byte {
+		stream, err := c.DownloadRecord(ctx, &proto.DownloadRecordRequest{Id: "scan.pdf", Offset: offset})
+		require.NoError(t, err)
+
+		var res []byte
+		for {
+			chunk, err := stream.Recv()
+			if err == io.EOF {
+				return res
+			}
+			require.NoError(t, err)
+			assert.Equal(t, crc32.Checksum(chunk.Data, crc32.MakeTable(crc32.Castagnoli)), chunk.Checksum)
+			res = append(res, chunk.Data...)
+		}
+	}
+
+	serverTest(t, "should require authentication", func(t *testing.T, c proto.MpassServiceClient) {
+		_, err := c.StartUpload(context.Background(), &proto.StartUploadRequest{Id: "scan.pdf"})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		stream, err := c.DownloadRecord(context.Background(), &proto.DownloadRecordRequest{Id: "scan.pdf"})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	serverTest(t, "upload and download in chunks", func(t *testing.T, c proto.MpassServiceClient) {
+		ctx := authorisedContext(t, c, "login", "password")
+
+		upload := startUpload(t, ctx, c)
+		assert.Equal(t, int64(0), upload.Offset)
+
+		resp, err := sendChunks(t, ctx, c, upload.UploadId, 0, len(payload))
+		require.NoError(t, err)
+		assert.Empty(t, resp.Conflicts)
+
+		changes, err := c.GetChangesSince(ctx, &proto.GetChangesSinceRequest{})
+		require.NoError(t, err)
+		if assert.Len(t, changes.Records, 1) {
+			assert.Equal(t, int64(len(payload)), changes.Records[0].PayloadSize)
+			assert.Empty(t, changes.Records[0].GetEncryptedRecord().Payload, "big payload should not be sent inline")
+		}
+
+		assert.Equal(t, payload, download(t, ctx, c, 0))
+		assert.Equal(t, payload[3<<20:], download(t, ctx, c, 3<<20), "download should be resumable")
+	})
+
+	serverTest(t, "resume the interrupted upload", func(t *testing.T, c proto.MpassServiceClient) {
+		ctx := authorisedContext(t, c, "login", "password")
+
+		upload := startUpload(t, ctx, c)
+		_, err := sendChunks(t, ctx, c, upload.UploadId, 0, 2<<20)
+		assert.Error(t, err, "incomplete upload should not be stored")
+
+		resumed := startUpload(t, ctx, c)
+		assert.Equal(t, upload.UploadId, resumed.UploadId)
+		assert.Equal(t, int64(2<<20), resumed.Offset)
+
+		_, err = sendChunks(t, ctx, c, resumed.UploadId, int(resumed.Offset), len(payload))
+		require.NoError(t, err)
+
+		assert.Equal(t, payload, download(t, ctx, c, 0))
+	})
+
+	serverTest(t, "reject the corrupted chunk", func(t *testing.T, c proto.MpassServiceClient) {
+		ctx := authorisedContext(t, c, "login", "password")
+
+		upload := startUpload(t, ctx, c)
+
+		stream, err := c.UploadRecord(ctx)
+		require.NoError(t, err)
+		require.NoError(t, stream.Send(&proto.RecordChunk{UploadId: upload.UploadId, Data: payload[:10], Checksum: 42}))
+
+		_, err = stream.CloseAndRecv()
+		assert.Equal(t, codes.DataLoss, status.Code(err))
+	})
+}
+
 // -- Test helpers --
 
 // serverTest creates the environment for testing the server.
 // It creates and runs the server before the test and then stops it afterwards.
 // Also it provides a sat up client to use with the defined server.
+func Test_TLS(t *testing.T) {
+	certs, err := tls_config.GenerateCerts(t.TempDir(), []string{"localhost", "127.0.0.1"})
+	require.NoError(t, err)
+
+	s := buildServer(buildParams{
+		conf: config.Config{
+			Host:        "127.0.0.1:0",
+			Secret:      "secret",
+			TLSCert:     certs.ServerCert,
+			TLSKey:      certs.ServerKey,
+			TLSClientCA: certs.CACert,
+		},
+		logService:          logging.New(),
+		useInMemoryStorages: true,
+	})
+	s.Start()
+	defer s.Stop()
+
+	signUp := func(params tls_config.ClientParams, creds func(*tls.Config) credentials.TransportCredentials) error {
+		tlsConf, err := tls_config.ClientConfig(params)
+		require.NoError(t, err)
+
+		conn, err := grpc.Dial(s.Host(), grpc.WithTransportCredentials(creds(tlsConf)))
+		require.NoError(t, err)
+		defer conn.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		_, err = proto.NewMpassServiceClient(conn).SignUp(ctx, &proto.SignUpRequest{Login: "login", Password: "password"})
+		return err
+	}
+
+	t.Run("mutual TLS", func(t *testing.T) {
+		err := signUp(tls_config.ClientParams{
+			CAFile:   certs.CACert,
+			CertFile: certs.ClientCert,
+			KeyFile:  certs.ClientKey,
+		}, credentials.NewTLS)
+		assert.NoError(t, err)
+	})
+
+	t.Run("client without certificate", func(t *testing.T) {
+		err := signUp(tls_config.ClientParams{CAFile: certs.CACert}, credentials.NewTLS)
+		assert.Error(t, err)
+	})
+
+	t.Run("client without TLS", func(t *testing.T) {
+		err := signUp(tls_config.ClientParams{}, func(*tls.Config) credentials.TransportCredentials {
+			return insecure.NewCredentials()
+		})
+		assert.Error(t, err)
+	})
+}
+
 func serverTest(t *testing.T, description string, f func(*testing.T, proto.MpassServiceClient)) {
 	logService := logging.New()
 	conf := config.Config{
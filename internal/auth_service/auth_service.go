@@ -2,98 +2,431 @@ package auth_service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
 
 	"github.com/denistakeda/mpass/internal/domain"
+	"github.com/denistakeda/mpass/internal/password_hasher"
 	"github.com/denistakeda/mpass/internal/ports"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
-	"golang.org/x/crypto/bcrypt"
 )
 
-type authService struct {
-	secret string
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+	defaultLockoutDuration = time.Minute
 
-	logger    zerolog.Logger
-	userStore ports.UserStore
+	// unknownDevice is used for the sessions of the clients not providing the device name
+	unknownDevice = "unknown"
+)
+
+type (
+	authService struct {
+		secret          string
+		accessTokenTTL  time.Duration
+		refreshTokenTTL time.Duration
+		lockout         Lockout
+
+		logger         zerolog.Logger
+		userStore      userStore
+		tokenStore     tokenStore
+		passwordHasher passwordHasher
+	}
+
+	userStore interface {
+		AddNewUser(ctx context.Context, login, passwordHash string) error
+		GetUser(ctx context.Context, login string) (domain.User, error)
+		InitVaultKey(ctx context.Context, login string, wrappedKey []byte) error
+		GetVaultKey(ctx context.Context, login string) ([]byte, error)
+		UpdateCredentials(ctx context.Context, login, oldHash, newHash, stamp string, wrappedKey []byte) error
+		UpdatePasswordHash(ctx context.Context, login, oldHash, newHash string) error
+		GetLoginFailures(ctx context.Context, login string) (domain.LoginFailures, error)
+		AddLoginFailure(ctx context.Context, login string) (int, error)
+		LockLogin(ctx context.Context, login string, until time.Time) error
+		ResetLoginFailures(ctx context.Context, login string) error
+	}
+
+	tokenStore interface {
+		AddRefreshToken(ctx context.Context, token domain.RefreshToken) error
+		TakeRefreshToken(ctx context.Context, hash string) (domain.RefreshToken, error)
+		RevokeRefreshTokens(ctx context.Context, login string) error
+	}
+
+	passwordHasher interface {
+		Hash(password string) (string, error)
+		Verify(hash, password string) (ok bool, outdated bool, err error)
+	}
+
+	claims struct {
+		Login string `json:"login"`
+		// SessionStamp binds the token to the session stamp of the user, so that the token is rejected
+		// once the credentials change
+		SessionStamp string `json:"stamp"`
+		jwt.RegisteredClaims
+	}
+)
+
+// Lockout locks the login for Duration after Threshold failed sign ins in a row,
+// the lock doubles with every next failure up to MaxDuration. The zero Threshold disables the lockout,
+// the default duration is used if zero, and the lock does not grow if MaxDuration is not above Duration.
+type Lockout struct {
+	Threshold   int
+	Duration    time.Duration
+	MaxDuration time.Duration
 }
 
 type NewAuthServiceParams struct {
 	Secret string
+	// AccessTokenTTL and RefreshTokenTTL define the lifetime of the tokens, the defaults are used if zero
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	Lockout         Lockout
 
 	LogService ports.LogService
-	UserStore  ports.UserStore
+	UserStore  userStore
+	TokenStore tokenStore
+	// PasswordHasher hashes the passwords, Argon2id with the default parameters is used if nil
+	PasswordHasher passwordHasher
 }
 
 func New(params NewAuthServiceParams) *authService {
-	return &authService{
-		secret: params.Secret,
+	a := &authService{
+		secret:          params.Secret,
+		accessTokenTTL:  params.AccessTokenTTL,
+		refreshTokenTTL: params.RefreshTokenTTL,
+		lockout:         params.Lockout,
+
+		logger:         params.LogService.ComponentLogger("authService"),
+		userStore:      params.UserStore,
+		tokenStore:     params.TokenStore,
+		passwordHasher: params.PasswordHasher,
+	}
 
-		logger:    params.LogService.ComponentLogger("authService"),
-		userStore: params.UserStore,
+	if a.accessTokenTTL == 0 {
+		a.accessTokenTTL = defaultAccessTokenTTL
+	}
+	if a.refreshTokenTTL == 0 {
+		a.refreshTokenTTL = defaultRefreshTokenTTL
 	}
+	if a.passwordHasher == nil {
+		a.passwordHasher = password_hasher.Default()
+	}
+	if a.lockout.Duration == 0 {
+		a.lockout.Duration = defaultLockoutDuration
+	}
+	if a.lockout.MaxDuration < a.lockout.Duration {
+		a.lockout.MaxDuration = a.lockout.Duration
+	}
+
+	return a
 }
 
-func (a *authService) SignUp(ctx context.Context, login, password string) (string, error) {
+func (a *authService) SignUp(ctx context.Context, login, password, device string) (domain.Tokens, error) {
 	if login == "" {
-		return "", errors.New("login is empty")
+		return domain.Tokens{}, errors.New("login is empty")
 	}
 
 	if password == "" {
-		return "", errors.New("password is empty")
+		return domain.Tokens{}, errors.New("password is empty")
 	}
 
-	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	passwordHash, err := a.passwordHasher.Hash(password)
 	if err != nil {
-		return "", errors.Wrap(err, "failed to generate password hash")
+		return domain.Tokens{}, errors.Wrap(err, "failed to generate password hash")
 	}
 
-	if err := a.userStore.AddNewUser(ctx, login, string(passwordHash)); err != nil {
+	if err := a.userStore.AddNewUser(ctx, login, passwordHash); err != nil {
 		a.logger.Error().Err(err).Msg("failed to add a new user")
-		return "", errors.Errorf("login %q is busy", login)
+		return domain.Tokens{}, errors.Errorf("login %q is busy", login)
 	}
 
-	return a.generateJWT(login)
+	return a.newSession(ctx, domain.User{Login: login, PasswordHash: passwordHash}, device)
 }
 
-func (a *authService) SignIn(ctx context.Context, login, password string) (string, error) {
+func (a *authService) SignIn(ctx context.Context, login, password, device string) (domain.Tokens, error) {
 	if login == "" {
-		return "", errors.New("login is empty")
+		return domain.Tokens{}, errors.New("login is empty")
 	}
 	if password == "" {
-		return "", errors.New("password is empty")
+		return domain.Tokens{}, errors.New("password is empty")
+	}
+
+	user, outdated, err := a.verifyPassword(ctx, login, password)
+	if err != nil {
+		return domain.Tokens{}, err
+	}
+
+	if outdated {
+		user = a.rehashPassword(ctx, user, password)
+	}
+
+	return a.newSession(ctx, user, device)
+}
+
+// ChangePassword changes the password of the user and replaces the wrapped vault key, e.g. wrapped with
+// a new master password, if it is provided. The old password is checked the same way as on sign in.
+// The sessions of all the devices are revoked and a new one is started for the device.
+func (a *authService) ChangePassword(ctx context.Context, login, oldPassword, newPassword string, wrappedKey []byte, device string) (domain.Tokens, error) {
+	if oldPassword == "" {
+		return domain.Tokens{}, errors.New("old password is empty")
+	}
+	if newPassword == "" && len(wrappedKey) == 0 {
+		return domain.Tokens{}, errors.New("neither new password nor vault key is provided")
+	}
+
+	user, _, err := a.verifyPassword(ctx, login, oldPassword)
+	if err != nil {
+		return domain.Tokens{}, err
 	}
 
+	passwordHash := user.PasswordHash
+	if newPassword != "" {
+		if passwordHash, err = a.passwordHasher.Hash(newPassword); err != nil {
+			return domain.Tokens{}, errors.Wrap(err, "failed to generate password hash")
+		}
+	}
+
+	// the access tokens are revoked along with the refresh tokens, even if only the vault key is changed
+	stamp, err := randomToken()
+	if err != nil {
+		return domain.Tokens{}, errors.Wrap(err, "failed to generate session stamp")
+	}
+
+	// the password and the vault key are changed at once, so the key is never left wrapped for the old password
+	if err := a.userStore.UpdateCredentials(ctx, login, user.PasswordHash, passwordHash, stamp, wrappedKey); err != nil {
+		return domain.Tokens{}, errors.Wrap(err, "failed to change credentials")
+	}
+	user.PasswordHash, user.SessionStamp = passwordHash, stamp
+
+	if err := a.tokenStore.RevokeRefreshTokens(ctx, login); err != nil {
+		return domain.Tokens{}, errors.Wrap(err, "failed to revoke sessions")
+	}
+
+	a.logger.Info().Str("login", login).Bool("password", newPassword != "").Bool("vault_key", len(wrappedKey) > 0).
+		Msg("credentials were changed, all the sessions were revoked")
+
+	return a.newSession(ctx, user, device)
+}
+
+// verifyPassword checks the password of the user and reports whether the password hash is outdated.
+// The failures are counted and lock the login once there are too many of them.
+func (a *authService) verifyPassword(ctx context.Context, login, password string) (domain.User, bool, error) {
 	user, err := a.userStore.GetUser(ctx, login)
 	if err != nil {
-		return "", errors.Wrap(err, "login or password incorrect")
+		return domain.User{}, false, errors.Wrap(err, "login or password incorrect")
+	}
+
+	var failures domain.LoginFailures
+	if a.lockout.Threshold > 0 {
+		failures, err = a.userStore.GetLoginFailures(ctx, login)
+		if err != nil {
+			return domain.User{}, false, err
+		}
+
+		// the password is not even checked while the login is locked
+		if retryAfter := time.Until(failures.LockedUntil); retryAfter > 0 {
+			return domain.User{}, false, &domain.LoginLockedError{RetryAfter: retryAfter}
+		}
+	}
+
+	ok, outdated, err := a.passwordHasher.Verify(user.PasswordHash, password)
+	if err != nil {
+		return domain.User{}, false, errors.Wrapf(err, "failed to verify password of user %q", login)
+	}
+	if !ok {
+		a.addLoginFailure(ctx, login)
+		return domain.User{}, false, errors.New("login or password incorrect")
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-		return "", errors.Wrap(err, "login or password incorrect")
+	if failures.Count > 0 {
+		if err := a.userStore.ResetLoginFailures(ctx, login); err != nil {
+			return domain.User{}, false, err
+		}
 	}
-	return a.generateJWT(login)
+
+	return user, outdated, nil
 }
 
+// rehashPassword replaces the outdated password hash with the one made with the current algorithm and parameters.
+// It is possible only on sign in, when the password is known. The failure is only logged, the old hash still works.
+// The user is returned with the hash actually stored.
+func (a *authService) rehashPassword(ctx context.Context, user domain.User, password string) domain.User {
+	newHash, err := a.passwordHasher.Hash(password)
+	if err != nil {
+		a.logger.Error().Err(err).Str("login", user.Login).Msg("failed to rehash password")
+		return user
+	}
+
+	if err := a.userStore.UpdatePasswordHash(ctx, user.Login, user.PasswordHash, newHash); err != nil {
+		a.logger.Error().Err(err).Str("login", user.Login).Msg("failed to store rehashed password")
+		return user
+	}
+
+	a.logger.Info().Str("login", user.Login).Msg("outdated password hash was replaced")
+	user.PasswordHash = newHash
+
+	return user
+}
+
+// addLoginFailure counts the failed sign in and locks the login once there are too many of them.
+// The failure to count is only logged, the sign in fails anyway.
+func (a *authService) addLoginFailure(ctx context.Context, login string) {
+	if a.lockout.Threshold <= 0 {
+		return
+	}
+
+	count, err := a.userStore.AddLoginFailure(ctx, login)
+	if err != nil {
+		a.logger.Error().Err(err).Str("login", login).Msg("failed to count failed sign in")
+		return
+	}
+	if count < a.lockout.Threshold {
+		return
+	}
+
+	duration := a.lockout.lockDuration(count)
+	if err := a.userStore.LockLogin(ctx, login, time.Now().Add(duration)); err != nil {
+		a.logger.Error().Err(err).Str("login", login).Msg("failed to lock login")
+		return
+	}
+
+	a.logger.Warn().Str("login", login).Msgf("login is locked for %s after %d failed sign ins", duration, count)
+}
+
+// lockDuration returns how long the login is locked after the count failed sign ins in a row.
+func (l Lockout) lockDuration(count int) time.Duration {
+	duration := l.Duration
+	for i := l.Threshold; i < count && duration < l.MaxDuration; i++ {
+		duration *= 2
+	}
+
+	if duration > l.MaxDuration {
+		return l.MaxDuration
+	}
+
+	return duration
+}
+
+// RefreshToken exchanges the refresh token for a new pair of tokens.
+// The refresh token is rotated: the provided one could not be used anymore.
+func (a *authService) RefreshToken(ctx context.Context, refreshToken string) (domain.Tokens, error) {
+	stored, err := a.tokenStore.TakeRefreshToken(ctx, hashToken(refreshToken))
+	if err != nil {
+		return domain.Tokens{}, errors.Wrap(err, "invalid refresh token")
+	}
+
+	if time.Now().After(stored.ExpiresAt) {
+		return domain.Tokens{}, errors.New("refresh token is expired")
+	}
+
+	user, err := a.userStore.GetUser(ctx, stored.Login)
+	if err != nil {
+		return domain.Tokens{}, errors.Wrap(err, "no such user")
+	}
+
+	return a.newSession(ctx, user, stored.Device)
+}
+
+// Logout revokes the refresh token, the session could not be refreshed anymore.
+func (a *authService) Logout(ctx context.Context, refreshToken string) error {
+	if _, err := a.tokenStore.TakeRefreshToken(ctx, hashToken(refreshToken)); err != nil {
+		return errors.Wrap(err, "invalid refresh token")
+	}
+
+	return nil
+}
+
+// AuthenticateUser returns the user the access token was issued to.
+// The tokens issued before the credentials of the user were changed are rejected.
 func (a *authService) AuthenticateUser(ctx context.Context, token string) (domain.User, error) {
-	login, err := a.extractLoginFromToken(token)
+	c, err := a.parseToken(token)
 	if err != nil {
 		return domain.User{}, errors.Wrap(err, "failed to authenticate user")
 	}
 
-	user, err := a.userStore.GetUser(ctx, login)
+	user, err := a.userStore.GetUser(ctx, c.Login)
 	if err != nil {
 		return domain.User{}, errors.Wrap(err, "no such user")
 	}
 
+	if c.SessionStamp != user.SessionStamp {
+		return domain.User{}, errors.New("the credentials were changed, sign in again")
+	}
+
 	return user, nil
 }
 
-func (a *authService) generateJWT(login string) (string, error) {
-	// Create a new token object, specifying signing method and the claims
-	// you would like it to contain.
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"login": login,
+// InitVaultKey stores the wrapped vault key of the user if it was not stored before.
+func (a *authService) InitVaultKey(ctx context.Context, login string, wrappedKey []byte) error {
+	if len(wrappedKey) == 0 {
+		return errors.New("vault key is empty")
+	}
+
+	if err := a.userStore.InitVaultKey(ctx, login, wrappedKey); err != nil {
+		return errors.Wrapf(err, "failed to init vault key for user %q", login)
+	}
+
+	return nil
+}
+
+// GetVaultKey returns the wrapped vault key of the user, nil means that the key was not initiated yet.
+func (a *authService) GetVaultKey(ctx context.Context, login string) ([]byte, error) {
+	wrappedKey, err := a.userStore.GetVaultKey(ctx, login)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get vault key for user %q", login)
+	}
+
+	return wrappedKey, nil
+}
+
+// newSession issues a new pair of tokens for the device of the user.
+func (a *authService) newSession(ctx context.Context, user domain.User, device string) (domain.Tokens, error) {
+	if device == "" {
+		device = unknownDevice
+	}
+
+	accessToken, err := a.generateJWT(user)
+	if err != nil {
+		return domain.Tokens{}, err
+	}
+
+	refreshToken, err := randomToken()
+	if err != nil {
+		return domain.Tokens{}, errors.Wrap(err, "failed to create a refresh token")
+	}
+
+	if err := a.tokenStore.AddRefreshToken(ctx, domain.RefreshToken{
+		Hash:      hashToken(refreshToken),
+		Login:     user.Login,
+		Device:    device,
+		ExpiresAt: time.Now().Add(a.refreshTokenTTL),
+	}); err != nil {
+		return domain.Tokens{}, errors.Wrap(err, "failed to store the refresh token")
+	}
+
+	return domain.Tokens{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+func (a *authService) generateJWT(user domain.User) (string, error) {
+	id, err := randomToken()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create a token")
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims{
+		Login:        user.Login,
+		SessionStamp: user.SessionStamp,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        id,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(a.accessTokenTTL)),
+		},
 	})
 
 	// Sign and get the complete encoded token as a string using the secret
@@ -105,8 +438,9 @@ func (a *authService) generateJWT(login string) (string, error) {
 	return tokenString, nil
 }
 
-func (a *authService) extractLoginFromToken(token string) (string, error) {
-	t, err := jwt.Parse(token, func(token *jwt.Token) (any, error) {
+func (a *authService) parseToken(token string) (claims, error) {
+	var c claims
+	t, err := jwt.ParseWithClaims(token, &c, func(token *jwt.Token) (any, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
@@ -115,12 +449,33 @@ func (a *authService) extractLoginFromToken(token string) (string, error) {
 	})
 
 	if err != nil {
-		return "", errors.Wrap(err, "failed to parse token")
+		return c, errors.Wrap(err, "failed to parse token")
+	}
+
+	if !t.Valid || c.Login == "" {
+		return c, errors.New("failed to parse token")
+	}
+
+	// the tokens issued before the expiration was introduced are not accepted anymore
+	if c.ExpiresAt == nil {
+		return c, errors.New("token has no expiration time")
 	}
 
-	if claims, ok := t.Claims.(jwt.MapClaims); ok && t.Valid {
-		return claims["login"].(string), nil
-	} else {
-		return "", errors.New("failed to parse token")
+	return c, nil
+}
+
+// randomToken returns a random url-safe string.
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
 	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the hash of the refresh token to be stored instead of the token itself.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
@@ -3,13 +3,21 @@ package auth_service
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/denistakeda/mpass/internal/domain"
 	"github.com/denistakeda/mpass/internal/logging"
+	"github.com/denistakeda/mpass/internal/password_hasher"
+	"github.com/denistakeda/mpass/internal/token_store"
+	"github.com/denistakeda/mpass/internal/user_store"
 	auth_service_mock "github.com/denistakeda/mpass/mocks/auth_service"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func Test_authService_SignUp(t *testing.T) {
@@ -18,11 +26,12 @@ func Test_authService_SignUp(t *testing.T) {
 		password string
 	}
 	tests := []struct {
-		name                  string
-		args                  args
-		userStoreExpectations func(us *auth_service_mock.MockuserStore)
-		wantNotEmpty          bool
-		wantErr               bool
+		name                   string
+		args                   args
+		userStoreExpectations  func(us *auth_service_mock.MockuserStore)
+		tokenStoreExpectations func(ts *auth_service_mock.MocktokenStore)
+		wantNotEmpty           bool
+		wantErr                bool
 	}{
 		{
 			name: "empty login",
@@ -71,18 +80,26 @@ func Test_authService_SignUp(t *testing.T) {
 					Return(nil).
 					Times(1)
 			},
+			tokenStoreExpectations: func(ts *auth_service_mock.MocktokenStore) {
+				ts.EXPECT().
+					AddRefreshToken(gomock.Any(), gomock.Any()).
+					Return(nil).
+					Times(1)
+			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			userStore := auth_service_mock.NewMockuserStore(ctrl)
+			tokenStore := auth_service_mock.NewMocktokenStore(ctrl)
 
 			a := New(NewAuthServiceParams{
 				Secret: "secret",
 
 				LogService: logging.New(),
 				UserStore:  userStore,
+				TokenStore: tokenStore,
 			})
 
 			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
@@ -91,13 +108,16 @@ func Test_authService_SignUp(t *testing.T) {
 			if tt.userStoreExpectations != nil {
 				tt.userStoreExpectations(userStore)
 			}
+			if tt.tokenStoreExpectations != nil {
+				tt.tokenStoreExpectations(tokenStore)
+			}
 
-			got, err := a.SignUp(ctx, tt.args.login, tt.args.password)
+			got, err := a.SignUp(ctx, tt.args.login, tt.args.password, "laptop")
 			if (err != nil) != tt.wantErr {
 				t.Errorf("authService.SignUp() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if tt.wantNotEmpty && got == "" {
+			if tt.wantNotEmpty && (got.AccessToken == "" || got.RefreshToken == "") {
 				t.Errorf("result is empty. expected not empty result")
 			}
 		})
@@ -110,11 +130,12 @@ func Test_authService_SignIn(t *testing.T) {
 		password string
 	}
 	tests := []struct {
-		name                  string
-		args                  args
-		userStoreExpectations func(us *auth_service_mock.MockuserStore)
-		wantNotEmpty          bool
-		wantErr               bool
+		name                   string
+		args                   args
+		userStoreExpectations  func(us *auth_service_mock.MockuserStore)
+		tokenStoreExpectations func(ts *auth_service_mock.MocktokenStore)
+		wantNotEmpty           bool
+		wantErr                bool
 	}{
 		{
 			name: "empty login",
@@ -154,12 +175,14 @@ func Test_authService_SignIn(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			userStore := auth_service_mock.NewMockuserStore(ctrl)
+			tokenStore := auth_service_mock.NewMocktokenStore(ctrl)
 
 			a := New(NewAuthServiceParams{
 				Secret: "secret",
 
 				LogService: logging.New(),
 				UserStore:  userStore,
+				TokenStore: tokenStore,
 			})
 
 			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
@@ -168,15 +191,347 @@ func Test_authService_SignIn(t *testing.T) {
 			if tt.userStoreExpectations != nil {
 				tt.userStoreExpectations(userStore)
 			}
+			if tt.tokenStoreExpectations != nil {
+				tt.tokenStoreExpectations(tokenStore)
+			}
 
-			got, err := a.SignIn(ctx, tt.args.login, tt.args.password)
+			got, err := a.SignIn(ctx, tt.args.login, tt.args.password, "laptop")
 			if (err != nil) != tt.wantErr {
 				t.Errorf("authService.SignUp() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if tt.wantNotEmpty && got == "" {
+			if tt.wantNotEmpty && (got.AccessToken == "" || got.RefreshToken == "") {
 				t.Errorf("result is empty. expected not empty result")
 			}
 		})
 	}
 }
+
+func Test_authService_Session(t *testing.T) {
+	newService := func(t *testing.T, accessTokenTTL time.Duration) *authService {
+		ctrl := gomock.NewController(t)
+		userStore := auth_service_mock.NewMockuserStore(ctrl)
+		userStore.EXPECT().
+			GetUser(gomock.Any(), "login").
+			Return(domain.User{Login: "login"}, nil).
+			AnyTimes()
+
+		return New(NewAuthServiceParams{
+			Secret:         "secret",
+			AccessTokenTTL: accessTokenTTL,
+
+			LogService: logging.New(),
+			UserStore:  userStore,
+			TokenStore: token_store.NewInMemory(),
+		})
+	}
+
+	t.Run("access token is accepted", func(t *testing.T) {
+		a := newService(t, time.Minute)
+
+		tokens, err := a.newSession(context.Background(), domain.User{Login: "login"}, "laptop")
+		require.NoError(t, err)
+
+		user, err := a.AuthenticateUser(context.Background(), tokens.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, "login", user.Login)
+	})
+
+	t.Run("expired access token is rejected", func(t *testing.T) {
+		a := newService(t, -time.Minute)
+
+		tokens, err := a.newSession(context.Background(), domain.User{Login: "login"}, "laptop")
+		require.NoError(t, err)
+
+		_, err = a.AuthenticateUser(context.Background(), tokens.AccessToken)
+		assert.Error(t, err)
+	})
+
+	t.Run("token without expiration is rejected", func(t *testing.T) {
+		a := newService(t, time.Minute)
+
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"login": "login"}).
+			SignedString([]byte("secret"))
+		require.NoError(t, err)
+
+		_, err = a.AuthenticateUser(context.Background(), token)
+		assert.Error(t, err)
+	})
+
+	t.Run("refresh token is rotated", func(t *testing.T) {
+		a := newService(t, -time.Minute)
+
+		tokens, err := a.newSession(context.Background(), domain.User{Login: "login"}, "laptop")
+		require.NoError(t, err)
+
+		refreshed, err := a.RefreshToken(context.Background(), tokens.RefreshToken)
+		require.NoError(t, err)
+		assert.NotEqual(t, tokens.RefreshToken, refreshed.RefreshToken)
+
+		_, err = a.RefreshToken(context.Background(), tokens.RefreshToken)
+		assert.Error(t, err, "used refresh token should not be accepted")
+
+		_, err = a.RefreshToken(context.Background(), refreshed.RefreshToken)
+		assert.NoError(t, err)
+	})
+
+	t.Run("refresh token is revoked on logout", func(t *testing.T) {
+		a := newService(t, time.Minute)
+
+		tokens, err := a.newSession(context.Background(), domain.User{Login: "login"}, "laptop")
+		require.NoError(t, err)
+
+		require.NoError(t, a.Logout(context.Background(), tokens.RefreshToken))
+
+		_, err = a.RefreshToken(context.Background(), tokens.RefreshToken)
+		assert.Error(t, err)
+	})
+
+	t.Run("expired refresh token is rejected", func(t *testing.T) {
+		a := newService(t, time.Minute)
+		a.refreshTokenTTL = -time.Minute
+
+		tokens, err := a.newSession(context.Background(), domain.User{Login: "login"}, "laptop")
+		require.NoError(t, err)
+
+		_, err = a.RefreshToken(context.Background(), tokens.RefreshToken)
+		assert.Error(t, err)
+	})
+}
+
+func Test_authService_Lockout(t *testing.T) {
+	newService := func(t *testing.T) *authService {
+		a := New(NewAuthServiceParams{
+			Secret:  "secret",
+			Lockout: Lockout{Threshold: 3, Duration: time.Minute, MaxDuration: time.Hour},
+
+			LogService: logging.New(),
+			UserStore:  user_store.NewInMemory(),
+			TokenStore: token_store.NewInMemory(),
+		})
+
+		_, err := a.SignUp(context.Background(), "login", "password", "laptop")
+		require.NoError(t, err)
+
+		return a
+	}
+
+	t.Run("login is locked after too many failures", func(t *testing.T) {
+		a := newService(t)
+		ctx := context.Background()
+
+		for i := 0; i < 3; i++ {
+			_, err := a.SignIn(ctx, "login", "wrong", "laptop")
+			require.Error(t, err)
+		}
+
+		_, err := a.SignIn(ctx, "login", "password", "laptop")
+		var locked *domain.LoginLockedError
+		require.ErrorAs(t, err, &locked, "even the correct password should be rejected")
+		assert.InDelta(t, time.Minute, locked.RetryAfter, float64(time.Second))
+	})
+
+	t.Run("successful sign in resets the failures", func(t *testing.T) {
+		a := newService(t)
+		ctx := context.Background()
+
+		for i := 0; i < 2; i++ {
+			_, err := a.SignIn(ctx, "login", "wrong", "laptop")
+			require.Error(t, err)
+		}
+
+		_, err := a.SignIn(ctx, "login", "password", "laptop")
+		require.NoError(t, err)
+
+		failures, err := a.userStore.GetLoginFailures(ctx, "login")
+		require.NoError(t, err)
+		assert.Equal(t, domain.LoginFailures{}, failures)
+	})
+
+	t.Run("lock doubles up to the maximum", func(t *testing.T) {
+		l := Lockout{Threshold: 3, Duration: time.Minute, MaxDuration: 10 * time.Minute}
+
+		assert.Equal(t, time.Minute, l.lockDuration(3))
+		assert.Equal(t, 2*time.Minute, l.lockDuration(4))
+		assert.Equal(t, 8*time.Minute, l.lockDuration(6))
+		assert.Equal(t, 10*time.Minute, l.lockDuration(7))
+		assert.Equal(t, 10*time.Minute, l.lockDuration(1000))
+	})
+}
+
+func Test_authService_PasswordRehash(t *testing.T) {
+	newService := func(t *testing.T, opts password_hasher.Options) *authService {
+		hasher, err := password_hasher.New(opts)
+		require.NoError(t, err)
+
+		return New(NewAuthServiceParams{
+			Secret: "secret",
+
+			LogService:     logging.New(),
+			UserStore:      user_store.NewInMemory(),
+			TokenStore:     token_store.NewInMemory(),
+			PasswordHasher: hasher,
+		})
+	}
+
+	t.Run("legacy bcrypt hash is upgraded on sign in", func(t *testing.T) {
+		a := newService(t, password_hasher.Options{})
+		ctx := context.Background()
+
+		legacy, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+		require.NoError(t, err)
+		require.NoError(t, a.userStore.AddNewUser(ctx, "login", string(legacy)))
+
+		_, err = a.SignIn(ctx, "login", "wrong", "laptop")
+		require.Error(t, err)
+
+		user, err := a.userStore.GetUser(ctx, "login")
+		require.NoError(t, err)
+		assert.Equal(t, string(legacy), user.PasswordHash, "hash should not be replaced on failed sign in")
+
+		_, err = a.SignIn(ctx, "login", "password", "laptop")
+		require.NoError(t, err)
+
+		user, err = a.userStore.GetUser(ctx, "login")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(user.PasswordHash, "$argon2id$"), "hash should be upgraded, got %q", user.PasswordHash)
+
+		_, err = a.SignIn(ctx, "login", "password", "laptop")
+		assert.NoError(t, err, "upgraded hash should be accepted")
+		_, err = a.SignIn(ctx, "login", "wrong", "laptop")
+		assert.Error(t, err)
+	})
+
+	t.Run("hash with outdated parameters is upgraded on sign in", func(t *testing.T) {
+		weak := newService(t, password_hasher.Options{Argon2id: password_hasher.Argon2idParams{Time: 1, Memory: 8 * 1024, Threads: 1}})
+		ctx := context.Background()
+
+		tokens, err := weak.SignUp(ctx, "login", "password", "laptop")
+		require.NoError(t, err)
+		old, err := weak.userStore.GetUser(ctx, "login")
+		require.NoError(t, err)
+
+		a := newService(t, password_hasher.Options{})
+		a.userStore = weak.userStore
+
+		_, err = a.SignIn(ctx, "login", "password", "laptop")
+		require.NoError(t, err)
+
+		user, err := a.userStore.GetUser(ctx, "login")
+		require.NoError(t, err)
+		assert.NotEqual(t, old.PasswordHash, user.PasswordHash)
+		assert.True(t, strings.HasPrefix(user.PasswordHash, "$argon2id$v=19$m=19456,t=2,p=1$"), "unexpected hash %q", user.PasswordHash)
+
+		_, err = a.AuthenticateUser(ctx, tokens.AccessToken)
+		assert.NoError(t, err, "access token should stay valid after the rehash")
+	})
+
+	t.Run("current hash is kept", func(t *testing.T) {
+		a := newService(t, password_hasher.Options{})
+		ctx := context.Background()
+
+		_, err := a.SignUp(ctx, "login", "password", "laptop")
+		require.NoError(t, err)
+		old, err := a.userStore.GetUser(ctx, "login")
+		require.NoError(t, err)
+
+		_, err = a.SignIn(ctx, "login", "password", "laptop")
+		require.NoError(t, err)
+
+		user, err := a.userStore.GetUser(ctx, "login")
+		require.NoError(t, err)
+		assert.Equal(t, old.PasswordHash, user.PasswordHash)
+	})
+}
+
+func Test_authService_ChangePassword(t *testing.T) {
+	newService := func(t *testing.T) (*authService, domain.Tokens) {
+		a := New(NewAuthServiceParams{
+			Secret: "secret",
+
+			LogService: logging.New(),
+			UserStore:  user_store.NewInMemory(),
+			TokenStore: token_store.NewInMemory(),
+		})
+
+		tokens, err := a.SignUp(context.Background(), "login", "password", "laptop")
+		require.NoError(t, err)
+		require.NoError(t, a.InitVaultKey(context.Background(), "login", []byte("key")))
+
+		return a, tokens
+	}
+
+	t.Run("password is changed and the sessions are revoked", func(t *testing.T) {
+		a, old := newService(t)
+		ctx := context.Background()
+
+		phone, err := a.SignIn(ctx, "login", "password", "phone")
+		require.NoError(t, err)
+
+		tokens, err := a.ChangePassword(ctx, "login", "password", "new password", nil, "laptop")
+		require.NoError(t, err)
+
+		_, err = a.SignIn(ctx, "login", "password", "laptop")
+		assert.Error(t, err, "old password should be rejected")
+		_, err = a.SignIn(ctx, "login", "new password", "laptop")
+		assert.NoError(t, err)
+
+		_, err = a.AuthenticateUser(ctx, old.AccessToken)
+		assert.Error(t, err, "access token issued before the change should be rejected")
+		_, err = a.RefreshToken(ctx, phone.RefreshToken)
+		assert.Error(t, err, "sessions of the other devices should be revoked")
+
+		_, err = a.AuthenticateUser(ctx, tokens.AccessToken)
+		assert.NoError(t, err)
+
+		key, err := a.GetVaultKey(ctx, "login")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("key"), key, "vault key should be kept")
+	})
+
+	t.Run("vault key is replaced", func(t *testing.T) {
+		a, old := newService(t)
+		ctx := context.Background()
+
+		tokens, err := a.ChangePassword(ctx, "login", "password", "", []byte("rewrapped key"), "laptop")
+		require.NoError(t, err)
+
+		_, err = a.AuthenticateUser(ctx, old.AccessToken)
+		assert.Error(t, err, "access token issued before the change should be rejected")
+		_, err = a.RefreshToken(ctx, old.RefreshToken)
+		assert.Error(t, err, "sessions should be revoked")
+		_, err = a.AuthenticateUser(ctx, tokens.AccessToken)
+		assert.NoError(t, err)
+
+		key, err := a.GetVaultKey(ctx, "login")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("rewrapped key"), key)
+
+		_, err = a.SignIn(ctx, "login", "password", "laptop")
+		assert.NoError(t, err, "password should be kept")
+	})
+
+	t.Run("wrong old password is rejected", func(t *testing.T) {
+		a, old := newService(t)
+		ctx := context.Background()
+
+		_, err := a.ChangePassword(ctx, "login", "wrong", "new password", []byte("rewrapped key"), "laptop")
+		require.Error(t, err)
+
+		_, err = a.RefreshToken(ctx, old.RefreshToken)
+		assert.NoError(t, err, "sessions should be kept")
+		_, err = a.SignIn(ctx, "login", "password", "laptop")
+		assert.NoError(t, err, "password should be kept")
+
+		key, err := a.GetVaultKey(ctx, "login")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("key"), key)
+	})
+
+	t.Run("nothing to change", func(t *testing.T) {
+		a, _ := newService(t)
+
+		_, err := a.ChangePassword(context.Background(), "login", "password", "", nil, "laptop")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,192 @@
+// package backup writes the whole vault to a self-describing archive and reads it back.
+// The archive is a JSON document, by default it is encrypted with a key derived from the passphrase
+// with Argon2id and sealed with XChaCha20-Poly1305, see the encryption package.
+package backup
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/denistakeda/mpass/internal/domain/record"
+	"github.com/denistakeda/mpass/internal/encryption"
+	"github.com/pkg/errors"
+)
+
+// The formats of the unencrypted backups.
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+// Formats lists the formats of the unencrypted backups.
+var Formats = []string{FormatJSON, FormatCSV}
+
+const (
+	archiveFormat  = "mpass-backup"
+	archiveVersion = 1
+
+	encodingBase64 = "base64"
+)
+
+// archive is the document written to the backup.
+type archive struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Records   []entry   `json:"records"`
+}
+
+// entry is the record as it is kept in the backup.
+// The data is the payload of the record, the payloads which are not valid UTF-8 are encoded with base64.
+type entry struct {
+	Key            string          `json:"key"`
+	Type           record.Kind     `json:"type"`
+	LastUpdateDate time.Time       `json:"last_update_date"`
+	Metadata       record.Metadata `json:"metadata,omitempty"`
+	Tags           record.Tags     `json:"tags,omitempty"`
+	Encoding       string          `json:"encoding,omitempty"`
+	Data           string          `json:"data"`
+}
+
+func newEntry(r record.Record) (entry, error) {
+	if r.Kind() == record.KindEncrypted {
+		return entry{}, errors.Errorf("record %q is encrypted", r.GetId())
+	}
+
+	payload, err := record.Marshal(r)
+	if err != nil {
+		return entry{}, err
+	}
+
+	annotations := r.GetAnnotations()
+	e := entry{
+		Key:            r.GetId(),
+		Type:           r.Kind(),
+		LastUpdateDate: r.GetLastUpdateDate().UTC(),
+		Metadata:       annotations.Metadata,
+		Tags:           annotations.Tags,
+	}
+	if r.Kind() == record.KindFile || !utf8.Valid(payload) {
+		e.Encoding = encodingBase64
+		e.Data = base64.StdEncoding.EncodeToString(payload)
+	} else {
+		e.Data = string(payload)
+	}
+
+	return e, nil
+}
+
+func (e entry) record() (record.Record, error) {
+	if _, err := record.ParseKind(string(e.Type)); err != nil {
+		return nil, errors.Wrapf(err, "record %q", e.Key)
+	}
+
+	payload := []byte(e.Data)
+	switch e.Encoding {
+	case "":
+	case encodingBase64:
+		var err error
+		if payload, err = base64.StdEncoding.DecodeString(e.Data); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode data of record %q", e.Key)
+		}
+	default:
+		return nil, errors.Errorf("record %q has unknown encoding %q", e.Key, e.Encoding)
+	}
+
+	rec, err := record.Unmarshal(e.Type, e.Key, e.LastUpdateDate, payload)
+	if err != nil {
+		return nil, err
+	}
+	rec.SetAnnotations(record.Annotations{Metadata: e.Metadata, Tags: e.Tags})
+
+	return rec, nil
+}
+
+// Marshal writes the records to the unencrypted backup of the format.
+func Marshal(format string, recs []record.Record) ([]byte, error) {
+	entries := make([]entry, 0, len(recs))
+	for _, r := range recs {
+		e, err := newEntry(r)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	switch format {
+	case FormatJSON:
+		return json.MarshalIndent(archive{
+			Format:    archiveFormat,
+			Version:   archiveVersion,
+			CreatedAt: time.Now().UTC(),
+			Records:   entries,
+		}, "", "  ")
+	case FormatCSV:
+		return marshalCSV(entries)
+	default:
+		return nil, errors.Errorf("unknown format %q, expected one of: %s", format, strings.Join(Formats, ", "))
+	}
+}
+
+// Seal writes the records to the backup encrypted with the passphrase.
+func Seal(passphrase string, recs []record.Record) ([]byte, error) {
+	plaintext, err := Marshal(FormatJSON, recs)
+	if err != nil {
+		return nil, err
+	}
+
+	return encryption.SealWithPassword(passphrase, plaintext)
+}
+
+// Encrypted reports whether the backup is protected with a passphrase.
+func Encrypted(data []byte) bool {
+	return encryption.HasHeader(data)
+}
+
+// Open reads the records from the backup encrypted with the passphrase.
+func Open(passphrase string, data []byte) ([]record.Record, error) {
+	plaintext, err := encryption.OpenWithPassword(passphrase, data)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decrypt backup")
+	}
+
+	return Unmarshal(plaintext)
+}
+
+// Unmarshal reads the records from the unencrypted backup, the format is detected from the content.
+func Unmarshal(data []byte) ([]record.Record, error) {
+	var entries []entry
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var a archive
+		if err := json.Unmarshal(data, &a); err != nil {
+			return nil, errors.Wrap(err, "failed to parse backup")
+		}
+		if a.Format != archiveFormat {
+			return nil, errors.New("the file is not an mpass backup")
+		}
+		if a.Version > archiveVersion {
+			return nil, errors.Errorf("backup version %d is not supported, update mpass", a.Version)
+		}
+		entries = a.Records
+	} else {
+		var err error
+		if entries, err = unmarshalCSV(data); err != nil {
+			return nil, err
+		}
+	}
+
+	recs := make([]record.Record, 0, len(entries))
+	for _, e := range entries {
+		rec, err := e.record()
+		if err != nil {
+			return nil, err
+		}
+		recs = append(recs, rec)
+	}
+
+	return recs, nil
+}
@@ -0,0 +1,74 @@
+package backup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/denistakeda/mpass/internal/domain/record"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Backup(t *testing.T) {
+	date := time.Date(2023, time.June, 1, 12, 30, 0, 123, time.UTC)
+
+	login := record.NewLoginPasswordRecord("github.com", "hunter2")
+	login.LastUpdateDate = date
+	login.Metadata = record.Metadata{"url": "https://github.com", "notes": "line, \"quoted\"\nnext line"}
+	login.Tags = record.Tags{"dev", "work"}
+
+	card := record.NewBankCardRecord("4111111111111111", time.July, 12, 123)
+	card.LastUpdateDate = date
+
+	note := record.NewTextRecord("notes/wifi", "the password is on the router")
+	note.LastUpdateDate = date
+
+	file := record.NewBinaryRecord("scan.pdf", []byte{0x25, 0x50, 0x44, 0x46, 0xff, 0x00})
+	file.LastUpdateDate = date
+
+	totp, err := record.ParseTOTPRecord("github.com.totp", "otpauth://totp/GitHub:octocat?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+	require.NoError(t, err)
+	totp.LastUpdateDate = date
+
+	ssh, err := record.GenerateSSHKeyRecord("ssh/github", "octocat@laptop")
+	require.NoError(t, err)
+	ssh.LastUpdateDate = date
+
+	recs := []record.Record{login, card, note, file, totp, ssh}
+
+	for _, format := range Formats {
+		t.Run(format, func(t *testing.T) {
+			data, err := Marshal(format, recs)
+			require.NoError(t, err)
+			assert.False(t, Encrypted(data))
+
+			restored, err := Unmarshal(data)
+			require.NoError(t, err)
+			assert.Equal(t, recs, restored)
+		})
+	}
+
+	t.Run("encrypted", func(t *testing.T) {
+		data, err := Seal("passphrase", recs)
+		require.NoError(t, err)
+		assert.True(t, Encrypted(data))
+		assert.NotContains(t, string(data), "hunter2")
+
+		restored, err := Open("passphrase", data)
+		require.NoError(t, err)
+		assert.Equal(t, recs, restored)
+
+		_, err = Open("wrong", data)
+		assert.Error(t, err)
+	})
+
+	t.Run("newer version", func(t *testing.T) {
+		_, err := Unmarshal([]byte(`{"format": "mpass-backup", "version": 2, "records": []}`))
+		assert.Error(t, err)
+	})
+
+	t.Run("not a backup", func(t *testing.T) {
+		_, err := Unmarshal([]byte("name,url,username,password\n"))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,119 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"time"
+
+	"github.com/denistakeda/mpass/internal/domain/record"
+	"github.com/pkg/errors"
+)
+
+// csvHeader identifies the CSV backup, the metadata and the tags are kept as JSON to survive any characters.
+var csvHeader = []string{"key", "type", "last_update_date", "metadata", "tags", "encoding", "data"}
+
+func marshalCSV(entries []entry) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	if err := writer.Write(csvHeader); err != nil {
+		return nil, errors.Wrap(err, "failed to write CSV")
+	}
+
+	for _, e := range entries {
+		if e.Metadata == nil {
+			e.Metadata = record.Metadata{}
+		}
+		if e.Tags == nil {
+			e.Tags = record.Tags{}
+		}
+
+		metadata, err := json.Marshal(e.Metadata)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to encode metadata of record %q", e.Key)
+		}
+		tags, err := json.Marshal(e.Tags)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to encode tags of record %q", e.Key)
+		}
+
+		err = writer.Write([]string{
+			e.Key,
+			string(e.Type),
+			e.LastUpdateDate.Format(time.RFC3339Nano),
+			string(metadata),
+			string(tags),
+			e.Encoding,
+			e.Data,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to write CSV")
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, errors.Wrap(err, "failed to write CSV")
+	}
+
+	return buf.Bytes(), nil
+}
+
+func unmarshalCSV(data []byte) ([]entry, error) {
+	rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse backup")
+	}
+
+	if len(rows) == 0 || !equal(rows[0], csvHeader) {
+		return nil, errors.New("the file is not an mpass backup")
+	}
+
+	entries := make([]entry, 0, len(rows)-1)
+	for i, row := range rows[1:] {
+		line := i + 2
+
+		lastUpdateDate, err := time.Parse(time.RFC3339Nano, row[2])
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d: failed to parse last update date", line)
+		}
+
+		e := entry{
+			Key:            row[0],
+			Type:           record.Kind(row[1]),
+			LastUpdateDate: lastUpdateDate,
+			Encoding:       row[5],
+			Data:           row[6],
+		}
+		if err := json.Unmarshal([]byte(row[3]), &e.Metadata); err != nil {
+			return nil, errors.Wrapf(err, "line %d: failed to parse metadata", line)
+		}
+		if err := json.Unmarshal([]byte(row[4]), &e.Tags); err != nil {
+			return nil, errors.Wrapf(err, "line %d: failed to parse tags", line)
+		}
+		if len(e.Metadata) == 0 {
+			e.Metadata = nil
+		}
+		if len(e.Tags) == 0 {
+			e.Tags = nil
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
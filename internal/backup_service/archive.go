@@ -0,0 +1,345 @@
+package backup_service
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/denistakeda/mpass/internal/domain"
+	"github.com/denistakeda/mpass/internal/domain/record"
+	"github.com/pkg/errors"
+)
+
+// The archive is a gzipped tar with a JSON file for the vault of every user and the manifest written last.
+// The manifest keeps the SHA-256 checksums of the vault files, so that a damaged archive is detected before
+// anything is restored. The archive does not depend on the storage backend the server uses.
+const (
+	archiveFormat  = "mpass-server-backup"
+	archiveVersion = 1
+
+	manifestName = "manifest.json"
+)
+
+type (
+	manifest struct {
+		Format    string          `json:"format"`
+		Version   int             `json:"version"`
+		CreatedAt time.Time       `json:"created_at"`
+		Vaults    []manifestVault `json:"vaults"`
+	}
+
+	manifestVault struct {
+		Login    string `json:"login"`
+		File     string `json:"file"`
+		Size     int64  `json:"size"`
+		Checksum string `json:"sha256"`
+	}
+
+	// vault is the user together with the snapshot of the records.
+	vault struct {
+		Login          string `json:"login"`
+		PasswordHash   string `json:"password_hash"`
+		VaultKey       []byte `json:"vault_key,omitempty"`
+		Revision       int64  `json:"revision"`
+		PurgedRevision int64  `json:"purged_revision"`
+
+		HistoryRetention *int `json:"history_retention,omitempty"`
+
+		Records    []archivedRecord    `json:"records"`
+		Tombstones []archivedTombstone `json:"tombstones"`
+		Versions   []archivedVersion   `json:"versions"`
+	}
+
+	archivedRecord struct {
+		ID             string          `json:"id"`
+		Type           record.Kind     `json:"type"`
+		LastUpdateDate time.Time       `json:"last_update_date"`
+		Revision       int64           `json:"revision"`
+		Metadata       record.Metadata `json:"metadata,omitempty"`
+		Tags           record.Tags     `json:"tags,omitempty"`
+		Payload        []byte          `json:"payload"`
+	}
+
+	archivedTombstone struct {
+		ID           string    `json:"id"`
+		DeletionDate time.Time `json:"deletion_date"`
+		Revision     int64     `json:"revision"`
+	}
+
+	archivedVersion struct {
+		archivedRecord
+		ReplacedAt time.Time `json:"replaced_at"`
+		Deleted    bool      `json:"deleted"`
+	}
+)
+
+func newVault(user domain.User, vaultKey []byte, snapshot record.Snapshot) (vault, error) {
+	v := vault{
+		Login:          user.Login,
+		PasswordHash:   user.PasswordHash,
+		VaultKey:       vaultKey,
+		Revision:       snapshot.Revision,
+		PurgedRevision: snapshot.PurgedRevision,
+
+		HistoryRetention: user.HistoryRetention,
+
+		Records:    make([]archivedRecord, 0, len(snapshot.Records)),
+		Tombstones: make([]archivedTombstone, 0, len(snapshot.Tombstones)),
+		Versions:   make([]archivedVersion, 0, len(snapshot.Versions)),
+	}
+
+	for _, rec := range snapshot.Records {
+		archived, err := newArchivedRecord(rec)
+		if err != nil {
+			return v, err
+		}
+		v.Records = append(v.Records, archived)
+	}
+
+	for _, t := range snapshot.Tombstones {
+		v.Tombstones = append(v.Tombstones, archivedTombstone{ID: t.ID, DeletionDate: t.DeletionDate, Revision: t.Revision})
+	}
+
+	for _, version := range snapshot.Versions {
+		archived, err := newArchivedRecord(version.Record)
+		if err != nil {
+			return v, err
+		}
+		archived.Revision = version.Version.Version
+		v.Versions = append(v.Versions, archivedVersion{archivedRecord: archived, ReplacedAt: version.ReplacedAt, Deleted: version.Deleted})
+	}
+
+	return v, nil
+}
+
+func newArchivedRecord(rec record.Record) (archivedRecord, error) {
+	payload, err := record.Marshal(rec)
+	if err != nil {
+		return archivedRecord{}, err
+	}
+
+	annotations := rec.GetAnnotations()
+	return archivedRecord{
+		ID:             rec.GetId(),
+		Type:           rec.Kind(),
+		LastUpdateDate: rec.GetLastUpdateDate(),
+		Revision:       rec.GetRevision(),
+		Metadata:       annotations.Metadata,
+		Tags:           annotations.Tags,
+		Payload:        payload,
+	}, nil
+}
+
+func (r archivedRecord) record() (record.Record, error) {
+	rec, err := record.Unmarshal(r.Type, r.ID, r.LastUpdateDate, r.Payload)
+	if err != nil {
+		return nil, err
+	}
+	rec.SetRevision(r.Revision)
+	rec.SetAnnotations(record.Annotations{Metadata: r.Metadata, Tags: r.Tags})
+
+	return rec, nil
+}
+
+func (v vault) user() domain.User {
+	return domain.User{Login: v.Login, PasswordHash: v.PasswordHash, HistoryRetention: v.HistoryRetention}
+}
+
+func (v vault) snapshot() (record.Snapshot, error) {
+	snapshot := record.Snapshot{
+		Revision:       v.Revision,
+		PurgedRevision: v.PurgedRevision,
+	}
+
+	for _, archived := range v.Records {
+		rec, err := archived.record()
+		if err != nil {
+			return snapshot, err
+		}
+		snapshot.Records = append(snapshot.Records, rec)
+	}
+
+	for _, t := range v.Tombstones {
+		snapshot.Tombstones = append(snapshot.Tombstones, record.RevisedTombstone{
+			Tombstone: record.Tombstone{ID: t.ID, DeletionDate: t.DeletionDate},
+			Revision:  t.Revision,
+		})
+	}
+
+	for _, archived := range v.Versions {
+		rec, err := archived.record()
+		if err != nil {
+			return snapshot, err
+		}
+		snapshot.Versions = append(snapshot.Versions, record.ArchivedVersion{
+			Version: record.Version{
+				Version:        archived.Revision,
+				LastUpdateDate: archived.LastUpdateDate,
+				ReplacedAt:     archived.ReplacedAt,
+				Deleted:        archived.Deleted,
+			},
+			Record: rec,
+		})
+	}
+
+	return snapshot, nil
+}
+
+// archiveWriter writes the vaults one by one and the manifest on close.
+type archiveWriter struct {
+	gz       *gzip.Writer
+	tar      *tar.Writer
+	manifest manifest
+}
+
+func newArchiveWriter(w io.Writer, createdAt time.Time) *archiveWriter {
+	gz := gzip.NewWriter(w)
+
+	return &archiveWriter{
+		gz:  gz,
+		tar: tar.NewWriter(gz),
+		manifest: manifest{
+			Format:    archiveFormat,
+			Version:   archiveVersion,
+			CreatedAt: createdAt,
+			Vaults:    []manifestVault{},
+		},
+	}
+}
+
+func (w *archiveWriter) writeVault(v vault) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrapf(err, "failed to encode vault of user %q", v.Login)
+	}
+
+	// the logins could contain any characters, so the files are numbered
+	name := fmt.Sprintf("vaults/%06d.json", len(w.manifest.Vaults)+1)
+	if err := w.writeFile(name, data); err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(data)
+	w.manifest.Vaults = append(w.manifest.Vaults, manifestVault{
+		Login:    v.Login,
+		File:     name,
+		Size:     int64(len(data)),
+		Checksum: hex.EncodeToString(sum[:]),
+	})
+
+	return nil
+}
+
+func (w *archiveWriter) writeFile(name string, data []byte) error {
+	if err := w.tar.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o600,
+		Size:    int64(len(data)),
+		ModTime: w.manifest.CreatedAt,
+	}); err != nil {
+		return errors.Wrapf(err, "failed to write %q to the archive", name)
+	}
+
+	if _, err := w.tar.Write(data); err != nil {
+		return errors.Wrapf(err, "failed to write %q to the archive", name)
+	}
+
+	return nil
+}
+
+func (w *archiveWriter) close() error {
+	data, err := json.MarshalIndent(w.manifest, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to encode manifest")
+	}
+
+	if err := w.writeFile(manifestName, data); err != nil {
+		return err
+	}
+
+	if err := w.tar.Close(); err != nil {
+		return errors.Wrap(err, "failed to finish the archive")
+	}
+
+	if err := w.gz.Close(); err != nil {
+		return errors.Wrap(err, "failed to finish the archive")
+	}
+
+	return nil
+}
+
+// readArchive calls the function for every file of the archive.
+func readArchive(r io.Reader, fn func(name string, data []byte) error) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return errors.Wrap(err, "the file is not an mpass server backup")
+	}
+	defer gz.Close()
+
+	archive := tar.NewReader(gz)
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "failed to read the archive")
+		}
+
+		data, err := io.ReadAll(archive)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %q from the archive", header.Name)
+		}
+
+		if err := fn(header.Name, data); err != nil {
+			return err
+		}
+	}
+}
+
+// verifyArchive reads the whole archive and checks the vault files against the manifest.
+func verifyArchive(r io.Reader) (manifest, error) {
+	var (
+		m         manifest
+		found     bool
+		checksums = make(map[string]string)
+	)
+
+	err := readArchive(r, func(name string, data []byte) error {
+		if name == manifestName {
+			found = true
+			return json.Unmarshal(data, &m)
+		}
+
+		sum := sha256.Sum256(data)
+		checksums[name] = hex.EncodeToString(sum[:])
+		return nil
+	})
+	if err != nil {
+		return m, err
+	}
+
+	if !found || m.Format != archiveFormat {
+		return m, errors.New("the file is not an mpass server backup, the manifest is missing")
+	}
+	if m.Version > archiveVersion {
+		return m, errors.Errorf("backup version %d is not supported, update mpass-server", m.Version)
+	}
+
+	for _, v := range m.Vaults {
+		checksum, ok := checksums[v.File]
+		if !ok {
+			return m, errors.Errorf("vault of user %q is missing in the archive", v.Login)
+		}
+		if checksum != v.Checksum {
+			return m, errors.Errorf("vault of user %q is damaged, the checksum does not match", v.Login)
+		}
+	}
+
+	return m, nil
+}
@@ -0,0 +1,264 @@
+// package backup_service backs up the users and their vaults to a portable archive and restores them,
+// it also runs the periodic backups of the server.
+package backup_service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/denistakeda/mpass/internal/domain"
+	"github.com/denistakeda/mpass/internal/domain/record"
+	"github.com/denistakeda/mpass/internal/ports"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+const (
+	backupPrefix = "mpass-backup-"
+	backupSuffix = ".tar.gz"
+	// backupTimeFormat keeps the names of the backups sorted by time
+	backupTimeFormat = "20060102T150405Z"
+)
+
+type (
+	backupService struct {
+		dir       string
+		retention int
+
+		logger      zerolog.Logger
+		userStore   userStore
+		recordStore recordStore
+	}
+
+	userStore interface {
+		ListUsers(ctx context.Context) ([]domain.User, error)
+		GetVaultKey(ctx context.Context, login string) ([]byte, error)
+		RestoreUser(ctx context.Context, user domain.User, wrappedKey []byte) error
+	}
+
+	recordStore interface {
+		Snapshot(ctx context.Context, login string) (record.Snapshot, error)
+		RestoreSnapshot(ctx context.Context, login string, snapshot record.Snapshot) error
+	}
+)
+
+type NewBackupServiceParams struct {
+	// Dir is the directory the periodic backups are written to,
+	// only Retention latest backups are kept there, zero means all of them.
+	Dir       string
+	Retention int
+
+	LogService  ports.LogService
+	UserStore   userStore
+	RecordStore recordStore
+}
+
+func New(params NewBackupServiceParams) *backupService {
+	return &backupService{
+		dir:       params.Dir,
+		retention: params.Retention,
+
+		logger:      params.LogService.ComponentLogger("backupService"),
+		userStore:   params.UserStore,
+		recordStore: params.RecordStore,
+	}
+}
+
+// Summary describes the content of the backup.
+type Summary struct {
+	CreatedAt time.Time
+	Users     int
+	Records   int
+}
+
+// Backup writes all the users and their vaults to the archive.
+// Every vault is consistent, but the vaults of different users are read one after another.
+func (b *backupService) Backup(ctx context.Context, w io.Writer) (Summary, error) {
+	summary := Summary{CreatedAt: time.Now().UTC()}
+
+	users, err := b.userStore.ListUsers(ctx)
+	if err != nil {
+		return summary, err
+	}
+
+	archive := newArchiveWriter(w, summary.CreatedAt)
+	for _, user := range users {
+		vaultKey, err := b.userStore.GetVaultKey(ctx, user.Login)
+		if err != nil {
+			return summary, err
+		}
+
+		snapshot, err := b.recordStore.Snapshot(ctx, user.Login)
+		if err != nil {
+			return summary, errors.Wrapf(err, "failed to read vault of user %q", user.Login)
+		}
+
+		v, err := newVault(user, vaultKey, snapshot)
+		if err != nil {
+			return summary, err
+		}
+
+		if err := archive.writeVault(v); err != nil {
+			return summary, err
+		}
+
+		summary.Users++
+		summary.Records += len(snapshot.Records)
+	}
+
+	if err := archive.close(); err != nil {
+		return summary, err
+	}
+
+	return summary, nil
+}
+
+// Restore replaces the vaults of the users found in the archive, the other users are not touched.
+// The whole archive is verified first, so nothing is changed if it is damaged.
+func (b *backupService) Restore(ctx context.Context, r io.ReadSeeker) (Summary, error) {
+	var summary Summary
+
+	m, err := verifyArchive(r)
+	if err != nil {
+		return summary, err
+	}
+	summary.CreatedAt = m.CreatedAt
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return summary, errors.Wrap(err, "failed to read the archive")
+	}
+
+	files := make(map[string]bool, len(m.Vaults))
+	for _, v := range m.Vaults {
+		files[v.File] = true
+	}
+
+	err = readArchive(r, func(name string, data []byte) error {
+		if !files[name] {
+			return nil
+		}
+
+		var v vault
+		if err := json.Unmarshal(data, &v); err != nil {
+			return errors.Wrapf(err, "failed to decode %q", name)
+		}
+
+		snapshot, err := v.snapshot()
+		if err != nil {
+			return errors.Wrapf(err, "failed to decode vault of user %q", v.Login)
+		}
+
+		if err := b.userStore.RestoreUser(ctx, v.user(), v.VaultKey); err != nil {
+			return err
+		}
+
+		if err := b.recordStore.RestoreSnapshot(ctx, v.Login, snapshot); err != nil {
+			return errors.Wrapf(err, "failed to restore vault of user %q", v.Login)
+		}
+
+		b.logger.Info().Str("login", v.Login).Msgf("vault with %d records was restored", len(snapshot.Records))
+		summary.Users++
+		summary.Records += len(snapshot.Records)
+
+		return nil
+	})
+
+	return summary, err
+}
+
+// BackupToDir writes the backup to the backup directory and removes the backups beyond the retention.
+// The backup is written to a temporary file first, so that a failed backup never looks like a complete one.
+func (b *backupService) BackupToDir(ctx context.Context) (string, Summary, error) {
+	var summary Summary
+
+	if b.dir == "" {
+		return "", summary, errors.New("backup directory is not configured")
+	}
+
+	if err := os.MkdirAll(b.dir, 0o700); err != nil {
+		return "", summary, errors.Wrapf(err, "failed to create backup directory %q", b.dir)
+	}
+
+	tmp, err := os.CreateTemp(b.dir, backupPrefix+"*.tmp")
+	if err != nil {
+		return "", summary, errors.Wrap(err, "failed to create backup")
+	}
+	defer os.Remove(tmp.Name())
+
+	summary, err = b.Backup(ctx, tmp)
+	if err != nil {
+		tmp.Close()
+		return "", summary, err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return "", summary, errors.Wrap(err, "failed to write backup")
+	}
+
+	if err := tmp.Close(); err != nil {
+		return "", summary, errors.Wrap(err, "failed to write backup")
+	}
+
+	path := filepath.Join(b.dir, backupPrefix+summary.CreatedAt.Format(backupTimeFormat)+backupSuffix)
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", summary, errors.Wrap(err, "failed to write backup")
+	}
+
+	if err := b.prune(); err != nil {
+		// the backup has already succeeded, the old ones will be removed next time
+		b.logger.Error().Err(err).Msg("failed to remove old backups")
+	}
+
+	return path, summary, nil
+}
+
+// Run backs up the server every interval until the context is done.
+func (b *backupService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			path, summary, err := b.BackupToDir(ctx)
+			if err != nil {
+				b.logger.Error().Err(err).Msg("failed to back up the server")
+				continue
+			}
+
+			b.logger.Info().Str("path", path).Msgf("%d users with %d records were backed up", summary.Users, summary.Records)
+		}
+	}
+}
+
+// prune removes all but the latest backups in the backup directory.
+func (b *backupService) prune() error {
+	if b.retention <= 0 {
+		return nil
+	}
+
+	backups, err := filepath.Glob(filepath.Join(b.dir, backupPrefix+"*"+backupSuffix))
+	if err != nil {
+		return err
+	}
+	if len(backups) <= b.retention {
+		return nil
+	}
+
+	sort.Strings(backups)
+	for _, path := range backups[:len(backups)-b.retention] {
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package backup_service
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/denistakeda/mpass/internal/domain"
+	"github.com/denistakeda/mpass/internal/domain/record"
+	"github.com/denistakeda/mpass/internal/logging"
+	"github.com/denistakeda/mpass/internal/ports"
+	"github.com/denistakeda/mpass/internal/record_store"
+	"github.com/denistakeda/mpass/internal/user_store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_backupService(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	newService := func(dir string, retention int) (*backupService, ports.UserStore, ports.RecordStore) {
+		users, records := user_store.NewInMemory(), record_store.NewInMemory()
+		return New(NewBackupServiceParams{
+			Dir:         dir,
+			Retention:   retention,
+			LogService:  logging.New(),
+			UserStore:   users,
+			RecordStore: records,
+		}), users, records
+	}
+
+	source, users, records := newService("", 0)
+	require.NoError(t, users.AddNewUser(ctx, "alice", "alice-hash"))
+	require.NoError(t, users.InitVaultKey(ctx, "alice", []byte("wrapped key")))
+	require.NoError(t, users.AddNewUser(ctx, "bob", "bob-hash"))
+
+	_, err := records.AddRecords(ctx, "alice", []record.Record{
+		&record.EncryptedRecord{ID: "github.com", LastUpdateDate: now, Payload: []byte("sealed")},
+		&record.EncryptedRecord{ID: "notes", LastUpdateDate: now, Payload: []byte("sealed notes")},
+	})
+	require.NoError(t, err)
+	_, err = records.AddRecords(ctx, "alice", []record.Record{
+		&record.EncryptedRecord{ID: "github.com", LastUpdateDate: now.Add(time.Second), Revision: 1, Payload: []byte("sealed again")},
+	})
+	require.NoError(t, err)
+	require.NoError(t, records.DeleteRecords(ctx, "alice", []record.Tombstone{{ID: "notes", DeletionDate: now.Add(time.Second)}}))
+
+	var archive bytes.Buffer
+	summary, err := source.Backup(ctx, &archive)
+	require.NoError(t, err)
+	assert.Equal(t, 2, summary.Users)
+	assert.Equal(t, 1, summary.Records)
+
+	t.Run("restore to the empty server", func(t *testing.T) {
+		target, restoredUsers, restoredRecords := newService("", 0)
+
+		summary, err := target.Restore(ctx, bytes.NewReader(archive.Bytes()))
+		require.NoError(t, err)
+		assert.Equal(t, 2, summary.Users)
+
+		list, err := restoredUsers.ListUsers(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.User{{Login: "alice", PasswordHash: "alice-hash"}, {Login: "bob", PasswordHash: "bob-hash"}}, list)
+
+		key, err := restoredUsers.GetVaultKey(ctx, "alice")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("wrapped key"), key)
+
+		want, err := records.Snapshot(ctx, "alice")
+		require.NoError(t, err)
+		got, err := restoredRecords.Snapshot(ctx, "alice")
+		require.NoError(t, err)
+		assert.Equal(t, want.Revision, got.Revision)
+		assert.Equal(t, want.Records, got.Records)
+		assert.Equal(t, want.Tombstones, got.Tombstones)
+		assert.Len(t, got.Versions, 2)
+
+		rec, err := restoredRecords.GetRecordVersion(ctx, "alice", "github.com", 1)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("sealed"), rec.(*record.EncryptedRecord).Payload)
+	})
+
+	t.Run("damaged archive is not restored", func(t *testing.T) {
+		damaged := rewriteArchive(t, archive.Bytes(), func(name string, data []byte) []byte {
+			return bytes.Replace(data, []byte("alice-hash"), []byte("evil-hash!"), 1)
+		})
+
+		target, restoredUsers, _ := newService("", 0)
+		_, err := target.Restore(ctx, bytes.NewReader(damaged))
+		assert.Error(t, err)
+
+		list, err := restoredUsers.ListUsers(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, list, "nothing should be restored from the damaged archive")
+
+		_, err = target.Restore(ctx, bytes.NewReader([]byte("not an archive")))
+		assert.Error(t, err)
+	})
+
+	t.Run("keep only the latest backups", func(t *testing.T) {
+		dir := t.TempDir()
+		service, _, _ := newService(dir, 2)
+
+		for _, name := range []string{"mpass-backup-20200101T000000Z.tar.gz", "mpass-backup-20210101T000000Z.tar.gz", "unrelated.txt"} {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0o600))
+		}
+
+		path, _, err := service.BackupToDir(ctx)
+		require.NoError(t, err)
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		assert.Equal(t, []string{"mpass-backup-20210101T000000Z.tar.gz", filepath.Base(path), "unrelated.txt"}, names)
+	})
+}
+
+// rewriteArchive changes the files of the archive keeping the manifest as is.
+func rewriteArchive(t *testing.T, archive []byte, change func(name string, data []byte) []byte) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	w := tar.NewWriter(gz)
+
+	require.NoError(t, readArchive(bytes.NewReader(archive), func(name string, data []byte) error {
+		if name != manifestName {
+			data = change(name, data)
+		}
+
+		if err := w.WriteHeader(&tar.Header{Name: name, Mode: 0o600, Size: int64(len(data))}); err != nil {
+			return err
+		}
+		_, err := io.Copy(w, bytes.NewReader(data))
+		return err
+	}))
+
+	require.NoError(t, w.Close())
+	require.NoError(t, gz.Close())
+
+	return buf.Bytes()
+}
@@ -0,0 +1,89 @@
+// Package blob_store keeps the binary payloads of the records outside of the database.
+// The blobs are addressed by the SHA-256 hash of their content, so the same payload is stored once.
+package blob_store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strings"
+
+	"github.com/denistakeda/mpass/internal/ports"
+	"github.com/pkg/errors"
+)
+
+// New creates the blob store the URI points to, it is either a path to the local directory
+// or an S3 compatible bucket like s3://bucket/prefix?endpoint=https://s3.amazonaws.com&region=us-east-1.
+// The credentials are used only by the S3 compatible storage.
+func New(uri, accessKey, secretKey string) (ports.BlobStore, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse blob storage uri")
+	}
+
+	switch u.Scheme {
+	case "", "file":
+		if u.Path == "" {
+			return nil, errors.New("path to the blob directory is empty")
+		}
+		s, err := NewFS(u.Path)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	case "s3":
+		prefix := strings.TrimPrefix(u.Path, "/")
+		if prefix != "" && !strings.HasSuffix(prefix, "/") {
+			prefix += "/"
+		}
+
+		s, err := NewS3(S3Params{
+			Endpoint:  u.Query().Get("endpoint"),
+			Region:    u.Query().Get("region"),
+			Bucket:    u.Host,
+			Prefix:    prefix,
+			AccessKey: accessKey,
+			SecretKey: secretKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	default:
+		return nil, errors.Errorf("unsupported blob storage %q, expected a path or s3://", u.Scheme)
+	}
+}
+
+// Hash returns the address of the data in the blob store.
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// checkHash makes sure that the hash is a valid address and the data matches it,
+// so that a corrupted blob is never returned.
+func checkHash(hash string, data []byte) error {
+	if err := checkAddress(hash); err != nil {
+		return err
+	}
+
+	if Hash(data) != hash {
+		return errors.Errorf("content of blob %q does not match its hash", hash)
+	}
+
+	return nil
+}
+
+// checkAddress makes sure that the hash is a hex encoded SHA-256 hash,
+// it is used as a file name and as a part of the URL.
+func checkAddress(hash string) error {
+	if len(hash) != sha256.Size*2 {
+		return errors.Errorf("invalid blob hash %q", hash)
+	}
+
+	if _, err := hex.DecodeString(hash); err != nil {
+		return errors.Errorf("invalid blob hash %q", hash)
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package blob_store
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "blobs")
+
+	s, err := New(dir, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, &FSStore{dir: dir}, s)
+
+	s, err = New("file://"+dir, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, &FSStore{dir: dir}, s)
+
+	s, err = New("s3://bucket/mpass?endpoint=http://localhost:9000&region=eu-central-1", "access", "secret")
+	require.NoError(t, err)
+	if assert.IsType(t, &S3Store{}, s) {
+		params := s.(*S3Store).params
+		assert.Equal(t, "http://localhost:9000", params.Endpoint)
+		assert.Equal(t, "eu-central-1", params.Region)
+		assert.Equal(t, "bucket", params.Bucket)
+		assert.Equal(t, "mpass/", params.Prefix)
+		assert.Equal(t, "access", params.AccessKey)
+		assert.Equal(t, "secret", params.SecretKey)
+	}
+
+	_, err = New("s3://bucket", "access", "secret")
+	assert.Error(t, err, "endpoint is required")
+
+	_, err = New("ftp://host/blobs", "", "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,102 @@
+package blob_store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// FSStore keeps the blobs as files in the local directory.
+// The files are spread over subdirectories named by the first two characters of the hash,
+// so that no directory grows too big.
+type FSStore struct {
+	dir string
+}
+
+// NewFS creates the store in the directory, the directory is created if it does not exist.
+func NewFS(dir string) (*FSStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, errors.Wrapf(err, "failed to create blob directory %q", dir)
+	}
+
+	return &FSStore{dir: dir}, nil
+}
+
+// Put writes the blob to a temporary file first and renames it,
+// so that a reader never sees a partially written blob.
+func (s *FSStore) Put(ctx context.Context, hash string, data []byte) error {
+	if err := checkHash(hash, data); err != nil {
+		return err
+	}
+
+	path := s.path(hash)
+	if _, err := os.Stat(path); err == nil {
+		// the same content is already stored
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return errors.Wrapf(err, "failed to store blob %q", hash)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), hash+".*.tmp")
+	if err != nil {
+		return errors.Wrapf(err, "failed to store blob %q", hash)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Wrapf(err, "failed to store blob %q", hash)
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return errors.Wrapf(err, "failed to store blob %q", hash)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return errors.Wrapf(err, "failed to store blob %q", hash)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return errors.Wrapf(err, "failed to store blob %q", hash)
+	}
+
+	return nil
+}
+
+func (s *FSStore) Get(ctx context.Context, hash string) ([]byte, error) {
+	if err := checkAddress(hash); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(s.path(hash))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read blob %q", hash)
+	}
+
+	if err := checkHash(hash, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (s *FSStore) Delete(ctx context.Context, hash string) error {
+	if err := checkAddress(hash); err != nil {
+		return err
+	}
+
+	if err := os.Remove(s.path(hash)); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to delete blob %q", hash)
+	}
+
+	return nil
+}
+
+func (s *FSStore) path(hash string) string {
+	return filepath.Join(s.dir, hash[:2], hash)
+}
@@ -0,0 +1,33 @@
+package blob_store
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/denistakeda/mpass/internal/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FSStore(t *testing.T) {
+	testBlobStore(t, func(t *testing.T) ports.BlobStore {
+		s, err := NewFS(filepath.Join(t.TempDir(), "blobs"))
+		require.NoError(t, err)
+		return s
+	})
+
+	t.Run("detect the corrupted blob", func(t *testing.T) {
+		s, err := NewFS(t.TempDir())
+		require.NoError(t, err)
+
+		data := []byte("the content of the file")
+		hash := Hash(data)
+		require.NoError(t, s.Put(context.Background(), hash, data))
+		require.NoError(t, os.WriteFile(s.path(hash), []byte("corrupted"), 0o600))
+
+		_, err = s.Get(context.Background(), hash)
+		assert.Error(t, err)
+	})
+}
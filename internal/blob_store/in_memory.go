@@ -0,0 +1,51 @@
+package blob_store
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+type inMemoryBlobStore struct {
+	mx    sync.RWMutex
+	blobs map[string][]byte
+}
+
+func NewInMemory() *inMemoryBlobStore {
+	return &inMemoryBlobStore{blobs: make(map[string][]byte)}
+}
+
+func (s *inMemoryBlobStore) Put(ctx context.Context, hash string, data []byte) error {
+	if err := checkHash(hash, data); err != nil {
+		return err
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.blobs[hash] = append([]byte(nil), data...)
+
+	return nil
+}
+
+func (s *inMemoryBlobStore) Get(ctx context.Context, hash string) ([]byte, error) {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	data, ok := s.blobs[hash]
+	if !ok {
+		return nil, errors.Errorf("no blob %q", hash)
+	}
+
+	return append([]byte(nil), data...), nil
+}
+
+func (s *inMemoryBlobStore) Delete(ctx context.Context, hash string) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	delete(s.blobs, hash)
+
+	return nil
+}
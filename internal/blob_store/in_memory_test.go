@@ -0,0 +1,13 @@
+package blob_store
+
+import (
+	"testing"
+
+	"github.com/denistakeda/mpass/internal/ports"
+)
+
+func Test_inMemoryBlobStore(t *testing.T) {
+	testBlobStore(t, func(t *testing.T) ports.BlobStore {
+		return NewInMemory()
+	})
+}
@@ -0,0 +1,219 @@
+package blob_store
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// the hash of the empty body of GET and DELETE requests
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// S3Params describe the bucket of an S3 compatible object storage.
+type S3Params struct {
+	// Endpoint is the URL of the storage like https://s3.eu-central-1.amazonaws.com
+	Endpoint string
+	Region   string
+	Bucket   string
+	// Prefix is prepended to the hash to get the key of the object.
+	Prefix string
+
+	AccessKey string
+	SecretKey string
+
+	// Client is used to send the requests, http.DefaultClient is used if it is not set.
+	Client *http.Client
+}
+
+// S3Store keeps the blobs as objects in the S3 compatible storage.
+// The objects are addressed in the path style, the requests are signed with AWS Signature Version 4.
+type S3Store struct {
+	params   S3Params
+	endpoint *url.URL
+}
+
+func NewS3(params S3Params) (*S3Store, error) {
+	endpoint, err := url.Parse(params.Endpoint)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse S3 endpoint %q", params.Endpoint)
+	}
+	if endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, errors.Errorf("invalid S3 endpoint %q, expected http(s)://host", params.Endpoint)
+	}
+	if params.Bucket == "" {
+		return nil, errors.New("S3 bucket is not set")
+	}
+
+	if params.Region == "" {
+		params.Region = "us-east-1"
+	}
+	if params.Client == nil {
+		params.Client = http.DefaultClient
+	}
+
+	return &S3Store{params: params, endpoint: endpoint}, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, hash string, data []byte) error {
+	if err := checkHash(hash, data); err != nil {
+		return err
+	}
+
+	// the key of the object is the hash of its content, so it is the hash of the payload as well
+	resp, err := s.do(ctx, http.MethodPut, hash, data, hash)
+	if err != nil {
+		return errors.Wrapf(err, "failed to store blob %q", hash)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Wrapf(responseError(resp), "failed to store blob %q", hash)
+	}
+
+	return nil
+}
+
+func (s *S3Store) Get(ctx context.Context, hash string) ([]byte, error) {
+	if err := checkAddress(hash); err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(ctx, http.MethodGet, hash, nil, emptyPayloadHash)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read blob %q", hash)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errors.Errorf("no blob %q", hash)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Wrapf(responseError(resp), "failed to read blob %q", hash)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read blob %q", hash)
+	}
+
+	if err := checkHash(hash, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, hash string) error {
+	if err := checkAddress(hash); err != nil {
+		return err
+	}
+
+	resp, err := s.do(ctx, http.MethodDelete, hash, nil, emptyPayloadHash)
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete blob %q", hash)
+	}
+	defer resp.Body.Close()
+
+	// some of the compatible storages report the missing object
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return errors.Wrapf(responseError(resp), "failed to delete blob %q", hash)
+	}
+
+	return nil
+}
+
+func (s *S3Store) do(ctx context.Context, method, hash string, body []byte, payloadHash string) (*http.Response, error) {
+	u := *s.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + s.params.Bucket + "/" + s.params.Prefix + hash
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body == nil {
+		req.Body, req.ContentLength = http.NoBody, 0
+	}
+
+	s.sign(req, payloadHash, time.Now())
+
+	return s.params.Client.Do(req)
+}
+
+// sign adds the AWS Signature Version 4 to the request.
+// The host and all the headers of the request are signed.
+func (s *S3Store) sign(req *http.Request, payloadHash string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", now.Format("20060102"), s.params.Region)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.params.SecretKey), now.Format("20060102"))
+	key = hmacSHA256(key, s.params.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.params.AccessKey, scope, signedHeaders, signature,
+	))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// responseError describes the unexpected response of the storage.
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<10))
+	return errors.Errorf("unexpected response %s: %s", resp.Status, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,56 @@
+package blob_store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/denistakeda/mpass/internal/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testBlobStore runs the tests every implementation of the blob store should pass.
+func testBlobStore(t *testing.T, newStore func(t *testing.T) ports.BlobStore) {
+	ctx := context.Background()
+	data := []byte("the content of the file")
+	hash := Hash(data)
+
+	t.Run("put and get", func(t *testing.T) {
+		s := newStore(t)
+
+		require.NoError(t, s.Put(ctx, hash, data))
+		require.NoError(t, s.Put(ctx, hash, data), "the same content could be stored twice")
+
+		got, err := s.Get(ctx, hash)
+		require.NoError(t, err)
+		assert.Equal(t, data, got)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		s := newStore(t)
+
+		require.NoError(t, s.Put(ctx, hash, data))
+		require.NoError(t, s.Delete(ctx, hash))
+		require.NoError(t, s.Delete(ctx, hash), "the missing blob could be deleted")
+
+		_, err := s.Get(ctx, hash)
+		assert.Error(t, err)
+	})
+
+	t.Run("reject the content not matching the hash", func(t *testing.T) {
+		s := newStore(t)
+
+		assert.Error(t, s.Put(ctx, hash, []byte("another content")))
+		assert.Error(t, s.Put(ctx, "../../etc/passwd", data))
+
+		_, err := s.Get(ctx, hash)
+		assert.Error(t, err, "rejected content should not be stored")
+	})
+
+	t.Run("get the missing blob", func(t *testing.T) {
+		s := newStore(t)
+
+		_, err := s.Get(ctx, hash)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,80 @@
+package client
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/denistakeda/mpass/internal/domain/record"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+// annotationFlags are shared by all the `mpass set` commands.
+func annotationFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:  "meta",
+			Usage: "attach metadata to the record in the form key=value, an empty value removes the key",
+		},
+		&cli.StringSliceFlag{
+			Name:  "tag",
+			Usage: "tag the record",
+		},
+	}
+}
+
+// annotate applies the annotations from the flags to the record.
+// The annotations of the record previously stored under the same key are kept.
+func annotate(cCtx *cli.Context, service clientService, rec record.Record) error {
+	var annotations record.Annotations
+	if old, err := service.GetRecord(rec.GetId()); err == nil {
+		annotations = old.GetAnnotations()
+	}
+
+	metadata := make(record.Metadata, len(annotations.Metadata))
+	for k, v := range annotations.Metadata {
+		metadata[k] = v
+	}
+	for _, meta := range cCtx.StringSlice("meta") {
+		k, v, ok := strings.Cut(meta, "=")
+		if !ok || k == "" {
+			return errors.Errorf("invalid metadata %q, expected key=value", meta)
+		}
+		if v == "" {
+			delete(metadata, k)
+		} else {
+			metadata[k] = v
+		}
+	}
+	if len(metadata) > 0 {
+		annotations.Metadata = metadata
+	} else {
+		annotations.Metadata = nil
+	}
+
+	annotations.Tags = append(record.Tags(nil), annotations.Tags...)
+	annotations.AddTags(cCtx.StringSlice("tag")...)
+
+	rec.SetAnnotations(annotations)
+
+	return nil
+}
+
+func printAnnotations(printer printer, annotations record.Annotations) {
+	if len(annotations.Metadata) > 0 {
+		keys := make([]string, 0, len(annotations.Metadata))
+		for k := range annotations.Metadata {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		printer.Printf("Metadata:\n")
+		for _, k := range keys {
+			printer.Printf(" %s: %s\n", k, annotations.Metadata[k])
+		}
+	}
+
+	if len(annotations.Tags) > 0 {
+		printer.Printf("Tags: %s\n", strings.Join(annotations.Tags, ", "))
+	}
+}
@@ -0,0 +1,91 @@
+package client
+
+import (
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// App is the command line interface of mpass.
+type App struct {
+	*cli.App
+}
+
+// Run runs the command given by the arguments. The flags of the command are accepted after its arguments too,
+// like in `mpass resolve <key> --keep local`, urfave/cli stops parsing the flags at the first argument,
+// so they are moved before the arguments.
+func (a *App) Run(args []string) error {
+	return a.App.Run(moveFlagsFirst(a.App.Commands, args))
+}
+
+// moveFlagsFirst finds the command in the arguments and moves its flags before its arguments,
+// the arguments after "--" are left as they are.
+func moveFlagsFirst(commands []*cli.Command, args []string) []string {
+	if len(args) == 0 {
+		return args
+	}
+
+	res, rest := []string{args[0]}, args[1:]
+
+	var cmd *cli.Command
+	for len(rest) > 0 {
+		sub := findCommand(commands, rest[0])
+		if sub == nil {
+			break
+		}
+
+		cmd, commands = sub, sub.Subcommands
+		res, rest = append(res, rest[0]), rest[1:]
+	}
+	if cmd == nil {
+		return args
+	}
+
+	var flags, positional []string
+	for i := 0; i < len(rest); i++ {
+		arg := rest[i]
+		switch {
+		case arg == "--":
+			positional = append(positional, rest[i:]...)
+			i = len(rest)
+		case len(arg) > 1 && arg[0] == '-':
+			flags = append(flags, arg)
+			if !strings.Contains(arg, "=") && takesValue(cmd, strings.TrimLeft(arg, "-")) && i+1 < len(rest) {
+				i++
+				flags = append(flags, rest[i])
+			}
+		default:
+			positional = append(positional, arg)
+		}
+	}
+
+	return append(append(res, flags...), positional...)
+}
+
+func findCommand(commands []*cli.Command, name string) *cli.Command {
+	for _, cmd := range commands {
+		if cmd.HasName(name) {
+			return cmd
+		}
+	}
+
+	return nil
+}
+
+// takesValue tells whether the flag of the command is followed by its value, the unknown flags are not.
+func takesValue(cmd *cli.Command, name string) bool {
+	for _, f := range cmd.Flags {
+		for _, n := range f.Names() {
+			if n != name {
+				continue
+			}
+
+			if f, ok := f.(cli.DocGenerationFlag); ok {
+				return f.TakesValue()
+			}
+			return false
+		}
+	}
+
+	return false
+}
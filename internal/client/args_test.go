@@ -0,0 +1,58 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_moveFlagsFirst(t *testing.T) {
+	commands := New(NewClientParams{}).Commands
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{
+			name: "flags after the argument",
+			args: []string{"mpass", "ssh-keygen", "github", "--comment", "me@host", "--tag", "work"},
+			want: []string{"mpass", "ssh-keygen", "--comment", "me@host", "--tag", "work", "github"},
+		},
+		{
+			name: "flags between the arguments of a subcommand",
+			args: []string{"mpass", "set", "ssh", "github", "--comment", "me@host", "id_ed25519"},
+			want: []string{"mpass", "set", "ssh", "--comment", "me@host", "github", "id_ed25519"},
+		},
+		{
+			name: "flag without value",
+			args: []string{"mpass", "import", "export.csv", "--dry-run", "--format=chrome-csv"},
+			want: []string{"mpass", "import", "--dry-run", "--format=chrome-csv", "export.csv"},
+		},
+		{
+			name: "bool flag is not followed by its value",
+			args: []string{"mpass", "set", "password", "github", "--generate", "--length", "32"},
+			want: []string{"mpass", "set", "password", "--generate", "--length", "32", "github"},
+		},
+		{
+			name: "arguments after the terminator",
+			args: []string{"mpass", "set", "text", "--", "--not-a-flag"},
+			want: []string{"mpass", "set", "text", "--", "--not-a-flag"},
+		},
+		{
+			name: "flags first",
+			args: []string{"mpass", "restore", "--version", "3", "github"},
+			want: []string{"mpass", "restore", "--version", "3", "github"},
+		},
+		{
+			name: "unknown command",
+			args: []string{"mpass", "unknown", "key", "--flag"},
+			want: []string{"mpass", "unknown", "key", "--flag"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, moveFlagsFirst(commands, tt.args))
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package client
+
+import (
+	"os"
+	"strings"
+
+	"github.com/denistakeda/mpass/internal/backup"
+	"github.com/denistakeda/mpass/internal/domain/record"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+const backupPassphraseEnv = "MPASS_BACKUP_PASSPHRASE"
+
+func exportFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "unencrypted",
+			Usage: "write the records in plain text instead of encrypting them with a passphrase",
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "the format of the unencrypted backup: " + strings.Join(backup.Formats, ", "),
+			Value: backup.FormatJSON,
+		},
+		&cli.BoolFlag{
+			Name:  "yes",
+			Usage: "do not ask for the confirmation before writing the records unencrypted",
+		},
+	}
+}
+
+// exportRecords writes all the local records to the backup.
+// The backup is encrypted with the passphrase unless the user explicitly asks otherwise.
+func exportRecords(cCtx *cli.Context, params NewClientParams) error {
+	path := cCtx.Args().First()
+	if path == "" {
+		return errors.New("file was not provided")
+	}
+
+	unencrypted := cCtx.Bool("unencrypted")
+	if cCtx.IsSet("format") && !unencrypted {
+		return errors.New("the format could be chosen only for the unencrypted backup, use --unencrypted")
+	}
+
+	recs, err := params.ClientService.ListRecords("")
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	if unencrypted {
+		if !cCtx.Bool("yes") {
+			params.Printer.Printf("anyone who can read %q will see all the passwords, continue? [y/N]: ", path)
+			answer, err := params.Scanner.Readln()
+			if err != nil {
+				return errors.New("failed to read the confirmation")
+			}
+			if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+				return errors.New("export was cancelled")
+			}
+		}
+
+		data, err = backup.Marshal(cCtx.String("format"), recs)
+	} else {
+		var passphrase string
+		passphrase, err = readBackupPassphrase(params, true)
+		if err != nil {
+			return err
+		}
+
+		data, err = backup.Seal(passphrase, recs)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return errors.Wrapf(err, "failed to write file %q", path)
+	}
+
+	params.Printer.Printf("%d records were exported to %q\n", len(recs), path)
+
+	return nil
+}
+
+// restoreBackup stores the records from the backup as the local records,
+// the records are sent to the server on the next sync.
+func restoreBackup(cCtx *cli.Context, params NewClientParams) error {
+	path := cCtx.Args().First()
+	if path == "" {
+		return errors.New("file was not provided")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read file %q", path)
+	}
+
+	var recs []record.Record
+	if backup.Encrypted(data) {
+		passphrase, err := readBackupPassphrase(params, false)
+		if err != nil {
+			return err
+		}
+
+		recs, err = backup.Open(passphrase, data)
+		if err != nil {
+			return err
+		}
+	} else {
+		recs, err = backup.Unmarshal(data)
+		if err != nil {
+			return err
+		}
+	}
+
+	dryRun := cCtx.Bool("dry-run")
+	// the backup is restored over the current records
+	summary, err := storeRecords(params, recs, true, dryRun)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		params.Printer.Printf("%d records would be restored, %d of them replace the existing ones, nothing was changed\n", len(recs), summary.replaced)
+		return nil
+	}
+
+	params.Printer.Printf("%d records were restored, %d of them replaced the existing ones, they will be sent to the server on the next sync\n", len(recs), summary.replaced)
+
+	return nil
+}
+
+// readBackupPassphrase asks for the passphrase of the backup, a new passphrase is asked twice.
+// For non-interactive usage it could be provided by the MPASS_BACKUP_PASSPHRASE environment variable.
+func readBackupPassphrase(params NewClientParams, confirm bool) (string, error) {
+	if passphrase := os.Getenv(backupPassphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+
+	passphrase, err := newParamReader(params.Printer, params.Scanner, "Backup Passphrase").
+		String().
+		StripWhitespaces(false).
+		NotEmpty(true).
+		Read()
+	if err != nil || !confirm {
+		return passphrase, err
+	}
+
+	repeated, err := newParamReader(params.Printer, params.Scanner, "Backup Passphrase again").
+		String().
+		StripWhitespaces(false).
+		NotEmpty(true).
+		Read()
+	if err != nil {
+		return "", err
+	}
+
+	if passphrase != repeated {
+		return "", errors.New("passphrases do not match")
+	}
+
+	return passphrase, nil
+}
@@ -2,6 +2,10 @@ package client
 
 import (
 	"io/ioutil"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
 	"time"
 
 	"github.com/denistakeda/mpass/internal/domain/record"
@@ -19,9 +23,19 @@ type (
 	clientService interface {
 		SetRecord(record.Record) error
 		GetRecord(string) (record.Record, error)
+		ListRecords(kind record.Kind, tags ...string) ([]record.Record, error)
+		DeleteRecord(string) error
 		RegisterUser(login, password string) error
 		LoginUser(login, password string) error
-		Sync() error
+		Logout() error
+		ChangePassword(oldPassword, newPassword string) error
+		ChangeMasterPassword(password, newMasterPassword string) error
+		Sync(progress func(key string, done, total int64)) error
+		Conflicts() ([]record.Record, error)
+		ResolveConflict(key, keep string) (string, error)
+		RecordHistory(key string) ([]record.Version, error)
+		RestoreRecord(key string, version int64) error
+		SetHistoryRetention(keep int) error
 	}
 )
 
@@ -31,8 +45,10 @@ type NewClientParams struct {
 	ClientService clientService
 }
 
-func New(params NewClientParams) *cli.App {
-	return &cli.App{
+func New(params NewClientParams) *App {
+	return &App{App: &cli.App{
+		// metadata values could contain commas
+		DisableSliceFlagSeparator: true,
 		Commands: []*cli.Command{
 			{
 				Name:        "register",
@@ -92,12 +108,122 @@ func New(params NewClientParams) *cli.App {
 					return nil
 				},
 			},
+			{
+				Name:        "logout",
+				Usage:       "mpass logout",
+				Description: "logout from the mpass server, the local records are kept",
+				Action: func(cCtx *cli.Context) error {
+					if err := params.ClientService.Logout(); err != nil {
+						return err
+					}
+
+					params.Printer.Printf("user was successfully logged out\n")
+
+					return nil
+				},
+			},
+			{
+				Name:        "passwd",
+				Usage:       "mpass passwd [--master]",
+				Description: "change the account password, or the master password with --master",
+				Flags:       passwdFlags(),
+				Action: func(cCtx *cli.Context) error {
+					return changePassword(cCtx, params)
+				},
+			},
 			{
 				Name:        "sync",
 				Usage:       "mpass sync",
 				Description: "sync local and server database",
 				Action: func(cCtx *cli.Context) error {
-					return params.ClientService.Sync()
+					if err := params.ClientService.Sync(newProgressPrinter(params.Printer)); err != nil {
+						return err
+					}
+
+					conflicts, err := params.ClientService.Conflicts()
+					if err != nil {
+						return err
+					}
+					if len(conflicts) > 0 {
+						params.Printer.Printf("%d records were changed on another device, see `mpass conflicts`\n", len(conflicts))
+					}
+
+					return nil
+				},
+			},
+			{
+				Name:        "conflicts",
+				Usage:       "mpass conflicts",
+				Description: "lists the records changed both locally and on another device",
+				Action: func(cCtx *cli.Context) error {
+					conflicts, err := params.ClientService.Conflicts()
+					if err != nil {
+						return err
+					}
+
+					for _, local := range conflicts {
+						remote, err := params.ClientService.GetRecord(local.GetId())
+						if err != nil {
+							params.Printer.Printf("%s: changed locally at %s, deleted remotely\n",
+								local.GetId(), local.GetLastUpdateDate().Format(time.RFC3339))
+							continue
+						}
+
+						params.Printer.Printf("%s: changed locally at %s, remotely at %s\n",
+							local.GetId(), local.GetLastUpdateDate().Format(time.RFC3339), remote.GetLastUpdateDate().Format(time.RFC3339))
+					}
+
+					return nil
+				},
+			},
+			{
+				Name:        "resolve",
+				Usage:       "mpass resolve <key> --keep local|remote|both",
+				Description: "resolves the conflict keeping the local, the remote or both versions of the record",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "keep",
+						Usage:    "the version to keep: local, remote or both",
+						Required: true,
+					},
+				},
+				Action: func(cCtx *cli.Context) error {
+					key := cCtx.Args().First()
+					if key == "" {
+						return errors.New("key is not provided")
+					}
+
+					if cCtx.Args().Len() > 1 {
+						return errors.Errorf("unexpected arguments %q", cCtx.Args().Tail())
+					}
+
+					newKey, err := params.ClientService.ResolveConflict(key, cCtx.String("keep"))
+					if err != nil {
+						return err
+					}
+
+					if newKey != "" {
+						params.Printer.Printf("the local version was stored as %q\n", newKey)
+					}
+
+					return nil
+				},
+			},
+			{
+				Name:        "generate",
+				Usage:       "mpass generate [--length N] [--no-lower] [--no-upper] [--no-digits] [--no-symbols] [--exclude-ambiguous] [--passphrase [--words N] [--separator S]]",
+				Description: "generates a random password or passphrase",
+				Flags:       generatorFlags(),
+				Action: func(cCtx *cli.Context) error {
+					password, entropy, err := generatePassword(cCtx)
+					if err != nil {
+						return err
+					}
+
+					params.Printer.Printf("%s\n", password)
+					params.Printer.Printf("Entropy: ~%.0f bits\n", entropy)
+
+					return nil
 				},
 			},
 			{
@@ -105,32 +231,58 @@ func New(params NewClientParams) *cli.App {
 				Subcommands: []*cli.Command{
 					{
 						Name:        "password",
-						Usage:       "mpass set password <login>",
+						Usage:       "mpass set password [--generate [generator options]] [--meta key=value] [--tag tag] <login>",
 						Description: "add the login/password item to the store",
+						Flags: append(append(annotationFlags(), generatorFlags()...),
+							&cli.BoolFlag{
+								Name:  "generate",
+								Usage: "generate a strong password instead of asking for it",
+							},
+						),
 						Action: func(cCtx *cli.Context) error {
 							login := cCtx.Args().First()
 							if login == "" {
 								return errors.New("login was not provided")
 							}
 
-							password, err := newParamReader(params.Printer, params.Scanner, "Password").
-								String().
-								StripWhitespaces(true).
-								NotEmpty(true).
-								Read()
-							if err != nil {
-								return err
+							var password string
+							if cCtx.Bool("generate") {
+								var (
+									entropy float64
+									err     error
+								)
+								password, entropy, err = generatePassword(cCtx)
+								if err != nil {
+									return err
+								}
+
+								params.Printer.Printf("the password was generated, entropy is about %.0f bits\n", entropy)
+							} else {
+								var err error
+								password, err = newParamReader(params.Printer, params.Scanner, "Password").
+									String().
+									StripWhitespaces(true).
+									NotEmpty(true).
+									Read()
+								if err != nil {
+									return err
+								}
 							}
 
 							rec := record.NewLoginPasswordRecord(login, password)
 
+							if err := annotate(cCtx, params.ClientService, rec); err != nil {
+								return err
+							}
+
 							return params.ClientService.SetRecord(rec)
 						},
 					},
 					{
 						Name:        "card",
-						Usage:       "mpass set card",
+						Usage:       "mpass set card [--meta key=value] [--tag tag]",
 						Description: "add the bank card to the store",
+						Flags:       annotationFlags(),
 						Action: func(cCtx *cli.Context) error {
 							cardNumber, err := newParamReader(params.Printer, params.Scanner, "Card Number").
 								String().
@@ -158,13 +310,18 @@ func New(params NewClientParams) *cli.App {
 
 							rec := record.NewBankCardRecord(cardNumber, time.Month(month), uint32(day), uint(cardCode))
 
+							if err := annotate(cCtx, params.ClientService, rec); err != nil {
+								return err
+							}
+
 							return params.ClientService.SetRecord(rec)
 						},
 					},
 					{
 						Name:        "text",
-						Usage:       "mpass set text <key>",
+						Usage:       "mpass set text [--meta key=value] [--tag tag] <key>",
 						Description: "add the text to the store with defined key",
+						Flags:       annotationFlags(),
 						Action: func(cCtx *cli.Context) error {
 							key := cCtx.Args().First()
 							if key == "" {
@@ -182,13 +339,18 @@ func New(params NewClientParams) *cli.App {
 
 							rec := record.NewTextRecord(key, text)
 
+							if err := annotate(cCtx, params.ClientService, rec); err != nil {
+								return err
+							}
+
 							return params.ClientService.SetRecord(rec)
 						},
 					},
 					{
 						Name:        "file",
-						Usage:       "mpass set file <key> <file_path>",
+						Usage:       "mpass set file [--meta key=value] [--tag tag] <key> <file_path>",
 						Description: "add the file to the store with defined key",
+						Flags:       annotationFlags(),
 						Action: func(cCtx *cli.Context) error {
 							key := cCtx.Args().First()
 							if key == "" {
@@ -207,6 +369,70 @@ func New(params NewClientParams) *cli.App {
 
 							rec := record.NewBinaryRecord(key, data)
 
+							if err := annotate(cCtx, params.ClientService, rec); err != nil {
+								return err
+							}
+
+							return params.ClientService.SetRecord(rec)
+						},
+					},
+					{
+						Name:        "totp",
+						Usage:       "mpass set totp [--meta key=value] [--tag tag] <key>",
+						Description: "add the seed of the one-time passwords, either the base32 secret or the otpauth:// URI",
+						Flags:       annotationFlags(),
+						Action: func(cCtx *cli.Context) error {
+							key := cCtx.Args().First()
+							if key == "" {
+								return errors.New("key was not provided")
+							}
+
+							secret, err := newParamReader(params.Printer, params.Scanner, "Secret").
+								String().
+								StripWhitespaces(true).
+								NotEmpty(true).
+								Read()
+							if err != nil {
+								return err
+							}
+
+							rec, err := record.ParseTOTPRecord(key, secret)
+							if err != nil {
+								return err
+							}
+
+							if err := annotate(cCtx, params.ClientService, rec); err != nil {
+								return err
+							}
+
+							return params.ClientService.SetRecord(rec)
+						},
+					},
+					{
+						Name:        "ssh",
+						Usage:       "mpass set ssh [--comment comment] [--meta key=value] [--tag tag] <key> <private_key_path>",
+						Description: "import the SSH private key in the OpenSSH or PEM format, the passphrase is asked for the encrypted keys",
+						Flags:       sshKeyFlags(),
+						Action: func(cCtx *cli.Context) error {
+							key := cCtx.Args().First()
+							if key == "" {
+								return errors.New("key was not provided")
+							}
+
+							path := cCtx.Args().Get(1)
+							if path == "" {
+								return errors.New("private_key_path was not provided")
+							}
+
+							rec, err := importSSHKey(cCtx, params, key, path)
+							if err != nil {
+								return err
+							}
+
+							if err := annotate(cCtx, params.ClientService, rec); err != nil {
+								return err
+							}
+
 							return params.ClientService.SetRecord(rec)
 						},
 					},
@@ -232,9 +458,265 @@ func New(params NewClientParams) *cli.App {
 						return err
 					}
 
+					printAnnotations(params.Printer, rec.GetAnnotations())
+
+					return nil
+				},
+			},
+			{
+				Name:        "list",
+				Usage:       "mpass list [--type password|card|text|file|totp|ssh] [--tag tag] [--glob <pattern>] [--regex <pattern>] [--sort key|type|date] [--reverse]",
+				Description: "lists the records from the local database",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "type",
+						Usage: "show only the records of the type: password, card, text, file, totp or ssh",
+					},
+					&cli.StringSliceFlag{
+						Name:  "tag",
+						Usage: "show only the records tagged with all the tags",
+					},
+					&cli.StringFlag{
+						Name:  "glob",
+						Usage: "show only the records with the key matching the glob pattern",
+					},
+					&cli.StringFlag{
+						Name:  "regex",
+						Usage: "show only the records with the key matching the regular expression",
+					},
+					&cli.StringFlag{
+						Name:  "sort",
+						Usage: "sort the records by key, type or date",
+						Value: sortByKey,
+					},
+					&cli.BoolFlag{
+						Name:  "reverse",
+						Usage: "reverse the sort order",
+					},
+				},
+				Action: func(cCtx *cli.Context) error {
+					opts := listOptions{
+						tags:    cCtx.StringSlice("tag"),
+						glob:    cCtx.String("glob"),
+						regex:   cCtx.String("regex"),
+						sortBy:  cCtx.String("sort"),
+						reverse: cCtx.Bool("reverse"),
+					}
+					if t := cCtx.String("type"); t != "" {
+						kind, err := record.ParseKind(t)
+						if err != nil {
+							return err
+						}
+						opts.kind = kind
+					}
+
+					recs, err := params.ClientService.ListRecords(opts.kind, opts.tags...)
+					if err != nil {
+						return err
+					}
+
+					recs, err = listRecords(recs, opts)
+					if err != nil {
+						return err
+					}
+
+					printRecords(params.Printer, recs)
+
+					return nil
+				},
+			},
+			{
+				Name:        "delete",
+				Usage:       "mpass delete <key>",
+				Description: "deletes the value by key, the deletion is sent to the server on the next sync",
+				Action: func(cCtx *cli.Context) error {
+					key := cCtx.Args().First()
+					if key == "" {
+						return errors.New("key is not provided")
+					}
+
+					return params.ClientService.DeleteRecord(key)
+				},
+			},
+			{
+				Name:        "history",
+				Usage:       "mpass history <key>",
+				Description: "lists the prior versions of the record kept on the server",
+				Action: func(cCtx *cli.Context) error {
+					key := cCtx.Args().First()
+					if key == "" {
+						return errors.New("key is not provided")
+					}
+
+					versions, err := params.ClientService.RecordHistory(key)
+					if err != nil {
+						return err
+					}
+
+					if len(versions) == 0 {
+						params.Printer.Printf("record %q has no prior versions\n", key)
+						return nil
+					}
+
+					printVersions(params.Printer, versions)
+
 					return nil
 				},
 			},
+			{
+				Name:        "restore",
+				Usage:       "mpass restore <key> --version N",
+				Description: "replaces the record with its prior version, the record is sent to the server on the next sync",
+				Flags: []cli.Flag{
+					&cli.Int64Flag{
+						Name:     "version",
+						Usage:    "the version to restore, see mpass history",
+						Required: true,
+					},
+				},
+				Action: func(cCtx *cli.Context) error {
+					key := cCtx.Args().First()
+					if key == "" {
+						return errors.New("key is not provided")
+					}
+
+					if cCtx.Args().Len() > 1 {
+						return errors.Errorf("unexpected arguments %q", cCtx.Args().Tail())
+					}
+
+					version := cCtx.Int64("version")
+					if err := params.ClientService.RestoreRecord(key, version); err != nil {
+						return err
+					}
+
+					params.Printer.Printf("version %d of %q is restored, it will be sent to the server on the next sync\n", version, key)
+
+					return nil
+				},
+			},
+			{
+				Name:        "history-retention",
+				Usage:       "mpass history-retention <count>",
+				Description: "sets how many prior versions of every record the server keeps, 0 keeps all of them",
+				Action: func(cCtx *cli.Context) error {
+					keep, err := strconv.Atoi(cCtx.Args().First())
+					if err != nil || keep < 0 {
+						return errors.Errorf("count should be a non-negative number, got %q", cCtx.Args().First())
+					}
+
+					if err := params.ClientService.SetHistoryRetention(keep); err != nil {
+						return err
+					}
+
+					if keep == 0 {
+						params.Printer.Printf("all the prior versions are kept\n")
+					} else {
+						params.Printer.Printf("%d prior versions of every record are kept\n", keep)
+					}
+
+					return nil
+				},
+			},
+			{
+				Name:        "import",
+				Usage:       "mpass import --format keepass|bitwarden|chrome-csv|firefox-csv|1password-csv [--dry-run] [--replace] [--key-file path] <file>",
+				Description: "import the export of another password manager, the records are sent to the server on the next sync",
+				Flags:       importFlags(),
+				Action: func(cCtx *cli.Context) error {
+					return importRecords(cCtx, params)
+				},
+			},
+			{
+				Name:        "export",
+				Usage:       "mpass export [--unencrypted [--format json|csv] [--yes]] <file>",
+				Description: "write all the local records to the backup encrypted with a passphrase",
+				Flags:       exportFlags(),
+				Action: func(cCtx *cli.Context) error {
+					return exportRecords(cCtx, params)
+				},
+			},
+			{
+				Name:        "restore-backup",
+				Usage:       "mpass restore-backup [--dry-run] <file>",
+				Description: "store the records from the backup made by mpass export over the existing ones, the records are sent to the server on the next sync",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "only show what would be restored",
+					},
+				},
+				Action: func(cCtx *cli.Context) error {
+					return restoreBackup(cCtx, params)
+				},
+			},
+			{
+				Name:        "ssh-keygen",
+				Usage:       "mpass ssh-keygen [--comment comment] [--meta key=value] [--tag tag] <key>",
+				Description: "generate the ed25519 SSH key inside the store and print its public key",
+				Flags:       sshKeyFlags(),
+				Action: func(cCtx *cli.Context) error {
+					key := cCtx.Args().First()
+					if key == "" {
+						return errors.New("key was not provided")
+					}
+
+					rec, err := record.GenerateSSHKeyRecord(key, cCtx.String("comment"))
+					if err != nil {
+						return err
+					}
+
+					if err := annotate(cCtx, params.ClientService, rec); err != nil {
+						return err
+					}
+
+					if err := params.ClientService.SetRecord(rec); err != nil {
+						return err
+					}
+
+					params.Printer.Printf("%s\n", rec.AuthorizedKey())
+
+					return nil
+				},
+			},
+			{
+				Name:        "ssh-agent",
+				Usage:       "mpass ssh-agent [--socket path]",
+				Description: "serve the SSH keys of the store over the SSH agent protocol until interrupted, the keys are kept only in memory",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "socket",
+						Usage: "the path of the UNIX socket, a private temporary directory is used by default",
+					},
+				},
+				Action: func(cCtx *cli.Context) error {
+					keyring, err := loadSSHKeys(params.Printer, params.ClientService)
+					if err != nil {
+						return err
+					}
+
+					listener, cleanup, err := listenAgentSocket(cCtx.String("socket"))
+					if err != nil {
+						return err
+					}
+					defer cleanup()
+
+					ctx, stop := signal.NotifyContext(cCtx.Context, os.Interrupt, syscall.SIGTERM)
+					defer stop()
+					go func() {
+						<-ctx.Done()
+						listener.Close()
+					}()
+
+					keys, err := keyring.List()
+					if err != nil {
+						return err
+					}
+					params.Printer.Printf("SSH_AUTH_SOCK=%s; export SSH_AUTH_SOCK;\n", listener.Addr())
+					params.Printer.Printf("# %d keys are served, press Ctrl+C to stop\n", len(keys))
+
+					return serveSSHAgent(listener, keyring)
+				},
+			},
 		},
-	}
+	}}
 }
@@ -0,0 +1,178 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/denistakeda/mpass/internal/domain/record"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePrinter struct{}
+
+func (fakePrinter) Printf(string, ...any) {}
+
+// fakeClientService records the calls of the commands under test, the rest of the methods are not implemented.
+type fakeClientService struct {
+	clientService
+
+	calls    []string
+	existing map[string]record.Record
+}
+
+func (s *fakeClientService) GetRecord(key string) (record.Record, error) {
+	if rec, ok := s.existing[key]; ok {
+		return rec, nil
+	}
+
+	return nil, errors.Errorf("no record with key %q", key)
+}
+
+func (s *fakeClientService) SetRecord(rec record.Record) error {
+	s.calls = append(s.calls, fmt.Sprintf("set %s@%d", rec.GetId(), rec.GetRevision()))
+	return nil
+}
+
+func (s *fakeClientService) ResolveConflict(key, keep string) (string, error) {
+	s.calls = append(s.calls, fmt.Sprintf("resolve %s %s", key, keep))
+	return "", nil
+}
+
+func (s *fakeClientService) RestoreRecord(key string, version int64) error {
+	s.calls = append(s.calls, fmt.Sprintf("restore %s %d", key, version))
+	return nil
+}
+
+func Test_client_Resolve(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "flag after the key",
+			args: []string{"mpass", "resolve", "github.com", "--keep", "local"},
+			want: []string{"resolve github.com local"},
+		},
+		{
+			name: "flag before the key",
+			args: []string{"mpass", "resolve", "--keep", "remote", "github.com"},
+			want: []string{"resolve github.com remote"},
+		},
+		{
+			name:    "flag is missing",
+			args:    []string{"mpass", "resolve", "github.com"},
+			wantErr: true,
+		},
+		{
+			name:    "unexpected argument",
+			args:    []string{"mpass", "resolve", "github.com", "--keep", "local", "gitlab.com"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeClientService{}
+			app := New(NewClientParams{Printer: fakePrinter{}, ClientService: service})
+
+			err := app.Run(tt.args)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Empty(t, service.calls)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, service.calls)
+		})
+	}
+}
+
+func Test_client_Restore(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "flag after the key",
+			args: []string{"mpass", "restore", "github.com", "--version", "3"},
+			want: []string{"restore github.com 3"},
+		},
+		{
+			name: "flag before the key",
+			args: []string{"mpass", "restore", "--version", "2", "github.com"},
+			want: []string{"restore github.com 2"},
+		},
+		{
+			name:    "flag is missing",
+			args:    []string{"mpass", "restore", "github.com"},
+			wantErr: true,
+		},
+		{
+			name:    "version is not a number",
+			args:    []string{"mpass", "restore", "github.com", "--version", "latest"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeClientService{}
+			app := New(NewClientParams{Printer: fakePrinter{}, ClientService: service})
+
+			err := app.Run(tt.args)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Empty(t, service.calls)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, service.calls)
+		})
+	}
+}
+
+func Test_client_Import(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export.csv")
+	content := "name,url,username,password\n" +
+		"github,https://github.com,bob,new-password\n" +
+		"gitlab,https://gitlab.com,bob,password\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{
+			name: "existing records are skipped",
+			args: []string{"mpass", "import", "--format", "chrome-csv", path},
+			want: []string{"set gitlab@0"},
+		},
+		{
+			name: "existing records are replaced",
+			args: []string{"mpass", "import", path, "--format", "chrome-csv", "--replace"},
+			want: []string{"set github@3", "set gitlab@0"},
+		},
+		{
+			name: "nothing is stored on a dry run",
+			args: []string{"mpass", "import", "--format", "chrome-csv", "--replace", "--dry-run", path},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			existing := record.NewLoginPasswordRecord("github", "old-password")
+			existing.SetRevision(3)
+			service := &fakeClientService{existing: map[string]record.Record{"github": existing}}
+			app := New(NewClientParams{Printer: fakePrinter{}, ClientService: service})
+
+			require.NoError(t, app.Run(tt.args))
+			assert.Equal(t, tt.want, service.calls)
+		})
+	}
+}
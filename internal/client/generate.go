@@ -0,0 +1,76 @@
+package client
+
+import (
+	"github.com/denistakeda/mpass/internal/generator"
+	"github.com/urfave/cli/v2"
+)
+
+// generatorFlags are shared by `mpass generate` and `mpass set password --generate`.
+func generatorFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.IntFlag{
+			Name:  "length",
+			Usage: "length of the password",
+			Value: generator.DefaultOptions.Length,
+		},
+		&cli.BoolFlag{
+			Name:  "no-lower",
+			Usage: "do not use lowercase letters",
+		},
+		&cli.BoolFlag{
+			Name:  "no-upper",
+			Usage: "do not use uppercase letters",
+		},
+		&cli.BoolFlag{
+			Name:  "no-digits",
+			Usage: "do not use digits",
+		},
+		&cli.BoolFlag{
+			Name:  "no-symbols",
+			Usage: "do not use symbols",
+		},
+		&cli.BoolFlag{
+			Name:  "exclude-ambiguous",
+			Usage: "do not use the characters that are easy to confuse, like l, 1, O and 0",
+		},
+		&cli.BoolFlag{
+			Name:  "passphrase",
+			Usage: "generate a passphrase of random words instead",
+		},
+		&cli.IntFlag{
+			Name:  "words",
+			Usage: "number of words in the passphrase",
+			Value: generator.DefaultPassphraseOptions.Words,
+		},
+		&cli.StringFlag{
+			Name:  "separator",
+			Usage: "separator of the words in the passphrase",
+			Value: generator.DefaultPassphraseOptions.Separator,
+		},
+	}
+}
+
+// generatePassword generates a password according to the flags and returns it with its entropy in bits.
+func generatePassword(cCtx *cli.Context) (string, float64, error) {
+	if cCtx.Bool("passphrase") {
+		opts := generator.PassphraseOptions{
+			Words:     cCtx.Int("words"),
+			Separator: cCtx.String("separator"),
+		}
+
+		passphrase, err := generator.Passphrase(opts)
+		return passphrase, opts.Entropy(), err
+	}
+
+	opts := generator.Options{
+		Length:           cCtx.Int("length"),
+		Lower:            !cCtx.Bool("no-lower"),
+		Upper:            !cCtx.Bool("no-upper"),
+		Digits:           !cCtx.Bool("no-digits"),
+		Symbols:          !cCtx.Bool("no-symbols"),
+		ExcludeAmbiguous: cCtx.Bool("exclude-ambiguous"),
+	}
+
+	password, err := generator.Password(opts)
+	return password, opts.Entropy(), err
+}
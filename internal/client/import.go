@@ -0,0 +1,140 @@
+package client
+
+import (
+	"os"
+	"strings"
+
+	"github.com/denistakeda/mpass/internal/domain/record"
+	"github.com/denistakeda/mpass/internal/importer"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+func importFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:     "format",
+			Usage:    "the format of the export: " + strings.Join(importer.Formats, ", "),
+			Required: true,
+		},
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "only show what would be imported",
+		},
+		&cli.BoolFlag{
+			Name:  "replace",
+			Usage: "replace the existing records with the same keys, they are skipped otherwise",
+		},
+		&cli.StringFlag{
+			Name:  "key-file",
+			Usage: "the key file of the KeePass database",
+		},
+	}
+}
+
+// importRecords reads the export of another password manager and stores its entries as the local records,
+// the records are sent to the server on the next sync.
+func importRecords(cCtx *cli.Context, params NewClientParams) error {
+	path := cCtx.Args().First()
+	if path == "" {
+		return errors.New("file was not provided")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open file %q", path)
+	}
+	defer file.Close()
+
+	format := cCtx.String("format")
+
+	var creds importer.Credentials
+	if format == importer.FormatKeePass {
+		if keyFile := cCtx.String("key-file"); keyFile != "" {
+			creds.KeyFile, err = os.ReadFile(keyFile)
+			if err != nil {
+				return errors.Wrapf(err, "failed to read key file %q", keyFile)
+			}
+		}
+
+		creds.Password, err = newParamReader(params.Printer, params.Scanner, "Database Password").
+			String().
+			StripWhitespaces(false).
+			NotEmpty(len(creds.KeyFile) == 0).
+			Read()
+		if err != nil {
+			return err
+		}
+	}
+
+	res, err := importer.Parse(format, file, creds)
+	if err != nil {
+		return err
+	}
+
+	dryRun := cCtx.Bool("dry-run")
+	summary, err := storeRecords(params, res.Records, cCtx.Bool("replace"), dryRun)
+	if err != nil {
+		return err
+	}
+
+	for _, skipped := range res.Skipped {
+		params.Printer.Printf("skipped: %s\n", skipped)
+	}
+
+	imported := summary.added + summary.replaced
+	if dryRun {
+		params.Printer.Printf("%d records would be imported, %d of them replace the existing ones, nothing was changed\n", imported, summary.replaced)
+	} else {
+		params.Printer.Printf("%d records were imported, %d of them replaced the existing ones, they will be sent to the server on the next sync\n", imported, summary.replaced)
+	}
+	if summary.kept > 0 {
+		params.Printer.Printf("%d records with the existing keys were skipped, use --replace to replace them\n", summary.kept)
+	}
+
+	return nil
+}
+
+// storeSummary counts the records stored by storeRecords.
+type storeSummary struct {
+	added    int
+	replaced int
+	kept     int // the existing records not replaced
+}
+
+// storeRecords prints and stores the records, the existing records with the same keys are replaced
+// only if replace is set, otherwise they are kept. Nothing is stored on a dry run.
+func storeRecords(params NewClientParams, recs []record.Record, replace, dryRun bool) (storeSummary, error) {
+	var summary storeSummary
+	for _, rec := range recs {
+		existing, err := params.ClientService.GetRecord(rec.GetId())
+		switch {
+		case err != nil:
+			summary.added++
+			params.Printer.Printf("+ %s (%s)\n", rec.GetId(), rec.Kind())
+		case replace:
+			summary.replaced++
+			params.Printer.Printf("~ %s (%s) replaces the existing record\n", rec.GetId(), rec.Kind())
+		default:
+			summary.kept++
+			params.Printer.Printf("= %s (%s) exists, skipped\n", rec.GetId(), rec.Kind())
+			continue
+		}
+
+		if dryRun {
+			continue
+		}
+
+		// the record replaces the version the client has seen instead of conflicting with it
+		var revision int64
+		if existing != nil {
+			revision = existing.GetRevision()
+		}
+
+		if err := params.ClientService.SetRecord(record.Rebase(rec, rec.GetId(), revision)); err != nil {
+			return summary, err
+		}
+	}
+
+	return summary, nil
+}
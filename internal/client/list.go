@@ -0,0 +1,136 @@
+package client
+
+import (
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/denistakeda/mpass/internal/domain/record"
+	"github.com/pkg/errors"
+)
+
+const (
+	sortByKey  = "key"
+	sortByType = "type"
+	sortByDate = "date"
+)
+
+// listOptions describe which records `mpass list` shows and in which order.
+type listOptions struct {
+	kind    record.Kind // any kind if empty
+	tags    []string
+	glob    string
+	regex   string
+	sortBy  string
+	reverse bool
+}
+
+// listRecords filters and sorts the records according to the options.
+func listRecords(recs []record.Record, opts listOptions) ([]record.Record, error) {
+	var re *regexp.Regexp
+	if opts.regex != "" {
+		var err error
+		if re, err = regexp.Compile(opts.regex); err != nil {
+			return nil, errors.Wrapf(err, "invalid regex %q", opts.regex)
+		}
+	}
+	if opts.glob != "" {
+		if _, err := path.Match(opts.glob, ""); err != nil {
+			return nil, errors.Wrapf(err, "invalid glob %q", opts.glob)
+		}
+	}
+
+	res := make([]record.Record, 0, len(recs))
+	for _, rec := range recs {
+		if opts.kind != "" && rec.Kind() != opts.kind {
+			continue
+		}
+		if !hasTags(rec, opts.tags) {
+			continue
+		}
+		if opts.glob != "" {
+			if ok, _ := path.Match(opts.glob, rec.GetId()); !ok {
+				continue
+			}
+		}
+		if re != nil && !re.MatchString(rec.GetId()) {
+			continue
+		}
+		res = append(res, rec)
+	}
+
+	var less func(a, b record.Record) bool
+	switch opts.sortBy {
+	case sortByKey, "":
+		less = func(a, b record.Record) bool {
+			return a.GetId() < b.GetId()
+		}
+	case sortByType:
+		less = func(a, b record.Record) bool {
+			if a.Kind() != b.Kind() {
+				return a.Kind() < b.Kind()
+			}
+			return a.GetId() < b.GetId()
+		}
+	case sortByDate:
+		less = func(a, b record.Record) bool {
+			if !a.GetLastUpdateDate().Equal(b.GetLastUpdateDate()) {
+				return a.GetLastUpdateDate().Before(b.GetLastUpdateDate())
+			}
+			return a.GetId() < b.GetId()
+		}
+	default:
+		return nil, errors.Errorf("unknown sort order %q, expected one of: %s, %s, %s", opts.sortBy, sortByKey, sortByType, sortByDate)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if opts.reverse {
+			return less(res[j], res[i])
+		}
+		return less(res[i], res[j])
+	})
+
+	return res, nil
+}
+
+func hasTags(rec record.Record, tags []string) bool {
+	annotations := rec.GetAnnotations()
+	for _, tag := range tags {
+		if !annotations.HasTag(tag) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func printRecords(printer printer, recs []record.Record) {
+	keyWidth := len("KEY")
+	for _, rec := range recs {
+		if len(rec.GetId()) > keyWidth {
+			keyWidth = len(rec.GetId())
+		}
+	}
+
+	printer.Printf("%-*s  %-8s  %-19s  %s\n", keyWidth, "KEY", "TYPE", "UPDATED", "TAGS")
+	for _, rec := range recs {
+		printer.Printf("%-*s  %-8s  %-19s  %s\n",
+			keyWidth, rec.GetId(), rec.Kind(), rec.GetLastUpdateDate().Local().Format("2006-01-02 15:04:05"),
+			strings.Join(rec.GetAnnotations().Tags, ","))
+	}
+}
+
+func printVersions(printer printer, versions []record.Version) {
+	const dateFormat = "2006-01-02 15:04:05"
+
+	printer.Printf("%-8s  %-19s  %s\n", "VERSION", "UPDATED", "REPLACED")
+	for _, version := range versions {
+		replaced := version.ReplacedAt.Local().Format(dateFormat)
+		if version.Deleted {
+			replaced += " (deleted)"
+		}
+
+		printer.Printf("%-8d  %-19s  %s\n", version.Version, version.LastUpdateDate.Local().Format(dateFormat), replaced)
+	}
+}
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/denistakeda/mpass/internal/domain/record"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_listRecords(t *testing.T) {
+	now := time.Now()
+	recs := []record.Record{
+		&record.TextRecord{ID: "notes/work", LastUpdateDate: now, Annotations: record.Annotations{Tags: record.Tags{"work"}}},
+		&record.LoginPasswordRecord{ID: "github.com", LastUpdateDate: now.Add(-time.Hour)},
+		&record.LoginPasswordRecord{ID: "gitlab.com", LastUpdateDate: now.Add(time.Hour)},
+		&record.BinaryRecord{ID: "notes/scan.pdf", LastUpdateDate: now.Add(-2 * time.Hour), Annotations: record.Annotations{Tags: record.Tags{"home", "work"}}},
+	}
+
+	tests := []struct {
+		name    string
+		opts    listOptions
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "all sorted by key",
+			opts: listOptions{},
+			want: []string{"github.com", "gitlab.com", "notes/scan.pdf", "notes/work"},
+		},
+		{
+			name: "filter by type",
+			opts: listOptions{kind: record.KindPassword},
+			want: []string{"github.com", "gitlab.com"},
+		},
+		{
+			name: "filter by tags",
+			opts: listOptions{tags: []string{"work", "home"}},
+			want: []string{"notes/scan.pdf"},
+		},
+		{
+			name: "filter by glob",
+			opts: listOptions{glob: "notes/*"},
+			want: []string{"notes/scan.pdf", "notes/work"},
+		},
+		{
+			name: "filter by regex",
+			opts: listOptions{regex: `^git(hub|lab)\.`},
+			want: []string{"github.com", "gitlab.com"},
+		},
+		{
+			name: "sort by date",
+			opts: listOptions{sortBy: sortByDate},
+			want: []string{"notes/scan.pdf", "github.com", "notes/work", "gitlab.com"},
+		},
+		{
+			name: "sort by type reversed",
+			opts: listOptions{sortBy: sortByType, reverse: true},
+			want: []string{"notes/work", "gitlab.com", "github.com", "notes/scan.pdf"},
+		},
+		{
+			name:    "invalid regex",
+			opts:    listOptions{regex: "("},
+			wantErr: true,
+		},
+		{
+			name:    "unknown sort order",
+			opts:    listOptions{sortBy: "size"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := listRecords(recs, tt.opts)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			keys := make([]string, 0, len(got))
+			for _, rec := range got {
+				keys = append(keys, rec.GetId())
+			}
+			assert.Equal(t, tt.want, keys)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package client
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+const masterPasswordEnv = "MPASS_MASTER_PASSWORD"
+
+// NewMasterPasswordReader returns a function that asks the user for the master password.
+// The password is asked only once per run, the new one, e.g. for a new state or a new vault key,
+// is asked twice to catch a typo. For non-interactive usage it could be provided by the
+// MPASS_MASTER_PASSWORD environment variable, then it is not confirmed.
+func NewMasterPasswordReader(printer printer, scanner scanner) func(confirm bool) (string, error) {
+	var (
+		password  string
+		confirmed bool
+	)
+
+	read := func(name string) (string, error) {
+		return newParamReader(printer, scanner, name).
+			String().
+			StripWhitespaces(false).
+			NotEmpty(true).
+			Read()
+	}
+
+	return func(confirm bool) (string, error) {
+		if password == "" {
+			if envPassword := os.Getenv(masterPasswordEnv); envPassword != "" {
+				password, confirmed = envPassword, true
+			} else {
+				p, err := read("Master Password")
+				if err != nil {
+					return "", err
+				}
+				password = p
+			}
+		}
+
+		if confirm && !confirmed {
+			repeated, err := read("Master Password again")
+			if err != nil {
+				return "", err
+			}
+
+			if repeated != password {
+				password = ""
+				return "", errors.New("master passwords do not match")
+			}
+			confirmed = true
+		}
+
+		return password, nil
+	}
+}
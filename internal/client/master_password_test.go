@@ -0,0 +1,81 @@
+package client
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeScanner returns the lines one by one.
+type fakeScanner struct {
+	lines []string
+}
+
+func (s *fakeScanner) Readln() (string, error) {
+	if len(s.lines) == 0 {
+		return "", io.EOF
+	}
+
+	line := s.lines[0]
+	s.lines = s.lines[1:]
+
+	return line, nil
+}
+
+func Test_masterPasswordReader(t *testing.T) {
+	t.Setenv(masterPasswordEnv, "")
+
+	t.Run("password is asked once", func(t *testing.T) {
+		read := NewMasterPasswordReader(fakePrinter{}, &fakeScanner{lines: []string{"secret"}})
+
+		for i := 0; i < 2; i++ {
+			password, err := read(false)
+			assert.NoError(t, err)
+			assert.Equal(t, "secret", password)
+		}
+	})
+
+	t.Run("new password is confirmed", func(t *testing.T) {
+		read := NewMasterPasswordReader(fakePrinter{}, &fakeScanner{lines: []string{"secret", "secret"}})
+
+		password, err := read(true)
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", password)
+
+		password, err = read(true)
+		assert.NoError(t, err, "confirmed password should not be asked again")
+		assert.Equal(t, "secret", password)
+	})
+
+	t.Run("known password is confirmed once it is new", func(t *testing.T) {
+		read := NewMasterPasswordReader(fakePrinter{}, &fakeScanner{lines: []string{"secret", "secret"}})
+
+		_, err := read(false)
+		assert.NoError(t, err)
+
+		password, err := read(true)
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", password)
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		read := NewMasterPasswordReader(fakePrinter{}, &fakeScanner{lines: []string{"secret", "typo", "another"}})
+
+		_, err := read(true)
+		assert.Error(t, err)
+
+		password, err := read(false)
+		assert.NoError(t, err)
+		assert.Equal(t, "another", password, "mismatched password should not be kept")
+	})
+
+	t.Run("password from the environment is not confirmed", func(t *testing.T) {
+		t.Setenv(masterPasswordEnv, "secret")
+		read := NewMasterPasswordReader(fakePrinter{}, &fakeScanner{})
+
+		password, err := read(true)
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", password)
+	})
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+)
+
+func passwdFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "master",
+			Usage: "change the master password instead of the account password",
+		},
+	}
+}
+
+// changePassword changes either the account password or, with --master, the master password.
+// The current account password is asked in both cases, it authorizes re-wrapping the vault key.
+func changePassword(cCtx *cli.Context, params NewClientParams) error {
+	password, err := newParamReader(params.Printer, params.Scanner, "Current Password").
+		String().
+		StripWhitespaces(false).
+		NotEmpty(true).
+		Read()
+	if err != nil {
+		return err
+	}
+
+	if cCtx.Bool("master") {
+		newMasterPassword, err := readNewPassword(params, "New Master Password")
+		if err != nil {
+			return err
+		}
+
+		if err := params.ClientService.ChangeMasterPassword(password, newMasterPassword); err != nil {
+			return err
+		}
+
+		params.Printer.Printf("master password was successfully changed, use `mpass passwd --master` on the other devices too\n")
+
+		return nil
+	}
+
+	newPassword, err := readNewPassword(params, "New Password")
+	if err != nil {
+		return err
+	}
+
+	if err := params.ClientService.ChangePassword(password, newPassword); err != nil {
+		return err
+	}
+
+	params.Printer.Printf("password was successfully changed, the other devices have to login again\n")
+
+	return nil
+}
+
+// readNewPassword asks for the new password twice.
+func readNewPassword(params NewClientParams, name string) (string, error) {
+	password, err := newParamReader(params.Printer, params.Scanner, name).
+		String().
+		StripWhitespaces(false).
+		NotEmpty(true).
+		Read()
+	if err != nil {
+		return "", err
+	}
+
+	repeated, err := newParamReader(params.Printer, params.Scanner, name+" again").
+		String().
+		StripWhitespaces(false).
+		NotEmpty(true).
+		Read()
+	if err != nil {
+		return "", err
+	}
+
+	if password != repeated {
+		return "", errors.New("passwords do not match")
+	}
+
+	return password, nil
+}
@@ -0,0 +1,41 @@
+package client
+
+import "fmt"
+
+// newProgressPrinter returns a function printing the progress of the transferred records.
+// The line of the record is updated in place and finished once the transfer is done.
+func newProgressPrinter(printer printer) func(key string, done, total int64) {
+	lastKey, lastPercent := "", -1
+
+	return func(key string, done, total int64) {
+		if total <= 0 {
+			return
+		}
+
+		percent := int(done * 100 / total)
+		if key == lastKey && percent == lastPercent {
+			return
+		}
+		lastKey, lastPercent = key, percent
+
+		printer.Printf("\r%s: %3d%% (%s of %s)", key, percent, formatSize(done), formatSize(total))
+		if done >= total {
+			printer.Printf("\n")
+		}
+	}
+}
+
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,34 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type bufferPrinter struct {
+	strings.Builder
+}
+
+func (b *bufferPrinter) Printf(format string, a ...any) {
+	fmt.Fprintf(&b.Builder, format, a...)
+}
+
+func Test_newProgressPrinter(t *testing.T) {
+	var out bufferPrinter
+	progress := newProgressPrinter(&out)
+
+	progress("scan.pdf", 1<<20, 4<<20)
+	progress("scan.pdf", 1<<20+1, 4<<20) // the same percent is not printed again
+	progress("scan.pdf", 4<<20, 4<<20)
+
+	assert.Equal(t, "\rscan.pdf:  25% (1.0 MiB of 4.0 MiB)\rscan.pdf: 100% (4.0 MiB of 4.0 MiB)\n", out.String())
+}
+
+func Test_formatSize(t *testing.T) {
+	assert.Equal(t, "512 B", formatSize(512))
+	assert.Equal(t, "1.5 KiB", formatSize(1536))
+	assert.Equal(t, "2.0 GiB", formatSize(2<<30))
+}
@@ -0,0 +1,143 @@
+package client
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/denistakeda/mpass/internal/domain/record"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func sshKeyFlags() []cli.Flag {
+	return append(annotationFlags(),
+		&cli.StringFlag{
+			Name:  "comment",
+			Usage: "the comment of the public key, it is taken from the .pub file next to the imported key by default",
+		},
+	)
+}
+
+// importSSHKey reads the private key from the file and asks for the passphrase if the key is encrypted.
+func importSSHKey(cCtx *cli.Context, params NewClientParams, key, path string) (*record.SSHKeyRecord, error) {
+	privateKey, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read file %q", path)
+	}
+
+	comment := cCtx.String("comment")
+	if comment == "" {
+		comment = publicKeyComment(path + ".pub")
+	}
+
+	rec, err := record.ParseSSHKeyRecord(key, privateKey, "", comment)
+
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		passphrase, err := newParamReader(params.Printer, params.Scanner, "Passphrase").
+			String().
+			StripWhitespaces(false).
+			NotEmpty(true).
+			Read()
+		if err != nil {
+			return nil, err
+		}
+
+		return record.ParseSSHKeyRecord(key, privateKey, passphrase, comment)
+	}
+
+	return rec, err
+}
+
+// publicKeyComment returns the comment of the public key from the file, if there is one.
+func publicKeyComment(path string) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+
+	_, comment, _, _, err := ssh.ParseAuthorizedKey(content)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(comment)
+}
+
+// loadSSHKeys adds all the SSH keys of the vault to the in-memory agent.
+// The keys that could not be used are reported and skipped.
+func loadSSHKeys(printer printer, clientService clientService) (agent.Agent, error) {
+	recs, err := clientService.ListRecords(record.KindSSHKey)
+	if err != nil {
+		return nil, err
+	}
+
+	keyring := agent.NewKeyring()
+	for _, rec := range recs {
+		key, err := rec.(*record.SSHKeyRecord).AgentKey()
+		if err == nil {
+			err = keyring.Add(key)
+		}
+		if err != nil {
+			printer.Printf("key %q is skipped: %v\n", rec.GetId(), err)
+			continue
+		}
+	}
+
+	return keyring, nil
+}
+
+// listenAgentSocket creates the UNIX socket only the user could connect to.
+// A private temporary directory is created for the socket if the path is not set.
+func listenAgentSocket(path string) (net.Listener, func(), error) {
+	cleanup := func() {}
+	if path == "" {
+		dir, err := os.MkdirTemp("", "mpass-agent-")
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to create the socket directory")
+		}
+
+		path = filepath.Join(dir, "agent.sock")
+		cleanup = func() { os.RemoveAll(dir) }
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		cleanup()
+		return nil, nil, errors.Wrapf(err, "failed to listen on %q", path)
+	}
+
+	if err := os.Chmod(path, 0o600); err != nil {
+		listener.Close()
+		cleanup()
+		return nil, nil, errors.Wrapf(err, "failed to restrict access to %q", path)
+	}
+
+	return listener, func() {
+		listener.Close()
+		cleanup()
+	}, nil
+}
+
+// serveSSHAgent serves the keys over the SSH agent protocol until the listener is closed.
+func serveSSHAgent(listener net.Listener, keyring agent.Agent) error {
+	for {
+		conn, err := listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "failed to accept the connection")
+		}
+
+		go func() {
+			defer conn.Close()
+			// the error only means that the client has disconnected
+			_ = agent.ServeAgent(keyring, conn)
+		}()
+	}
+}
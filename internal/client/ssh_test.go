@@ -0,0 +1,62 @@
+package client
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/denistakeda/mpass/internal/domain/record"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func Test_serveSSHAgent(t *testing.T) {
+	rec, err := record.GenerateSSHKeyRecord("github", "alice@example.com")
+	require.NoError(t, err)
+	key, err := rec.AgentKey()
+	require.NoError(t, err)
+
+	keyring := agent.NewKeyring()
+	require.NoError(t, keyring.Add(key))
+
+	listener, cleanup, err := listenAgentSocket(filepath.Join(t.TempDir(), "agent.sock"))
+	require.NoError(t, err)
+	defer cleanup()
+
+	info, err := os.Stat(listener.Addr().String())
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm(), "only the user should connect to the agent")
+
+	served := make(chan error)
+	go func() { served <- serveSSHAgent(listener, keyring) }()
+
+	conn, err := net.Dial("unix", listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	client := agent.NewClient(conn)
+
+	keys, err := client.List()
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, rec.AuthorizedKey(), keys[0].String())
+
+	data := []byte("data to sign")
+	signature, err := client.Sign(keys[0], data)
+	require.NoError(t, err)
+	assert.NoError(t, keys[0].Verify(data, signature))
+
+	listener.Close()
+	assert.NoError(t, <-served, "closing the listener should stop the agent")
+}
+
+func Test_publicKeyComment(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "id_ed25519.pub")
+	require.NoError(t, os.WriteFile(path, []byte("ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIKV62KA6lmqGYDwYOHgGpf9rFxf9v6CegDjF3a9Y/0Fg alice@example.com\n"), 0o600))
+
+	assert.Equal(t, "alice@example.com", publicKeyComment(path))
+	assert.Equal(t, "", publicKeyComment(filepath.Join(dir, "missing.pub")))
+}
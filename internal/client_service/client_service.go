@@ -3,13 +3,18 @@ package client_service
 import (
 	"context"
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/denistakeda/mpass/internal/domain/record"
+	"github.com/denistakeda/mpass/internal/encryption"
 	"github.com/denistakeda/mpass/proto"
 	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 var (
@@ -17,28 +22,59 @@ var (
 	syncTimeout   = 10 * time.Second
 )
 
+// The ways to resolve a conflict.
+const (
+	KeepLocal  = "local"
+	KeepRemote = "remote"
+	KeepBoth   = "both"
+)
+
 type (
 	clientService struct {
-		clientStorage clientStorage
-		grpcClient    grpcClient
+		clientStorage  clientStorage
+		grpcClient     grpcClient
+		masterPassword masterPasswordProvider
 	}
 
 	clientStorage interface {
 		SetRecord(record.Record) error
 		GetRecord(string) (record.Record, error)
+		ListRecords(kind record.Kind, tags ...string) ([]record.Record, error)
 		SetToken(string) error
 		GetToken() (string, error)
+		SetRefreshToken(string) error
+		GetRefreshToken() (string, error)
+		SetVaultKey([]byte) error
+		GetVaultKey() ([]byte, error)
+		DeleteRecord(string) error
 		ItemsToSync() ([]record.Record, error)
-		SyncRecords([]record.Record) error
+		ItemsToDelete() ([]record.Tombstone, error)
+		GetRevision() (int64, error)
+		SetRevision(int64) error
+		IDsMigrated() (bool, error)
+		SetIDsMigrated(bool) error
+		ApplyChanges(record.Changes) error
+		ClearSent([]string) error
+		GetUpload(string) ([]byte, error)
+		SetUpload(string, []byte) error
+		MarkConflicts([]string) error
+		Conflicts() ([]record.Record, error)
+		GetConflict(string) (record.Record, error)
+		RemoveConflict(string) error
+		ChangeMasterPassword(newPassword string) error
 	}
 
 	grpcClient interface {
 		GetClient() (proto.MpassServiceClient, error)
 	}
+
+	// masterPasswordProvider asks the user for the master password, the new one is confirmed if confirm is set.
+	// The master password never leaves the client, it only protects the vault key.
+	masterPasswordProvider func(confirm bool) (string, error)
 )
 
-func New(clientStorage clientStorage, grpcClient grpcClient) *clientService {
-	return &clientService{clientStorage: clientStorage, grpcClient: grpcClient}
+func New(clientStorage clientStorage, grpcClient grpcClient, masterPassword masterPasswordProvider) *clientService {
+	return &clientService{clientStorage: clientStorage, grpcClient: grpcClient, masterPassword: masterPassword}
 }
 
 func (c *clientService) SetRecord(r record.Record) error {
@@ -58,6 +94,65 @@ func (c *clientService) GetRecord(key string) (record.Record, error) {
 	return rec, nil
 }
 
+// ListRecords returns the local records of the kind having all the tags,
+// the records of any kind if the kind is empty.
+func (c *clientService) ListRecords(kind record.Kind, tags ...string) ([]record.Record, error) {
+	recs, err := c.clientStorage.ListRecords(kind, tags...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list records")
+	}
+
+	return recs, nil
+}
+
+func (c *clientService) DeleteRecord(key string) error {
+	if err := c.clientStorage.DeleteRecord(key); err != nil {
+		return errors.Wrapf(err, "failed to delete record %q", key)
+	}
+
+	return nil
+}
+
+// Conflicts returns the local versions of the records changed on another device concurrently.
+func (c *clientService) Conflicts() ([]record.Record, error) {
+	conflicts, err := c.clientStorage.Conflicts()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get conflicts")
+	}
+
+	return conflicts, nil
+}
+
+// ResolveConflict resolves the conflict keeping the local, the remote or both versions of the record.
+// When both versions are kept, the local one is stored under a new key which is returned.
+func (c *clientService) ResolveConflict(key, keep string) (string, error) {
+	local, err := c.clientStorage.GetConflict(key)
+	if err != nil {
+		return "", err
+	}
+
+	var newKey string
+	switch keep {
+	case KeepLocal:
+		err = c.clientStorage.SetRecord(local)
+	case KeepRemote:
+	case KeepBoth:
+		newKey = fmt.Sprintf("%s.conflict-%s", key, local.GetLastUpdateDate().Format("20060102150405"))
+		err = c.setRebased(local, newKey)
+	default:
+		return "", errors.Errorf("unknown conflict resolution %q, expected one of: %s, %s, %s", keep, KeepLocal, KeepRemote, KeepBoth)
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to resolve conflict for record %q", key)
+	}
+
+	if err := c.clientStorage.RemoveConflict(key); err != nil {
+		return "", errors.Wrapf(err, "failed to resolve conflict for record %q", key)
+	}
+
+	return newKey, nil
+}
+
 func (c *clientService) RegisterUser(login, password string) error {
 	client, err := c.grpcClient.GetClient()
 	if err != nil {
@@ -67,12 +162,12 @@ func (c *clientService) RegisterUser(login, password string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), signUpTimeout)
 	defer cancel()
 
-	resp, err := client.SignUp(ctx, &proto.SignUpRequest{Login: login, Password: password})
+	resp, err := client.SignUp(ctx, &proto.SignUpRequest{Login: login, Password: password, Device: deviceName()})
 	if err != nil {
 		return errors.Wrapf(err, "failed to request user registration for user %q", login)
 	}
 
-	return c.clientStorage.SetToken(resp.Token)
+	return c.setSession(resp.Token, resp.RefreshToken)
 }
 
 func (c *clientService) LoginUser(login, password string) error {
@@ -84,15 +179,51 @@ func (c *clientService) LoginUser(login, password string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), signUpTimeout)
 	defer cancel()
 
-	resp, err := client.SignIn(ctx, &proto.SignInRequest{Login: login, Password: password})
+	resp, err := client.SignIn(ctx, &proto.SignInRequest{Login: login, Password: password, Device: deviceName()})
 	if err != nil {
 		return errors.Wrapf(err, "failed to request user login for user %q", login)
 	}
 
-	return c.clientStorage.SetToken(resp.Token)
+	return c.setSession(resp.Token, resp.RefreshToken)
 }
 
-func (c *clientService) Sync() error {
+// Logout forgets the session tokens and revokes the session on the server.
+// The local records are kept.
+func (c *clientService) Logout() error {
+	refreshToken, err := c.clientStorage.GetRefreshToken()
+	if err != nil {
+		return errors.Wrap(err, "failed to get refresh token")
+	}
+
+	if err := c.clientStorage.SetToken(""); err != nil {
+		return err
+	}
+	if err := c.clientStorage.SetRefreshToken(""); err != nil {
+		return err
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+
+	client, err := c.grpcClient.GetClient()
+	if err != nil {
+		return errors.Wrap(err, "logged out locally, but failed to revoke the session on the server")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), signUpTimeout)
+	defer cancel()
+
+	if _, err := client.Logout(ctx, &proto.LogoutRequest{RefreshToken: refreshToken}); err != nil {
+		return errors.Wrap(err, "logged out locally, but failed to revoke the session on the server")
+	}
+
+	return nil
+}
+
+// Sync sends the local changes to the server and applies the changes received from it.
+// The progress of the records transferred in chunks is reported with the progress function if it is not nil.
+func (c *clientService) Sync(progress func(key string, done, total int64)) error {
 	token, err := c.clientStorage.GetToken()
 	if err != nil {
 		return errors.Wrap(err, "failed to get user token")
@@ -106,42 +237,502 @@ func (c *clientService) Sync() error {
 		return errors.Wrapf(err, "failed to sync")
 	}
 
+	if progress == nil {
+		progress = func(string, int64, int64) {}
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), syncTimeout)
 	defer cancel()
 
-	md := metadata.New(map[string]string{"authorization": fmt.Sprintf("Bearer %s", token)})
-	ctx = metadata.NewOutgoingContext(context.Background(), md)
+	vaultKey, err := c.unlockVault(ctx, client)
+	if err != nil {
+		return err
+	}
 
-	toSync, err := c.clientStorage.ItemsToSync()
+	if err := c.migrateIDs(ctx, client, vaultKey, progress); err != nil {
+		return err
+	}
+
+	if err := c.sendDeletions(ctx, client, vaultKey); err != nil {
+		return err
+	}
+
+	large, err := c.sendRecords(ctx, client, vaultKey)
 	if err != nil {
 		return err
 	}
 
-	if len(toSync) > 0 {
-		var addRecordsRequest proto.AddRecordsRequest
-		for _, item := range toSync {
-			addRecordsRequest.Records = append(addRecordsRequest.Records, item.ToProto())
+	// every transfer has its own timeout
+	for _, rec := range large {
+		if err := c.uploadRecord(client, rec, progress); err != nil {
+			return err
 		}
-		_, err = client.AddRecords(ctx, &addRecordsRequest)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), syncTimeout)
+	defer cancel()
+
+	return c.receiveChanges(ctx, client, vaultKey, progress)
+}
+
+func (c *clientService) sendDeletions(ctx context.Context, client proto.MpassServiceClient, vaultKey []byte) error {
+	toDelete, err := c.clientStorage.ItemsToDelete()
+	if err != nil {
+		return err
+	}
+
+	if len(toDelete) == 0 {
+		return nil
+	}
+
+	var (
+		deleteRecordsRequest proto.DeleteRecordsRequest
+		keys                 []string
+	)
+	for _, item := range toDelete {
+		tombstone := item.ToProto()
+		tombstone.Id = record.OpaqueID(vaultKey, item.ID)
+		deleteRecordsRequest.Tombstones = append(deleteRecordsRequest.Tombstones, tombstone)
+		keys = append(keys, item.ID)
+	}
+
+	err = c.call(ctx, client, func(ctx context.Context) error {
+		_, err := client.DeleteRecords(ctx, &deleteRecordsRequest)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.clientStorage.ClearSent(keys)
+}
+
+// sendRecords sends the changed records in batches small enough for a single message
+// and returns the records too big to be sent inline, they should be uploaded in chunks.
+func (c *clientService) sendRecords(ctx context.Context, client proto.MpassServiceClient, vaultKey []byte) ([]largeRecord, error) {
+	toSync, err := c.clientStorage.ItemsToSync()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		large     []largeRecord
+		batch     []*proto.Record
+		batchSize int
+		// the keys of the records in the batch by their opaque ids
+		batchKeys = make(map[string]string)
+	)
+
+	send := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		var resp *proto.AddRecordsResponse
+		err := c.call(ctx, client, func(ctx context.Context) (err error) {
+			resp, err = client.AddRecords(ctx, &proto.AddRecordsRequest{Records: batch})
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		keys := make([]string, 0, len(batch))
+		for _, rec := range batch {
+			keys = append(keys, batchKeys[rec.Id])
+		}
+		conflicts := make([]string, 0, len(resp.Conflicts))
+		for _, id := range resp.Conflicts {
+			conflicts = append(conflicts, batchKeys[id])
+		}
+		batch, batchSize, batchKeys = nil, 0, make(map[string]string)
+
+		if err := c.clientStorage.MarkConflicts(conflicts); err != nil {
+			return errors.Wrap(err, "failed to store conflicts")
+		}
+
+		// the accepted records are not sent again even if the rest of the sync fails
+		return c.clientStorage.ClearSent(keys)
+	}
+
+	for _, item := range toSync {
+		// the payload of the interrupted upload is sent again as it is, the upload is resumed by its checksum
+		payload, err := c.clientStorage.GetUpload(item.GetId())
 		if err != nil {
+			return nil, err
+		}
+		if payload != nil {
+			large = append(large, largeRecord{key: item.GetId(), EncryptedRecord: &record.EncryptedRecord{
+				ID:             record.OpaqueID(vaultKey, item.GetId()),
+				LastUpdateDate: item.GetLastUpdateDate(),
+				Revision:       item.GetRevision(),
+
+				Payload: payload,
+			}})
+			continue
+		}
+
+		encrypted, err := record.Encrypt(item, vaultKey)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(encrypted.Payload) > maxInlinePayload {
+			if err := c.clientStorage.SetUpload(item.GetId(), encrypted.Payload); err != nil {
+				return nil, err
+			}
+			large = append(large, largeRecord{key: item.GetId(), EncryptedRecord: encrypted})
+			continue
+		}
+
+		if batchSize+len(encrypted.Payload) > maxBatchSize {
+			if err := send(); err != nil {
+				return nil, err
+			}
+		}
+
+		batch = append(batch, encrypted.ToProto())
+		batchSize += len(encrypted.Payload)
+		batchKeys[encrypted.ID] = item.GetId()
+	}
+
+	if err := send(); err != nil {
+		return nil, err
+	}
+
+	return large, nil
+}
+
+func (c *clientService) receiveChanges(ctx context.Context, client proto.MpassServiceClient, vaultKey []byte, progress func(key string, done, total int64)) error {
+	revision, err := c.clientStorage.GetRevision()
+	if err != nil {
+		return err
+	}
+
+	var resp *proto.GetChangesSinceResponse
+	err = c.call(ctx, client, func(ctx context.Context) (err error) {
+		resp, err = client.GetChangesSince(ctx, &proto.GetChangesSinceRequest{Revision: revision})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	changes := record.Changes{
+		Records:    make([]record.Record, 0, len(resp.Records)),
+		Tombstones: make([]record.Tombstone, 0, len(resp.Tombstones)),
+		Revision:   resp.Revision,
+		// the very first sync replaces everything stored locally
+		Full: resp.Full || revision == 0,
+	}
+	// the server knows the records by the opaque ids, the keys of the local records are shown in the progress
+	// of the downloads and the tombstones of the local records are applied to them
+	needKeys := len(resp.Tombstones) > 0
+	for _, item := range resp.Records {
+		needKeys = needKeys || item.PayloadSize > 0
+	}
+	var keys map[string]string
+	if needKeys {
+		if keys, err = c.keysByID(vaultKey); err != nil {
 			return err
 		}
 	}
 
-	resp, err := client.AllRecords(ctx, &empty.Empty{})
+	for _, item := range resp.Records {
+		// the payloads of the big records are not sent inline
+		if item.PayloadSize > 0 {
+			label := item.Id
+			if key, ok := keys[item.Id]; ok {
+				label = key
+			}
+			payload, err := c.downloadRecord(client, item.Id, 0, item.PayloadSize, func(_ string, done, total int64) {
+				progress(label, done, total)
+			})
+			if err != nil {
+				return err
+			}
+			item.Record = &proto.Record_EncryptedRecord{EncryptedRecord: &proto.EncryptedRecord{Payload: payload}}
+		}
+
+		rec := record.FromProto(item)
+		if encrypted, ok := rec.(*record.EncryptedRecord); ok {
+			rec, err = encrypted.Decrypt(vaultKey)
+			if err != nil {
+				return err
+			}
+		}
+		changes.Records = append(changes.Records, rec)
+	}
+
+	for _, item := range resp.Tombstones {
+		tombstone := record.TombstoneFromProto(item)
+		if key, ok := keys[tombstone.ID]; ok {
+			tombstone.ID = key
+		}
+		changes.Tombstones = append(changes.Tombstones, tombstone)
+	}
+
+	return c.clientStorage.ApplyChanges(changes)
+}
+
+// keysByID returns the keys of the local records by their opaque ids.
+func (c *clientService) keysByID(vaultKey []byte) (map[string]string, error) {
+	recs, err := c.clientStorage.ListRecords("")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list records")
+	}
+
+	keys := make(map[string]string, len(recs))
+	for _, rec := range recs {
+		keys[record.OpaqueID(vaultKey, rec.GetId())] = rec.GetId()
+	}
+
+	return keys, nil
+}
+
+// migrateIDs moves the records the earlier versions stored on the server under their keys to the opaque ids once.
+// The local copies of such records are queued to be sent again and the rows under the keys are purged
+// from the server together with their history, so that the keys are not kept there in clear.
+func (c *clientService) migrateIDs(ctx context.Context, client proto.MpassServiceClient, vaultKey []byte, progress func(key string, done, total int64)) error {
+	migrated, err := c.clientStorage.IDsMigrated()
+	if err != nil || migrated {
+		return err
+	}
+
+	// every legacy record should have a local copy before it is purged
+	if err := c.receiveChanges(ctx, client, vaultKey, progress); err != nil {
+		return err
+	}
+
+	recs, err := c.clientStorage.ListRecords("")
+	if err != nil {
+		return errors.Wrap(err, "failed to list records")
+	}
+	local := make(map[string]record.Record, len(recs))
+	for _, rec := range recs {
+		local[rec.GetId()] = rec
+	}
+
+	// the deletions not sent yet are sent under the opaque ids, the records under the keys are purged instead
+	toDelete, err := c.clientStorage.ItemsToDelete()
 	if err != nil {
 		return err
 	}
-	records := make([]record.Record, 0, len(resp.Records))
+	deleted := make(map[string]bool, len(toDelete))
+	for _, tombstone := range toDelete {
+		deleted[tombstone.ID] = true
+	}
+
+	var resp *proto.GetChangesSinceResponse
+	err = c.call(ctx, client, func(ctx context.Context) (err error) {
+		resp, err = client.GetChangesSince(ctx, &proto.GetChangesSinceRequest{})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	purge := proto.DeleteRecordsRequest{Purge: true}
 	for _, item := range resp.Records {
-		records = append(records, record.FromProto(item))
+		rec, ok := local[item.Id]
+		if !ok && !deleted[item.Id] {
+			continue
+		}
+
+		if ok {
+			if err := c.clientStorage.SetRecord(rec); err != nil {
+				return errors.Wrapf(err, "failed to queue record %q", item.Id)
+			}
+		}
+		purge.Tombstones = append(purge.Tombstones, &proto.Tombstone{Id: item.Id, DeletionDate: timestamppb.Now()})
 	}
 
-	err = c.clientStorage.SyncRecords(records)
+	if len(purge.Tombstones) > 0 {
+		err = c.call(ctx, client, func(ctx context.Context) error {
+			_, err := client.DeleteRecords(ctx, &purge)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.clientStorage.SetIDsMigrated(true)
+}
+
+// setSession stores the tokens of a newly signed in user.
+// The vault key of the previous user is forgotten, the new one will be fetched
+// together with the whole vault on the next sync.
+func (c *clientService) setSession(token, refreshToken string) error {
+	if err := c.clientStorage.SetVaultKey(nil); err != nil {
+		return err
+	}
+
+	if err := c.clientStorage.SetRevision(0); err != nil {
+		return err
+	}
+
+	if err := c.clientStorage.SetIDsMigrated(false); err != nil {
+		return err
+	}
+
+	return c.setTokens(token, refreshToken)
+}
+
+func (c *clientService) setTokens(token, refreshToken string) error {
+	if err := c.clientStorage.SetToken(token); err != nil {
+		return err
+	}
+
+	return c.clientStorage.SetRefreshToken(refreshToken)
+}
+
+// call calls the server with the access token of the user.
+// If the access token is expired, it is refreshed and the call is repeated once.
+func (c *clientService) call(ctx context.Context, client proto.MpassServiceClient, f func(ctx context.Context) error) error {
+	token, err := c.clientStorage.GetToken()
 	if err != nil {
+		return errors.Wrap(err, "failed to get user token")
+	}
+
+	err = f(withToken(ctx, token))
+	if status.Code(err) != codes.Unauthenticated {
 		return err
 	}
 
-	return nil
+	token, err = c.refreshSession(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	return f(withToken(ctx, token))
+}
+
+// refreshSession exchanges the refresh token for the new pair of tokens and returns the new access token.
+func (c *clientService) refreshSession(ctx context.Context, client proto.MpassServiceClient) (string, error) {
+	refreshToken, err := c.clientStorage.GetRefreshToken()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get refresh token")
+	}
+	if refreshToken == "" {
+		return "", errors.New("the session is expired, use `mpass login` again")
+	}
+
+	resp, err := client.RefreshToken(ctx, &proto.RefreshTokenRequest{RefreshToken: refreshToken})
+	if err != nil {
+		return "", errors.Wrap(err, "the session is expired, use `mpass login` again")
+	}
+
+	if err := c.setTokens(resp.Token, resp.RefreshToken); err != nil {
+		return "", errors.Wrap(err, "failed to store the session tokens")
+	}
+
+	return resp.Token, nil
+}
+
+// unlockVault returns the vault key unwrapped with the master password.
+func (c *clientService) unlockVault(ctx context.Context, client proto.MpassServiceClient) ([]byte, error) {
+	password, err := c.masterPassword(false)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get master password")
+	}
+
+	wrappedKey, err := c.clientStorage.GetVaultKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get vault key")
+	}
+
+	if len(wrappedKey) == 0 {
+		wrappedKey, err = c.fetchVaultKey(ctx, client, password)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := c.clientStorage.SetVaultKey(wrappedKey); err != nil {
+			return nil, errors.Wrap(err, "failed to store vault key")
+		}
+	}
+
+	vaultKey, err := encryption.OpenWithPassword(password, wrappedKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unlock the vault, check the master password or use `mpass passwd --master` if it was changed on another device")
+	}
+
+	return vaultKey, nil
+}
+
+// fetchVaultKey gets the wrapped vault key from the server.
+// The very first device of the user generates the vault key and sends it to the server.
+func (c *clientService) fetchVaultKey(ctx context.Context, client proto.MpassServiceClient, password string) ([]byte, error) {
+	var resp *proto.VaultKey
+	err := c.call(ctx, client, func(ctx context.Context) (err error) {
+		resp, err = client.GetVaultKey(ctx, &empty.Empty{})
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch vault key")
+	}
+	if len(resp.WrappedKey) > 0 {
+		return resp.WrappedKey, nil
+	}
+
+	vaultKey, err := encryption.NewKey()
+	if err != nil {
+		return nil, err
+	}
+
+	// the master password is new for the vault, a typo would lock it
+	if password, err = c.masterPassword(true); err != nil {
+		return nil, errors.Wrap(err, "failed to get master password")
+	}
+
+	wrappedKey, err := encryption.SealWithPassword(password, vaultKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to wrap vault key")
+	}
+
+	err = c.call(ctx, client, func(ctx context.Context) error {
+		_, err := client.InitVaultKey(ctx, &proto.VaultKey{WrappedKey: wrappedKey})
+		return err
+	})
+	if err != nil {
+		// another device could have initiated the key in the meantime
+		getErr := c.call(ctx, client, func(ctx context.Context) (err error) {
+			resp, err = client.GetVaultKey(ctx, &empty.Empty{})
+			return err
+		})
+		if getErr != nil || len(resp.WrappedKey) == 0 {
+			return nil, errors.Wrap(err, "failed to init vault key")
+		}
+
+		return resp.WrappedKey, nil
+	}
+
+	return wrappedKey, nil
+}
+
+func withToken(ctx context.Context, token string) context.Context {
+	md := metadata.New(map[string]string{"authorization": fmt.Sprintf("Bearer %s", token)})
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// deviceName names the session of this client on the server.
+func deviceName() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+
+	return hostname
+}
+
+// setRebased stores the copy of the record under the key based on the revision of the local record with the key,
+// so that it replaces the version the client has seen instead of conflicting with it.
+func (c *clientService) setRebased(r record.Record, key string) error {
+	var revision int64
+	if local, err := c.clientStorage.GetRecord(key); err == nil {
+		revision = local.GetRevision()
+	}
 
+	return c.clientStorage.SetRecord(record.Rebase(r, key, revision))
 }
@@ -2,10 +2,12 @@ package client_service
 
 import (
 	"context"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/denistakeda/mpass/internal/auth_service"
+	"github.com/denistakeda/mpass/internal/blob_store"
 	"github.com/denistakeda/mpass/internal/client_storage"
 	"github.com/denistakeda/mpass/internal/db"
 	"github.com/denistakeda/mpass/internal/domain/record"
@@ -14,12 +16,18 @@ import (
 	"github.com/denistakeda/mpass/internal/record_service"
 	"github.com/denistakeda/mpass/internal/record_store"
 	"github.com/denistakeda/mpass/internal/server"
+	"github.com/denistakeda/mpass/internal/token_store"
+	"github.com/denistakeda/mpass/internal/upload_store"
 	"github.com/denistakeda/mpass/internal/user_store"
+	"github.com/denistakeda/mpass/proto"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
 	"github.com/testcontainers/testcontainers-go/wait"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 )
 
 func TestTest(t *testing.T) {
@@ -51,7 +59,9 @@ func TestTest(t *testing.T) {
 	require.NoError(t, err, "failed to initiate database")
 
 	userStore := user_store.NewWithDB(db)
-	recordStore := record_store.NewWithDb(db)
+	tokenStore := token_store.NewWithDB(db)
+	recordStore := record_store.NewWithDb(db, blob_store.NewInMemory())
+	uploadStore := upload_store.NewWithDB(db)
 
 	// Services
 	authService := auth_service.New(auth_service.NewAuthServiceParams{
@@ -59,9 +69,10 @@ func TestTest(t *testing.T) {
 
 		LogService: logService,
 		UserStore:  userStore,
+		TokenStore: tokenStore,
 	})
 
-	recordService := record_service.New(logService, recordStore)
+	recordService := record_service.New(logService, recordStore, uploadStore, userStore, 0, 0)
 
 	s := server.New(server.NewServerParams{
 		Host:          ":3200",
@@ -73,13 +84,17 @@ func TestTest(t *testing.T) {
 	defer s.Stop()
 
 	// setup client service
-	grpcClient := grpc_client.New(":3200")
+	grpcClient := grpc_client.New(":3200", insecure.NewCredentials())
 	defer grpcClient.Close()
 
-	clientStorage := client_storage.NewInMemory("~/.mpass/state.gob")
+	masterPassword := func(bool) (string, error) {
+		return "master-password", nil
+	}
+
+	clientStorage := client_storage.NewWithSQLite(filepath.Join(t.TempDir(), "state.db"), masterPassword)
 	defer clientStorage.Close()
 
-	clientService := New(clientStorage, grpcClient)
+	clientService := New(clientStorage, grpcClient, masterPassword)
 
 	// -- TEST DATA --
 
@@ -113,7 +128,97 @@ func TestTest(t *testing.T) {
 	})
 
 	t.Run("sync with the server", func(t *testing.T) {
-		err := clientService.Sync()
+		err := clientService.Sync(nil)
 		assert.NoError(t, err, "failed to create a login-password record")
 	})
+
+	t.Run("restore overwritten record", func(t *testing.T) {
+		require.NoError(t, clientService.SetRecord(record.NewTextRecord("just-a-text", "overwritten")))
+		require.NoError(t, clientService.Sync(nil))
+
+		versions, err := clientService.RecordHistory("just-a-text")
+		require.NoError(t, err)
+		require.Len(t, versions, 1)
+
+		require.NoError(t, clientService.RestoreRecord("just-a-text", versions[0].Version))
+		require.NoError(t, clientService.Sync(nil))
+
+		rec, err := clientService.GetRecord("just-a-text")
+		require.NoError(t, err)
+		assert.Equal(t, "text text text", rec.(*record.TextRecord).Text)
+	})
+}
+
+// interruptedClient fails to receive the changes from the server while interrupted is set.
+type interruptedClient struct {
+	grpcClient
+
+	interrupted bool
+}
+
+func (c *interruptedClient) GetClient() (proto.MpassServiceClient, error) {
+	client, err := c.grpcClient.GetClient()
+	if err != nil || !c.interrupted {
+		return client, err
+	}
+
+	return failingReceiveClient{client}, nil
+}
+
+type failingReceiveClient struct {
+	proto.MpassServiceClient
+}
+
+func (failingReceiveClient) GetChangesSince(context.Context, *proto.GetChangesSinceRequest, ...grpc.CallOption) (*proto.GetChangesSinceResponse, error) {
+	return nil, errors.New("connection lost")
+}
+
+func Test_clientService_interruptedSync(t *testing.T) {
+	logService := logging.New()
+	userStore := user_store.NewInMemory()
+	s := server.New(server.NewServerParams{
+		Host:       ":0",
+		LogService: logService,
+		AuthService: auth_service.New(auth_service.NewAuthServiceParams{
+			Secret:     "secret",
+			LogService: logService,
+			UserStore:  userStore,
+			TokenStore: token_store.NewInMemory(),
+		}),
+		RecordService: record_service.New(logService, record_store.NewInMemory(), upload_store.NewInMemory(), userStore, 0, 0),
+	})
+	s.Start()
+	defer s.Stop()
+
+	grpcClient := grpc_client.New(s.Host(), insecure.NewCredentials())
+	defer grpcClient.Close()
+	client := &interruptedClient{grpcClient: grpcClient}
+
+	masterPassword := func(bool) (string, error) {
+		return "master-password", nil
+	}
+
+	clientStorage := client_storage.NewWithSQLite(filepath.Join(t.TempDir(), "state.db"), masterPassword)
+	defer clientStorage.Close()
+
+	clientService := New(clientStorage, client, masterPassword)
+
+	require.NoError(t, clientService.RegisterUser("login", "password"))
+	require.NoError(t, clientService.SetRecord(record.NewTextRecord("notes", "first")))
+	require.NoError(t, clientService.Sync(nil))
+
+	require.NoError(t, clientService.SetRecord(record.NewTextRecord("notes", "second")))
+	client.interrupted = true
+	require.Error(t, clientService.Sync(nil), "sync should fail after the records are sent")
+
+	client.interrupted = false
+	require.NoError(t, clientService.Sync(nil))
+
+	conflicts, err := clientService.Conflicts()
+	require.NoError(t, err)
+	assert.Empty(t, conflicts, "the accepted record should not be sent again")
+
+	rec, err := clientService.GetRecord("notes")
+	require.NoError(t, err)
+	assert.Equal(t, "second", rec.(*record.TextRecord).Text)
 }
@@ -0,0 +1,129 @@
+package client_service
+
+import (
+	"context"
+
+	"github.com/denistakeda/mpass/internal/domain/record"
+	"github.com/denistakeda/mpass/proto"
+	"github.com/pkg/errors"
+)
+
+// RecordHistory returns the prior versions of the record the server keeps, the latest first.
+func (c *clientService) RecordHistory(key string) ([]record.Version, error) {
+	client, err := c.signedInClient()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), syncTimeout)
+	defer cancel()
+
+	vaultKey, err := c.unlockVault(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp *proto.ListRecordVersionsResponse
+	err = c.call(ctx, client, func(ctx context.Context) (err error) {
+		resp, err = client.ListRecordVersions(ctx, &proto.ListRecordVersionsRequest{Id: record.OpaqueID(vaultKey, key)})
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get history of record %q", key)
+	}
+
+	versions := make([]record.Version, 0, len(resp.Versions))
+	for _, version := range resp.Versions {
+		versions = append(versions, record.VersionFromProto(version))
+	}
+
+	return versions, nil
+}
+
+// RestoreRecord replaces the local record with its prior version, the restored record
+// is sent to the server on the next sync as a regular edit.
+func (c *clientService) RestoreRecord(key string, version int64) error {
+	client, err := c.signedInClient()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), syncTimeout)
+	defer cancel()
+
+	vaultKey, err := c.unlockVault(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	var item *proto.Record
+	err = c.call(ctx, client, func(ctx context.Context) (err error) {
+		item, err = client.GetRecordVersion(ctx, &proto.GetRecordVersionRequest{Id: record.OpaqueID(vaultKey, key), Version: version})
+		return err
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get version %d of record %q", version, key)
+	}
+
+	// the payloads of the big records are not sent inline
+	if item.PayloadSize > 0 {
+		payload, err := c.downloadRecord(client, item.Id, version, item.PayloadSize, func(string, int64, int64) {})
+		if err != nil {
+			return err
+		}
+		item.Record = &proto.Record_EncryptedRecord{EncryptedRecord: &proto.EncryptedRecord{Payload: payload}}
+	}
+
+	rec := record.FromProto(item)
+	if encrypted, ok := rec.(*record.EncryptedRecord); ok {
+		rec, err = encrypted.Decrypt(vaultKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := c.setRebased(rec, key); err != nil {
+		return errors.Wrapf(err, "failed to restore record %q", key)
+	}
+
+	return nil
+}
+
+// SetHistoryRetention sets how many prior versions of every record the server keeps, zero keeps all of them.
+func (c *clientService) SetHistoryRetention(keep int) error {
+	client, err := c.signedInClient()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), syncTimeout)
+	defer cancel()
+
+	err = c.call(ctx, client, func(ctx context.Context) error {
+		_, err := client.SetHistoryRetention(ctx, &proto.SetHistoryRetentionRequest{Keep: int64(keep)})
+		return err
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to set history retention")
+	}
+
+	return nil
+}
+
+// signedInClient returns the client of the server if the user is signed in.
+func (c *clientService) signedInClient() (proto.MpassServiceClient, error) {
+	token, err := c.clientStorage.GetToken()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get user token")
+	}
+	if token == "" {
+		return nil, errors.New("user is not signed in, use `mpass login` first")
+	}
+
+	client, err := c.grpcClient.GetClient()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect to the server")
+	}
+
+	return client, nil
+}
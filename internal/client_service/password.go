@@ -0,0 +1,119 @@
+package client_service
+
+import (
+	"context"
+
+	"github.com/denistakeda/mpass/internal/encryption"
+	"github.com/denistakeda/mpass/proto"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/pkg/errors"
+)
+
+// ChangePassword changes the account password on the server.
+// The sessions of all the devices are revoked, this one gets a new session.
+func (c *clientService) ChangePassword(oldPassword, newPassword string) error {
+	if newPassword == "" {
+		return errors.New("new password is empty")
+	}
+
+	client, err := c.signedInClient()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), signUpTimeout)
+	defer cancel()
+
+	return c.changePassword(ctx, client, &proto.ChangePasswordRequest{OldPassword: oldPassword, NewPassword: newPassword})
+}
+
+// ChangeMasterPassword changes the master password. The vault key is re-wrapped with the new master password
+// and replaced on the server, the account password authorizes it. The records are encrypted with the vault key
+// itself, so they are not re-uploaded, only the local state is sealed again with the new master password.
+// If the master password was already changed on another device, only the local state is changed.
+func (c *clientService) ChangeMasterPassword(password, newMasterPassword string) error {
+	if newMasterPassword == "" {
+		return errors.New("new master password is empty")
+	}
+
+	masterPassword, err := c.masterPassword(false)
+	if err != nil {
+		return errors.Wrap(err, "failed to get master password")
+	}
+
+	token, err := c.clientStorage.GetToken()
+	if err != nil {
+		return errors.Wrap(err, "failed to get user token")
+	}
+
+	// the vault key is kept only on the server, the local state of a device never signed in is just sealed again
+	if token != "" {
+		if err := c.rewrapVaultKey(password, masterPassword, newMasterPassword); err != nil {
+			return err
+		}
+	}
+
+	if err := c.clientStorage.ChangeMasterPassword(newMasterPassword); err != nil {
+		return errors.Wrap(err, "the vault key was re-wrapped, but failed to change the master password locally, run the command again")
+	}
+
+	return nil
+}
+
+// rewrapVaultKey wraps the vault key with the new master password and replaces it on the server.
+func (c *clientService) rewrapVaultKey(password, masterPassword, newMasterPassword string) error {
+	client, err := c.signedInClient()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), syncTimeout)
+	defer cancel()
+
+	var resp *proto.VaultKey
+	err = c.call(ctx, client, func(ctx context.Context) (err error) {
+		resp, err = client.GetVaultKey(ctx, &empty.Empty{})
+		return err
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch vault key")
+	}
+
+	// the vault key is not generated until the first sync
+	if len(resp.WrappedKey) == 0 {
+		return nil
+	}
+
+	wrappedKey := resp.WrappedKey
+	if _, err := encryption.OpenWithPassword(newMasterPassword, wrappedKey); err != nil {
+		vaultKey, err := encryption.OpenWithPassword(masterPassword, wrappedKey)
+		if err != nil {
+			return errors.Wrap(err, "failed to unlock the vault, check the master password")
+		}
+
+		if wrappedKey, err = encryption.SealWithPassword(newMasterPassword, vaultKey); err != nil {
+			return errors.Wrap(err, "failed to wrap vault key")
+		}
+
+		if err := c.changePassword(ctx, client, &proto.ChangePasswordRequest{OldPassword: password, WrappedKey: wrappedKey}); err != nil {
+			return err
+		}
+	}
+
+	return c.clientStorage.SetVaultKey(wrappedKey)
+}
+
+func (c *clientService) changePassword(ctx context.Context, client proto.MpassServiceClient, req *proto.ChangePasswordRequest) error {
+	req.Device = deviceName()
+
+	var resp *proto.ChangePasswordResponse
+	err := c.call(ctx, client, func(ctx context.Context) (err error) {
+		resp, err = client.ChangePassword(ctx, req)
+		return err
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to change password")
+	}
+
+	return c.setTokens(resp.Token, resp.RefreshToken)
+}
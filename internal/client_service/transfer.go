@@ -0,0 +1,188 @@
+package client_service
+
+import (
+	"context"
+	"crypto/sha256"
+	"hash/crc32"
+	"io"
+	"time"
+
+	"github.com/denistakeda/mpass/internal/domain/record"
+	"github.com/denistakeda/mpass/proto"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// the records with the encrypted payload bigger than maxInlinePayload are uploaded in chunks,
+	// the rest are sent in batches of up to maxBatchSize bytes
+	maxInlinePayload = 256 << 10
+	maxBatchSize     = 2 << 20
+	uploadChunkSize  = 1 << 20
+
+	// an interrupted transfer is resumed from the last received chunk
+	transferAttempts = 3
+	transferTimeout  = 10 * time.Minute
+)
+
+var crc32c = crc32.MakeTable(crc32.Castagnoli)
+
+// largeRecord is the encrypted record to be uploaded in chunks together with its key.
+type largeRecord struct {
+	key string
+	*record.EncryptedRecord
+}
+
+// uploadRecord uploads the encrypted record in chunks and marks it as conflicting if the server rejects it.
+func (c *clientService) uploadRecord(client proto.MpassServiceClient, rec largeRecord, progress func(key string, done, total int64)) error {
+	checksum := sha256.Sum256(rec.Payload)
+
+	var (
+		conflicts []string
+		err       error
+	)
+	for attempt := 1; attempt <= transferAttempts; attempt++ {
+		conflicts, err = c.tryUpload(client, rec, checksum[:], progress)
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to upload record %q", rec.key)
+	}
+
+	if len(conflicts) > 0 {
+		if err := c.clientStorage.MarkConflicts([]string{rec.key}); err != nil {
+			return errors.Wrap(err, "failed to store conflicts")
+		}
+	}
+
+	return c.clientStorage.ClearSent([]string{rec.key})
+}
+
+// tryUpload starts or continues the upload of the record and sends the rest of the payload.
+func (c *clientService) tryUpload(client proto.MpassServiceClient, rec largeRecord, checksum []byte, progress func(key string, done, total int64)) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), transferTimeout)
+	defer cancel()
+
+	size := int64(len(rec.Payload))
+
+	var upload *proto.StartUploadResponse
+	err := c.call(ctx, client, func(ctx context.Context) (err error) {
+		upload, err = client.StartUpload(ctx, &proto.StartUploadRequest{
+			Id:             rec.ID,
+			LastUpdateDate: timestamppb.New(rec.LastUpdateDate),
+			Revision:       rec.Revision,
+			Size:           size,
+			Checksum:       checksum,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var resp *proto.AddRecordsResponse
+	err = c.call(ctx, client, func(ctx context.Context) error {
+		stream, err := client.UploadRecord(ctx)
+		if err != nil {
+			return err
+		}
+
+		// at least one chunk is sent, even if the whole payload was received before
+		for offset := upload.Offset; ; {
+			end := offset + uploadChunkSize
+			if end > size {
+				end = size
+			}
+
+			data := rec.Payload[offset:end]
+			err := stream.Send(&proto.RecordChunk{
+				UploadId: upload.UploadId,
+				Offset:   offset,
+				Data:     data,
+				Checksum: crc32.Checksum(data, crc32c),
+			})
+			if err == io.EOF {
+				// the server has closed the stream, the error is returned by CloseAndRecv
+				break
+			}
+			if err != nil {
+				return err
+			}
+
+			offset = end
+			progress(rec.key, offset, size)
+
+			if offset >= size {
+				break
+			}
+		}
+
+		resp, err = stream.CloseAndRecv()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Conflicts, nil
+}
+
+// downloadRecord downloads the encrypted payload of the record in chunks,
+// the payload of the prior version is downloaded if the version is not zero.
+func (c *clientService) downloadRecord(client proto.MpassServiceClient, id string, version, size int64, progress func(key string, done, total int64)) ([]byte, error) {
+	payload := make([]byte, 0, size)
+
+	var err error
+	for attempt := 1; attempt <= transferAttempts; attempt++ {
+		payload, err = c.tryDownload(client, id, version, payload, size, progress)
+		if err == nil {
+			return payload, nil
+		}
+	}
+
+	return nil, errors.Wrapf(err, "failed to download record %q", id)
+}
+
+// tryDownload continues the download of the payload and returns the payload received so far.
+func (c *clientService) tryDownload(client proto.MpassServiceClient, id string, version int64, payload []byte, size int64, progress func(key string, done, total int64)) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), transferTimeout)
+	defer cancel()
+
+	err := c.call(ctx, client, func(ctx context.Context) error {
+		stream, err := client.DownloadRecord(ctx, &proto.DownloadRecordRequest{Id: id, Version: version, Offset: int64(len(payload))})
+		if err != nil {
+			return err
+		}
+
+		for {
+			chunk, err := stream.Recv()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			if chunk.Offset != int64(len(payload)) {
+				return errors.Errorf("expected chunk at offset %d, got %d", len(payload), chunk.Offset)
+			}
+			if crc32.Checksum(chunk.Data, crc32c) != chunk.Checksum {
+				return errors.Errorf("checksum of the chunk at offset %d does not match", chunk.Offset)
+			}
+
+			payload = append(payload, chunk.Data...)
+			progress(id, int64(len(payload)), size)
+		}
+	})
+	if err != nil {
+		return payload, err
+	}
+
+	if int64(len(payload)) != size {
+		return payload, errors.Errorf("received %d of %d bytes", len(payload), size)
+	}
+
+	return payload, nil
+}
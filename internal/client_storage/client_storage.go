@@ -0,0 +1,1141 @@
+package client_storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/denistakeda/mpass/internal/domain/record"
+	"github.com/denistakeda/mpass/internal/encryption"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	_ "modernc.org/sqlite"
+)
+
+const (
+	// the record keys are not stored in plain text, the rows are looked up by the keyed hash
+	// of the key and everything else is sealed in the payload, the types and the tags of the
+	// records are indexed by their keyed hashes too
+	schema = `
+create table if not exists meta (
+	name  text primary key,
+	value blob not null
+);
+
+create table if not exists records (
+	id      text primary key,
+	kind    text not null,
+	payload blob not null
+);
+
+create index if not exists records_kind on records (kind);
+
+create table if not exists record_tags (
+	tag text not null,
+	id  text not null references records (id) on delete cascade,
+	primary key (tag, id)
+);
+
+create index if not exists record_tags_id on record_tags (id);
+
+-- the local changes not sent to the server yet in the order they were made,
+-- the sealed tombstone is set for the deleted records
+create table if not exists pending (
+	seq       integer primary key autoincrement,
+	id        text not null unique,
+	tombstone blob
+);
+
+create table if not exists conflicts (
+	id      text primary key,
+	payload blob not null
+);
+
+-- the encrypted payloads of the large records being uploaded, they are kept while the change is pending,
+-- so that an interrupted upload is resumed with the same payload
+create table if not exists uploads (
+	id      text primary key references pending (id) on delete cascade,
+	payload blob not null
+);
+`
+
+	// the key check is a known value sealed with the key, it allows to reject a wrong
+	// master password before anything is written with a key derived from it
+	metaHeader   = "header"
+	metaKeyCheck = "key_check"
+	keyCheck     = "mpass"
+
+	metaToken        = "token"
+	metaRefreshToken = "refresh_token"
+	metaVaultKey     = "vault_key"
+	metaRevision     = "revision"
+	metaIDsMigrated  = "ids_migrated"
+)
+
+type (
+	clientStorage struct {
+		path           string
+		masterPassword masterPasswordProvider
+
+		mx    sync.Mutex
+		db    *sqlx.DB
+		key   []byte // derived from the master password, seals everything but the key derivation header
+		idKey []byte // derived from the key, the records are stored and indexed by the HMACs of their keys, types and tags with it
+
+		// sent are the sequence numbers of the pending changes returned to be sent to the server
+		// by the record ids, only they are cleared to keep the changes made by another process meanwhile
+		sent map[string]int64
+	}
+
+	// masterPasswordProvider asks for the master password, the new one is confirmed if confirm is set
+	masterPasswordProvider func(confirm bool) (string, error)
+
+	// sealedRecord wraps the record to encode it together with its concrete type
+	sealedRecord struct {
+		Record record.Record
+	}
+
+	sealedRow struct {
+		ID      string `db:"id"`
+		Payload []byte `db:"payload"`
+	}
+
+	pendingRow struct {
+		Seq       int64  `db:"seq"`
+		ID        string `db:"id"`
+		Tombstone []byte `db:"tombstone"`
+	}
+)
+
+// NewWithSQLite creates a storage keeping the state in the SQLite database at the path.
+// Every call is a separate transaction, so several processes could work with the same
+// database at once. Everything but the key derivation header is sealed with a key derived
+// from the master password, the records are stored by the keyed hashes of their keys.
+// The legacy state file found next to the database is migrated into it on first access.
+func NewWithSQLite(path string, masterPassword masterPasswordProvider) *clientStorage {
+	return &clientStorage{path: path, masterPassword: masterPassword}
+}
+
+func (c *clientStorage) GetToken() (string, error) {
+	token, err := c.getMeta(metaToken)
+	return string(token), err
+}
+
+func (c *clientStorage) SetToken(t string) error {
+	return c.setMeta(metaToken, []byte(t))
+}
+
+func (c *clientStorage) GetRefreshToken() (string, error) {
+	token, err := c.getMeta(metaRefreshToken)
+	return string(token), err
+}
+
+func (c *clientStorage) SetRefreshToken(t string) error {
+	return c.setMeta(metaRefreshToken, []byte(t))
+}
+
+func (c *clientStorage) GetVaultKey() ([]byte, error) {
+	wrappedKey, err := c.getMeta(metaVaultKey)
+	if len(wrappedKey) == 0 {
+		return nil, err
+	}
+
+	return wrappedKey, err
+}
+
+func (c *clientStorage) SetVaultKey(wrappedKey []byte) error {
+	return c.setMeta(metaVaultKey, wrappedKey)
+}
+
+func (c *clientStorage) GetRevision() (int64, error) {
+	value, err := c.getMeta(metaRevision)
+	if err != nil || len(value) == 0 {
+		return 0, err
+	}
+
+	revision, err := strconv.ParseInt(string(value), 10, 64)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to parse the revision")
+	}
+
+	return revision, nil
+}
+
+func (c *clientStorage) SetRevision(revision int64) error {
+	return c.setMeta(metaRevision, []byte(strconv.FormatInt(revision, 10)))
+}
+
+// IDsMigrated reports whether the records were moved on the server from their keys to the opaque ids.
+func (c *clientStorage) IDsMigrated() (bool, error) {
+	value, err := c.getMeta(metaIDsMigrated)
+	return len(value) > 0, err
+}
+
+func (c *clientStorage) SetIDsMigrated(migrated bool) error {
+	var value []byte
+	if migrated {
+		value = []byte("1")
+	}
+
+	return c.setMeta(metaIDsMigrated, value)
+}
+
+func (c *clientStorage) SetRecord(r record.Record) error {
+	return c.inTx(func(tx *sqlx.Tx) error {
+		// the new version is based on the same revision as the replaced one
+		replaced, err := c.getRecord(tx, r.GetId())
+		if err == nil {
+			r.SetRevision(replaced.GetRevision())
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		if err := c.putRecord(tx, r); err != nil {
+			return err
+		}
+
+		return addPending(tx, c.recordID(r.GetId()), nil)
+	})
+}
+
+func (c *clientStorage) DeleteRecord(key string) error {
+	return c.inTx(func(tx *sqlx.Tx) error {
+		res, err := tx.Exec(`delete from records where id = $1`, c.recordID(key))
+		if err != nil {
+			return errors.Wrapf(err, "failed to delete record %q", key)
+		}
+
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			return errors.Errorf("no record with key %q", key)
+		}
+
+		return c.addDeletion(tx, record.Tombstone{ID: key, DeletionDate: time.Now().UTC()})
+	})
+}
+
+func (c *clientStorage) GetRecord(key string) (record.Record, error) {
+	db, err := c.getDB()
+	if err != nil {
+		return nil, err
+	}
+
+	rec, err := c.getRecord(db, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.Errorf("no record with key %q", key)
+	}
+
+	return rec, err
+}
+
+// ListRecords returns the records of the kind having all the tags ordered by key,
+// the records of any kind if the kind is empty. The records are looked up by the indexes,
+// only the matching ones are opened.
+func (c *clientStorage) ListRecords(kind record.Kind, tags ...string) ([]record.Record, error) {
+	db, err := c.getDB()
+	if err != nil {
+		return nil, err
+	}
+
+	query, args := `select r.id, r.payload from records r where true`, []any{}
+	if kind != "" {
+		args = append(args, c.indexValue("kind", string(kind)))
+		query += fmt.Sprintf(` and r.kind = $%d`, len(args))
+	}
+	for _, tag := range tags {
+		args = append(args, c.indexValue("tag", tag))
+		query += fmt.Sprintf(` and exists (select 1 from record_tags t where t.tag = $%d and t.id = r.id)`, len(args))
+	}
+
+	var rows []sealedRow
+	if err := db.Select(&rows, query, args...); err != nil {
+		return nil, errors.Wrap(err, "failed to list records")
+	}
+
+	res := make([]record.Record, 0, len(rows))
+	for _, row := range rows {
+		rec, err := openRecord(c.key, row.ID, row.Payload)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, rec)
+	}
+	sortByKey(res)
+
+	return res, nil
+}
+
+func (c *clientStorage) ItemsToSync() ([]record.Record, error) {
+	db, err := c.getDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		Seq int64 `db:"seq"`
+		sealedRow
+	}
+	err = db.Select(&rows, `
+		select p.seq, r.id, r.payload
+		from pending p join records r on r.id = p.id
+		where p.tombstone is null
+		order by p.seq
+	`)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get records to sync")
+	}
+
+	res := make([]record.Record, 0, len(rows))
+	for _, row := range rows {
+		rec, err := openRecord(c.key, row.ID, row.Payload)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, rec)
+		c.markSent(rec.GetId(), row.Seq)
+	}
+
+	return res, nil
+}
+
+func (c *clientStorage) ItemsToDelete() ([]record.Tombstone, error) {
+	db, err := c.getDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []pendingRow
+	err = db.Select(&rows, `
+		select seq, id, tombstone
+		from pending
+		where tombstone is not null
+		order by seq
+	`)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get records to delete")
+	}
+
+	res := make([]record.Tombstone, 0, len(rows))
+	for _, row := range rows {
+		tombstone, err := openTombstone(c.key, row.ID, row.Tombstone)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, tombstone)
+		c.markSent(tombstone.ID, row.Seq)
+	}
+
+	return res, nil
+}
+
+// ClearSent clears the sent changes of the records accepted by the server,
+// so they are not sent again if the rest of the sync fails.
+func (c *clientStorage) ClearSent(keys []string) error {
+	c.mx.Lock()
+	seqs := make([]int64, 0, len(keys))
+	for _, key := range keys {
+		if seq, ok := c.sent[key]; ok {
+			seqs = append(seqs, seq)
+			delete(c.sent, key)
+		}
+	}
+	c.mx.Unlock()
+
+	return c.inTx(func(tx *sqlx.Tx) error {
+		return clearPending(tx, seqs)
+	})
+}
+
+// GetUpload returns the encrypted payload stored by SetUpload for the pending change of the record,
+// nil if there is none.
+func (c *clientStorage) GetUpload(key string) ([]byte, error) {
+	db, err := c.getDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var payload []byte
+	err = db.Get(&payload, `select payload from uploads where id = $1`, c.recordID(key))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get upload of record %q", key)
+	}
+
+	return payload, nil
+}
+
+// SetUpload keeps the encrypted payload of the record returned by ItemsToSync until the change is cleared
+// or replaced by a newer one, the payload is not stored if the record was changed since.
+func (c *clientStorage) SetUpload(key string, payload []byte) error {
+	c.mx.Lock()
+	seq, ok := c.sent[key]
+	c.mx.Unlock()
+	if !ok {
+		return errors.Errorf("record %q is not being sent", key)
+	}
+
+	return c.inTx(func(tx *sqlx.Tx) error {
+		_, err := tx.Exec(`
+			insert into uploads (id, payload)
+			select id, $1 from pending where seq = $2
+			on conflict (id) do update set payload = excluded.payload
+		`, payload, seq)
+		if err != nil {
+			return errors.Wrapf(err, "failed to store upload of record %q", key)
+		}
+
+		return nil
+	})
+}
+
+// MarkConflicts keeps the local versions of the records to sync rejected by the server.
+func (c *clientStorage) MarkConflicts(keys []string) error {
+	return c.inTx(func(tx *sqlx.Tx) error {
+		for _, key := range keys {
+			_, err := tx.Exec(`
+				insert into conflicts (id, payload)
+				select r.id, r.payload
+				from records r join pending p on p.id = r.id
+				where r.id = $1 and p.tombstone is null
+				on conflict (id) do update set payload = excluded.payload
+			`, c.recordID(key))
+			if err != nil {
+				return errors.Wrapf(err, "failed to mark record %q as conflicting", key)
+			}
+
+			_, err = tx.Exec(`delete from pending where id = $1 and tombstone is null`, c.recordID(key))
+			if err != nil {
+				return errors.Wrapf(err, "failed to mark record %q as conflicting", key)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (c *clientStorage) Conflicts() ([]record.Record, error) {
+	db, err := c.getDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []sealedRow
+	if err := db.Select(&rows, `select id, payload from conflicts`); err != nil {
+		return nil, errors.Wrap(err, "failed to get conflicts")
+	}
+
+	res := make([]record.Record, 0, len(rows))
+	for _, row := range rows {
+		rec, err := openRecord(c.key, row.ID, row.Payload)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, rec)
+	}
+	sortByKey(res)
+
+	return res, nil
+}
+
+func (c *clientStorage) GetConflict(key string) (record.Record, error) {
+	db, err := c.getDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var payload []byte
+	err = db.Get(&payload, `select payload from conflicts where id = $1`, c.recordID(key))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.Errorf("no conflict for key %q", key)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get conflict %q", key)
+	}
+
+	return openRecord(c.key, c.recordID(key), payload)
+}
+
+func (c *clientStorage) RemoveConflict(key string) error {
+	db, err := c.getDB()
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(`delete from conflicts where id = $1`, c.recordID(key)); err != nil {
+		return errors.Wrapf(err, "failed to remove conflict %q", key)
+	}
+
+	return nil
+}
+
+// ApplyChanges merges the changes received from the server into the local copy.
+// The local changes sent to the server before are cleared. The records changed locally
+// since then, e.g. by another process, are kept and will be sent on the next sync.
+func (c *clientStorage) ApplyChanges(changes record.Changes) error {
+	c.mx.Lock()
+	sent := make([]int64, 0, len(c.sent))
+	for _, seq := range c.sent {
+		sent = append(sent, seq)
+	}
+	c.sent = nil
+	c.mx.Unlock()
+
+	return c.inTx(func(tx *sqlx.Tx) error {
+		if err := clearPending(tx, sent); err != nil {
+			return err
+		}
+
+		if changes.Full {
+			if _, err := tx.Exec(`delete from records where id not in (select id from pending)`); err != nil {
+				return errors.Wrap(err, "failed to clear records")
+			}
+		}
+
+		for _, item := range changes.Records {
+			var isPending bool
+			err := tx.Get(&isPending, `select exists (select 1 from pending where id = $1)`, c.recordID(item.GetId()))
+			if err != nil {
+				return errors.Wrapf(err, "failed to check record %q", item.GetId())
+			}
+			if isPending {
+				continue
+			}
+
+			if err := c.putRecord(tx, item); err != nil {
+				return err
+			}
+		}
+
+		for _, item := range changes.Tombstones {
+			_, err := tx.Exec(`delete from records where id = $1 and id not in (select id from pending)`, c.recordID(item.ID))
+			if err != nil {
+				return errors.Wrapf(err, "failed to delete record %q", item.ID)
+			}
+		}
+
+		return c.setMetaTx(tx, metaRevision, []byte(strconv.FormatInt(changes.Revision, 10)))
+	})
+}
+
+// ChangeMasterPassword seals the whole state with a key derived from the new master password.
+// The state is re-sealed in a single transaction, so it is never left sealed with two different keys.
+func (c *clientStorage) ChangeMasterPassword(newPassword string) error {
+	if newPassword == "" {
+		return errors.New("master password is empty")
+	}
+
+	var next clientStorage
+	err := c.inTx(func(tx *sqlx.Tx) error {
+		var meta []struct {
+			Name  string `db:"name"`
+			Value []byte `db:"value"`
+		}
+		err := tx.Select(&meta, `select name, value from meta where name not in ($1, $2)`, metaHeader, metaKeyCheck)
+		if err != nil {
+			return errors.Wrap(err, "failed to read the state")
+		}
+
+		var (
+			records, conflicts []sealedRow
+			pending            []pendingRow
+		)
+		if err := tx.Select(&records, `select id, payload from records`); err != nil {
+			return errors.Wrap(err, "failed to read the records")
+		}
+		if err := tx.Select(&conflicts, `select id, payload from conflicts`); err != nil {
+			return errors.Wrap(err, "failed to read the conflicts")
+		}
+		if err := tx.Select(&pending, `select seq, id, tombstone from pending order by seq`); err != nil {
+			return errors.Wrap(err, "failed to read the pending changes")
+		}
+
+		if next.key, err = storeNewKey(tx, newPassword); err != nil {
+			return err
+		}
+		next.idKey = deriveIDKey(next.key)
+
+		for _, m := range meta {
+			value, err := reseal(c.key, next.key, m.Value, "meta/"+m.Name)
+			if err != nil {
+				return errors.Wrapf(err, "failed to re-encrypt %s", m.Name)
+			}
+			if _, err := tx.Exec(`update meta set value = $1 where name = $2`, value, m.Name); err != nil {
+				return errors.Wrapf(err, "failed to store %s", m.Name)
+			}
+		}
+
+		// the ids and the indexes are the keyed hashes, so they change together with the key
+		// and the rows are written again in the order of the pending changes
+		if _, err := tx.Exec(`delete from records; delete from conflicts; delete from pending`); err != nil {
+			return errors.Wrap(err, "failed to clear the state")
+		}
+
+		keys := make(map[string]string, len(records))
+		for _, row := range records {
+			rec, err := openRecord(c.key, row.ID, row.Payload)
+			if err != nil {
+				return err
+			}
+			if err := next.putRecord(tx, rec); err != nil {
+				return err
+			}
+			keys[row.ID] = rec.GetId()
+		}
+		for _, row := range conflicts {
+			rec, err := openRecord(c.key, row.ID, row.Payload)
+			if err != nil {
+				return err
+			}
+			if err := next.putConflict(tx, rec); err != nil {
+				return err
+			}
+		}
+		for _, row := range pending {
+			if row.Tombstone == nil {
+				if key, ok := keys[row.ID]; ok {
+					if err := addPending(tx, next.recordID(key), nil); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+
+			tombstone, err := openTombstone(c.key, row.ID, row.Tombstone)
+			if err != nil {
+				return err
+			}
+			if err := next.addDeletion(tx, tombstone); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	c.mx.Lock()
+	c.key, c.idKey, c.sent = next.key, next.idKey, nil
+	c.mx.Unlock()
+
+	return nil
+}
+
+// reseal opens the value sealed with the old key and seals it with the new one.
+func reseal(oldKey, newKey, sealed []byte, additionalData string) ([]byte, error) {
+	value, err := encryption.Open(oldKey, sealed, []byte(additionalData))
+	if err != nil {
+		return nil, err
+	}
+
+	return encryption.Seal(newKey, value, []byte(additionalData))
+}
+
+func (c *clientStorage) Close() error {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if c.db == nil {
+		return nil
+	}
+
+	err := c.db.Close()
+	c.db, c.key, c.idKey = nil, nil, nil
+
+	return err
+}
+
+func (c *clientStorage) getDB() (*sqlx.DB, error) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if c.db == nil {
+		if err := c.open(); err != nil {
+			return nil, err
+		}
+	}
+
+	return c.db, nil
+}
+
+func (c *clientStorage) open() error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return errors.Wrapf(err, "failed to create the directory of %q", c.path)
+	}
+
+	// SQLite creates the database readable by everyone
+	f, err := os.OpenFile(c.path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open file %q", c.path)
+	}
+	f.Close()
+
+	// the write lock is taken at the start of every transaction, so the transactions
+	// of several processes wait for each other instead of failing on upgrade
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(10000)&_pragma=journal_mode(wal)&_pragma=foreign_keys(1)&_txlock=immediate", c.path)
+	db, err := sqlx.Open("sqlite", dsn)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open database %q", c.path)
+	}
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return errors.Wrapf(err, "failed to create the schema of database %q", c.path)
+	}
+
+	// the password of a new state is confirmed, otherwise a typo would lock the state
+	var headers int
+	if err := db.Get(&headers, `select count(*) from meta where name = $1`, metaHeader); err != nil {
+		db.Close()
+		return errors.Wrapf(err, "failed to read database %q", c.path)
+	}
+
+	password, err := c.masterPassword(headers == 0)
+	if err != nil {
+		db.Close()
+		return errors.Wrap(err, "master password is required to open the state")
+	}
+
+	key, err := deriveKey(db, password)
+	if err != nil {
+		db.Close()
+		return err
+	}
+
+	c.db, c.key, c.idKey = db, key, deriveIDKey(key)
+
+	if err := c.migrateLegacyState(password); err != nil {
+		c.db, c.key, c.idKey = nil, nil, nil
+		db.Close()
+		return err
+	}
+
+	return nil
+}
+
+// deriveKey derives the key from the password with the header stored in the database,
+// the header is generated if the database is new.
+func deriveKey(db *sqlx.DB, password string) ([]byte, error) {
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start transaction")
+	}
+	defer tx.Rollback()
+
+	var meta []struct {
+		Name  string `db:"name"`
+		Value []byte `db:"value"`
+	}
+	err = tx.Select(&meta, `select name, value from meta where name in ($1, $2)`, metaHeader, metaKeyCheck)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the key derivation header")
+	}
+
+	var headerBytes, check []byte
+	for _, m := range meta {
+		switch m.Name {
+		case metaHeader:
+			headerBytes = m.Value
+		case metaKeyCheck:
+			check = m.Value
+		}
+	}
+
+	if headerBytes != nil {
+		header, _, err := encryption.ParseHeader(headerBytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse the key derivation header")
+		}
+
+		key := header.DeriveKey(password)
+		if _, err := encryption.Open(key, check, []byte(metaKeyCheck)); err != nil {
+			return nil, errors.New("failed to open the state, the master password is wrong")
+		}
+
+		return key, nil
+	}
+
+	key, err := storeNewKey(tx, password)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "failed to store the key derivation header")
+	}
+
+	return key, nil
+}
+
+// storeNewKey derives a new key from the password with a new header and stores the header and the key check.
+func storeNewKey(tx *sqlx.Tx, password string) ([]byte, error) {
+	header, err := encryption.NewHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	headerBytes, err := header.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	key := header.DeriveKey(password)
+	check, err := encryption.Seal(key, []byte(keyCheck), []byte(metaKeyCheck))
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`insert into meta (name, value) values ($1, $2), ($3, $4)
+		on conflict (name) do update set value = excluded.value`,
+		metaHeader, headerBytes, metaKeyCheck, check)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to store the key derivation header")
+	}
+
+	return key, nil
+}
+
+// migrateLegacyState moves the content of the legacy state file into the database
+// and renames the file, so it is migrated only once.
+func (c *clientStorage) migrateLegacyState(password string) error {
+	path := filepath.Join(filepath.Dir(c.path), LegacyStateFile)
+
+	legacy, err := loadLegacyState(path, password)
+	if err != nil || legacy == nil {
+		return err
+	}
+
+	tx, err := c.db.Beginx()
+	if err != nil {
+		return errors.Wrap(err, "failed to start transaction")
+	}
+	defer tx.Rollback()
+
+	// another process could have migrated the file while it was being read
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	meta := map[string][]byte{
+		metaToken:        []byte(legacy.Token),
+		metaRefreshToken: []byte(legacy.RefreshToken),
+		metaVaultKey:     legacy.VaultKey,
+		metaRevision:     []byte(strconv.FormatInt(legacy.Revision, 10)),
+	}
+	for name, value := range meta {
+		if err := c.setMetaTx(tx, name, value); err != nil {
+			return err
+		}
+	}
+
+	for _, rec := range legacy.Records {
+		if err := c.putRecord(tx, rec); err != nil {
+			return err
+		}
+	}
+	for _, rec := range legacy.ToSync {
+		if err := c.putRecord(tx, rec); err != nil {
+			return err
+		}
+		if err := addPending(tx, c.recordID(rec.GetId()), nil); err != nil {
+			return err
+		}
+	}
+	for _, tombstone := range legacy.ToDelete {
+		tombstone.DeletionDate = tombstone.DeletionDate.UTC()
+		if err := c.addDeletion(tx, tombstone); err != nil {
+			return err
+		}
+	}
+	for _, rec := range legacy.Conflicts {
+		if err := c.putConflict(tx, rec); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "failed to migrate the state file")
+	}
+
+	// the file is kept in case something went wrong
+	if err := os.Rename(path, path+".bak"); err != nil {
+		return errors.Wrapf(err, "failed to rename the migrated state file %q", path)
+	}
+
+	return nil
+}
+
+func (c *clientStorage) inTx(f func(tx *sqlx.Tx) error) error {
+	db, err := c.getDB()
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return errors.Wrap(err, "failed to start transaction")
+	}
+	defer tx.Rollback()
+
+	if err := f(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+func (c *clientStorage) markSent(id string, seq int64) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if c.sent == nil {
+		c.sent = make(map[string]int64)
+	}
+	c.sent[id] = seq
+}
+
+func (c *clientStorage) getMeta(name string) ([]byte, error) {
+	db, err := c.getDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var sealed []byte
+	err = db.Get(&sealed, `select value from meta where name = $1`, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get %s", name)
+	}
+
+	value, err := encryption.Open(c.key, sealed, []byte("meta/"+name))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decrypt %s", name)
+	}
+
+	return value, nil
+}
+
+func (c *clientStorage) setMeta(name string, value []byte) error {
+	return c.inTx(func(tx *sqlx.Tx) error {
+		return c.setMetaTx(tx, name, value)
+	})
+}
+
+func (c *clientStorage) setMetaTx(tx *sqlx.Tx, name string, value []byte) error {
+	sealed, err := encryption.Seal(c.key, value, []byte("meta/"+name))
+	if err != nil {
+		return errors.Wrapf(err, "failed to encrypt %s", name)
+	}
+
+	_, err = tx.Exec(`insert into meta (name, value) values ($1, $2)
+		on conflict (name) do update set value = excluded.value`, name, sealed)
+	if err != nil {
+		return errors.Wrapf(err, "failed to set %s", name)
+	}
+
+	return nil
+}
+
+// recordID returns the id the record with the key is stored by.
+func (c *clientStorage) recordID(key string) string {
+	return recordID(c.idKey, key)
+}
+
+// indexValue returns the value the records are indexed by, the name of the index is hashed
+// together with the value, so the same value has different hashes in different indexes.
+func (c *clientStorage) indexValue(index, value string) string {
+	return recordID(c.idKey, index+"/"+value)
+}
+
+// getRecord returns sql.ErrNoRows if there is no record with the key.
+func (c *clientStorage) getRecord(q sqlx.Queryer, key string) (record.Record, error) {
+	id := c.recordID(key)
+
+	var payload []byte
+	err := sqlx.Get(q, &payload, `select payload from records where id = $1`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get record %q", key)
+	}
+
+	return openRecord(c.key, id, payload)
+}
+
+// putRecord stores the record and indexes it by the keyed hashes of its type and tags.
+func (c *clientStorage) putRecord(tx *sqlx.Tx, r record.Record) error {
+	id := c.recordID(r.GetId())
+	payload, err := sealRecord(c.key, id, r)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`insert into records (id, kind, payload) values ($1, $2, $3)
+		on conflict (id) do update set kind = excluded.kind, payload = excluded.payload`,
+		id, c.indexValue("kind", string(r.Kind())), payload)
+	if err != nil {
+		return errors.Wrapf(err, "failed to store record %q", r.GetId())
+	}
+
+	if _, err := tx.Exec(`delete from record_tags where id = $1`, id); err != nil {
+		return errors.Wrapf(err, "failed to index record %q", r.GetId())
+	}
+	for _, tag := range r.GetAnnotations().Tags {
+		_, err := tx.Exec(`insert into record_tags (tag, id) values ($1, $2) on conflict do nothing`,
+			c.indexValue("tag", tag), id)
+		if err != nil {
+			return errors.Wrapf(err, "failed to index record %q", r.GetId())
+		}
+	}
+
+	return nil
+}
+
+func (c *clientStorage) putConflict(tx *sqlx.Tx, r record.Record) error {
+	id := c.recordID(r.GetId())
+	payload, err := sealRecord(c.key, id, r)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`insert into conflicts (id, payload) values ($1, $2)
+		on conflict (id) do update set payload = excluded.payload`, id, payload)
+	if err != nil {
+		return errors.Wrapf(err, "failed to store conflict %q", r.GetId())
+	}
+
+	return nil
+}
+
+// addDeletion queues the deletion of the record, the tombstone is sealed as it contains the key.
+func (c *clientStorage) addDeletion(tx *sqlx.Tx, tombstone record.Tombstone) error {
+	id := c.recordID(tombstone.ID)
+	sealed, err := sealTombstone(c.key, id, tombstone)
+	if err != nil {
+		return err
+	}
+
+	return addPending(tx, id, sealed)
+}
+
+// clearPending removes the pending changes sent to the server.
+func clearPending(tx *sqlx.Tx, seqs []int64) error {
+	for _, seq := range seqs {
+		if _, err := tx.Exec(`delete from pending where seq = $1`, seq); err != nil {
+			return errors.Wrap(err, "failed to clear the sent changes")
+		}
+	}
+
+	return nil
+}
+
+// addPending queues the change of the record with the id to be sent to the server,
+// it replaces the change of the same record queued before.
+func addPending(tx *sqlx.Tx, id string, tombstone []byte) error {
+	if _, err := tx.Exec(`delete from pending where id = $1`, id); err != nil {
+		return errors.Wrap(err, "failed to queue the change")
+	}
+
+	_, err := tx.Exec(`insert into pending (id, tombstone) values ($1, $2)`, id, tombstone)
+	if err != nil {
+		return errors.Wrap(err, "failed to queue the change")
+	}
+
+	return nil
+}
+
+// deriveIDKey derives the key of the record ids, so the same key is not used for two purposes.
+func deriveIDKey(key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("record-id"))
+	return mac.Sum(nil)
+}
+
+func recordID(idKey []byte, key string) string {
+	mac := hmac.New(sha256.New, idKey)
+	mac.Write([]byte(key))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func sortByKey(recs []record.Record) {
+	sort.Slice(recs, func(i, j int) bool {
+		return recs[i].GetId() < recs[j].GetId()
+	})
+}
+
+func sealRecord(key []byte, id string, r record.Record) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(sealedRecord{Record: r}); err != nil {
+		return nil, errors.Wrapf(err, "failed to encode record %q", r.GetId())
+	}
+
+	payload, err := encryption.Seal(key, buf.Bytes(), []byte("record/"+id))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to encrypt record %q", r.GetId())
+	}
+
+	return payload, nil
+}
+
+func openRecord(key []byte, id string, payload []byte) (record.Record, error) {
+	plaintext, err := encryption.Open(key, payload, []byte("record/"+id))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decrypt a record")
+	}
+
+	var sealed sealedRecord
+	if err := gob.NewDecoder(bytes.NewReader(plaintext)).Decode(&sealed); err != nil {
+		return nil, errors.Wrap(err, "failed to decode a record")
+	}
+
+	return sealed.Record, nil
+}
+
+func sealTombstone(key []byte, id string, tombstone record.Tombstone) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(tombstone); err != nil {
+		return nil, errors.Wrapf(err, "failed to encode the tombstone of record %q", tombstone.ID)
+	}
+
+	sealed, err := encryption.Seal(key, buf.Bytes(), []byte("tombstone/"+id))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to encrypt the tombstone of record %q", tombstone.ID)
+	}
+
+	return sealed, nil
+}
+
+func openTombstone(key []byte, id string, sealed []byte) (record.Tombstone, error) {
+	var tombstone record.Tombstone
+
+	plaintext, err := encryption.Open(key, sealed, []byte("tombstone/"+id))
+	if err != nil {
+		return tombstone, errors.Wrap(err, "failed to decrypt a tombstone")
+	}
+
+	if err := gob.NewDecoder(bytes.NewReader(plaintext)).Decode(&tombstone); err != nil {
+		return tombstone, errors.Wrap(err, "failed to decode a tombstone")
+	}
+
+	return tombstone, nil
+}
@@ -0,0 +1,499 @@
+package client_storage
+
+import (
+	"bytes"
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/denistakeda/mpass/internal/domain/record"
+	"github.com/denistakeda/mpass/internal/encryption"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_clientStorage_encryptedState(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "state.db")
+
+	s := NewWithSQLite(dbPath, password("master-password"))
+	require.NoError(t, s.SetToken("secret-token"))
+	require.NoError(t, s.SetRecord(record.NewTextRecord("key", "secret text")))
+	tagged := record.NewLoginPasswordRecord("secret-site.com", "password")
+	tagged.AddTags("secret-tag")
+	require.NoError(t, s.SetRecord(tagged))
+	require.NoError(t, s.SetRecord(record.NewTextRecord("deleted-site.com", "text")))
+	require.NoError(t, s.DeleteRecord("deleted-site.com"))
+	require.NoError(t, s.MarkConflicts([]string{"secret-site.com"}))
+	require.NoError(t, s.Close())
+
+	t.Run("database does not contain secrets", func(t *testing.T) {
+		content, err := os.ReadFile(dbPath)
+		require.NoError(t, err)
+
+		assert.False(t, bytes.Contains(content, []byte("secret-token")))
+		assert.False(t, bytes.Contains(content, []byte("secret text")))
+		assert.False(t, bytes.Contains(content, []byte("secret-site.com")), "record keys should not be stored in plain text")
+		assert.False(t, bytes.Contains(content, []byte("deleted-site.com")), "keys of the deleted records should not be stored in plain text")
+		assert.False(t, bytes.Contains(content, []byte(record.KindPassword)), "record types should not be stored in plain text")
+		assert.False(t, bytes.Contains(content, []byte("secret-tag")), "record tags should not be stored in plain text")
+	})
+
+	t.Run("open with the correct password", func(t *testing.T) {
+		s := NewWithSQLite(dbPath, password("master-password"))
+		defer s.Close()
+
+		token, err := s.GetToken()
+		assert.NoError(t, err)
+		assert.Equal(t, "secret-token", token)
+
+		rec, err := s.GetRecord("key")
+		assert.NoError(t, err)
+		assert.Equal(t, "secret text", rec.(*record.TextRecord).Text)
+	})
+
+	t.Run("refuse to open with a wrong password", func(t *testing.T) {
+		s := NewWithSQLite(dbPath, password("wrong-password"))
+
+		_, err := s.GetToken()
+		assert.Error(t, err)
+		assert.Error(t, s.SetToken("another-token"), "should not write with a wrong key")
+		assert.NoError(t, s.Close())
+
+		s = NewWithSQLite(dbPath, password("master-password"))
+		defer s.Close()
+		token, err := s.GetToken()
+		assert.NoError(t, err)
+		assert.Equal(t, "secret-token", token)
+	})
+
+	t.Run("refuse to open without a password", func(t *testing.T) {
+		s := NewWithSQLite(dbPath, func(bool) (string, error) {
+			return "", errors.New("no password")
+		})
+
+		_, err := s.GetToken()
+		assert.Error(t, err)
+	})
+}
+
+func Test_clientStorage_ChangeMasterPassword(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "state.db")
+
+	s := NewWithSQLite(dbPath, password("old-password"))
+	require.NoError(t, s.SetToken("secret-token"))
+	require.NoError(t, s.SetVaultKey([]byte("wrapped-key")))
+	require.NoError(t, s.SetRecord(record.NewTextRecord("key", "secret text")))
+	require.NoError(t, s.SetRecord(record.NewTextRecord("conflict", "local")))
+	require.NoError(t, s.MarkConflicts([]string{"conflict"}))
+	require.NoError(t, s.SetRecord(record.NewTextRecord("deleted", "text")))
+	require.NoError(t, s.DeleteRecord("deleted"))
+
+	require.NoError(t, s.ChangeMasterPassword("new-password"))
+
+	token, err := s.GetToken()
+	require.NoError(t, err)
+	assert.Equal(t, "secret-token", token, "the open state should keep working")
+	require.NoError(t, s.Close())
+
+	t.Run("refuse to open with the old password", func(t *testing.T) {
+		s := NewWithSQLite(dbPath, password("old-password"))
+		defer s.Close()
+
+		_, err := s.GetToken()
+		assert.Error(t, err)
+	})
+
+	t.Run("open with the new password", func(t *testing.T) {
+		s := NewWithSQLite(dbPath, password("new-password"))
+		defer s.Close()
+
+		token, err := s.GetToken()
+		require.NoError(t, err)
+		assert.Equal(t, "secret-token", token)
+
+		key, err := s.GetVaultKey()
+		require.NoError(t, err)
+		assert.Equal(t, []byte("wrapped-key"), key)
+
+		rec, err := s.GetRecord("key")
+		require.NoError(t, err)
+		assert.Equal(t, "secret text", rec.(*record.TextRecord).Text)
+
+		conflict, err := s.GetConflict("conflict")
+		require.NoError(t, err)
+		assert.Equal(t, "local", conflict.(*record.TextRecord).Text)
+
+		recs, err := s.ListRecords(record.KindText)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"conflict", "key"}, ids(recs), "records should be indexed with the new key")
+
+		toSync, err := s.ItemsToSync()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"key"}, ids(toSync))
+
+		toDelete, err := s.ItemsToDelete()
+		require.NoError(t, err)
+		if assert.Len(t, toDelete, 1) {
+			assert.Equal(t, "deleted", toDelete[0].ID)
+		}
+	})
+}
+
+func Test_clientStorage_legacyState(t *testing.T) {
+	conflict := record.NewTextRecord("conflict", "local")
+	legacy := state{
+		Token:    "secret-token",
+		VaultKey: []byte("wrapped-key"),
+		Revision: 7,
+		Records: map[string]record.Record{
+			"synced":  record.NewTextRecord("synced", "text"),
+			"changed": record.NewTextRecord("changed", "text"),
+		},
+		ToSync: map[string]record.Record{
+			"changed": record.NewTextRecord("changed", "text"),
+		},
+		ToDelete: map[string]record.Tombstone{
+			"deleted": record.NewTombstone("deleted"),
+		},
+		Conflicts: map[string]record.Record{"conflict": conflict},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, gob.NewEncoder(&buf).Encode(&legacy))
+	encrypted, err := encryption.SealWithPassword("master-password", buf.Bytes())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		content []byte
+	}{
+		{name: "encrypted state", content: encrypted},
+		// state files written before the encryption were plain gob
+		{name: "plain state", content: buf.Bytes()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			statePath := filepath.Join(dir, LegacyStateFile)
+			require.NoError(t, os.WriteFile(statePath, tt.content, 0600))
+
+			s := NewWithSQLite(filepath.Join(dir, "state.db"), password("master-password"))
+			defer s.Close()
+
+			token, err := s.GetToken()
+			require.NoError(t, err)
+			assert.Equal(t, "secret-token", token)
+
+			vaultKey, err := s.GetVaultKey()
+			assert.NoError(t, err)
+			assert.Equal(t, []byte("wrapped-key"), vaultKey)
+
+			revision, err := s.GetRevision()
+			assert.NoError(t, err)
+			assert.Equal(t, int64(7), revision)
+
+			recs, err := s.ListRecords("")
+			assert.NoError(t, err)
+			assert.Len(t, recs, 2)
+
+			toSync, err := s.ItemsToSync()
+			assert.NoError(t, err)
+			if assert.Len(t, toSync, 1) {
+				assert.Equal(t, "changed", toSync[0].GetId())
+			}
+
+			toDelete, err := s.ItemsToDelete()
+			assert.NoError(t, err)
+			if assert.Len(t, toDelete, 1) {
+				assert.Equal(t, "deleted", toDelete[0].ID)
+			}
+
+			_, err = s.GetConflict("conflict")
+			assert.NoError(t, err)
+
+			_, err = os.Stat(statePath)
+			assert.ErrorIs(t, err, os.ErrNotExist, "state file should be migrated only once")
+			_, err = os.Stat(statePath + ".bak")
+			assert.NoError(t, err, "state file should be kept as a backup")
+		})
+	}
+}
+
+func Test_clientStorage_DeleteRecord(t *testing.T) {
+	s := NewWithSQLite(filepath.Join(t.TempDir(), "state.db"), password("master-password"))
+	defer s.Close()
+
+	require.NoError(t, s.SetRecord(record.NewTextRecord("key", "text")))
+
+	assert.NoError(t, s.DeleteRecord("key"))
+	assert.Error(t, s.DeleteRecord("key"), "should not delete non-existing record")
+
+	_, err := s.GetRecord("key")
+	assert.Error(t, err, "record should be deleted")
+
+	toSync, err := s.ItemsToSync()
+	assert.NoError(t, err)
+	assert.Empty(t, toSync, "deleted record should not be synced")
+
+	toDelete, err := s.ItemsToDelete()
+	assert.NoError(t, err)
+	if assert.Len(t, toDelete, 1) {
+		assert.Equal(t, "key", toDelete[0].ID)
+	}
+
+	require.NoError(t, s.SetRecord(record.NewTextRecord("key", "text")))
+	toDelete, err = s.ItemsToDelete()
+	assert.NoError(t, err)
+	assert.Empty(t, toDelete, "recreated record should not be deleted")
+}
+
+func Test_clientStorage_ListRecords(t *testing.T) {
+	s := NewWithSQLite(filepath.Join(t.TempDir(), "state.db"), password("master-password"))
+	defer s.Close()
+
+	require.NoError(t, s.SetRecord(record.NewTextRecord("b", "text")))
+	require.NoError(t, s.SetRecord(record.NewTextRecord("a", "text")))
+	require.NoError(t, s.SetRecord(record.NewLoginPasswordRecord("c", "password")))
+
+	recs, err := s.ListRecords("")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, ids(recs))
+
+	recs, err = s.ListRecords(record.KindPassword)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"c"}, ids(recs))
+
+	t.Run("by tags", func(t *testing.T) {
+		work := record.NewTextRecord("d", "text")
+		work.AddTags("work", "team")
+		require.NoError(t, s.SetRecord(work))
+		personal := record.NewLoginPasswordRecord("e", "password")
+		personal.AddTags("personal", "team")
+		require.NoError(t, s.SetRecord(personal))
+
+		recs, err := s.ListRecords("", "team")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"d", "e"}, ids(recs))
+
+		recs, err = s.ListRecords("", "team", "work")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"d"}, ids(recs))
+
+		recs, err = s.ListRecords(record.KindPassword, "team")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"e"}, ids(recs))
+
+		// the tags of the replaced record are dropped
+		require.NoError(t, s.SetRecord(record.NewTextRecord("d", "untagged")))
+		recs, err = s.ListRecords("", "work")
+		assert.NoError(t, err)
+		assert.Empty(t, recs)
+
+		require.NoError(t, s.DeleteRecord("e"))
+		recs, err = s.ListRecords("", "team")
+		assert.NoError(t, err)
+		assert.Empty(t, recs, "deleted record should not be listed")
+	})
+}
+
+func Test_clientStorage_ApplyChanges(t *testing.T) {
+	s := NewWithSQLite(filepath.Join(t.TempDir(), "state.db"), password("master-password"))
+	defer s.Close()
+
+	require.NoError(t, s.SetRecord(record.NewTextRecord("first", "text")))
+	require.NoError(t, s.SetRecord(record.NewTextRecord("second", "text")))
+
+	t.Run("merge the changes", func(t *testing.T) {
+		toSync, err := s.ItemsToSync()
+		require.NoError(t, err)
+		require.Len(t, toSync, 2)
+
+		require.NoError(t, s.ApplyChanges(record.Changes{
+			Records:    []record.Record{record.NewTextRecord("third", "text")},
+			Tombstones: []record.Tombstone{record.NewTombstone("first")},
+			Revision:   10,
+		}))
+
+		_, err = s.GetRecord("first")
+		assert.Error(t, err, "deleted record should be removed")
+		_, err = s.GetRecord("second")
+		assert.NoError(t, err, "untouched record should be kept")
+		_, err = s.GetRecord("third")
+		assert.NoError(t, err, "new record should be added")
+
+		revision, err := s.GetRevision()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(10), revision)
+
+		toSync, err = s.ItemsToSync()
+		assert.NoError(t, err)
+		assert.Empty(t, toSync, "sent records should not be synced again")
+	})
+
+	t.Run("keep the changes made during the sync", func(t *testing.T) {
+		require.NoError(t, s.SetRecord(record.NewTextRecord("second", "sent")))
+		_, err := s.ItemsToSync()
+		require.NoError(t, err)
+
+		// another process changes the records while the sync is in progress
+		other := NewWithSQLite(s.path, password("master-password"))
+		defer other.Close()
+		require.NoError(t, other.SetRecord(record.NewTextRecord("second", "changed")))
+		require.NoError(t, other.SetRecord(record.NewTextRecord("fifth", "changed")))
+
+		require.NoError(t, s.ApplyChanges(record.Changes{
+			Records:  []record.Record{record.NewTextRecord("second", "remote")},
+			Revision: 11,
+			Full:     true,
+		}))
+
+		rec, err := s.GetRecord("second")
+		assert.NoError(t, err)
+		assert.Equal(t, "changed", rec.(*record.TextRecord).Text, "local change should win until it is synced")
+
+		toSync, err := s.ItemsToSync()
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{"second", "fifth"}, ids(toSync))
+	})
+
+	t.Run("replace everything with full changes", func(t *testing.T) {
+		require.NoError(t, s.ApplyChanges(record.Changes{
+			Records:  []record.Record{record.NewTextRecord("fourth", "text")},
+			Revision: 20,
+			Full:     true,
+		}))
+
+		_, err := s.GetRecord("third")
+		assert.Error(t, err)
+		_, err = s.GetRecord("fourth")
+		assert.NoError(t, err)
+	})
+}
+
+func Test_clientStorage_ClearSent(t *testing.T) {
+	s := NewWithSQLite(filepath.Join(t.TempDir(), "state.db"), password("master-password"))
+	defer s.Close()
+
+	require.NoError(t, s.SetRecord(record.NewTextRecord("first", "text")))
+	require.NoError(t, s.SetRecord(record.NewTextRecord("second", "text")))
+
+	_, err := s.ItemsToSync()
+	require.NoError(t, err)
+
+	// the record is changed again after it was sent
+	require.NoError(t, s.SetRecord(record.NewTextRecord("second", "changed")))
+
+	require.NoError(t, s.ClearSent([]string{"first", "second"}))
+
+	toSync, err := s.ItemsToSync()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"second"}, ids(toSync), "only the accepted changes should be cleared")
+}
+
+func Test_clientStorage_Upload(t *testing.T) {
+	s := NewWithSQLite(filepath.Join(t.TempDir(), "state.db"), password("master-password"))
+	defer s.Close()
+
+	require.NoError(t, s.SetRecord(record.NewTextRecord("first", "text")))
+	require.NoError(t, s.SetRecord(record.NewTextRecord("second", "text")))
+
+	assert.Error(t, s.SetUpload("first", []byte("payload")), "only the record being sent has an upload")
+
+	_, err := s.ItemsToSync()
+	require.NoError(t, err)
+	require.NoError(t, s.SetUpload("first", []byte("first payload")))
+	require.NoError(t, s.SetUpload("second", []byte("second payload")))
+
+	payload, err := s.GetUpload("first")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("first payload"), payload, "payload should be kept until the change is sent")
+
+	// the record is changed again, the payload of the previous change is stale
+	require.NoError(t, s.SetRecord(record.NewTextRecord("second", "changed")))
+	payload, err = s.GetUpload("second")
+	assert.NoError(t, err)
+	assert.Nil(t, payload)
+
+	require.NoError(t, s.ClearSent([]string{"first"}))
+	payload, err = s.GetUpload("first")
+	assert.NoError(t, err)
+	assert.Nil(t, payload, "payload should be removed once the change is sent")
+}
+
+func Test_clientStorage_Conflicts(t *testing.T) {
+	s := NewWithSQLite(filepath.Join(t.TempDir(), "state.db"), password("master-password"))
+	defer s.Close()
+
+	remote := record.NewTextRecord("key", "remote")
+	remote.Revision = 5
+	require.NoError(t, s.ApplyChanges(record.Changes{Records: []record.Record{remote}, Revision: 5}))
+
+	local := record.NewTextRecord("key", "local")
+	require.NoError(t, s.SetRecord(local))
+	assert.Equal(t, int64(5), local.GetRevision(), "edit should be based on the revision of the replaced record")
+
+	require.NoError(t, s.MarkConflicts([]string{"key", "unknown"}))
+
+	toSync, err := s.ItemsToSync()
+	assert.NoError(t, err)
+	assert.Empty(t, toSync, "conflicting record should not be synced")
+
+	conflicts, err := s.Conflicts()
+	assert.NoError(t, err)
+	if assert.Len(t, conflicts, 1) {
+		assert.Equal(t, "local", conflicts[0].(*record.TextRecord).Text)
+	}
+
+	require.NoError(t, s.ApplyChanges(record.Changes{Revision: 6, Full: true}))
+	_, err = s.GetConflict("key")
+	assert.NoError(t, err, "conflicts should survive the sync")
+
+	require.NoError(t, s.RemoveConflict("key"))
+	_, err = s.GetConflict("key")
+	assert.Error(t, err)
+}
+
+func Test_clientStorage_concurrentProcesses(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "state.db")
+
+	storages := make([]*clientStorage, 3)
+	for i := range storages {
+		storages[i] = NewWithSQLite(dbPath, password("master-password"))
+		defer storages[i].Close()
+	}
+
+	var wg sync.WaitGroup
+	for i, s := range storages {
+		wg.Add(1)
+		go func(i int, s *clientStorage) {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				key := string(rune('a'+i)) + string(rune('0'+j))
+				assert.NoError(t, s.SetRecord(record.NewTextRecord(key, "text")))
+			}
+		}(i, s)
+	}
+	wg.Wait()
+
+	recs, err := storages[0].ListRecords("")
+	assert.NoError(t, err)
+	assert.Len(t, recs, 30, "no change should be lost")
+}
+
+func password(p string) masterPasswordProvider {
+	return func(bool) (string, error) {
+		return p, nil
+	}
+}
+
+func ids(recs []record.Record) []string {
+	res := make([]string, 0, len(recs))
+	for _, rec := range recs {
+		res = append(res, rec.GetId())
+	}
+
+	return res
+}
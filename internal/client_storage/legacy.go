@@ -0,0 +1,57 @@
+package client_storage
+
+import (
+	"bytes"
+	"encoding/gob"
+	"os"
+
+	"github.com/denistakeda/mpass/internal/domain/record"
+	"github.com/denistakeda/mpass/internal/encryption"
+	"github.com/pkg/errors"
+)
+
+// LegacyStateFile is the name of the file the whole state was kept in before the database.
+// It is migrated into the database next to it on first access.
+const LegacyStateFile = "state.gob"
+
+// state is the content of the legacy state file.
+type state struct {
+	Token        string
+	RefreshToken string
+	VaultKey     []byte // wrapped with the master password
+	Revision     int64  // the latest revision of the server vault seen by the client
+
+	Records  map[string]record.Record
+	ToSync   map[string]record.Record
+	ToDelete map[string]record.Tombstone
+
+	// Conflicts are the local versions of the records rejected by the server
+	// because they were changed on another device
+	Conflicts map[string]record.Record
+}
+
+// loadLegacyState reads the legacy state file, it returns nil if there is no such file.
+func loadLegacyState(path, password string) (*state, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read file %q", path)
+	}
+
+	// the state files created before the encryption was introduced are plain gob
+	if encryption.HasHeader(content) {
+		content, err = encryption.OpenWithPassword(password, content)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decrypt the state file %q", path)
+		}
+	}
+
+	var s state
+	if err := gob.NewDecoder(bytes.NewReader(content)).Decode(&s); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode the content of file %q", path)
+	}
+
+	return &s, nil
+}
@@ -9,6 +9,18 @@ import (
 
 type ClientCfg struct {
 	Address string `json:"address"`
+
+	// Insecure disables TLS, it should only be used for local development.
+	Insecure bool `json:"insecure"`
+	// CACert is the bundle of the CAs to verify the server, the system CAs are used if empty.
+	CACert string `json:"ca_cert"`
+	// CertFingerprint pins the SHA-256 fingerprint of the server certificate.
+	CertFingerprint string `json:"cert_fingerprint"`
+	// ServerName overrides the name the server certificate is verified against.
+	ServerName string `json:"server_name"`
+	// ClientCert and ClientKey are used when the server requires mutual TLS.
+	ClientCert string `json:"client_cert"`
+	ClientKey  string `json:"client_key"`
 }
 
 func ParseClientCfg(configPath string) (ClientCfg, error) {
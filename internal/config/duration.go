@@ -0,0 +1,27 @@
+package config
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Duration is a time.Duration which could be read from a string like "720h" both from JSON and env variables.
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	duration, err := time.ParseDuration(string(text))
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse duration %q", text)
+	}
+
+	d.Duration = duration
+
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.Duration.String()), nil
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Rate is a number of events per period which could be read from a string like "10/1m" or "10/m"
+// both from JSON and env variables. The zero Rate means no limit.
+type Rate struct {
+	Count int
+	Per   time.Duration
+}
+
+func (r *Rate) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*r = Rate{}
+		return nil
+	}
+
+	count, per, ok := strings.Cut(string(text), "/")
+	if !ok {
+		return errors.Errorf("failed to parse rate %q, expected a value like \"10/1m\"", text)
+	}
+
+	n, err := strconv.Atoi(count)
+	if err != nil || n < 0 {
+		return errors.Errorf("failed to parse rate %q, the count should be a non-negative number", text)
+	}
+
+	// "10/m" is the same as "10/1m"
+	if per != "" && (per[0] < '0' || per[0] > '9') {
+		per = "1" + per
+	}
+
+	duration, err := time.ParseDuration(per)
+	if err != nil || duration <= 0 {
+		return errors.Errorf("failed to parse rate %q, the period should be a positive duration", text)
+	}
+
+	*r = Rate{Count: n, Per: duration}
+
+	return nil
+}
+
+func (r Rate) MarshalText() ([]byte, error) {
+	if r.Count == 0 {
+		return []byte{}, nil
+	}
+
+	return []byte(strconv.Itoa(r.Count) + "/" + r.Per.String()), nil
+}
@@ -0,0 +1,71 @@
+// Package dbtest provides migrated databases for the tests of the stores.
+package dbtest
+
+import (
+	"context"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/denistakeda/mpass/internal/db"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// Postgres starts a migrated postgres database, the test is skipped if docker is not available.
+func Postgres(t *testing.T) *sqlx.DB {
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+
+	container, err := postgres.RunContainer(context.Background(),
+		testcontainers.WithImage("docker.io/postgres:15.2-alpine"),
+		postgres.WithDatabase("postgres"),
+		postgres.WithUsername("postgres"),
+		postgres.WithPassword("postgres"),
+		testcontainers.WithWaitStrategy(wait.ForLog("database system is ready to accept connections").WithOccurrence(2).WithStartupTimeout(5*time.Second)),
+	)
+	require.NoError(t, err, "failed to start postgres")
+	t.Cleanup(func() {
+		stopTime := time.Second
+		container.Stop(context.Background(), &stopTime)
+	})
+
+	databaseURI, err := container.ConnectionString(context.Background(), "sslmode=disable")
+	require.NoError(t, err, "failed to get database connections string")
+
+	database, err := db.NewDB(databaseURI, "file://"+migrations())
+	require.NoError(t, err, "failed to initiate database")
+
+	return database
+}
+
+// SQLite creates a migrated SQLite database in a temporary directory.
+func SQLite(t *testing.T) *sqlx.DB {
+	database, err := db.NewSQLite("sqlite://"+filepath.Join(t.TempDir(), "mpass.db"), "file://"+filepath.Join(migrations(), "sqlite"))
+	require.NoError(t, err, "failed to initiate database")
+	t.Cleanup(func() { database.Close() })
+
+	return database
+}
+
+// Reset removes all the data and adds the users with the given logins.
+func Reset(t *testing.T, database *sqlx.DB, logins ...string) {
+	for _, table := range []string{"user_revision", "tombstone", "records", "record_versions", "blobs", "upload_chunks", "uploads", "refresh_tokens", "users"} {
+		_, err := database.Exec("delete from " + table)
+		require.NoError(t, err, "failed to reset the database")
+	}
+
+	for _, login := range logins {
+		_, err := database.Exec("insert into users (login, password, created_at) values ($1, '', $2)", login, time.Now().UTC())
+		require.NoError(t, err, "failed to add user %q", login)
+	}
+}
+
+// migrations returns the path to the migrations directory of the repository.
+func migrations() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..", "..", "migrations")
+}
@@ -0,0 +1,89 @@
+package record
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// Annotations are the free-form data the user can attach to any record.
+type Annotations struct {
+	Metadata Metadata `db:"metadata"`
+	Tags     Tags     `db:"tags"`
+}
+
+func (a *Annotations) GetAnnotations() Annotations {
+	return *a
+}
+
+func (a *Annotations) SetAnnotations(annotations Annotations) {
+	*a = annotations
+}
+
+// HasTag reports whether the record is tagged with the tag.
+func (a *Annotations) HasTag(tag string) bool {
+	for _, t := range a.Tags {
+		if t == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AddTags adds the tags skipping the duplicates.
+func (a *Annotations) AddTags(tags ...string) {
+	for _, tag := range tags {
+		if !a.HasTag(tag) {
+			a.Tags = append(a.Tags, tag)
+		}
+	}
+	sort.Strings(a.Tags)
+}
+
+// Metadata is an arbitrary key/value map, e.g. the website of a login or the bank of a card.
+// It is stored as a JSON column.
+type Metadata map[string]string
+
+func (m Metadata) Value() (driver.Value, error) {
+	if m == nil {
+		return []byte("{}"), nil
+	}
+
+	return json.Marshal(m)
+}
+
+func (m *Metadata) Scan(src any) error {
+	return scanJSON(src, m)
+}
+
+// Tags is a list of labels to group the records.
+// It is stored as a JSON column.
+type Tags []string
+
+func (t Tags) Value() (driver.Value, error) {
+	if t == nil {
+		return []byte("[]"), nil
+	}
+
+	return json.Marshal(t)
+}
+
+func (t *Tags) Scan(src any) error {
+	return scanJSON(src, t)
+}
+
+func scanJSON(src any, dest any) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, dest)
+	case string:
+		return json.Unmarshal([]byte(v), dest)
+	default:
+		return errors.Errorf("unsupported type %T for JSON column", src)
+	}
+}
@@ -0,0 +1,66 @@
+package record
+
+import (
+	"testing"
+
+	"github.com/denistakeda/mpass/internal/encryption"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Annotations(t *testing.T) {
+	annotations := Annotations{
+		Metadata: Metadata{"url": "https://example.com"},
+		Tags:     Tags{"personal", "web"},
+	}
+
+	t.Run("carried through proto", func(t *testing.T) {
+		for _, rec := range []Record{
+			NewLoginPasswordRecord("login", "password"),
+			NewTextRecord("key", "text"),
+			NewBinaryRecord("key", []byte("binary")),
+			NewBankCardRecord("4242424242424242", 1, 1, 123),
+		} {
+			rec.SetAnnotations(annotations)
+
+			got := FromProto(rec.ToProto())
+			assert.Equal(t, annotations, got.GetAnnotations(), "%T should keep annotations", rec)
+		}
+	})
+
+	t.Run("encrypted with the record", func(t *testing.T) {
+		key, err := encryption.NewKey()
+		require.NoError(t, err)
+
+		rec := NewTextRecord("key", "text")
+		rec.SetAnnotations(annotations)
+
+		encrypted, err := Encrypt(rec, key)
+		require.NoError(t, err)
+		assert.Empty(t, encrypted.GetAnnotations(), "annotations should not be readable by the server")
+
+		got, err := encrypted.Decrypt(key)
+		require.NoError(t, err)
+		assert.Equal(t, annotations, got.GetAnnotations())
+	})
+
+	t.Run("stored as JSON", func(t *testing.T) {
+		value, err := annotations.Metadata.Value()
+		require.NoError(t, err)
+
+		var metadata Metadata
+		require.NoError(t, metadata.Scan(value))
+		assert.Equal(t, annotations.Metadata, metadata)
+
+		value, err = annotations.Tags.Value()
+		require.NoError(t, err)
+
+		var tags Tags
+		require.NoError(t, tags.Scan(string(value.([]byte))))
+		assert.Equal(t, annotations.Tags, tags)
+
+		value, err = Tags(nil).Value()
+		require.NoError(t, err)
+		assert.Equal(t, []byte("[]"), value)
+	})
+}
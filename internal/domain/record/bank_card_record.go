@@ -2,6 +2,7 @@ package record
 
 import (
 	"encoding/gob"
+	"encoding/json"
 	"time"
 
 	"github.com/denistakeda/mpass/proto"
@@ -12,11 +13,43 @@ var _ Record = (*BankCardRecord)(nil)
 
 func init() {
 	gob.Register(&BankCardRecord{})
+
+	Register(KindCard, Codec{
+		Encode: func(r Record) ([]byte, error) {
+			rec := r.(*BankCardRecord)
+			return json.Marshal(bankCardPayload{CardNumber: rec.CardNumber, Month: rec.Month, Day: rec.Day, Code: rec.Code})
+		},
+		Decode: func(id string, lastUpdateDate time.Time, payload []byte) (Record, error) {
+			var p bankCardPayload
+			if err := json.Unmarshal(payload, &p); err != nil {
+				return nil, err
+			}
+
+			return &BankCardRecord{
+				ID:             id,
+				LastUpdateDate: lastUpdateDate,
+
+				CardNumber: p.CardNumber,
+				Month:      p.Month,
+				Day:        p.Day,
+				Code:       p.Code,
+			}, nil
+		},
+	})
+}
+
+type bankCardPayload struct {
+	CardNumber string     `json:"card_number"`
+	Month      time.Month `json:"month"`
+	Day        uint32     `json:"day"`
+	Code       uint       `json:"code"`
 }
 
 type BankCardRecord struct {
 	ID             string    `db:"id"`
 	LastUpdateDate time.Time `db:"last_update_date"`
+	Revision       int64     `db:"revision"`
+	Annotations
 
 	CardNumber string     `db:"card_number"`
 	Month      time.Month `db:"month"`
@@ -56,10 +89,25 @@ func (r *BankCardRecord) GetLastUpdateDate() time.Time {
 	return r.LastUpdateDate
 }
 
+func (r *BankCardRecord) Kind() Kind {
+	return KindCard
+}
+
+func (r *BankCardRecord) GetRevision() int64 {
+	return r.Revision
+}
+
+func (r *BankCardRecord) SetRevision(revision int64) {
+	r.Revision = revision
+}
+
 func (r *BankCardRecord) ToProto() *proto.Record {
 	return &proto.Record{
 		Id:             r.ID,
 		LastUpdateDate: timestamppb.New(r.LastUpdateDate),
+		Revision:       r.Revision,
+		Metadata:       r.Metadata,
+		Tags:           r.Tags,
 
 		Record: &proto.Record_BankCardRecord{
 			BankCardRecord: &proto.BankCardRecord{
@@ -73,6 +121,6 @@ func (r *BankCardRecord) ToProto() *proto.Record {
 }
 
 func (r *BankCardRecord) ProvideToClient(printer printer) error {
-	printer.Printf("Card Number: %s\nDate: %d/%d   Code: %s", r.CardNumber, r.Month, r.Day, r.Code)
+	printer.Printf("Card Number: %s\nDate: %d/%d   Code: %s\n", r.CardNumber, r.Month, r.Day, r.Code)
 	return nil
 }
@@ -14,11 +14,22 @@ var _ Record = (*BinaryRecord)(nil)
 
 func init() {
 	gob.Register(&BinaryRecord{})
+
+	Register(KindFile, Codec{
+		Encode: func(r Record) ([]byte, error) {
+			return r.(*BinaryRecord).Binary, nil
+		},
+		Decode: func(id string, lastUpdateDate time.Time, payload []byte) (Record, error) {
+			return &BinaryRecord{ID: id, LastUpdateDate: lastUpdateDate, Binary: payload}, nil
+		},
+	})
 }
 
 type BinaryRecord struct {
 	ID             string    `db:"id"`
 	LastUpdateDate time.Time `db:"last_update_date"`
+	Revision       int64     `db:"revision"`
+	Annotations
 
 	Binary []byte `db:"binary"`
 }
@@ -49,10 +60,25 @@ func (r *BinaryRecord) GetLastUpdateDate() time.Time {
 	return r.LastUpdateDate
 }
 
+func (r *BinaryRecord) Kind() Kind {
+	return KindFile
+}
+
+func (r *BinaryRecord) GetRevision() int64 {
+	return r.Revision
+}
+
+func (r *BinaryRecord) SetRevision(revision int64) {
+	r.Revision = revision
+}
+
 func (r *BinaryRecord) ToProto() *proto.Record {
 	return &proto.Record{
 		Id:             r.ID,
 		LastUpdateDate: timestamppb.New(r.LastUpdateDate),
+		Revision:       r.Revision,
+		Metadata:       r.Metadata,
+		Tags:           r.Tags,
 
 		Record: &proto.Record_BinaryRecord{
 			BinaryRecord: &proto.BinaryRecord{Binary: r.Binary},
@@ -61,5 +87,5 @@ func (r *BinaryRecord) ToProto() *proto.Record {
 }
 
 func (r *BinaryRecord) ProvideToClient(printer printer) error {
-	return os.WriteFile(fmt.Sprintf("./%s", r), r.Binary, 760)
+	return os.WriteFile(fmt.Sprintf("./%s", r.ID), r.Binary, 760)
 }
@@ -0,0 +1,153 @@
+package record
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"time"
+
+	"github.com/denistakeda/mpass/internal/encryption"
+	"github.com/denistakeda/mpass/proto"
+	"github.com/pkg/errors"
+	protobuf "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var _ Record = (*EncryptedRecord)(nil)
+
+func init() {
+	gob.Register(&EncryptedRecord{})
+
+	Register(KindEncrypted, Codec{
+		Encode: func(r Record) ([]byte, error) {
+			return r.(*EncryptedRecord).Payload, nil
+		},
+		Decode: func(id string, lastUpdateDate time.Time, payload []byte) (Record, error) {
+			return &EncryptedRecord{ID: id, LastUpdateDate: lastUpdateDate, Payload: payload}, nil
+		},
+	})
+}
+
+// EncryptedRecord wraps any other record encrypted with the vault key.
+// Only the ID and the last update date stay readable for the server, the ID is opaque, see OpaqueID.
+type EncryptedRecord struct {
+	ID             string    `db:"id"`
+	LastUpdateDate time.Time `db:"last_update_date"`
+	Revision       int64     `db:"revision"`
+	Annotations
+
+	Payload []byte `db:"payload"`
+}
+
+// OpaqueID returns the id the record with the key is stored under on the server, the keyed hash
+// of the key with the vault key. The key itself, e.g. a login or a card number, is only sealed inside the payload.
+func OpaqueID(vaultKey []byte, key string) string {
+	mac := hmac.New(sha256.New, vaultKey)
+	mac.Write([]byte("record-id"))
+
+	mac = hmac.New(sha256.New, mac.Sum(nil))
+	mac.Write([]byte(key))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Encrypt seals the record with the vault key under the opaque id.
+func Encrypt(r Record, key []byte) (*EncryptedRecord, error) {
+	plaintext, err := protobuf.Marshal(r.ToProto())
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal record %q", r.GetId())
+	}
+
+	id := OpaqueID(key, r.GetId())
+	payload, err := encryption.Seal(key, plaintext, []byte(id))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to encrypt record %q", r.GetId())
+	}
+
+	return &EncryptedRecord{
+		ID:             id,
+		LastUpdateDate: r.GetLastUpdateDate(),
+		Revision:       r.GetRevision(),
+
+		Payload: payload,
+	}, nil
+}
+
+func encryptedRecordFromProto(id string, lastUpdateDate time.Time, p *proto.EncryptedRecord) *EncryptedRecord {
+	return &EncryptedRecord{
+		ID:             id,
+		LastUpdateDate: lastUpdateDate,
+
+		Payload: p.Payload,
+	}
+}
+
+// Decrypt opens the record with the vault key.
+func (r *EncryptedRecord) Decrypt(key []byte) (Record, error) {
+	plaintext, err := encryption.Open(key, r.Payload, []byte(r.ID))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decrypt record %q", r.ID)
+	}
+
+	var p proto.Record
+	if err := protobuf.Unmarshal(plaintext, &p); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal record %q", r.ID)
+	}
+
+	rec := FromProto(&p)
+	if rec == nil {
+		return nil, errors.Errorf("record %q has unknown type", r.ID)
+	}
+	if _, ok := rec.(*EncryptedRecord); ok {
+		return nil, errors.Errorf("record %q is encrypted twice", r.ID)
+	}
+
+	// the records encrypted before the ids became opaque are stored under their keys
+	if r.ID != OpaqueID(key, rec.GetId()) && r.ID != rec.GetId() {
+		return nil, errors.Errorf("record %q is stored under a wrong id", r.ID)
+	}
+
+	// only the revision of the outer record is maintained by the server
+	rec.SetRevision(r.Revision)
+
+	return rec, nil
+}
+
+func (r *EncryptedRecord) GetId() string {
+	return r.ID
+}
+
+func (r *EncryptedRecord) GetLastUpdateDate() time.Time {
+	return r.LastUpdateDate
+}
+
+func (r *EncryptedRecord) Kind() Kind {
+	return KindEncrypted
+}
+
+func (r *EncryptedRecord) GetRevision() int64 {
+	return r.Revision
+}
+
+func (r *EncryptedRecord) SetRevision(revision int64) {
+	r.Revision = revision
+}
+
+func (r *EncryptedRecord) ToProto() *proto.Record {
+	return &proto.Record{
+		Id:             r.ID,
+		LastUpdateDate: timestamppb.New(r.LastUpdateDate),
+		Revision:       r.Revision,
+		Metadata:       r.Metadata,
+		Tags:           r.Tags,
+
+		Record: &proto.Record_EncryptedRecord{
+			EncryptedRecord: &proto.EncryptedRecord{Payload: r.Payload},
+		},
+	}
+}
+
+func (r *EncryptedRecord) ProvideToClient(printer printer) error {
+	return errors.Errorf("record %q is encrypted", r.ID)
+}
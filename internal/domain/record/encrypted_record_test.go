@@ -0,0 +1,78 @@
+package record
+
+import (
+	"testing"
+
+	"github.com/denistakeda/mpass/internal/encryption"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	protobuf "google.golang.org/protobuf/proto"
+)
+
+func Test_EncryptDecrypt(t *testing.T) {
+	key, err := encryption.NewKey()
+	require.NoError(t, err)
+
+	rec := NewLoginPasswordRecord("login", "password")
+
+	encrypted, err := Encrypt(rec, key)
+	require.NoError(t, err)
+
+	t.Run("keeps only opaque id and last update date readable", func(t *testing.T) {
+		assert.Equal(t, OpaqueID(key, rec.ID), encrypted.ID)
+		assert.NotContains(t, encrypted.ID, "login")
+		assert.True(t, rec.LastUpdateDate.Equal(encrypted.LastUpdateDate))
+		assert.NotContains(t, string(encrypted.Payload), "password")
+	})
+
+	t.Run("decrypts the original record", func(t *testing.T) {
+		got, err := encrypted.Decrypt(key)
+		require.NoError(t, err)
+
+		lp, ok := got.(*LoginPasswordRecord)
+		require.True(t, ok, "should decrypt to the login-password record")
+		assert.Equal(t, rec.ID, lp.ID)
+		assert.Equal(t, rec.Login, lp.Login)
+		assert.Equal(t, rec.Password, lp.Password)
+	})
+
+	t.Run("fails with a wrong key", func(t *testing.T) {
+		anotherKey, err := encryption.NewKey()
+		require.NoError(t, err)
+
+		_, err = encrypted.Decrypt(anotherKey)
+		assert.Error(t, err)
+	})
+
+	t.Run("fails when the payload is moved to another id", func(t *testing.T) {
+		moved := *encrypted
+		moved.ID = OpaqueID(key, "another-login")
+
+		_, err := moved.Decrypt(key)
+		assert.Error(t, err)
+	})
+
+	t.Run("fails when the payload is sealed for another key", func(t *testing.T) {
+		another := NewLoginPasswordRecord("another-login", "password")
+		plaintext, err := protobuf.Marshal(another.ToProto())
+		require.NoError(t, err)
+
+		moved := *encrypted
+		moved.Payload, err = encryption.Seal(key, plaintext, []byte(encrypted.ID))
+		require.NoError(t, err)
+
+		_, err = moved.Decrypt(key)
+		assert.Error(t, err)
+	})
+
+	t.Run("decrypts the record stored under its key", func(t *testing.T) {
+		plaintext, err := protobuf.Marshal(rec.ToProto())
+		require.NoError(t, err)
+		payload, err := encryption.Seal(key, plaintext, []byte(rec.ID))
+		require.NoError(t, err)
+
+		got, err := (&EncryptedRecord{ID: rec.ID, Payload: payload}).Decrypt(key)
+		require.NoError(t, err)
+		assert.Equal(t, rec.ID, got.GetId())
+	})
+}
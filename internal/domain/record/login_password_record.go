@@ -2,6 +2,7 @@ package record
 
 import (
 	"encoding/gob"
+	"encoding/json"
 	"time"
 
 	"github.com/denistakeda/mpass/proto"
@@ -11,16 +12,34 @@ import (
 var _ Record = (*LoginPasswordRecord)(nil)
 
 func init() {
-	gob.Register(&BinaryRecord{})
+	gob.Register(&LoginPasswordRecord{})
+
+	Register(KindPassword, Codec{
+		Encode: func(r Record) ([]byte, error) {
+			rec := r.(*LoginPasswordRecord)
+			return json.Marshal(loginPasswordPayload{Login: rec.Login, Password: rec.Password})
+		},
+		Decode: func(id string, lastUpdateDate time.Time, payload []byte) (Record, error) {
+			var p loginPasswordPayload
+			if err := json.Unmarshal(payload, &p); err != nil {
+				return nil, err
+			}
+
+			return &LoginPasswordRecord{ID: id, LastUpdateDate: lastUpdateDate, Login: p.Login, Password: p.Password}, nil
+		},
+	})
 }
 
-func init() {
-	gob.Register(&LoginPasswordRecord{})
+type loginPasswordPayload struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
 }
 
 type LoginPasswordRecord struct {
 	ID             string    `db:"id"`
 	LastUpdateDate time.Time `db:"last_update_date"`
+	Revision       int64     `db:"revision"`
+	Annotations
 
 	Login    string `db:"login"`
 	Password string `db:"password"`
@@ -54,10 +73,25 @@ func (r *LoginPasswordRecord) GetLastUpdateDate() time.Time {
 	return r.LastUpdateDate
 }
 
+func (r *LoginPasswordRecord) Kind() Kind {
+	return KindPassword
+}
+
+func (r *LoginPasswordRecord) GetRevision() int64 {
+	return r.Revision
+}
+
+func (r *LoginPasswordRecord) SetRevision(revision int64) {
+	r.Revision = revision
+}
+
 func (r *LoginPasswordRecord) ToProto() *proto.Record {
 	return &proto.Record{
 		Id:             r.ID,
 		LastUpdateDate: timestamppb.New(r.LastUpdateDate),
+		Revision:       r.Revision,
+		Metadata:       r.Metadata,
+		Tags:           r.Tags,
 
 		Record: &proto.Record_LoginPasswordRecord{
 			LoginPasswordRecord: &proto.LoginPasswordRecord{
@@ -68,8 +102,11 @@ func (r *LoginPasswordRecord) ToProto() *proto.Record {
 	}
 }
 
-// ProvideToClient implements Record
+// ProvideToClient implements Record, the login is printed unless the record is named after it.
 func (r *LoginPasswordRecord) ProvideToClient(printer printer) error {
+	if r.Login != "" && r.Login != r.ID {
+		printer.Printf("Login: %s\n", r.Login)
+	}
 	printer.Printf("Password: %s\n", r.Password)
 	return nil
 }
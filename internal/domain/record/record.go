@@ -4,13 +4,49 @@ import (
 	"time"
 
 	"github.com/denistakeda/mpass/proto"
+	"github.com/pkg/errors"
 )
 
+// Kind is the type of the record as it is called in the CLI.
+type Kind string
+
+const (
+	KindPassword  Kind = "password"
+	KindCard      Kind = "card"
+	KindText      Kind = "text"
+	KindFile      Kind = "file"
+	KindTOTP      Kind = "totp"
+	KindSSHKey    Kind = "ssh"
+	KindEncrypted Kind = "encrypted"
+)
+
+// Kinds are the kinds of records the user can store.
+var Kinds = []Kind{KindPassword, KindCard, KindText, KindFile, KindTOTP, KindSSHKey}
+
+// ParseKind validates the kind name given by the user.
+func ParseKind(s string) (Kind, error) {
+	for _, k := range Kinds {
+		if string(k) == s {
+			return k, nil
+		}
+	}
+
+	return "", errors.Errorf("unknown record type %q, expected one of: %v", s, Kinds)
+}
+
 type Record interface {
 	GetId() string
 	GetLastUpdateDate() time.Time
+	Kind() Kind
 	ToProto() *proto.Record
 
+	// GetRevision returns the revision of the server vault this version of the record is based on.
+	GetRevision() int64
+	SetRevision(revision int64)
+
+	GetAnnotations() Annotations
+	SetAnnotations(annotations Annotations)
+
 	ProvideToClient(printer printer) error
 }
 
@@ -19,18 +55,55 @@ type printer interface {
 }
 
 func FromProto(rec *proto.Record) Record {
+	var res Record
+
 	lastUpdateDate := rec.LastUpdateDate.AsTime()
 	switch i := rec.Record.(type) {
 	case *proto.Record_LoginPasswordRecord:
-		return loginPasswordRecordFromProto(rec.Id, lastUpdateDate, i.LoginPasswordRecord)
+		res = loginPasswordRecordFromProto(rec.Id, lastUpdateDate, i.LoginPasswordRecord)
 	case *proto.Record_TextRecord:
-		return textRecordFromProto(rec.Id, lastUpdateDate, i.TextRecord)
+		res = textRecordFromProto(rec.Id, lastUpdateDate, i.TextRecord)
 	case *proto.Record_BinaryRecord:
-		return binaryRecordFromProto(rec.Id, lastUpdateDate, i.BinaryRecord)
+		res = binaryRecordFromProto(rec.Id, lastUpdateDate, i.BinaryRecord)
 	case *proto.Record_BankCardRecord:
-		return bankCardRecordFromProto(rec.Id, lastUpdateDate, i.BankCardRecord)
+		res = bankCardRecordFromProto(rec.Id, lastUpdateDate, i.BankCardRecord)
+	case *proto.Record_TotpRecord:
+		res = totpRecordFromProto(rec.Id, lastUpdateDate, i.TotpRecord)
+	case *proto.Record_SshKeyRecord:
+		res = sshKeyRecordFromProto(rec.Id, lastUpdateDate, i.SshKeyRecord)
+	case *proto.Record_EncryptedRecord:
+		res = encryptedRecordFromProto(rec.Id, lastUpdateDate, i.EncryptedRecord)
 	default:
 		// Should never happen
 		return nil
 	}
+
+	res.SetRevision(rec.Revision)
+	res.SetAnnotations(Annotations{Metadata: rec.Metadata, Tags: rec.Tags})
+
+	return res
+}
+
+// Rebase returns the copy of the record stored under the id and based on the revision of the server vault,
+// e.g. to put a restored version over the one the client has seen. The content, the annotations
+// and the last update date of the record are kept.
+func Rebase(r Record, id string, revision int64) Record {
+	p := r.ToProto()
+	p.Id = id
+	p.Revision = revision
+
+	return FromProto(p)
+}
+
+// Changes are the records changed since some revision of the user vault.
+type Changes struct {
+	Records    []Record
+	Tombstones []Tombstone
+
+	// Revision is the latest revision of the vault
+	Revision int64
+
+	// Full is set when the changes contain the whole vault instead of the difference,
+	// this happens when the tombstones the client has not seen yet were purged.
+	Full bool
 }
@@ -0,0 +1,55 @@
+package record
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Codec converts the records of one kind to the payload kept by the storage and back.
+// The id, the last update date, the revision and the annotations are stored separately.
+type Codec struct {
+	Encode func(r Record) ([]byte, error)
+	Decode func(id string, lastUpdateDate time.Time, payload []byte) (Record, error)
+}
+
+var codecs = make(map[Kind]Codec)
+
+// Register makes the kind of records storable, it is expected to be called from init.
+func Register(kind Kind, codec Codec) {
+	if _, ok := codecs[kind]; ok {
+		panic("record: codec of kind " + string(kind) + " is registered twice")
+	}
+
+	codecs[kind] = codec
+}
+
+// Marshal returns the payload of the record.
+func Marshal(r Record) ([]byte, error) {
+	codec, ok := codecs[r.Kind()]
+	if !ok {
+		return nil, errors.Errorf("unknown record type %q", r.Kind())
+	}
+
+	payload, err := codec.Encode(r)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to encode record %q", r.GetId())
+	}
+
+	return payload, nil
+}
+
+// Unmarshal restores the record of the kind from its payload.
+func Unmarshal(kind Kind, id string, lastUpdateDate time.Time, payload []byte) (Record, error) {
+	codec, ok := codecs[kind]
+	if !ok {
+		return nil, errors.Errorf("unknown record type %q", kind)
+	}
+
+	r, err := codec.Decode(id, lastUpdateDate, payload)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode record %q", id)
+	}
+
+	return r, nil
+}
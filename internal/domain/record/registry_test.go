@@ -0,0 +1,69 @@
+package record
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_MarshalUnmarshal(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name string
+		rec  Record
+	}{
+		{
+			name: "password",
+			rec:  &LoginPasswordRecord{ID: "key", LastUpdateDate: now, Login: "login", Password: "password"},
+		},
+		{
+			name: "card",
+			rec:  &BankCardRecord{ID: "key", LastUpdateDate: now, CardNumber: "1234123412341234", Month: time.May, Day: 12, Code: 123},
+		},
+		{
+			name: "text",
+			rec:  &TextRecord{ID: "key", LastUpdateDate: now, Text: "some text"},
+		},
+		{
+			name: "file",
+			rec:  &BinaryRecord{ID: "key", LastUpdateDate: now, Binary: []byte{0, 1, 2}},
+		},
+		{
+			name: "totp",
+			rec: &TOTPRecord{
+				ID: "key", LastUpdateDate: now,
+				Secret: []byte("12345678901234567890"), Issuer: "Example", Account: "alice@example.com",
+				Algorithm: "SHA256", Digits: 8, Period: 60,
+			},
+		},
+		{
+			name: "ssh",
+			rec: &SSHKeyRecord{
+				ID: "key", LastUpdateDate: now,
+				PrivateKey: []byte(pemKey), PublicKey: pemPublicKey, Comment: "bob", Passphrase: "secret",
+			},
+		},
+		{
+			name: "encrypted",
+			rec:  &EncryptedRecord{ID: "key", LastUpdateDate: now, Payload: []byte("ciphertext")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, err := Marshal(tt.rec)
+			require.NoError(t, err)
+
+			got, err := Unmarshal(tt.rec.Kind(), "key", now, payload)
+			require.NoError(t, err)
+			assert.Equal(t, tt.rec, got)
+		})
+	}
+
+	t.Run("unknown kind", func(t *testing.T) {
+		_, err := Unmarshal("unknown", "key", now, nil)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,26 @@
+package record
+
+// Snapshot is the whole vault of the user as it is kept by the server, it is used for the server backups.
+// The records keep the revisions they were stored with, so the clients could continue syncing after the restore.
+type Snapshot struct {
+	// Revision and PurgedRevision are the sync cursors of the vault, see Changes.
+	Revision       int64
+	PurgedRevision int64
+
+	Records    []Record
+	Tombstones []RevisedTombstone
+	// Versions is the history of the records, the revision of every record is its version.
+	Versions []ArchivedVersion
+}
+
+// RevisedTombstone is the tombstone together with the revision of the vault it was stored with.
+type RevisedTombstone struct {
+	Tombstone
+	Revision int64 `db:"revision"`
+}
+
+// ArchivedVersion is the prior version of the record kept in the history.
+type ArchivedVersion struct {
+	Version
+	Record Record
+}
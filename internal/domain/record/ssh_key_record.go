@@ -0,0 +1,236 @@
+package record
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/gob"
+	"encoding/json"
+	"encoding/pem"
+	"strings"
+	"time"
+
+	"github.com/denistakeda/mpass/proto"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var _ Record = (*SSHKeyRecord)(nil)
+
+func init() {
+	gob.Register(&SSHKeyRecord{})
+
+	Register(KindSSHKey, Codec{
+		Encode: func(r Record) ([]byte, error) {
+			rec := r.(*SSHKeyRecord)
+			return json.Marshal(sshKeyPayload{
+				PrivateKey: rec.PrivateKey,
+				PublicKey:  rec.PublicKey,
+				Comment:    rec.Comment,
+				Passphrase: rec.Passphrase,
+			})
+		},
+		Decode: func(id string, lastUpdateDate time.Time, payload []byte) (Record, error) {
+			var p sshKeyPayload
+			if err := json.Unmarshal(payload, &p); err != nil {
+				return nil, err
+			}
+
+			return &SSHKeyRecord{
+				ID:             id,
+				LastUpdateDate: lastUpdateDate,
+
+				PrivateKey: p.PrivateKey,
+				PublicKey:  p.PublicKey,
+				Comment:    p.Comment,
+				Passphrase: p.Passphrase,
+			}, nil
+		},
+	})
+}
+
+type sshKeyPayload struct {
+	PrivateKey []byte `json:"private_key"`
+	PublicKey  string `json:"public_key"`
+	Comment    string `json:"comment"`
+	Passphrase string `json:"passphrase"`
+}
+
+// SSHKeyRecord keeps the SSH key pair, the private key is kept as it was imported.
+type SSHKeyRecord struct {
+	ID             string    `db:"id"`
+	LastUpdateDate time.Time `db:"last_update_date"`
+	Revision       int64     `db:"revision"`
+	Annotations
+
+	// PrivateKey is in the OpenSSH or PEM format, it is encrypted if the passphrase is set
+	PrivateKey []byte `db:"private_key"`
+	// PublicKey is in the authorized_keys format
+	PublicKey  string `db:"public_key"`
+	Comment    string `db:"comment"`
+	Passphrase string `db:"passphrase"`
+}
+
+// ParseSSHKeyRecord imports the private key in the OpenSSH or PEM format,
+// the passphrase is required for the encrypted keys.
+func ParseSSHKeyRecord(key string, privateKey []byte, passphrase, comment string) (*SSHKeyRecord, error) {
+	rec := &SSHKeyRecord{
+		ID:             key,
+		LastUpdateDate: time.Now(),
+
+		PrivateKey: privateKey,
+		Comment:    comment,
+		Passphrase: passphrase,
+	}
+
+	signer, err := rec.Signer()
+	if err != nil {
+		return nil, err
+	}
+	rec.PublicKey = strings.TrimSpace(string(ssh.MarshalAuthorizedKey(signer.PublicKey())))
+
+	return rec, nil
+}
+
+// GenerateSSHKeyRecord generates a new ed25519 key pair.
+func GenerateSSHKeyRecord(key, comment string) (*SSHKeyRecord, error) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate ed25519 key")
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal ed25519 key")
+	}
+
+	return ParseSSHKeyRecord(key, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), "", comment)
+}
+
+// RawPrivateKey returns the decrypted private key, the error wraps *ssh.PassphraseMissingError
+// if the key is encrypted and the passphrase is not set.
+func (r *SSHKeyRecord) RawPrivateKey() (interface{}, error) {
+	var (
+		key interface{}
+		err error
+	)
+	if r.Passphrase == "" {
+		key, err = ssh.ParseRawPrivateKey(r.PrivateKey)
+	} else {
+		key, err = ssh.ParseRawPrivateKeyWithPassphrase(r.PrivateKey, []byte(r.Passphrase))
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse private key %q", r.ID)
+	}
+
+	// the OpenSSH keys are parsed to the pointer, the rest of the code expects the value
+	if k, ok := key.(*ed25519.PrivateKey); ok {
+		key = *k
+	}
+
+	return key, nil
+}
+
+func (r *SSHKeyRecord) Signer() (ssh.Signer, error) {
+	key, err := r.RawPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unsupported private key %q", r.ID)
+	}
+
+	return signer, nil
+}
+
+// AgentKey returns the key to be added to the SSH agent, the record key is used as a comment if there is none.
+func (r *SSHKeyRecord) AgentKey() (agent.AddedKey, error) {
+	key, err := r.RawPrivateKey()
+	if err != nil {
+		return agent.AddedKey{}, err
+	}
+
+	comment := r.Comment
+	if comment == "" {
+		comment = r.ID
+	}
+
+	return agent.AddedKey{PrivateKey: key, Comment: comment}, nil
+}
+
+// AuthorizedKey returns the line of the authorized_keys file with the public key.
+func (r *SSHKeyRecord) AuthorizedKey() string {
+	if r.Comment == "" {
+		return r.PublicKey
+	}
+
+	return r.PublicKey + " " + r.Comment
+}
+
+func sshKeyRecordFromProto(id string, lastUpdateDate time.Time, p *proto.SSHKeyRecord) *SSHKeyRecord {
+	return &SSHKeyRecord{
+		ID:             id,
+		LastUpdateDate: lastUpdateDate,
+
+		PrivateKey: p.PrivateKey,
+		PublicKey:  p.PublicKey,
+		Comment:    p.Comment,
+		Passphrase: p.Passphrase,
+	}
+}
+
+func (r *SSHKeyRecord) GetId() string {
+	return r.ID
+}
+
+func (r *SSHKeyRecord) GetLastUpdateDate() time.Time {
+	return r.LastUpdateDate
+}
+
+func (r *SSHKeyRecord) Kind() Kind {
+	return KindSSHKey
+}
+
+func (r *SSHKeyRecord) GetRevision() int64 {
+	return r.Revision
+}
+
+func (r *SSHKeyRecord) SetRevision(revision int64) {
+	r.Revision = revision
+}
+
+func (r *SSHKeyRecord) ToProto() *proto.Record {
+	return &proto.Record{
+		Id:             r.ID,
+		LastUpdateDate: timestamppb.New(r.LastUpdateDate),
+		Revision:       r.Revision,
+		Metadata:       r.Metadata,
+		Tags:           r.Tags,
+
+		Record: &proto.Record_SshKeyRecord{
+			SshKeyRecord: &proto.SSHKeyRecord{
+				PrivateKey: r.PrivateKey,
+				PublicKey:  r.PublicKey,
+				Comment:    r.Comment,
+				Passphrase: r.Passphrase,
+			},
+		},
+	}
+}
+
+// ProvideToClient prints only the public key, the private key is used by the SSH agent.
+func (r *SSHKeyRecord) ProvideToClient(printer printer) error {
+	publicKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(r.PublicKey))
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse public key %q", r.ID)
+	}
+
+	printer.Printf("Public key: %s\n", r.AuthorizedKey())
+	printer.Printf("Fingerprint: %s\n", ssh.FingerprintSHA256(publicKey))
+
+	return nil
+}
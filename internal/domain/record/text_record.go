@@ -12,11 +12,22 @@ var _ Record = (*TextRecord)(nil)
 
 func init() {
 	gob.Register(&TextRecord{})
+
+	Register(KindText, Codec{
+		Encode: func(r Record) ([]byte, error) {
+			return []byte(r.(*TextRecord).Text), nil
+		},
+		Decode: func(id string, lastUpdateDate time.Time, payload []byte) (Record, error) {
+			return &TextRecord{ID: id, LastUpdateDate: lastUpdateDate, Text: string(payload)}, nil
+		},
+	})
 }
 
 type TextRecord struct {
 	ID             string    `db:"id"`
 	LastUpdateDate time.Time `db:"last_update_date"`
+	Revision       int64     `db:"revision"`
+	Annotations
 
 	Text string `db:"text"`
 }
@@ -47,10 +58,25 @@ func (r *TextRecord) GetLastUpdateDate() time.Time {
 	return r.LastUpdateDate
 }
 
+func (r *TextRecord) Kind() Kind {
+	return KindText
+}
+
+func (r *TextRecord) GetRevision() int64 {
+	return r.Revision
+}
+
+func (r *TextRecord) SetRevision(revision int64) {
+	r.Revision = revision
+}
+
 func (r *TextRecord) ToProto() *proto.Record {
 	return &proto.Record{
 		Id:             r.ID,
 		LastUpdateDate: timestamppb.New(r.LastUpdateDate),
+		Revision:       r.Revision,
+		Metadata:       r.Metadata,
+		Tags:           r.Tags,
 
 		Record: &proto.Record_TextRecord{
 			TextRecord: &proto.TextRecord{Text: r.Text},
@@ -59,6 +85,6 @@ func (r *TextRecord) ToProto() *proto.Record {
 }
 
 func (r *TextRecord) ProvideToClient(printer printer) error {
-	printer.Printf("Text:\n %s", r.Text)
+	printer.Printf("Text:\n %s\n", r.Text)
 	return nil
 }
@@ -0,0 +1,42 @@
+package record
+
+import (
+	"time"
+
+	"github.com/denistakeda/mpass/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Tombstone marks the record as deleted.
+// It is kept for a while to propagate the deletion to all the devices
+// and to win over the edits made before the deletion.
+type Tombstone struct {
+	ID           string    `db:"id"`
+	DeletionDate time.Time `db:"deletion_date"`
+}
+
+func NewTombstone(id string) Tombstone {
+	return Tombstone{
+		ID:           id,
+		DeletionDate: time.Now(),
+	}
+}
+
+func TombstoneFromProto(p *proto.Tombstone) Tombstone {
+	return Tombstone{
+		ID:           p.Id,
+		DeletionDate: p.DeletionDate.AsTime(),
+	}
+}
+
+func (t Tombstone) ToProto() *proto.Tombstone {
+	return &proto.Tombstone{
+		Id:           t.ID,
+		DeletionDate: timestamppb.New(t.DeletionDate),
+	}
+}
+
+// Deletes reports whether the tombstone wins over the given record.
+func (t Tombstone) Deletes(r Record) bool {
+	return !r.GetLastUpdateDate().After(t.DeletionDate)
+}
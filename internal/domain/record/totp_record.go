@@ -0,0 +1,314 @@
+package record
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/denistakeda/mpass/proto"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var _ Record = (*TOTPRecord)(nil)
+
+// the defaults of the authenticator apps, they are used when the otpauth:// URI does not define the parameters
+const (
+	defaultTOTPAlgorithm = "SHA1"
+	defaultTOTPDigits    = 6
+	defaultTOTPPeriod    = 30
+)
+
+var totpAlgorithms = map[string]func() hash.Hash{
+	"SHA1":   sha1.New,
+	"SHA256": sha256.New,
+	"SHA512": sha512.New,
+}
+
+func init() {
+	gob.Register(&TOTPRecord{})
+
+	Register(KindTOTP, Codec{
+		Encode: func(r Record) ([]byte, error) {
+			rec := r.(*TOTPRecord)
+			return json.Marshal(totpPayload{
+				Secret:    rec.Secret,
+				Issuer:    rec.Issuer,
+				Account:   rec.Account,
+				Algorithm: rec.Algorithm,
+				Digits:    rec.Digits,
+				Period:    rec.Period,
+			})
+		},
+		Decode: func(id string, lastUpdateDate time.Time, payload []byte) (Record, error) {
+			var p totpPayload
+			if err := json.Unmarshal(payload, &p); err != nil {
+				return nil, err
+			}
+
+			return &TOTPRecord{
+				ID:             id,
+				LastUpdateDate: lastUpdateDate,
+
+				Secret:    p.Secret,
+				Issuer:    p.Issuer,
+				Account:   p.Account,
+				Algorithm: p.Algorithm,
+				Digits:    p.Digits,
+				Period:    p.Period,
+			}, nil
+		},
+	})
+}
+
+type totpPayload struct {
+	Secret    []byte `json:"secret"`
+	Issuer    string `json:"issuer"`
+	Account   string `json:"account"`
+	Algorithm string `json:"algorithm"`
+	Digits    uint32 `json:"digits"`
+	Period    uint32 `json:"period"`
+}
+
+// TOTPRecord keeps the seed of the time-based one-time passwords defined by RFC 6238.
+type TOTPRecord struct {
+	ID             string    `db:"id"`
+	LastUpdateDate time.Time `db:"last_update_date"`
+	Revision       int64     `db:"revision"`
+	Annotations
+
+	Secret  []byte `db:"secret"`
+	Issuer  string `db:"issuer"`
+	Account string `db:"account"`
+	// Algorithm is the hash function of HMAC: SHA1, SHA256 or SHA512
+	Algorithm string `db:"algorithm"`
+	Digits    uint32 `db:"digits"`
+	// Period is the lifetime of the code in seconds
+	Period uint32 `db:"period"`
+}
+
+// ParseTOTPRecord creates the record from either the base32 encoded secret
+// or the otpauth://totp/ URI the services show as a QR code.
+func ParseTOTPRecord(key, secretOrURI string) (*TOTPRecord, error) {
+	rec := &TOTPRecord{
+		ID:             key,
+		LastUpdateDate: time.Now(),
+
+		Algorithm: defaultTOTPAlgorithm,
+		Digits:    defaultTOTPDigits,
+		Period:    defaultTOTPPeriod,
+	}
+
+	secret := secretOrURI
+	if strings.HasPrefix(strings.ToLower(secretOrURI), "otpauth:") {
+		var err error
+		secret, err = rec.parseURI(secretOrURI)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// the secrets are often shown in groups, in lower case and without padding
+	secret = strings.TrimRight(strings.ToUpper(strings.ReplaceAll(secret, " ", "")), "=")
+	decoded, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		return nil, errors.Wrap(err, "secret is not a valid base32 string")
+	}
+	if len(decoded) == 0 {
+		return nil, errors.New("secret is empty")
+	}
+	rec.Secret = decoded
+
+	if err := rec.validate(); err != nil {
+		return nil, err
+	}
+
+	return rec, nil
+}
+
+// parseURI fills the parameters from the URI like otpauth://totp/Issuer:account?secret=...&issuer=Issuer
+// and returns the secret.
+func (r *TOTPRecord) parseURI(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse otpauth URI")
+	}
+
+	if !strings.EqualFold(u.Host, "totp") {
+		return "", errors.Errorf("unsupported one-time password type %q, only totp is supported", u.Host)
+	}
+
+	label := strings.TrimPrefix(u.Path, "/")
+	if issuer, account, ok := strings.Cut(label, ":"); ok {
+		r.Issuer, r.Account = strings.TrimSpace(issuer), strings.TrimSpace(account)
+	} else {
+		r.Account = label
+	}
+
+	query := u.Query()
+	if issuer := query.Get("issuer"); issuer != "" {
+		r.Issuer = issuer
+	}
+	if algorithm := query.Get("algorithm"); algorithm != "" {
+		r.Algorithm = strings.ToUpper(algorithm)
+	}
+	if digits := query.Get("digits"); digits != "" {
+		n, err := strconv.ParseUint(digits, 10, 32)
+		if err != nil {
+			return "", errors.Errorf("invalid number of digits %q", digits)
+		}
+		r.Digits = uint32(n)
+	}
+	if period := query.Get("period"); period != "" {
+		n, err := strconv.ParseUint(period, 10, 32)
+		if err != nil {
+			return "", errors.Errorf("invalid period %q", period)
+		}
+		r.Period = uint32(n)
+	}
+
+	secret := query.Get("secret")
+	if secret == "" {
+		return "", errors.New("otpauth URI has no secret")
+	}
+
+	return secret, nil
+}
+
+func (r *TOTPRecord) validate() error {
+	if _, ok := totpAlgorithms[r.Algorithm]; !ok {
+		return errors.Errorf("unsupported algorithm %q, expected SHA1, SHA256 or SHA512", r.Algorithm)
+	}
+
+	// the truncated HMAC is a 31-bit number, so it has at most 10 digits
+	if r.Digits < 6 || r.Digits > 10 {
+		return errors.Errorf("unsupported number of digits %d, expected from 6 to 10", r.Digits)
+	}
+
+	if r.Period == 0 {
+		return errors.New("period should be positive")
+	}
+
+	return nil
+}
+
+// Code returns the one-time password valid at the time.
+func (r *TOTPRecord) Code(t time.Time) (string, error) {
+	if err := r.validate(); err != nil {
+		return "", err
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(t.Unix())/uint64(r.Period))
+
+	mac := hmac.New(totpAlgorithms[r.Algorithm], r.Secret)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	// dynamic truncation from RFC 4226
+	offset := sum[len(sum)-1] & 0x0f
+	value := uint64(binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff)
+
+	modulo := uint64(1)
+	for i := uint32(0); i < r.Digits; i++ {
+		modulo *= 10
+	}
+
+	return fmt.Sprintf("%0*d", r.Digits, value%modulo), nil
+}
+
+// Remaining returns how long the code of the time stays valid.
+func (r *TOTPRecord) Remaining(t time.Time) time.Duration {
+	if r.Period == 0 {
+		return 0
+	}
+
+	period := int64(r.Period)
+	return time.Duration(period-t.Unix()%period) * time.Second
+}
+
+func totpRecordFromProto(id string, lastUpdateDate time.Time, p *proto.TOTPRecord) *TOTPRecord {
+	return &TOTPRecord{
+		ID:             id,
+		LastUpdateDate: lastUpdateDate,
+
+		Secret:    p.Secret,
+		Issuer:    p.Issuer,
+		Account:   p.Account,
+		Algorithm: p.Algorithm,
+		Digits:    p.Digits,
+		Period:    p.Period,
+	}
+}
+
+func (r *TOTPRecord) GetId() string {
+	return r.ID
+}
+
+func (r *TOTPRecord) GetLastUpdateDate() time.Time {
+	return r.LastUpdateDate
+}
+
+func (r *TOTPRecord) Kind() Kind {
+	return KindTOTP
+}
+
+func (r *TOTPRecord) GetRevision() int64 {
+	return r.Revision
+}
+
+func (r *TOTPRecord) SetRevision(revision int64) {
+	r.Revision = revision
+}
+
+func (r *TOTPRecord) ToProto() *proto.Record {
+	return &proto.Record{
+		Id:             r.ID,
+		LastUpdateDate: timestamppb.New(r.LastUpdateDate),
+		Revision:       r.Revision,
+		Metadata:       r.Metadata,
+		Tags:           r.Tags,
+
+		Record: &proto.Record_TotpRecord{
+			TotpRecord: &proto.TOTPRecord{
+				Secret:    r.Secret,
+				Issuer:    r.Issuer,
+				Account:   r.Account,
+				Algorithm: r.Algorithm,
+				Digits:    r.Digits,
+				Period:    r.Period,
+			},
+		},
+	}
+}
+
+// ProvideToClient prints the current code and how long it is valid.
+func (r *TOTPRecord) ProvideToClient(printer printer) error {
+	now := time.Now()
+	code, err := r.Code(now)
+	if err != nil {
+		return err
+	}
+
+	if r.Issuer != "" {
+		printer.Printf("Issuer: %s\n", r.Issuer)
+	}
+	if r.Account != "" {
+		printer.Printf("Account: %s\n", r.Account)
+	}
+	printer.Printf("Code: %s\n", code)
+	printer.Printf("Expires in: %ds\n", int(r.Remaining(now).Seconds()))
+
+	return nil
+}
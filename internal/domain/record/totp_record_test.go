@@ -0,0 +1,122 @@
+package record
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_TOTPRecord_Code(t *testing.T) {
+	// the test vectors from the appendix B of RFC 6238
+	secrets := map[string][]byte{
+		"SHA1":   []byte("12345678901234567890"),
+		"SHA256": []byte("12345678901234567890123456789012"),
+		"SHA512": []byte("1234567890123456789012345678901234567890123456789012345678901234"),
+	}
+
+	tests := []struct {
+		time  int64
+		codes map[string]string
+	}{
+		{time: 59, codes: map[string]string{"SHA1": "94287082", "SHA256": "46119246", "SHA512": "90693936"}},
+		{time: 1111111109, codes: map[string]string{"SHA1": "07081804", "SHA256": "68084774", "SHA512": "25091201"}},
+		{time: 1111111111, codes: map[string]string{"SHA1": "14050471", "SHA256": "67062674", "SHA512": "99943326"}},
+		{time: 1234567890, codes: map[string]string{"SHA1": "89005924", "SHA256": "91819424", "SHA512": "93441116"}},
+		{time: 2000000000, codes: map[string]string{"SHA1": "69279037", "SHA256": "90698825", "SHA512": "38618901"}},
+		{time: 20000000000, codes: map[string]string{"SHA1": "65353130", "SHA256": "77737706", "SHA512": "47863826"}},
+	}
+	for _, tt := range tests {
+		for algorithm, want := range tt.codes {
+			rec := &TOTPRecord{Secret: secrets[algorithm], Algorithm: algorithm, Digits: 8, Period: 30}
+
+			got, err := rec.Code(time.Unix(tt.time, 0))
+			require.NoError(t, err)
+			assert.Equal(t, want, got, "%s at %d", algorithm, tt.time)
+		}
+	}
+
+	t.Run("six digits", func(t *testing.T) {
+		rec := &TOTPRecord{Secret: secrets["SHA1"], Algorithm: "SHA1", Digits: 6, Period: 30}
+
+		got, err := rec.Code(time.Unix(59, 0))
+		require.NoError(t, err)
+		assert.Equal(t, "287082", got)
+	})
+}
+
+func Test_TOTPRecord_Remaining(t *testing.T) {
+	rec := &TOTPRecord{Period: 30}
+
+	assert.Equal(t, 30*time.Second, rec.Remaining(time.Unix(60, 0)))
+	assert.Equal(t, time.Second, rec.Remaining(time.Unix(59, 0)))
+	assert.Equal(t, 17*time.Second, rec.Remaining(time.Unix(73, 0)))
+}
+
+func Test_ParseTOTPRecord(t *testing.T) {
+	// base32 of "12345678901234567890"
+	const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+	tests := []struct {
+		name  string
+		input string
+		want  TOTPRecord
+	}{
+		{
+			name:  "base32 secret",
+			input: secret,
+			want:  TOTPRecord{Algorithm: "SHA1", Digits: 6, Period: 30},
+		},
+		{
+			name:  "grouped lower case secret",
+			input: "gezd gnbv gy3t qojq gezd gnbv gy3t qojq",
+			want:  TOTPRecord{Algorithm: "SHA1", Digits: 6, Period: 30},
+		},
+		{
+			name:  "minimal URI",
+			input: "otpauth://totp/alice@example.com?secret=" + secret,
+			want:  TOTPRecord{Account: "alice@example.com", Algorithm: "SHA1", Digits: 6, Period: 30},
+		},
+		{
+			name: "URI with all the parameters",
+			input: "otpauth://totp/Example%20Co:alice@example.com?secret=" + secret +
+				"&issuer=Example&algorithm=sha256&digits=8&period=60",
+			want: TOTPRecord{Issuer: "Example", Account: "alice@example.com", Algorithm: "SHA256", Digits: 8, Period: 60},
+		},
+		{
+			name:  "issuer from the label",
+			input: "otpauth://totp/Example%20Co:%20alice@example.com?secret=" + secret,
+			want:  TOTPRecord{Issuer: "Example Co", Account: "alice@example.com", Algorithm: "SHA1", Digits: 6, Period: 30},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTOTPRecord("key", tt.input)
+			require.NoError(t, err)
+
+			assert.Equal(t, "key", got.ID)
+			assert.Equal(t, []byte("12345678901234567890"), got.Secret)
+			assert.Equal(t, tt.want.Issuer, got.Issuer)
+			assert.Equal(t, tt.want.Account, got.Account)
+			assert.Equal(t, tt.want.Algorithm, got.Algorithm)
+			assert.Equal(t, tt.want.Digits, got.Digits)
+			assert.Equal(t, tt.want.Period, got.Period)
+		})
+	}
+
+	for name, input := range map[string]string{
+		"invalid base32":        "not a base32 secret!",
+		"empty secret":          "",
+		"hotp":                  "otpauth://hotp/alice?secret=" + secret + "&counter=1",
+		"no secret":             "otpauth://totp/alice?issuer=Example",
+		"unsupported algorithm": "otpauth://totp/alice?secret=" + secret + "&algorithm=MD5",
+		"too few digits":        "otpauth://totp/alice?secret=" + secret + "&digits=4",
+		"zero period":           "otpauth://totp/alice?secret=" + secret + "&period=0",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseTOTPRecord("key", input)
+			assert.Error(t, err)
+		})
+	}
+}
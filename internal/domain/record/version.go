@@ -0,0 +1,36 @@
+package record
+
+import (
+	"time"
+
+	"github.com/denistakeda/mpass/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Version describes the prior version of the record kept in the history.
+// The version is the revision of the vault the record was stored with.
+type Version struct {
+	Version        int64     `db:"version"`
+	LastUpdateDate time.Time `db:"last_update_date"`
+	// ReplacedAt is the time the version was overwritten or deleted
+	ReplacedAt time.Time `db:"replaced_at"`
+	Deleted    bool      `db:"deleted"`
+}
+
+func VersionFromProto(p *proto.RecordVersion) Version {
+	return Version{
+		Version:        p.Version,
+		LastUpdateDate: p.LastUpdateDate.AsTime(),
+		ReplacedAt:     p.ReplacedAt.AsTime(),
+		Deleted:        p.Deleted,
+	}
+}
+
+func (v Version) ToProto() *proto.RecordVersion {
+	return &proto.RecordVersion{
+		Version:        v.Version,
+		LastUpdateDate: timestamppb.New(v.LastUpdateDate),
+		ReplacedAt:     timestamppb.New(v.ReplacedAt),
+		Deleted:        v.Deleted,
+	}
+}
@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+// Tokens are the credentials of a user session.
+// The access token is short-lived, the refresh token is used to get a new pair once it expires.
+type Tokens struct {
+	AccessToken  string
+	RefreshToken string
+}
+
+// RefreshToken is a refresh token stored on the server, only the hash of the token itself is kept.
+type RefreshToken struct {
+	Hash      string    `db:"token_hash"`
+	Login     string    `db:"user_login"`
+	Device    string    `db:"device"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
@@ -0,0 +1,17 @@
+package domain
+
+import "time"
+
+// Upload is the payload of an encrypted record received in chunks.
+// It becomes the record once the whole payload is received.
+type Upload struct {
+	ID             string    `db:"id"`
+	Login          string    `db:"user_login"`
+	RecordID       string    `db:"record_id"`
+	LastUpdateDate time.Time `db:"last_update_date"`
+	Revision       int64     `db:"revision"`
+	Size           int64     `db:"size"`
+	Checksum       []byte    `db:"checksum"` // SHA-256 of the whole payload
+	Received       int64     `db:"received"` // the number of bytes received so far
+	CreatedAt      time.Time `db:"created_at"`
+}
@@ -1,6 +1,31 @@
 package domain
 
+import (
+	"fmt"
+	"time"
+)
+
 type User struct {
 	Login        string `db:"login"`
 	PasswordHash string `db:"password"`
+	// HistoryRetention is the number of prior versions of every record kept for the user,
+	// zero means all of them, the default of the server is used if nil.
+	HistoryRetention *int `db:"history_retention"`
+	// SessionStamp is carried by the access tokens, they are rejected once it is changed with the credentials.
+	SessionStamp string `db:"session_stamp"`
+}
+
+// LoginFailures are the failed sign ins of the user in a row, the zero LockedUntil means the login is not locked.
+type LoginFailures struct {
+	Count       int
+	LockedUntil time.Time
+}
+
+// LoginLockedError is returned on sign in while the login is locked after too many failed sign ins.
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("login is locked after too many failed sign ins, try again in %s", e.RetryAfter.Round(time.Second))
 }
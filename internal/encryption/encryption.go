@@ -0,0 +1,247 @@
+// package encryption contains the primitives used by the client to keep the user data secret.
+// Keys are derived from the master password with Argon2id and the data is sealed with XChaCha20-Poly1305.
+package encryption
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+const (
+	// KeySize is the size of every symmetric key in bytes.
+	KeySize = chacha20poly1305.KeySize
+
+	headerVersion = 1
+	saltSize      = 16
+
+	// The limits protect the client from a header crafted to exhaust its memory or CPU.
+	maxKDFTime   = 64
+	maxKDFMemory = 1024 * 1024 // 1 GiB
+)
+
+var headerMagic = []byte("MPASS")
+
+// KDFParams are the Argon2id parameters used to derive a key from a password.
+type KDFParams struct {
+	Time    uint32
+	Memory  uint32 // in KiB
+	Threads uint8
+}
+
+// validate checks that the parameters are accepted by Argon2id and are within the sane limits.
+func (p KDFParams) validate() error {
+	if p.Time < 1 || p.Time > maxKDFTime {
+		return errors.Errorf("KDF time %d is out of the range [1, %d]", p.Time, maxKDFTime)
+	}
+	if p.Memory > maxKDFMemory {
+		return errors.Errorf("KDF memory %d KiB exceeds %d KiB", p.Memory, maxKDFMemory)
+	}
+	if p.Threads < 1 {
+		return errors.New("KDF threads should not be zero")
+	}
+
+	return nil
+}
+
+// DefaultKDFParams follows the second recommended option of RFC 9106.
+var DefaultKDFParams = KDFParams{
+	Time:    3,
+	Memory:  64 * 1024,
+	Threads: 4,
+}
+
+// Header describes how a key was derived from a password.
+// It is stored in front of every password protected blob.
+type Header struct {
+	Version uint8
+	KDF     KDFParams
+	Salt    []byte
+}
+
+// NewHeader creates a header with the default KDF parameters and a random salt.
+func NewHeader() (Header, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return Header{}, errors.Wrap(err, "failed to generate salt")
+	}
+
+	return Header{
+		Version: headerVersion,
+		KDF:     DefaultKDFParams,
+		Salt:    salt,
+	}, nil
+}
+
+// DeriveKey derives a key from the password using the header parameters.
+func (h Header) DeriveKey(password string) []byte {
+	return argon2.IDKey([]byte(password), h.Salt, h.KDF.Time, h.KDF.Memory, h.KDF.Threads, KeySize)
+}
+
+// MarshalBinary encodes the header.
+func (h Header) MarshalBinary() ([]byte, error) {
+	if len(h.Salt) > 255 {
+		return nil, errors.New("salt is too long")
+	}
+
+	var buf bytes.Buffer
+	buf.Write(headerMagic)
+	buf.WriteByte(h.Version)
+	_ = binary.Write(&buf, binary.BigEndian, h.KDF.Time)
+	_ = binary.Write(&buf, binary.BigEndian, h.KDF.Memory)
+	buf.WriteByte(h.KDF.Threads)
+	buf.WriteByte(byte(len(h.Salt)))
+	buf.Write(h.Salt)
+
+	return buf.Bytes(), nil
+}
+
+// HasHeader reports whether the data starts with an encoded header.
+func HasHeader(data []byte) bool {
+	return bytes.HasPrefix(data, headerMagic)
+}
+
+// ParseHeader decodes the header from the beginning of data and returns the rest of the data.
+func ParseHeader(data []byte) (Header, []byte, error) {
+	var h Header
+
+	if !HasHeader(data) {
+		return h, nil, errors.New("data is not encrypted by mpass")
+	}
+
+	r := bytes.NewReader(data[len(headerMagic):])
+
+	version, err := r.ReadByte()
+	if err != nil {
+		return h, nil, errors.Wrap(err, "failed to read header version")
+	}
+	if version != headerVersion {
+		return h, nil, errors.Errorf("unsupported header version %d", version)
+	}
+	h.Version = version
+
+	if err := binary.Read(r, binary.BigEndian, &h.KDF.Time); err != nil {
+		return h, nil, errors.Wrap(err, "failed to read KDF time")
+	}
+	if err := binary.Read(r, binary.BigEndian, &h.KDF.Memory); err != nil {
+		return h, nil, errors.Wrap(err, "failed to read KDF memory")
+	}
+	if h.KDF.Threads, err = r.ReadByte(); err != nil {
+		return h, nil, errors.Wrap(err, "failed to read KDF threads")
+	}
+	if err := h.KDF.validate(); err != nil {
+		return h, nil, errors.Wrap(err, "invalid header")
+	}
+
+	saltLen, err := r.ReadByte()
+	if err != nil {
+		return h, nil, errors.Wrap(err, "failed to read salt length")
+	}
+	h.Salt = make([]byte, saltLen)
+	if _, err := io.ReadFull(r, h.Salt); err != nil || saltLen == 0 {
+		return h, nil, errors.New("failed to read salt")
+	}
+
+	headerLen := len(data) - r.Len()
+	return h, data[headerLen:], nil
+}
+
+// NewKey generates a random key.
+func NewKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, errors.Wrap(err, "failed to generate key")
+	}
+
+	return key, nil
+}
+
+// Seal encrypts and authenticates the plaintext and authenticates the additional data.
+// The random nonce is prepended to the result.
+func Seal(key, plaintext, additionalData []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create cipher")
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, errors.Wrap(err, "failed to generate nonce")
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// Open decrypts the data sealed by Seal.
+func Open(key, sealed, additionalData []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create cipher")
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext is too short")
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, errors.New("failed to decrypt data, the password is wrong or the data is corrupted")
+	}
+
+	return plaintext, nil
+}
+
+// SealWithPassword encrypts the plaintext with a key derived from the password.
+// The result is self-describing: it starts with the header containing the KDF parameters and the salt.
+func SealWithPassword(password string, plaintext []byte) ([]byte, error) {
+	header, err := NewHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	return SealWithKey(header, header.DeriveKey(password), plaintext)
+}
+
+// SealWithKey works as SealWithPassword but with a key already derived from the header.
+// It allows to avoid the expensive key derivation when the same password is used many times.
+func SealWithKey(header Header, key, plaintext []byte) ([]byte, error) {
+	headerBytes, err := header.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := Seal(key, plaintext, headerBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(headerBytes, sealed...), nil
+}
+
+// OpenWithPassword decrypts the data sealed by SealWithPassword.
+func OpenWithPassword(password string, data []byte) ([]byte, error) {
+	header, _, err := ParseHeader(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return OpenWithKey(header.DeriveKey(password), data)
+}
+
+// OpenWithKey decrypts the data sealed by SealWithKey with an already derived key.
+func OpenWithKey(key, data []byte) ([]byte, error) {
+	_, sealed, err := ParseHeader(data)
+	if err != nil {
+		return nil, err
+	}
+
+	headerBytes := data[:len(data)-len(sealed)]
+
+	return Open(key, sealed, headerBytes)
+}
@@ -0,0 +1,110 @@
+package encryption
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SealOpen(t *testing.T) {
+	key, err := NewKey()
+	require.NoError(t, err)
+
+	t.Run("round trip", func(t *testing.T) {
+		sealed, err := Seal(key, []byte("secret"), []byte("id"))
+		require.NoError(t, err)
+		assert.NotContains(t, string(sealed), "secret", "should not contain the plaintext")
+
+		got, err := Open(key, sealed, []byte("id"))
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("secret"), got)
+	})
+
+	t.Run("wrong additional data", func(t *testing.T) {
+		sealed, err := Seal(key, []byte("secret"), []byte("id"))
+		require.NoError(t, err)
+
+		_, err = Open(key, sealed, []byte("another-id"))
+		assert.Error(t, err)
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		sealed, err := Seal(key, []byte("secret"), nil)
+		require.NoError(t, err)
+
+		anotherKey, err := NewKey()
+		require.NoError(t, err)
+
+		_, err = Open(anotherKey, sealed, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("tampered ciphertext", func(t *testing.T) {
+		sealed, err := Seal(key, []byte("secret"), nil)
+		require.NoError(t, err)
+
+		sealed[len(sealed)-1] ^= 1
+
+		_, err = Open(key, sealed, nil)
+		assert.Error(t, err)
+	})
+}
+
+func Test_SealOpenWithPassword(t *testing.T) {
+	sealed, err := SealWithPassword("password", []byte("secret"))
+	require.NoError(t, err)
+
+	t.Run("correct password", func(t *testing.T) {
+		got, err := OpenWithPassword("password", sealed)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("secret"), got)
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		_, err := OpenWithPassword("wrong", sealed)
+		assert.Error(t, err)
+	})
+
+	t.Run("header is stored in front of the data", func(t *testing.T) {
+		header, _, err := ParseHeader(sealed)
+		require.NoError(t, err)
+		assert.Equal(t, DefaultKDFParams, header.KDF)
+		assert.Len(t, header.Salt, saltSize)
+	})
+
+	t.Run("not encrypted data", func(t *testing.T) {
+		_, err := OpenWithPassword("password", []byte("plain data"))
+		assert.Error(t, err)
+	})
+}
+
+func Test_ParseHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		kdf     KDFParams
+		wantErr bool
+	}{
+		{name: "default parameters", kdf: DefaultKDFParams},
+		{name: "zero time", kdf: KDFParams{Time: 0, Memory: 64 * 1024, Threads: 4}, wantErr: true},
+		{name: "zero threads", kdf: KDFParams{Time: 3, Memory: 64 * 1024, Threads: 0}, wantErr: true},
+		{name: "huge memory", kdf: KDFParams{Time: 3, Memory: 1<<32 - 1, Threads: 4}, wantErr: true},
+		{name: "huge time", kdf: KDFParams{Time: 1<<32 - 1, Memory: 64 * 1024, Threads: 4}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := Header{Version: headerVersion, KDF: tt.kdf, Salt: make([]byte, saltSize)}
+			data, err := header.MarshalBinary()
+			require.NoError(t, err)
+
+			_, _, err = ParseHeader(data)
+			if tt.wantErr {
+				assert.Error(t, err)
+				_, err = OpenWithPassword("password", append(data, make([]byte, 64)...))
+				assert.Error(t, err, "should fail instead of deriving the key")
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,185 @@
+// package generator creates random passwords and diceware-style passphrases.
+package generator
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	_ "embed"
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	lowerChars  = "abcdefghijklmnopqrstuvwxyz"
+	upperChars  = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	digitChars  = "0123456789"
+	symbolChars = "!#$%&()*+,-./:;<=>?@[]^_{|}~"
+
+	// ambiguousChars are easily confused with each other when typed by hand
+	ambiguousChars = "Il1|O0o"
+)
+
+// wordlist is the EFF large wordlist for passphrases, licensed under CC BY 3.0 US.
+// https://www.eff.org/deeplinks/2016/07/new-wordlists-random-passphrases
+//
+//go:embed eff_large_wordlist.txt
+var wordlistFile []byte
+
+var wordlist = parseWordlist(wordlistFile)
+
+// Options describe the passwords to generate.
+type Options struct {
+	Length int
+
+	Lower   bool
+	Upper   bool
+	Digits  bool
+	Symbols bool
+
+	ExcludeAmbiguous bool
+}
+
+// DefaultOptions are used by the CLI unless the user overrides them.
+var DefaultOptions = Options{
+	Length:  20,
+	Lower:   true,
+	Upper:   true,
+	Digits:  true,
+	Symbols: true,
+}
+
+// Password generates a random password containing at least one character of every enabled class.
+func Password(opts Options) (string, error) {
+	classes := opts.classes()
+	if len(classes) == 0 {
+		return "", errors.New("at least one character class should be enabled")
+	}
+	if opts.Length < len(classes) {
+		return "", errors.Errorf("password should be at least %d characters long to contain all the character classes", len(classes))
+	}
+
+	alphabet := strings.Join(classes, "")
+
+	for {
+		password := make([]byte, opts.Length)
+		for i := range password {
+			idx, err := randomInt(len(alphabet))
+			if err != nil {
+				return "", err
+			}
+			password[i] = alphabet[idx]
+		}
+
+		// rejecting the passwords missing some of the classes keeps the distribution uniform
+		if containsAll(string(password), classes) {
+			return string(password), nil
+		}
+	}
+}
+
+// Entropy estimates the strength of the generated passwords in bits.
+func (o Options) Entropy() float64 {
+	return float64(o.Length) * math.Log2(float64(len(strings.Join(o.classes(), ""))))
+}
+
+func (o Options) classes() []string {
+	var classes []string
+	for _, class := range []struct {
+		enabled bool
+		chars   string
+	}{
+		{o.Lower, lowerChars},
+		{o.Upper, upperChars},
+		{o.Digits, digitChars},
+		{o.Symbols, symbolChars},
+	} {
+		if !class.enabled {
+			continue
+		}
+
+		chars := class.chars
+		if o.ExcludeAmbiguous {
+			chars = strings.Map(func(r rune) rune {
+				if strings.ContainsRune(ambiguousChars, r) {
+					return -1
+				}
+				return r
+			}, chars)
+		}
+		classes = append(classes, chars)
+	}
+
+	return classes
+}
+
+// PassphraseOptions describe the passphrases to generate.
+type PassphraseOptions struct {
+	Words     int
+	Separator string
+}
+
+// DefaultPassphraseOptions are used by the CLI unless the user overrides them.
+var DefaultPassphraseOptions = PassphraseOptions{
+	Words:     6,
+	Separator: "-",
+}
+
+// Passphrase generates a passphrase of the words randomly picked from the EFF wordlist.
+func Passphrase(opts PassphraseOptions) (string, error) {
+	if opts.Words < 1 {
+		return "", errors.New("passphrase should contain at least one word")
+	}
+
+	words := make([]string, opts.Words)
+	for i := range words {
+		idx, err := randomInt(len(wordlist))
+		if err != nil {
+			return "", err
+		}
+		words[i] = wordlist[idx]
+	}
+
+	return strings.Join(words, opts.Separator), nil
+}
+
+// Entropy estimates the strength of the generated passphrases in bits.
+func (o PassphraseOptions) Entropy() float64 {
+	return float64(o.Words) * math.Log2(float64(len(wordlist)))
+}
+
+func randomInt(max int) (int, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(max)))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to generate random number")
+	}
+
+	return int(n.Int64()), nil
+}
+
+func containsAll(password string, classes []string) bool {
+	for _, class := range classes {
+		if !strings.ContainsAny(password, class) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parseWordlist reads the words from the lines in the form "<dice rolls>\t<word>".
+func parseWordlist(content []byte) []string {
+	var words []string
+
+	s := bufio.NewScanner(bytes.NewReader(content))
+	for s.Scan() {
+		if _, word, ok := strings.Cut(s.Text(), "\t"); ok {
+			words = append(words, word)
+		}
+	}
+
+	return words
+}
@@ -0,0 +1,78 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Password(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr bool
+	}{
+		{
+			name: "default options",
+			opts: DefaultOptions,
+		},
+		{
+			name: "digits only",
+			opts: Options{Length: 6, Digits: true},
+		},
+		{
+			name: "without ambiguous characters",
+			opts: Options{Length: 64, Lower: true, Upper: true, Digits: true, Symbols: true, ExcludeAmbiguous: true},
+		},
+		{
+			name:    "no character classes",
+			opts:    Options{Length: 10},
+			wantErr: true,
+		},
+		{
+			name:    "too short for all the classes",
+			opts:    Options{Length: 3, Lower: true, Upper: true, Digits: true, Symbols: true},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			password, err := Password(tt.opts)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Len(t, password, tt.opts.Length)
+
+			classes := tt.opts.classes()
+			assert.True(t, containsAll(password, classes), "should contain every character class")
+			for _, r := range password {
+				assert.True(t, strings.ContainsRune(strings.Join(classes, ""), r), "unexpected character %q", r)
+			}
+			if tt.opts.ExcludeAmbiguous {
+				assert.False(t, strings.ContainsAny(password, ambiguousChars))
+			}
+		})
+	}
+}
+
+func Test_Passphrase(t *testing.T) {
+	require.Len(t, wordlist, 7776, "should embed the whole EFF wordlist")
+
+	passphrase, err := Passphrase(PassphraseOptions{Words: 5, Separator: " "})
+	require.NoError(t, err)
+	assert.Len(t, strings.Split(passphrase, " "), 5)
+
+	_, err = Passphrase(PassphraseOptions{Words: 0})
+	assert.Error(t, err)
+}
+
+func Test_Entropy(t *testing.T) {
+	assert.InDelta(t, 64.6, PassphraseOptions{Words: 5}.Entropy(), 0.1)
+	assert.InDelta(t, 10*3.32, Options{Length: 10, Digits: true}.Entropy(), 0.1)
+}
@@ -4,21 +4,22 @@ import (
 	"github.com/denistakeda/mpass/proto"
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/credentials"
 )
 
 type grpcClient struct {
-	host string
-	conn *grpc.ClientConn
-	c    proto.MpassServiceClient
+	host        string
+	credentials credentials.TransportCredentials
+	conn        *grpc.ClientConn
+	c           proto.MpassServiceClient
 }
 
-func New(host string) *grpcClient {
-	return &grpcClient{host: host}
+func New(host string, credentials credentials.TransportCredentials) *grpcClient {
+	return &grpcClient{host: host, credentials: credentials}
 }
 
 func (gc *grpcClient) GetClient() (proto.MpassServiceClient, error) {
-	conn, err := grpc.Dial(gc.host, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := grpc.Dial(gc.host, grpc.WithTransportCredentials(gc.credentials))
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to instantiate a connection to %q", gc.host)
 	}
@@ -0,0 +1,191 @@
+package importer
+
+import (
+	"encoding/json"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/denistakeda/mpass/internal/domain/record"
+	"github.com/pkg/errors"
+)
+
+// the types of the Bitwarden items
+const (
+	bitwardenLogin      = 1
+	bitwardenSecureNote = 2
+	bitwardenCard       = 3
+	bitwardenIdentity   = 4
+	bitwardenSSHKey     = 5
+)
+
+// the types of the Bitwarden custom fields
+const (
+	bitwardenFieldBoolean = 2
+	bitwardenFieldLinked  = 3
+)
+
+type (
+	bitwardenExport struct {
+		Encrypted bool              `json:"encrypted"`
+		Folders   []bitwardenFolder `json:"folders"`
+		Items     []bitwardenItem   `json:"items"`
+	}
+
+	bitwardenFolder struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+
+	bitwardenItem struct {
+		Type     int              `json:"type"`
+		FolderID string           `json:"folderId"`
+		Name     string           `json:"name"`
+		Notes    string           `json:"notes"`
+		Fields   []bitwardenField `json:"fields"`
+		Login    *struct {
+			URIs []struct {
+				URI string `json:"uri"`
+			} `json:"uris"`
+			Username string `json:"username"`
+			Password string `json:"password"`
+			TOTP     string `json:"totp"`
+		} `json:"login"`
+		Card *struct {
+			CardholderName string `json:"cardholderName"`
+			Brand          string `json:"brand"`
+			Number         string `json:"number"`
+			ExpMonth       string `json:"expMonth"`
+			ExpYear        string `json:"expYear"`
+			Code           string `json:"code"`
+		} `json:"card"`
+		SSHKey *struct {
+			PrivateKey string `json:"privateKey"`
+		} `json:"sshKey"`
+	}
+
+	bitwardenField struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+		Type  int    `json:"type"`
+	}
+)
+
+// Bitwarden reads the unencrypted JSON export, the items are named after their folder and name.
+func Bitwarden(r io.Reader) (Result, error) {
+	var export bitwardenExport
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return Result{}, errors.Wrap(err, "failed to parse the Bitwarden export")
+	}
+
+	if export.Encrypted {
+		return Result{}, errors.New("encrypted Bitwarden exports are not supported, export the vault to the unencrypted JSON")
+	}
+
+	folders := make(map[string]string, len(export.Folders))
+	for _, folder := range export.Folders {
+		folders[folder.ID] = folder.Name
+	}
+
+	b := newBuilder()
+	for _, item := range export.Items {
+		importBitwardenItem(b, path.Join(folders[item.FolderID], item.Name), item)
+	}
+
+	return b.result, nil
+}
+
+func importBitwardenItem(b *builder, name string, item bitwardenItem) {
+	metadata := make(record.Metadata)
+	for _, field := range item.Fields {
+		switch field.Type {
+		case bitwardenFieldLinked:
+			b.skip("%s: linked field %q is skipped", name, field.Name)
+		case bitwardenFieldBoolean:
+			setMeta(metadata, field.Name, strconv.FormatBool(field.Value == "true"))
+		default:
+			setMeta(metadata, field.Name, field.Value)
+		}
+	}
+
+	switch item.Type {
+	case bitwardenLogin, bitwardenSecureNote:
+		var username, password, totp string
+		if login := item.Login; login != nil {
+			username, password, totp = login.Username, login.Password, login.TOTP
+			for _, uri := range login.URIs {
+				setMeta(metadata, "url", uri.URI)
+			}
+		}
+
+		if rec := loginOrNote(b.key(name), username, password, item.Notes, metadata, nil); rec != nil {
+			b.add(rec)
+		} else if totp == "" {
+			b.skip("%s: the item is empty", name)
+		}
+
+		if totp != "" {
+			b.addTOTP(name, totp)
+		}
+	case bitwardenCard:
+		if item.Card == nil {
+			b.skip("%s: the card has no details", name)
+			return
+		}
+		importBitwardenCard(b, name, item, metadata)
+	case bitwardenSSHKey:
+		if item.SSHKey == nil {
+			b.skip("%s: the SSH key has no private key", name)
+			return
+		}
+
+		rec, err := record.ParseSSHKeyRecord(b.key(name), []byte(item.SSHKey.PrivateKey), "", "")
+		if err != nil {
+			b.skip("%s: the SSH key is skipped: %v", name, err)
+			return
+		}
+		setMeta(metadata, "notes", item.Notes)
+		rec.SetAnnotations(annotations(metadata, nil))
+		b.add(rec)
+	case bitwardenIdentity:
+		b.skip("%s: identities are not supported", name)
+	default:
+		b.skip("%s: unknown item type %d", name, item.Type)
+	}
+}
+
+// importBitwardenCard keeps the expiration month in the card, the rest of the details are kept as metadata.
+func importBitwardenCard(b *builder, name string, item bitwardenItem, metadata record.Metadata) {
+	card := item.Card
+
+	rec := record.NewBankCardRecord(strings.ReplaceAll(card.Number, " ", ""), 0, 0, 0)
+	rec.ID = b.key(name)
+
+	if card.ExpMonth != "" {
+		month, err := strconv.Atoi(card.ExpMonth)
+		if err == nil && month >= int(time.January) && month <= int(time.December) {
+			rec.Month = time.Month(month)
+		} else {
+			b.skip("%s: invalid expiration month %q", name, card.ExpMonth)
+		}
+	}
+
+	if card.Code != "" {
+		code, err := strconv.ParseUint(card.Code, 10, 32)
+		if err == nil {
+			rec.Code = uint(code)
+		} else {
+			b.skip("%s: invalid card code", name)
+		}
+	}
+
+	setMeta(metadata, "cardholder", card.CardholderName)
+	setMeta(metadata, "brand", card.Brand)
+	setMeta(metadata, "expiration_year", card.ExpYear)
+	setMeta(metadata, "notes", item.Notes)
+	rec.SetAnnotations(annotations(metadata, nil))
+
+	b.add(rec)
+}
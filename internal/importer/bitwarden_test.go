@@ -0,0 +1,85 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/denistakeda/mpass/internal/domain/record"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const bitwardenExportJSON = `{
+  "encrypted": false,
+  "folders": [{"id": "f1", "name": "Work"}],
+  "items": [
+    {
+      "type": 1, "folderId": "f1", "name": "GitHub", "notes": "personal account",
+      "fields": [
+        {"name": "recovery", "value": "abcd-efgh", "type": 1},
+        {"name": "2fa", "value": "true", "type": 2},
+        {"name": "Username", "value": null, "type": 3, "linkedId": 100}
+      ],
+      "login": {
+        "uris": [{"match": null, "uri": "https://github.com"}, {"match": null, "uri": "https://gist.github.com"}],
+        "username": "octocat", "password": "hunter2",
+        "totp": "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+      }
+    },
+    {"type": 1, "folderId": "f1", "name": "GitHub", "login": {"username": "work", "password": "secret"}},
+    {"type": 2, "folderId": null, "name": "Wi-Fi", "notes": "the password is on the router", "secureNote": {"type": 0}},
+    {
+      "type": 3, "folderId": null, "name": "Visa",
+      "card": {"cardholderName": "Alice", "brand": "Visa", "number": "4111 1111 1111 1111", "expMonth": "7", "expYear": "2030", "code": "123"}
+    },
+    {"type": 4, "folderId": null, "name": "Alice", "identity": {"firstName": "Alice"}}
+  ]
+}`
+
+func Test_Bitwarden(t *testing.T) {
+	res, err := Bitwarden(strings.NewReader(bitwardenExportJSON))
+	require.NoError(t, err)
+
+	recs := recordsByKey(res.Records)
+	assert.Len(t, recs, 5)
+
+	if login, ok := recs["Work/GitHub"].(*record.LoginPasswordRecord); assert.True(t, ok) {
+		assert.Equal(t, "octocat", login.Login)
+		assert.Equal(t, "hunter2", login.Password)
+		assert.Equal(t, record.Metadata{
+			"url":      "https://github.com",
+			"url2":     "https://gist.github.com",
+			"notes":    "personal account",
+			"recovery": "abcd-efgh",
+			"2fa":      "true",
+		}, login.Metadata)
+	}
+
+	if login, ok := recs["Work/GitHub (2)"].(*record.LoginPasswordRecord); assert.True(t, ok, "the duplicate names should get a suffix") {
+		assert.Equal(t, "work", login.Login)
+	}
+
+	assert.IsType(t, &record.TOTPRecord{}, recs["Work/GitHub.totp"])
+
+	if note, ok := recs["Wi-Fi"].(*record.TextRecord); assert.True(t, ok) {
+		assert.Equal(t, "the password is on the router", note.Text)
+	}
+
+	if card, ok := recs["Visa"].(*record.BankCardRecord); assert.True(t, ok) {
+		assert.Equal(t, "4111111111111111", card.CardNumber)
+		assert.Equal(t, time.July, card.Month)
+		assert.Equal(t, uint(123), card.Code)
+		assert.Equal(t, record.Metadata{"cardholder": "Alice", "brand": "Visa", "expiration_year": "2030"}, card.Metadata)
+	}
+
+	assert.Equal(t, []string{
+		`Work/GitHub: linked field "Username" is skipped`,
+		"Alice: identities are not supported",
+	}, res.Skipped)
+
+	t.Run("encrypted export", func(t *testing.T) {
+		_, err := Bitwarden(strings.NewReader(`{"encrypted": true, "items": []}`))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,183 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/denistakeda/mpass/internal/domain/record"
+	"github.com/pkg/errors"
+)
+
+// csvFormat maps the columns of the CSV export to the fields of the login,
+// the names are compared ignoring the case. The columns the format does not know are kept as metadata.
+type csvFormat struct {
+	name     []string
+	url      []string
+	username []string
+	password []string
+	notes    []string
+	otp      []string
+	tags     []string
+	// skipped are the columns with nothing worth keeping, e.g. the usage statistics
+	skipped []string
+}
+
+var (
+	chromeCSV = csvFormat{
+		name:     []string{"name"},
+		url:      []string{"url"},
+		username: []string{"username"},
+		password: []string{"password"},
+		notes:    []string{"note"},
+	}
+
+	firefoxCSV = csvFormat{
+		url:      []string{"url"},
+		username: []string{"username"},
+		password: []string{"password"},
+		skipped:  []string{"httprealm", "formactionorigin", "guid", "timecreated", "timelastused", "timepasswordchanged"},
+	}
+
+	onePasswordCSV = csvFormat{
+		name:     []string{"title"},
+		url:      []string{"url", "website"},
+		username: []string{"username"},
+		password: []string{"password"},
+		notes:    []string{"notes", "notesplain"},
+		otp:      []string{"otpauth", "one-time password"},
+		tags:     []string{"tags"},
+		skipped:  []string{"favorite", "archived", "type"},
+	}
+)
+
+// ChromeCSV reads the passwords exported from Chrome, the entries are named after the site.
+func ChromeCSV(r io.Reader) (Result, error) {
+	return parseCSV(r, chromeCSV)
+}
+
+// FirefoxCSV reads the logins exported from Firefox, the entries are named after the host of the site.
+func FirefoxCSV(r io.Reader) (Result, error) {
+	return parseCSV(r, firefoxCSV)
+}
+
+// OnePasswordCSV reads the CSV export of 1Password, the entries are named after their title.
+func OnePasswordCSV(r io.Reader) (Result, error) {
+	return parseCSV(r, onePasswordCSV)
+}
+
+func parseCSV(r io.Reader, format csvFormat) (Result, error) {
+	// the byte order mark some exports start with breaks the quoted header
+	buffered := bufio.NewReader(r)
+	if bom, _ := buffered.Peek(3); bytes.Equal(bom, []byte("\ufeff")) {
+		buffered.Discard(len(bom))
+	}
+
+	reader := csv.NewReader(buffered)
+	// some exports omit the trailing empty columns
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return Result{}, errors.Wrap(err, "failed to read the CSV header")
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+
+	find := func(names []string) int {
+		for _, name := range names {
+			if i, ok := columns[name]; ok {
+				return i
+			}
+		}
+		return -1
+	}
+
+	if find(format.password) < 0 {
+		return Result{}, errors.Errorf("the CSV has no password column, found columns: %s", strings.Join(header, ", "))
+	}
+
+	known := make(map[int]bool)
+	for _, names := range [][]string{format.name, format.url, format.username, format.password, format.notes, format.otp, format.tags} {
+		if i := find(names); i >= 0 {
+			known[i] = true
+		}
+	}
+
+	b := newBuilder()
+
+	var skipped []string
+	for _, name := range format.skipped {
+		if i, ok := columns[name]; ok {
+			known[i] = true
+			skipped = append(skipped, header[i])
+		}
+	}
+	if len(skipped) > 0 {
+		sort.Strings(skipped)
+		b.skip("columns are skipped: %s", strings.Join(skipped, ", "))
+	}
+
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Result{}, errors.Wrap(err, "failed to read the CSV")
+		}
+
+		get := func(names []string) string {
+			if i := find(names); i >= 0 && i < len(row) {
+				return row[i]
+			}
+			return ""
+		}
+
+		line, _ := reader.FieldPos(0)
+		importCSVRow(b, line, header, row, known, format, get)
+	}
+
+	return b.result, nil
+}
+
+func importCSVRow(b *builder, line int, header, row []string, known map[int]bool, format csvFormat, get func([]string) string) {
+	url := get(format.url)
+
+	name := strings.TrimSpace(get(format.name))
+	if name == "" {
+		name = hostname(url)
+	}
+	if name == "" {
+		name = fmt.Sprintf("line %d", line)
+	}
+
+	metadata := make(record.Metadata)
+	setMeta(metadata, "url", url)
+	for i, value := range row {
+		if !known[i] && i < len(header) {
+			setMeta(metadata, header[i], value)
+		}
+	}
+
+	tags := strings.FieldsFunc(get(format.tags), func(r rune) bool { return r == ';' || r == ',' })
+
+	rec := loginOrNote(b.key(name), get(format.username), get(format.password), get(format.notes), metadata, tags)
+	otp := strings.TrimSpace(get(format.otp))
+	if rec != nil {
+		b.add(rec)
+	} else if otp == "" {
+		b.skip("line %d: the entry is empty", line)
+	}
+
+	if otp != "" {
+		b.addTOTP(name, otp)
+	}
+}
@@ -0,0 +1,100 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/denistakeda/mpass/internal/domain/record"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CSV(t *testing.T) {
+	type login struct {
+		username string
+		password string
+		metadata record.Metadata
+		tags     record.Tags
+	}
+
+	tests := []struct {
+		name        string
+		format      string
+		csv         string
+		wantLogins  map[string]login
+		wantTOTP    []string
+		wantSkipped []string
+	}{
+		{
+			name:   "chrome",
+			format: FormatChromeCSV,
+			csv: "name,url,username,password,note\n" +
+				"github.com,https://github.com/login,octocat,hunter2,personal\n" +
+				"github.com,https://github.com/login,work,secret,\n" +
+				",https://example.com/,alice,pa55,\n",
+			wantLogins: map[string]login{
+				"github.com":     {username: "octocat", password: "hunter2", metadata: record.Metadata{"url": "https://github.com/login", "notes": "personal"}},
+				"github.com (2)": {username: "work", password: "secret", metadata: record.Metadata{"url": "https://github.com/login"}},
+				"example.com":    {username: "alice", password: "pa55", metadata: record.Metadata{"url": "https://example.com/"}},
+			},
+		},
+		{
+			name:   "firefox",
+			format: FormatFirefoxCSV,
+			csv: "\ufeff\"url\",\"username\",\"password\",\"httpRealm\",\"formActionOrigin\",\"guid\",\"timeCreated\",\"timeLastUsed\",\"timePasswordChanged\"\n" +
+				"\"https://github.com\",\"octocat\",\"hunter2\",,\"https://github.com\",\"{5ec0d12f}\",\"1600000000000\",\"1600000000000\",\"1600000000000\"\n" +
+				"\"https://example.com\",\"\",\"\",,,\"{7ab4}\",\"1600000000000\",\"1600000000000\",\"1600000000000\"\n",
+			wantLogins: map[string]login{
+				"github.com": {username: "octocat", password: "hunter2", metadata: record.Metadata{"url": "https://github.com"}},
+			},
+			wantSkipped: []string{
+				"columns are skipped: formActionOrigin, guid, httpRealm, timeCreated, timeLastUsed, timePasswordChanged",
+				"line 3: the entry is empty",
+			},
+		},
+		{
+			name:   "1password",
+			format: Format1PasswordCSV,
+			csv: "Title,Url,Username,Password,OTPAuth,Favorite,Archived,Tags,Notes,Security question\n" +
+				"GitHub,https://github.com,octocat,hunter2,otpauth://totp/GitHub:octocat?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ,true,false,work;dev,\"multi\nline\",pet name\n",
+			wantLogins: map[string]login{
+				"GitHub": {
+					username: "octocat",
+					password: "hunter2",
+					metadata: record.Metadata{"url": "https://github.com", "notes": "multi\nline", "Security question": "pet name"},
+					tags:     record.Tags{"dev", "work"},
+				},
+			},
+			wantTOTP:    []string{"GitHub.totp"},
+			wantSkipped: []string{"columns are skipped: Archived, Favorite"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := Parse(tt.format, strings.NewReader(tt.csv), Credentials{})
+			require.NoError(t, err)
+
+			logins := make(map[string]login)
+			var totp []string
+			for _, rec := range res.Records {
+				switch rec := rec.(type) {
+				case *record.LoginPasswordRecord:
+					logins[rec.ID] = login{username: rec.Login, password: rec.Password, metadata: rec.Metadata, tags: rec.Tags}
+				case *record.TOTPRecord:
+					totp = append(totp, rec.ID)
+				default:
+					t.Errorf("unexpected record %q of kind %s", rec.GetId(), rec.Kind())
+				}
+			}
+
+			assert.Equal(t, tt.wantLogins, logins)
+			assert.Equal(t, tt.wantTOTP, totp)
+			assert.Equal(t, tt.wantSkipped, res.Skipped)
+		})
+	}
+
+	t.Run("no password column", func(t *testing.T) {
+		_, err := ChromeCSV(strings.NewReader("name,url\ngithub.com,https://github.com\n"))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,161 @@
+// package importer reads the exports of other password managers and converts their entries to records.
+package importer
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/denistakeda/mpass/internal/domain/record"
+	"github.com/pkg/errors"
+)
+
+// The supported export formats.
+const (
+	FormatKeePass      = "keepass"
+	FormatBitwarden    = "bitwarden"
+	FormatChromeCSV    = "chrome-csv"
+	FormatFirefoxCSV   = "firefox-csv"
+	Format1PasswordCSV = "1password-csv"
+)
+
+// Formats lists the supported export formats.
+var Formats = []string{FormatKeePass, FormatBitwarden, FormatChromeCSV, FormatFirefoxCSV, Format1PasswordCSV}
+
+// Result is the outcome of the import.
+type Result struct {
+	Records []record.Record
+	// Skipped describes the entries and the fields that could not be imported
+	Skipped []string
+}
+
+// Credentials unlock the encrypted exports, only KeePass databases are encrypted.
+type Credentials struct {
+	Password string
+	KeyFile  []byte
+}
+
+// Parse reads the export of the format.
+func Parse(format string, r io.Reader, creds Credentials) (Result, error) {
+	switch format {
+	case FormatKeePass:
+		return KeePass(r, creds)
+	case FormatBitwarden:
+		return Bitwarden(r)
+	case FormatChromeCSV:
+		return ChromeCSV(r)
+	case FormatFirefoxCSV:
+		return FirefoxCSV(r)
+	case Format1PasswordCSV:
+		return OnePasswordCSV(r)
+	default:
+		return Result{}, errors.Errorf("unknown format %q, expected one of: %s", format, strings.Join(Formats, ", "))
+	}
+}
+
+// builder collects the records making sure that their keys are unique.
+type builder struct {
+	result Result
+	keys   map[string]bool
+}
+
+func newBuilder() *builder {
+	return &builder{keys: make(map[string]bool)}
+}
+
+// key returns the unique key for the record, the duplicates get the numeric suffix.
+func (b *builder) key(key string) string {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		key = "imported"
+	}
+
+	unique := key
+	for i := 2; b.keys[unique]; i++ {
+		unique = fmt.Sprintf("%s (%d)", key, i)
+	}
+	b.keys[unique] = true
+
+	return unique
+}
+
+func (b *builder) add(rec record.Record) {
+	b.result.Records = append(b.result.Records, rec)
+}
+
+func (b *builder) skip(format string, args ...any) {
+	b.result.Skipped = append(b.result.Skipped, fmt.Sprintf(format, args...))
+}
+
+// addTOTP stores the one-time password seed of the entry as a separate record next to it.
+func (b *builder) addTOTP(entryKey, secretOrURI string) {
+	rec, err := record.ParseTOTPRecord(b.key(entryKey+".totp"), secretOrURI)
+	if err != nil {
+		b.skip("%s: one-time password is skipped: %v", entryKey, err)
+		return
+	}
+
+	b.add(rec)
+}
+
+// setMeta adds the non-empty value to the metadata, the numeric suffix is added to the repeated names.
+func setMeta(metadata record.Metadata, name, value string) {
+	name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+	if name == "" || value == "" {
+		return
+	}
+
+	unique := name
+	for i := 2; metadata[unique] != ""; i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+	metadata[unique] = value
+}
+
+// annotations converts the collected metadata and tags, the empty ones are left nil.
+func annotations(metadata record.Metadata, tags []string) record.Annotations {
+	var res record.Annotations
+	if len(metadata) > 0 {
+		res.Metadata = metadata
+	}
+	for _, tag := range tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			res.AddTags(tag)
+		}
+	}
+
+	return res
+}
+
+// hostname returns the host of the URL to name the entries without a title.
+func hostname(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Hostname() == "" {
+		return strings.TrimSpace(rawURL)
+	}
+
+	return u.Hostname()
+}
+
+// loginOrNote converts the entry to a login, the entries without credentials are kept as notes.
+// Nothing is returned if the entry is empty.
+func loginOrNote(key, username, password, notes string, metadata record.Metadata, tags []string) record.Record {
+	if username == "" && password == "" {
+		if strings.TrimSpace(notes) == "" {
+			return nil
+		}
+
+		rec := record.NewTextRecord(key, notes)
+		rec.SetAnnotations(annotations(metadata, tags))
+		return rec
+	}
+
+	setMeta(metadata, "notes", notes)
+
+	rec := record.NewLoginPasswordRecord(username, password)
+	rec.ID = key
+	rec.SetAnnotations(annotations(metadata, tags))
+
+	return rec
+}
@@ -0,0 +1,158 @@
+package importer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/denistakeda/mpass/internal/domain/record"
+	"github.com/pkg/errors"
+	"github.com/tobischo/gokeepasslib/v3"
+)
+
+// the standard fields of the KeePass entries, the rest are custom fields
+const (
+	keepassTitle    = "Title"
+	keepassUserName = "UserName"
+	keepassPassword = "Password"
+	keepassURL      = "URL"
+	keepassNotes    = "Notes"
+	// keepassOTP is the field KeePassXC keeps the otpauth:// URI in
+	keepassOTP = "otp"
+)
+
+// KeePass reads the KDBX database, the entries are named after their group path and title.
+// The attachments are imported as files next to the entry, the recycle bin and the history of the entries are skipped.
+func KeePass(r io.Reader, creds Credentials) (Result, error) {
+	db := gokeepasslib.NewDatabase()
+
+	var err error
+	switch {
+	case len(creds.KeyFile) > 0 && creds.Password != "":
+		db.Credentials, err = gokeepasslib.NewPasswordAndKeyDataCredentials(creds.Password, creds.KeyFile)
+	case len(creds.KeyFile) > 0:
+		db.Credentials, err = gokeepasslib.NewKeyDataCredentials(creds.KeyFile)
+	default:
+		db.Credentials = gokeepasslib.NewPasswordCredentials(creds.Password)
+	}
+	if err != nil {
+		return Result{}, errors.Wrap(err, "failed to read the key file")
+	}
+
+	if err := gokeepasslib.NewDecoder(r).Decode(db); err != nil {
+		return Result{}, errors.Wrap(err, "failed to open the KeePass database, check the password and the key file")
+	}
+
+	if err := db.UnlockProtectedEntries(); err != nil {
+		return Result{}, errors.Wrap(err, "failed to unlock the protected fields")
+	}
+
+	b := newBuilder()
+	for _, root := range db.Content.Root.Groups {
+		// the root group is named after the database, so it is not a part of the keys
+		importKeePassGroup(b, db, root, "")
+	}
+
+	return b.result, nil
+}
+
+func importKeePassGroup(b *builder, db *gokeepasslib.Database, group gokeepasslib.Group, prefix string) {
+	meta := db.Content.Meta
+	if meta.RecycleBinEnabled.Bool && group.UUID.Compare(meta.RecycleBinUUID) {
+		if len(group.Entries) > 0 || len(group.Groups) > 0 {
+			b.skip("%s: the recycle bin is skipped", prefix)
+		}
+		return
+	}
+
+	for _, entry := range group.Entries {
+		importKeePassEntry(b, db, entry, prefix)
+	}
+
+	for _, child := range group.Groups {
+		importKeePassGroup(b, db, child, path.Join(prefix, child.Name))
+	}
+}
+
+func importKeePassEntry(b *builder, db *gokeepasslib.Database, entry gokeepasslib.Entry, prefix string) {
+	title := entry.GetTitle()
+	if title == "" {
+		title = hostname(entry.GetContent(keepassURL))
+	}
+	name := path.Join(prefix, title)
+
+	metadata := make(record.Metadata)
+	setMeta(metadata, "url", entry.GetContent(keepassURL))
+
+	var otp string
+	for _, value := range entry.Values {
+		switch value.Key {
+		case keepassTitle, keepassUserName, keepassPassword, keepassURL, keepassNotes:
+		case keepassOTP:
+			otp = value.Value.Content
+		default:
+			setMeta(metadata, value.Key, value.Value.Content)
+		}
+	}
+
+	tags := strings.FieldsFunc(entry.Tags, func(r rune) bool { return r == ';' || r == ',' })
+
+	rec := loginOrNote(b.key(name), entry.GetContent(keepassUserName), entry.GetPassword(), entry.GetContent(keepassNotes), metadata, tags)
+	if rec != nil {
+		b.add(rec)
+	} else if otp == "" && len(entry.Binaries) == 0 {
+		b.skip("%s: the entry is empty", name)
+	}
+
+	if otp != "" {
+		b.addTOTP(name, otp)
+	}
+
+	for _, ref := range entry.Binaries {
+		importKeePassAttachment(b, db, name, ref)
+	}
+}
+
+func importKeePassAttachment(b *builder, db *gokeepasslib.Database, entryName string, ref gokeepasslib.BinaryReference) {
+	binary := ref.Find(db)
+	if binary == nil {
+		b.skip("%s: attachment %q is not found in the database", entryName, ref.Name)
+		return
+	}
+
+	content, err := keePassBinaryContent(db, binary)
+	if err != nil {
+		b.skip("%s: attachment %q could not be read: %v", entryName, ref.Name, err)
+		return
+	}
+
+	b.add(record.NewBinaryRecord(b.key(path.Join(entryName, ref.Name)), content))
+}
+
+// keePassBinaryContent decodes the attachment: KDBX 4 keeps it as is in the inner header,
+// KDBX 3.1 keeps it base64 encoded and optionally compressed in the metadata.
+func keePassBinaryContent(db *gokeepasslib.Database, binary *gokeepasslib.Binary) ([]byte, error) {
+	if db.Header.IsKdbx4() {
+		return binary.Content, nil
+	}
+
+	content, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(binary.Content)))
+	if err != nil {
+		return nil, err
+	}
+
+	if !binary.Compressed.Bool {
+		return content, nil
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return io.ReadAll(reader)
+}
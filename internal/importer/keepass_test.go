@@ -0,0 +1,139 @@
+package importer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/denistakeda/mpass/internal/domain/record"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tobischo/gokeepasslib/v3"
+	w "github.com/tobischo/gokeepasslib/v3/wrappers"
+)
+
+func Test_KeePass(t *testing.T) {
+	versions := map[string]gokeepasslib.DatabaseOption{
+		"KDBX 3.1": gokeepasslib.WithDatabaseKDBXVersion3(),
+		"KDBX 4":   gokeepasslib.WithDatabaseKDBXVersion4(),
+	}
+
+	for name, version := range versions {
+		t.Run(name, func(t *testing.T) {
+			kdbx := newKeePassDatabase(t, version, "master")
+
+			res, err := KeePass(bytes.NewReader(kdbx), Credentials{Password: "master"})
+			require.NoError(t, err)
+
+			recs := recordsByKey(res.Records)
+			assert.Len(t, recs, 5)
+
+			if login, ok := recs["Internet/github.com"].(*record.LoginPasswordRecord); assert.True(t, ok) {
+				assert.Equal(t, "octocat", login.Login)
+				assert.Equal(t, "hunter2", login.Password)
+				assert.Equal(t, record.Metadata{"url": "https://github.com", "notes": "personal account", "recovery": "abcd-efgh"}, login.Metadata)
+				assert.Equal(t, record.Tags{"dev", "work"}, login.Tags)
+			}
+
+			if totp, ok := recs["Internet/github.com.totp"].(*record.TOTPRecord); assert.True(t, ok) {
+				assert.Equal(t, []byte("12345678901234567890"), totp.Secret)
+			}
+
+			if note, ok := recs["Wi-Fi"].(*record.TextRecord); assert.True(t, ok) {
+				assert.Equal(t, "the password is on the router", note.Text)
+			}
+
+			if file, ok := recs["Documents/passport/scan.pdf"].(*record.BinaryRecord); assert.True(t, ok) {
+				assert.Equal(t, []byte("%PDF-1.4"), file.Binary)
+			}
+			assert.Contains(t, recs, "Documents/passport")
+
+			assert.Equal(t, []string{"Recycle Bin: the recycle bin is skipped"}, res.Skipped)
+		})
+	}
+
+	t.Run("wrong password", func(t *testing.T) {
+		kdbx := newKeePassDatabase(t, gokeepasslib.WithDatabaseKDBXVersion4(), "master")
+
+		_, err := KeePass(bytes.NewReader(kdbx), Credentials{Password: "wrong"})
+		assert.Error(t, err)
+	})
+}
+
+// newKeePassDatabase creates the database with the logins, the note, the attachment and the deleted entry.
+func newKeePassDatabase(t *testing.T, version gokeepasslib.DatabaseOption, password string) []byte {
+	db := gokeepasslib.NewDatabase(version)
+	db.Credentials = gokeepasslib.NewPasswordCredentials(password)
+
+	value := func(key, value string) gokeepasslib.ValueData {
+		return gokeepasslib.ValueData{Key: key, Value: gokeepasslib.V{Content: value}}
+	}
+	protected := func(key, value string) gokeepasslib.ValueData {
+		return gokeepasslib.ValueData{Key: key, Value: gokeepasslib.V{Content: value, Protected: w.NewBoolWrapper(true)}}
+	}
+
+	github := gokeepasslib.NewEntry()
+	github.Tags = "work;dev"
+	github.Values = []gokeepasslib.ValueData{
+		value("Title", "github.com"),
+		value("UserName", "octocat"),
+		protected("Password", "hunter2"),
+		value("URL", "https://github.com"),
+		value("Notes", "personal account"),
+		protected("recovery", "abcd-efgh"),
+		protected("otp", "otpauth://totp/GitHub:octocat?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"),
+	}
+
+	wifi := gokeepasslib.NewEntry()
+	wifi.Values = []gokeepasslib.ValueData{value("Title", "Wi-Fi"), value("Notes", "the password is on the router")}
+
+	// KDBX 3.1 keeps the attachments compressed in the metadata, KDBX 4 keeps them as is in the inner header
+	var attachment gokeepasslib.Binary
+	if db.Header.IsKdbx4() {
+		attachment = gokeepasslib.Binary{Content: []byte("%PDF-1.4")}
+		db.Content.InnerHeader.Binaries = gokeepasslib.Binaries{attachment}
+	} else {
+		attachment = *db.Content.Meta.Binaries.Add([]byte("%PDF-1.4"))
+	}
+	passport := gokeepasslib.NewEntry()
+	passport.Values = []gokeepasslib.ValueData{value("Title", "passport"), value("Notes", "expires in 2030")}
+	passport.Binaries = []gokeepasslib.BinaryReference{attachment.CreateReference("scan.pdf")}
+
+	deleted := gokeepasslib.NewEntry()
+	deleted.Values = []gokeepasslib.ValueData{value("Title", "old"), protected("Password", "old")}
+
+	internet := gokeepasslib.NewGroup()
+	internet.Name = "Internet"
+	internet.Entries = []gokeepasslib.Entry{github}
+
+	documents := gokeepasslib.NewGroup()
+	documents.Name = "Documents"
+	documents.Entries = []gokeepasslib.Entry{passport}
+
+	recycleBin := gokeepasslib.NewGroup()
+	recycleBin.Name = "Recycle Bin"
+	recycleBin.Entries = []gokeepasslib.Entry{deleted}
+	db.Content.Meta.RecycleBinEnabled = w.NewBoolWrapper(true)
+	db.Content.Meta.RecycleBinUUID = recycleBin.UUID
+
+	root := gokeepasslib.NewGroup()
+	root.Name = "Passwords"
+	root.Entries = []gokeepasslib.Entry{wifi}
+	root.Groups = []gokeepasslib.Group{internet, documents, recycleBin}
+	db.Content.Root = &gokeepasslib.RootData{Groups: []gokeepasslib.Group{root}}
+
+	require.NoError(t, db.LockProtectedEntries())
+
+	var buf bytes.Buffer
+	require.NoError(t, gokeepasslib.NewEncoder(&buf).Encode(db))
+
+	return buf.Bytes()
+}
+
+func recordsByKey(recs []record.Record) map[string]record.Record {
+	res := make(map[string]record.Record, len(recs))
+	for _, rec := range recs {
+		res[rec.GetId()] = rec
+	}
+
+	return res
+}
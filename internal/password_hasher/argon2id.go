@@ -0,0 +1,141 @@
+package password_hasher
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	argon2idPrefix = "$argon2id$"
+
+	argon2idSaltSize = 16
+	argon2idKeySize  = 32
+)
+
+// Argon2idParams are the cost parameters of Argon2id.
+type Argon2idParams struct {
+	Time    uint32
+	Memory  uint32 // in KiB
+	Threads uint8
+}
+
+// DefaultArgon2idParams follow the OWASP recommendation. They are lighter than the ones the client derives
+// the vault keys with, because the server hashes the passwords of all the users.
+var DefaultArgon2idParams = Argon2idParams{
+	Time:    2,
+	Memory:  19 * 1024,
+	Threads: 1,
+}
+
+type argon2idAlgorithm struct {
+	params Argon2idParams
+}
+
+func newArgon2id(params Argon2idParams) (argon2idAlgorithm, error) {
+	if params.Time == 0 {
+		params.Time = DefaultArgon2idParams.Time
+	}
+	if params.Memory == 0 {
+		params.Memory = DefaultArgon2idParams.Memory
+	}
+	if params.Threads == 0 {
+		params.Threads = DefaultArgon2idParams.Threads
+	}
+
+	if params.Memory < 8*uint32(params.Threads) {
+		return argon2idAlgorithm{}, errors.Errorf("argon2id memory should be at least %d KiB for %d threads", 8*uint32(params.Threads), params.Threads)
+	}
+
+	return argon2idAlgorithm{params: params}, nil
+}
+
+// hash encodes the hash in the PHC string format: $argon2id$v=19$m=19456,t=2,p=1$<salt>$<key>
+func (a argon2idAlgorithm) hash(password string) (string, error) {
+	salt := make([]byte, argon2idSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", errors.Wrap(err, "failed to generate salt")
+	}
+
+	key := argon2.IDKey([]byte(password), salt, a.params.Time, a.params.Memory, a.params.Threads, argon2idKeySize)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix, argon2.Version,
+		a.params.Memory, a.params.Time, a.params.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (a argon2idAlgorithm) verify(hash, password string) (bool, error) {
+	decoded, err := decodeArgon2id(hash)
+	if err != nil {
+		return false, err
+	}
+
+	key := argon2.IDKey([]byte(password), decoded.salt, decoded.params.Time, decoded.params.Memory, decoded.params.Threads, uint32(len(decoded.key)))
+
+	return subtle.ConstantTimeCompare(key, decoded.key) == 1, nil
+}
+
+func (a argon2idAlgorithm) recognizes(hash string) bool {
+	return strings.HasPrefix(hash, argon2idPrefix)
+}
+
+func (a argon2idAlgorithm) current(hash string) bool {
+	decoded, err := decodeArgon2id(hash)
+	if err != nil {
+		return false
+	}
+
+	return decoded.params == a.params && len(decoded.salt) == argon2idSaltSize && len(decoded.key) == argon2idKeySize
+}
+
+type argon2idHash struct {
+	params Argon2idParams
+	salt   []byte
+	key    []byte
+}
+
+func decodeArgon2id(hash string) (argon2idHash, error) {
+	var decoded argon2idHash
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != Argon2id {
+		return decoded, errors.New("invalid argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return decoded, errors.Wrap(err, "invalid argon2id hash version")
+	}
+	if version != argon2.Version {
+		return decoded, errors.Errorf("unsupported argon2id version %d", version)
+	}
+
+	p := &decoded.params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Time, &p.Threads); err != nil {
+		return decoded, errors.Wrap(err, "invalid argon2id hash parameters")
+	}
+	if p.Time == 0 || p.Threads == 0 {
+		return decoded, errors.New("invalid argon2id hash parameters")
+	}
+
+	var err error
+	if decoded.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return decoded, errors.Wrap(err, "invalid argon2id hash salt")
+	}
+	if decoded.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return decoded, errors.Wrap(err, "invalid argon2id hash key")
+	}
+	if len(decoded.key) == 0 {
+		return decoded, errors.New("invalid argon2id hash key")
+	}
+
+	return decoded, nil
+}
@@ -0,0 +1,57 @@
+package password_hasher
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// DefaultBcryptCost is used if the cost is not configured.
+const DefaultBcryptCost = 12
+
+type bcryptAlgorithm struct {
+	cost int
+}
+
+func newBcrypt(cost int) (bcryptAlgorithm, error) {
+	if cost == 0 {
+		cost = DefaultBcryptCost
+	}
+
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return bcryptAlgorithm{}, errors.Errorf("bcrypt cost should be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	return bcryptAlgorithm{cost: cost}, nil
+}
+
+func (a bcryptAlgorithm) hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), a.cost)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to generate password hash")
+	}
+
+	return string(hash), nil
+}
+
+func (a bcryptAlgorithm) verify(hash, password string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrap(err, "invalid bcrypt hash")
+	}
+
+	return true, nil
+}
+
+func (a bcryptAlgorithm) recognizes(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+func (a bcryptAlgorithm) current(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err == nil && cost == a.cost
+}
@@ -0,0 +1,99 @@
+// package password_hasher hashes the account passwords on the server.
+// The hashes are encoded together with the algorithm and its parameters, so that the hashes made with
+// an outdated algorithm or outdated parameters are still verified and could be replaced with new ones.
+package password_hasher
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// The supported algorithms.
+const (
+	Argon2id = "argon2id"
+	Bcrypt   = "bcrypt"
+)
+
+// Options configure the hasher, the defaults are used for the zero values.
+type Options struct {
+	// Algorithm is used for the new hashes, Argon2id by default.
+	Algorithm string
+	Argon2id  Argon2idParams
+	// BcryptCost is used only if the algorithm is bcrypt.
+	BcryptCost int
+}
+
+type algorithm interface {
+	hash(password string) (string, error)
+	verify(hash, password string) (bool, error)
+	// recognizes reports whether the hash was made with the algorithm
+	recognizes(hash string) bool
+	// current reports whether the hash was made with the parameters of the algorithm
+	current(hash string) bool
+}
+
+// Hasher makes the new hashes with the configured algorithm and verifies the hashes of all the supported ones.
+type Hasher struct {
+	preferred algorithm
+	known     []algorithm
+}
+
+// New creates the hasher, it fails on unknown algorithm or invalid parameters.
+func New(opts Options) (*Hasher, error) {
+	argon, err := newArgon2id(opts.Argon2id)
+	if err != nil {
+		return nil, err
+	}
+
+	bc, err := newBcrypt(opts.BcryptCost)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &Hasher{known: []algorithm{argon, bc}}
+	switch opts.Algorithm {
+	case "", Argon2id:
+		h.preferred = argon
+	case Bcrypt:
+		h.preferred = bc
+	default:
+		return nil, errors.Errorf("unknown password hash algorithm %q, expected one of: %s", opts.Algorithm, strings.Join([]string{Argon2id, Bcrypt}, ", "))
+	}
+
+	return h, nil
+}
+
+// Default creates the hasher with the default options.
+func Default() *Hasher {
+	h, err := New(Options{})
+	if err != nil {
+		panic(err)
+	}
+
+	return h
+}
+
+// Hash hashes the password with the configured algorithm.
+func (h *Hasher) Hash(password string) (string, error) {
+	return h.preferred.hash(password)
+}
+
+// Verify reports whether the password matches the hash, and whether the hash is outdated,
+// that is made with another algorithm or other parameters, and should be replaced with a new one.
+func (h *Hasher) Verify(hash, password string) (ok bool, outdated bool, err error) {
+	for _, a := range h.known {
+		if !a.recognizes(hash) {
+			continue
+		}
+
+		ok, err := a.verify(hash, password)
+		if err != nil || !ok {
+			return false, false, err
+		}
+
+		return true, a != h.preferred || !a.current(hash), nil
+	}
+
+	return false, false, errors.New("unknown password hash format")
+}
@@ -0,0 +1,132 @@
+package password_hasher
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func Test_Hasher(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       Options
+		wantPrefix string
+	}{
+		{
+			name:       "argon2id by default",
+			opts:       Options{},
+			wantPrefix: "$argon2id$v=19$m=19456,t=2,p=1$",
+		},
+		{
+			name:       "argon2id with custom parameters",
+			opts:       Options{Algorithm: Argon2id, Argon2id: Argon2idParams{Time: 1, Memory: 8 * 1024, Threads: 2}},
+			wantPrefix: "$argon2id$v=19$m=8192,t=1,p=2$",
+		},
+		{
+			name:       "bcrypt",
+			opts:       Options{Algorithm: Bcrypt, BcryptCost: bcrypt.MinCost},
+			wantPrefix: "$2a$04$",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := New(tt.opts)
+			require.NoError(t, err)
+
+			hash, err := h.Hash("password")
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(hash, tt.wantPrefix), "unexpected hash %q", hash)
+
+			another, err := h.Hash("password")
+			require.NoError(t, err)
+			assert.NotEqual(t, hash, another, "hashes should be salted")
+
+			ok, outdated, err := h.Verify(hash, "password")
+			require.NoError(t, err)
+			assert.True(t, ok)
+			assert.False(t, outdated)
+
+			ok, _, err = h.Verify(hash, "wrong")
+			require.NoError(t, err)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func Test_Hasher_outdated(t *testing.T) {
+	legacy, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	weakArgon, err := New(Options{Argon2id: Argon2idParams{Time: 1, Memory: 8 * 1024, Threads: 1}})
+	require.NoError(t, err)
+	weakArgonHash, err := weakArgon.Hash("password")
+	require.NoError(t, err)
+
+	argonHash, err := Default().Hash("password")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		opts         Options
+		hash         string
+		wantOutdated bool
+	}{
+		{
+			name:         "bcrypt hash is outdated for argon2id",
+			opts:         Options{},
+			hash:         string(legacy),
+			wantOutdated: true,
+		},
+		{
+			name:         "argon2id hash with other parameters is outdated",
+			opts:         Options{},
+			hash:         weakArgonHash,
+			wantOutdated: true,
+		},
+		{
+			name:         "bcrypt hash with lower cost is outdated",
+			opts:         Options{Algorithm: Bcrypt, BcryptCost: bcrypt.MinCost + 1},
+			hash:         string(legacy),
+			wantOutdated: true,
+		},
+		{
+			name:         "argon2id hash is outdated for bcrypt",
+			opts:         Options{Algorithm: Bcrypt, BcryptCost: bcrypt.MinCost},
+			hash:         argonHash,
+			wantOutdated: true,
+		},
+		{
+			name:         "bcrypt hash with the same cost is current",
+			opts:         Options{Algorithm: Bcrypt, BcryptCost: bcrypt.MinCost},
+			hash:         string(legacy),
+			wantOutdated: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := New(tt.opts)
+			require.NoError(t, err)
+
+			ok, outdated, err := h.Verify(tt.hash, "password")
+			require.NoError(t, err)
+			assert.True(t, ok, "outdated hashes should still be verified")
+			assert.Equal(t, tt.wantOutdated, outdated)
+		})
+	}
+}
+
+func Test_Hasher_invalid(t *testing.T) {
+	_, err := New(Options{Algorithm: "md5"})
+	assert.Error(t, err)
+
+	_, err = New(Options{Algorithm: Bcrypt, BcryptCost: 100})
+	assert.Error(t, err)
+
+	for _, hash := range []string{"", "plain text", "$argon2id$v=19$m=8192,t=1,p=1$c2FsdA", "$argon2id$v=18$m=8192,t=1,p=1$c2FsdA$a2V5"} {
+		_, _, err := Default().Verify(hash, "password")
+		assert.Error(t, err, "hash %q should be rejected", hash)
+	}
+}
@@ -2,6 +2,7 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"github.com/denistakeda/mpass/internal/domain"
 	"github.com/denistakeda/mpass/internal/domain/record"
@@ -16,10 +17,78 @@ type (
 	UserStore interface {
 		AddNewUser(ctx context.Context, login, passwordHash string) error
 		GetUser(ctx context.Context, login string) (domain.User, error)
+		InitVaultKey(ctx context.Context, login string, wrappedKey []byte) error
+		GetVaultKey(ctx context.Context, login string) ([]byte, error)
+		// ListUsers returns all the users ordered by login.
+		ListUsers(ctx context.Context) ([]domain.User, error)
+		// RestoreUser creates the user or overwrites the password hash, the vault key and the settings of the existing one.
+		RestoreUser(ctx context.Context, user domain.User, wrappedKey []byte) error
+		// SetHistoryRetention sets the number of prior versions of every record kept for the user, zero means all of them.
+		SetHistoryRetention(ctx context.Context, login string, keep int) error
+		// UpdatePasswordHash replaces the password hash of the user only if it is still the old one,
+		// so that the concurrent change of the password is not lost.
+		UpdatePasswordHash(ctx context.Context, login, oldHash, newHash string) error
+		// UpdateCredentials replaces the password hash, the session stamp and, if it is not empty, the wrapped vault key
+		// of the user at once, e.g. wrapped with a new master password. It fails the same way as UpdatePasswordHash.
+		UpdateCredentials(ctx context.Context, login, oldHash, newHash, stamp string, wrappedKey []byte) error
+		// GetLoginFailures returns the failed sign ins of the user in a row.
+		GetLoginFailures(ctx context.Context, login string) (domain.LoginFailures, error)
+		// AddLoginFailure counts the failed sign in and returns the number of the failed sign ins in a row,
+		// it is zero for an unknown user.
+		AddLoginFailure(ctx context.Context, login string) (int, error)
+		// LockLogin forbids the user to sign in until the time.
+		LockLogin(ctx context.Context, login string, until time.Time) error
+		// ResetLoginFailures forgets the failed sign ins and unlocks the login.
+		ResetLoginFailures(ctx context.Context, login string) error
+	}
+
+	TokenStore interface {
+		AddRefreshToken(ctx context.Context, token domain.RefreshToken) error
+		TakeRefreshToken(ctx context.Context, hash string) (domain.RefreshToken, error)
+		// RevokeRefreshTokens removes the refresh tokens of all the devices of the user.
+		RevokeRefreshTokens(ctx context.Context, login string) error
 	}
 
 	RecordStore interface {
-		AddRecords(ctx context.Context, login string, records []record.Record) error
+		// AddRecords stores the records and returns the ids of the conflicting records
+		// changed by someone else since the revision they are based on.
+		AddRecords(ctx context.Context, login string, records []record.Record) (conflicts []string, err error)
 		AllRecords(ctx context.Context, login string) ([]record.Record, error)
+		GetRecord(ctx context.Context, login, id string) (record.Record, error)
+		GetChangesSince(ctx context.Context, login string, revision int64) (record.Changes, error)
+		DeleteRecords(ctx context.Context, login string, tombstones []record.Tombstone) error
+		PurgeTombstones(ctx context.Context, login string, before time.Time) error
+		// ListRecordVersions returns the prior versions of the record kept in the history, the latest first.
+		ListRecordVersions(ctx context.Context, login, id string) ([]record.Version, error)
+		GetRecordVersion(ctx context.Context, login, id string, version int64) (record.Record, error)
+		// PurgeVersions keeps only the given number of the latest prior versions of every record of the user.
+		PurgeVersions(ctx context.Context, login string, keep int) error
+		// PurgeRecords removes the records together with their history and tombstones, nothing is left to sync.
+		PurgeRecords(ctx context.Context, login string, ids []string) error
+		// Snapshot returns the whole vault of the user including the tombstones and the history.
+		Snapshot(ctx context.Context, login string) (record.Snapshot, error)
+		// RestoreSnapshot replaces the whole vault of the user with the snapshot.
+		RestoreSnapshot(ctx context.Context, login string, snapshot record.Snapshot) error
+	}
+
+	UploadStore interface {
+		// StartUpload creates the upload or returns the unfinished upload of the same payload
+		// of the record, so that it could be continued.
+		StartUpload(ctx context.Context, upload domain.Upload) (domain.Upload, error)
+		GetUpload(ctx context.Context, login, id string) (domain.Upload, error)
+		// AddChunk appends the data to the upload, the offset should match the number of bytes received.
+		AddChunk(ctx context.Context, login, id string, offset int64, data []byte) error
+		GetPayload(ctx context.Context, login, id string) ([]byte, error)
+		DeleteUpload(ctx context.Context, login, id string) error
+		PurgeUploads(ctx context.Context, before time.Time) error
+	}
+
+	// BlobStore keeps the binary payloads addressed by the hex encoded SHA-256 hash of their content.
+	BlobStore interface {
+		// Put stores the data under its hash, storing the same data twice is not an error.
+		Put(ctx context.Context, hash string, data []byte) error
+		Get(ctx context.Context, hash string) ([]byte, error)
+		// Delete removes the blob, removing a missing blob is not an error.
+		Delete(ctx context.Context, hash string) error
 	}
 )
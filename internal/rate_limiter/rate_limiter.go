@@ -0,0 +1,94 @@
+// package rate_limiter limits the rate of the events per key, e.g. per login or per address, with token buckets.
+package rate_limiter
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter allows a burst of count events per key and refills the bucket of the key evenly during the period.
+// The nil Limiter allows everything.
+type Limiter struct {
+	capacity float64
+	refill   time.Duration // the time it takes to add a single token to the bucket
+	now      func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// New creates a limiter allowing count events per period, it returns nil, that is no limit, if count is not positive.
+func New(count int, per time.Duration) *Limiter {
+	if count <= 0 || per <= 0 {
+		return nil
+	}
+
+	return &Limiter{
+		capacity: float64(count),
+		refill:   per / time.Duration(count),
+		now:      time.Now,
+		buckets:  make(map[string]bucket),
+	}
+}
+
+// Allow takes a token from the bucket of the key. If the bucket is empty,
+// the event is not allowed and the time until the next token is returned.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if ok {
+		b.tokens = l.tokens(b, now)
+	} else {
+		b.tokens = l.capacity
+	}
+	b.updated = now
+
+	if b.tokens < 1 {
+		l.buckets[key] = b
+		return false, time.Duration((1 - b.tokens) * float64(l.refill))
+	}
+
+	b.tokens--
+	l.buckets[key] = b
+
+	return true, 0
+}
+
+// tokens returns the number of tokens in the bucket refilled since the last update.
+func (l *Limiter) tokens(b bucket, now time.Time) float64 {
+	tokens := b.tokens + float64(now.Sub(b.updated))/float64(l.refill)
+	if tokens > l.capacity {
+		return l.capacity
+	}
+
+	return tokens
+}
+
+// sweep forgets the full buckets once per period, so that the keys seen once do not pile up.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.refill*time.Duration(l.capacity) {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if l.tokens(b, now) >= l.capacity {
+			delete(l.buckets, key)
+		}
+	}
+}
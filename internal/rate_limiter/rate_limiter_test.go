@@ -0,0 +1,78 @@
+package rate_limiter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestLimiter(count int, per time.Duration) (*Limiter, *time.Time) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	l := New(count, per)
+	l.now = func() time.Time { return now }
+
+	return l, &now
+}
+
+func Test_Limiter(t *testing.T) {
+	t.Run("burst is allowed and then limited", func(t *testing.T) {
+		l, _ := newTestLimiter(3, time.Minute)
+
+		for i := 0; i < 3; i++ {
+			ok, _ := l.Allow("key")
+			assert.True(t, ok, "attempt %d should be allowed", i+1)
+		}
+
+		ok, retryAfter := l.Allow("key")
+		assert.False(t, ok)
+		assert.Equal(t, 20*time.Second, retryAfter)
+	})
+
+	t.Run("bucket is refilled over time", func(t *testing.T) {
+		l, now := newTestLimiter(2, time.Minute)
+
+		l.Allow("key")
+		l.Allow("key")
+
+		*now = now.Add(10 * time.Second)
+		ok, retryAfter := l.Allow("key")
+		assert.False(t, ok)
+		assert.Equal(t, 20*time.Second, retryAfter)
+
+		*now = now.Add(20 * time.Second)
+		ok, _ = l.Allow("key")
+		assert.True(t, ok)
+	})
+
+	t.Run("keys are limited separately", func(t *testing.T) {
+		l, _ := newTestLimiter(1, time.Minute)
+
+		ok, _ := l.Allow("alice")
+		assert.True(t, ok)
+		ok, _ = l.Allow("alice")
+		assert.False(t, ok)
+
+		ok, _ = l.Allow("bob")
+		assert.True(t, ok)
+	})
+
+	t.Run("full buckets are forgotten", func(t *testing.T) {
+		l, now := newTestLimiter(1, time.Minute)
+
+		l.Allow("alice")
+		*now = now.Add(2 * time.Minute)
+		l.Allow("bob")
+
+		assert.Len(t, l.buckets, 1)
+	})
+
+	t.Run("nil limiter allows everything", func(t *testing.T) {
+		l := New(0, time.Minute)
+
+		for i := 0; i < 100; i++ {
+			ok, _ := l.Allow("key")
+			assert.True(t, ok)
+		}
+	})
+}
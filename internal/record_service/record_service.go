@@ -1,34 +1,70 @@
 package record_service
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
 
+	"github.com/denistakeda/mpass/internal/domain"
 	"github.com/denistakeda/mpass/internal/domain/record"
 	"github.com/denistakeda/mpass/internal/ports"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 )
 
+// unfinished uploads are kept for a while to be resumed
+const uploadRetention = 24 * time.Hour
+
 type recordService struct {
 	logger      zerolog.Logger
 	recordStore ports.RecordStore
+	uploadStore ports.UploadStore
+	userStore   ports.UserStore
+
+	tombstoneRetention time.Duration
+	historyRetention   int
 }
 
-func New(logService ports.LogService, recordStore ports.RecordStore) *recordService {
+// New creates a record service.
+// Tombstones of the deleted records are purged after tombstoneRetention, zero means that they are kept forever.
+// Only historyRetention prior versions of every record are kept for the users that have not set their own
+// number, zero means that the whole history is kept.
+func New(
+	logService ports.LogService,
+	recordStore ports.RecordStore,
+	uploadStore ports.UploadStore,
+	userStore ports.UserStore,
+	tombstoneRetention time.Duration,
+	historyRetention int,
+) *recordService {
 	return &recordService{
 		logger:      logService.ComponentLogger("recordService"),
 		recordStore: recordStore,
+		uploadStore: uploadStore,
+		userStore:   userStore,
+
+		tombstoneRetention: tombstoneRetention,
+		historyRetention:   historyRetention,
 	}
 }
 
-func (r *recordService) AddRecords(ctx context.Context, login string, records []record.Record) error {
-	if err := r.recordStore.AddRecords(ctx, login, records); err != nil {
-		return errors.Wrapf(err, "failed to store records for user %q", login)
+func (r *recordService) AddRecords(ctx context.Context, login string, records []record.Record) ([]string, error) {
+	conflicts, err := r.recordStore.AddRecords(ctx, login, records)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to store records for user %q", login)
 	}
 
-	r.logger.Info().Str("login", login).Msgf("%d records were sucessfully stored", len(records))
+	r.logger.Info().Str("login", login).Msgf("%d records were sucessfully stored", len(records)-len(conflicts))
+	if len(conflicts) > 0 {
+		r.logger.Info().Str("login", login).Strs("conflicts", conflicts).Msg("records were changed concurrently")
+	}
 
-	return nil
+	r.purgeVersions(ctx, login)
+
+	return conflicts, nil
 }
 
 func (r *recordService) AllRecords(ctx context.Context, login string) ([]record.Record, error) {
@@ -39,3 +75,196 @@ func (r *recordService) AllRecords(ctx context.Context, login string) ([]record.
 
 	return records, nil
 }
+
+func (r *recordService) GetChangesSince(ctx context.Context, login string, revision int64) (record.Changes, error) {
+	changes, err := r.recordStore.GetChangesSince(ctx, login, revision)
+	if err != nil {
+		return changes, errors.Wrapf(err, "failed to fetch changes since revision %d", revision)
+	}
+
+	return changes, nil
+}
+
+func (r *recordService) DeleteRecords(ctx context.Context, login string, tombstones []record.Tombstone) error {
+	if err := r.recordStore.DeleteRecords(ctx, login, tombstones); err != nil {
+		return errors.Wrapf(err, "failed to delete records for user %q", login)
+	}
+
+	r.logger.Info().Str("login", login).Msgf("%d records were sucessfully deleted", len(tombstones))
+
+	if r.tombstoneRetention > 0 {
+		if err := r.recordStore.PurgeTombstones(ctx, login, time.Now().Add(-r.tombstoneRetention)); err != nil {
+			// the deletion has already succeeded, the tombstones will be purged next time
+			r.logger.Error().Err(err).Str("login", login).Msg("failed to purge tombstones")
+		}
+	}
+
+	r.purgeVersions(ctx, login)
+
+	return nil
+}
+
+// PurgeRecords removes the records together with their history, the other clients are not told about it.
+func (r *recordService) PurgeRecords(ctx context.Context, login string, ids []string) error {
+	if err := r.recordStore.PurgeRecords(ctx, login, ids); err != nil {
+		return errors.Wrapf(err, "failed to purge records for user %q", login)
+	}
+
+	r.logger.Info().Str("login", login).Msgf("%d records were sucessfully purged", len(ids))
+
+	return nil
+}
+
+// SetHistoryRetention sets the number of prior versions of every record kept for the user,
+// zero means all of them. The versions beyond the new retention are purged at once.
+func (r *recordService) SetHistoryRetention(ctx context.Context, login string, keep int) error {
+	if keep < 0 {
+		return errors.Errorf("history retention should not be negative, got %d", keep)
+	}
+
+	if err := r.userStore.SetHistoryRetention(ctx, login, keep); err != nil {
+		return errors.Wrapf(err, "failed to set history retention for user %q", login)
+	}
+
+	r.purgeVersions(ctx, login)
+
+	return nil
+}
+
+// purgeVersions removes the versions beyond the retention of the user, the changes have already succeeded,
+// so the failure is only reported and the versions will be purged next time.
+func (r *recordService) purgeVersions(ctx context.Context, login string) {
+	user, err := r.userStore.GetUser(ctx, login)
+	if err != nil {
+		r.logger.Error().Err(err).Str("login", login).Msg("failed to get history retention")
+		return
+	}
+
+	keep := r.historyRetention
+	if user.HistoryRetention != nil {
+		keep = *user.HistoryRetention
+	}
+	if keep <= 0 {
+		return
+	}
+
+	if err := r.recordStore.PurgeVersions(ctx, login, keep); err != nil {
+		r.logger.Error().Err(err).Str("login", login).Msg("failed to purge record versions")
+	}
+}
+
+// ListRecordVersions returns the prior versions of the record, the latest first.
+func (r *recordService) ListRecordVersions(ctx context.Context, login, id string) ([]record.Version, error) {
+	versions, err := r.recordStore.ListRecordVersions(ctx, login, id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list versions of record %q", id)
+	}
+
+	return versions, nil
+}
+
+func (r *recordService) GetRecordVersion(ctx context.Context, login, id string, version int64) (record.Record, error) {
+	rec, err := r.recordStore.GetRecordVersion(ctx, login, id, version)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get version %d of record %q", version, id)
+	}
+
+	return rec, nil
+}
+
+func (r *recordService) GetRecord(ctx context.Context, login, id string) (record.Record, error) {
+	rec, err := r.recordStore.GetRecord(ctx, login, id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get record %q", id)
+	}
+
+	return rec, nil
+}
+
+// StartUpload starts receiving the payload of the encrypted record in chunks.
+// The unfinished upload of the same payload is continued instead, the returned upload
+// tells how many bytes were already received.
+func (r *recordService) StartUpload(ctx context.Context, login string, upload domain.Upload) (domain.Upload, error) {
+	if err := r.uploadStore.PurgeUploads(ctx, time.Now().Add(-uploadRetention)); err != nil {
+		// the stale uploads will be purged next time
+		r.logger.Error().Err(err).Msg("failed to purge uploads")
+	}
+
+	id, err := newUploadID()
+	if err != nil {
+		return upload, err
+	}
+
+	upload.ID = id
+	upload.Login = login
+	upload.CreatedAt = time.Now()
+
+	upload, err = r.uploadStore.StartUpload(ctx, upload)
+	if err != nil {
+		return upload, errors.Wrapf(err, "failed to start upload for user %q", login)
+	}
+
+	return upload, nil
+}
+
+func (r *recordService) UploadChunk(ctx context.Context, login, uploadID string, offset int64, data []byte) error {
+	if err := r.uploadStore.AddChunk(ctx, login, uploadID, offset, data); err != nil {
+		return errors.Wrapf(err, "failed to store chunk for user %q", login)
+	}
+
+	return nil
+}
+
+// FinishUpload stores the uploaded record and returns the conflicts the same way as AddRecords does.
+func (r *recordService) FinishUpload(ctx context.Context, login, uploadID string) ([]string, error) {
+	upload, err := r.uploadStore.GetUpload(ctx, login, uploadID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to finish upload for user %q", login)
+	}
+
+	if upload.Received != upload.Size {
+		return nil, errors.Errorf("upload %q is not complete, %d of %d bytes received", uploadID, upload.Received, upload.Size)
+	}
+
+	payload, err := r.uploadStore.GetPayload(ctx, login, uploadID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to finish upload for user %q", login)
+	}
+
+	if checksum := sha256.Sum256(payload); !bytes.Equal(checksum[:], upload.Checksum) {
+		// the upload could not be continued, it should be started over
+		if err := r.uploadStore.DeleteUpload(ctx, login, uploadID); err != nil {
+			r.logger.Error().Err(err).Str("login", login).Msg("failed to delete corrupted upload")
+		}
+
+		return nil, errors.Errorf("checksum of upload %q does not match", uploadID)
+	}
+
+	rec := &record.EncryptedRecord{
+		ID:             upload.RecordID,
+		LastUpdateDate: upload.LastUpdateDate,
+		Revision:       upload.Revision,
+
+		Payload: payload,
+	}
+	conflicts, err := r.AddRecords(ctx, login, []record.Record{rec})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.uploadStore.DeleteUpload(ctx, login, uploadID); err != nil {
+		// the record is stored already, the upload will be purged later
+		r.logger.Error().Err(err).Str("login", login).Msg("failed to delete finished upload")
+	}
+
+	return conflicts, nil
+}
+
+func newUploadID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", errors.Wrap(err, "failed to generate upload id")
+	}
+
+	return hex.EncodeToString(id), nil
+}
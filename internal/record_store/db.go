@@ -2,200 +2,805 @@ package record_store
 
 import (
 	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"strings"
+	"time"
 
 	"github.com/denistakeda/mpass/internal/domain/record"
+	"github.com/denistakeda/mpass/internal/ports"
 	"github.com/jmoiron/sqlx"
-	"golang.org/x/sync/errgroup"
 )
 
-type tableNameT string
-
-const (
-	loginPasswordTableName tableNameT = "login_password_record"
-	binaryTableName                   = "binary_record"
-	textTableName                     = "text_record"
-	bankCardTableName                 = "bank_card_record"
-)
+// the payloads of the files and the payloads bigger than minBlobSize are kept in the blob store
+const minBlobSize = 64 << 10
 
 type dbStore struct {
-	db *sqlx.DB
+	db    *sqlx.DB
+	blobs ports.BlobStore
+
+	// timeArg prepares the time to be compared by the database
+	timeArg func(time.Time) time.Time
+}
+
+// NewWithDb creates a store in the postgres database, the binary payloads are kept in the blob store.
+func NewWithDb(db *sqlx.DB, blobs ports.BlobStore) *dbStore {
+	return &dbStore{db: db, blobs: blobs, timeArg: func(t time.Time) time.Time { return t }}
 }
 
-func NewWithDb(db *sqlx.DB) *dbStore {
-	return &dbStore{db: db}
+// NewWithSQLite creates a store in the SQLite database.
+// SQLite compares the time as text, so the time is always stored in UTC.
+func NewWithSQLite(db *sqlx.DB, blobs ports.BlobStore) *dbStore {
+	return &dbStore{db: db, blobs: blobs, timeArg: time.Time.UTC}
 }
 
-func (s *dbStore) AddRecords(ctx context.Context, login string, records []record.Record) error {
+// AddRecords stores the records and returns the ids of the records
+// that were changed since the revision they are based on, such records are not stored.
+func (s *dbStore) AddRecords(ctx context.Context, login string, records []record.Record) ([]string, error) {
 	if len(records) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	tx, err := s.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to start a transaction: %w", err)
+		return nil, fmt.Errorf("failed to start a transaction: %w", err)
+	}
+
+	revision, err := nextRevision(ctx, tx, login)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to get the next revision: %w", err)
 	}
 
+	var conflicts []string
 	for _, rec := range records {
-		switch r := rec.(type) {
-		case *record.LoginPasswordRecord:
-			err = upsertLoginPasswordRecord(ctx, tx, r, login)
-		case *record.BankCardRecord:
-			err = upsertBankCardRecord(ctx, tx, r, login)
-		case *record.BinaryRecord:
-			err = upsertBinaryRecord(ctx, tx, r, login)
-		case *record.TextRecord:
-			err = upsertTextRecord(ctx, tx, r, login)
-		default:
-			return fmt.Errorf("unknown record type: %v", r)
+		deleted, err := deletedByTombstone(ctx, tx, rec, login)
+		if err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("failed to check tombstone of record %q: %w", rec.GetId(), err)
 		}
+		if deleted {
+			continue
+		}
+
+		conflict, err := s.addRecord(ctx, tx, rec, login, revision)
+		if err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("failed to add records: %w", err)
+		}
+		if conflict {
+			conflicts = append(conflicts, rec.GetId())
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit records: %w", err)
+	}
+
+	s.purgeBlobs(ctx)
+
+	return conflicts, nil
+}
+
+func (s *dbStore) AllRecords(ctx context.Context, login string) ([]record.Record, error) {
+	return s.getRecordsSince(ctx, login, 0)
+}
+
+func (s *dbStore) GetRecord(ctx context.Context, login, id string) (record.Record, error) {
+	var row storedRecord
+	err := s.db.GetContext(ctx, &row, `
+		select id, type, last_update_date, revision, payload, blob_hash, metadata, tags
+		from records
+		where user_login=$1 and id=$2
+	`, login, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get record %q of user %q: %w", id, login, err)
 	}
 
+	return s.toRecord(ctx, row)
+}
+
+func (s *dbStore) GetChangesSince(ctx context.Context, login string, revision int64) (record.Changes, error) {
+	var changes record.Changes
+
+	// the cursor is read first, so the changes committed in the meantime
+	// will be sent again next time instead of being lost
+	var cursor struct {
+		Revision       int64 `db:"revision"`
+		PurgedRevision int64 `db:"purged_revision"`
+	}
+	err := s.db.GetContext(ctx, &cursor,
+		"select revision, purged_revision from user_revision where user_login=$1",
+		login,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		// nothing was stored yet, unless the client has seen the vault before it was restored from a backup
+		changes.Full = revision > 0
+		return changes, nil
+	}
+	if err != nil {
+		return changes, fmt.Errorf("failed to get revision of user %q: %w", login, err)
+	}
+
+	changes.Revision = cursor.Revision
+
+	// some of the deletions the client has not seen yet are forgotten
+	// or the client has seen the revisions lost when the vault was restored from a backup
+	if revision < cursor.PurgedRevision || revision > cursor.Revision {
+		changes.Full = true
+		revision = 0
+	}
+
+	changes.Records, err = s.getRecordsSince(ctx, login, revision)
+	if err != nil {
+		return changes, err
+	}
+
+	if changes.Full {
+		return changes, nil
+	}
+
+	err = s.db.SelectContext(ctx, &changes.Tombstones,
+		"select id, deletion_date from tombstone where user_login=$1 and revision > $2",
+		login, revision,
+	)
+	if err != nil {
+		return changes, fmt.Errorf("failed to fetch tombstones for user %q: %w", login, err)
+	}
+
+	return changes, nil
+}
+
+func (s *dbStore) DeleteRecords(ctx context.Context, login string, tombstones []record.Tombstone) error {
+	if len(tombstones) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start a transaction: %w", err)
+	}
+
+	revision, err := nextRevision(ctx, tx, login)
 	if err != nil {
 		tx.Rollback()
-		return fmt.Errorf("failed to add records: %w", err)
+		return fmt.Errorf("failed to get the next revision: %w", err)
+	}
+
+	for _, tombstone := range tombstones {
+		if err := s.deleteRecord(ctx, tx, tombstone, login, revision); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to delete record %q: %w", tombstone.ID, err)
+		}
 	}
 
 	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit records: %w", err)
+		return fmt.Errorf("failed to commit deletion: %w", err)
 	}
 
+	s.purgeBlobs(ctx)
+
 	return nil
 }
 
-func (s *dbStore) AllRecords(ctx context.Context, login string) ([]record.Record, error) {
-	var (
-		loginPasswordRecords []*record.LoginPasswordRecord
-		binaryRecords        []*record.BinaryRecord
-		textRecords          []*record.TextRecord
-		bankCardRecords      []*record.BankCardRecord
+// PurgeTombstones forgets the old deletions.
+// The latest purged revision is remembered to make the clients that have missed the deletions resync the whole vault.
+func (s *dbStore) PurgeTombstones(ctx context.Context, login string, before time.Time) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start a transaction: %w", err)
+	}
+
+	var purgedRevision int64
+	err = tx.GetContext(ctx, &purgedRevision,
+		"select coalesce(max(revision), 0) from tombstone where user_login=$1 and deletion_date < $2",
+		login, s.timeArg(before),
 	)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to find tombstones to purge for user %q: %w", login, err)
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		"delete from tombstone where user_login=$1 and deletion_date < $2",
+		login, s.timeArg(before),
+	); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to purge tombstones for user %q: %w", login, err)
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		"update user_revision set purged_revision=$2 where user_login=$1 and purged_revision < $2",
+		login, purgedRevision,
+	); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to purge tombstones for user %q: %w", login, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit purge: %w", err)
+	}
+
+	return nil
+}
+
+func (s *dbStore) ListRecordVersions(ctx context.Context, login, id string) ([]record.Version, error) {
+	var versions []record.Version
+	err := s.db.SelectContext(ctx, &versions, `
+		select version, last_update_date, replaced_at, deleted
+		from record_versions
+		where user_login=$1 and id=$2
+		order by version desc
+	`, login, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list versions of record %q: %w", id, err)
+	}
+
+	return versions, nil
+}
 
-	g, gCtx := errgroup.WithContext(ctx)
+func (s *dbStore) GetRecordVersion(ctx context.Context, login, id string, version int64) (record.Record, error) {
+	var row storedRecord
+	err := s.db.GetContext(ctx, &row, `
+		select id, type, last_update_date, version as revision, payload, blob_hash, metadata, tags
+		from record_versions
+		where user_login=$1 and id=$2 and version=$3
+	`, login, id, version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get version %d of record %q: %w", version, id, err)
+	}
 
-	g.Go(getRecords(gCtx, s.db, login, loginPasswordTableName, &loginPasswordRecords, "id", "last_update_date", "login", "password"))
-	g.Go(getRecords(gCtx, s.db, login, binaryTableName, &binaryRecords, "id", "last_update_date", "\"binary\""))
-	g.Go(getRecords(gCtx, s.db, login, textTableName, &textRecords, "id", "last_update_date", "text"))
-	g.Go(getRecords(gCtx, s.db, login, bankCardTableName, &bankCardRecords, "id", "last_update_date", "card_number", "month", "day", "code"))
+	return s.toRecord(ctx, row)
+}
 
-	if err := g.Wait(); err != nil {
-		return nil, fmt.Errorf("failed to fetch all the records: %w", err)
+// PurgeVersions removes all but the latest prior versions of every record of the user,
+// the blobs of the removed versions are released.
+func (s *dbStore) PurgeVersions(ctx context.Context, login string, keep int) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start a transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	res := make([]record.Record, 0, len(loginPasswordRecords)+len(binaryRecords)+len(textRecords)+len(bankCardRecords))
-	for _, r := range loginPasswordRecords {
-		res = append(res, r)
+	var stale []struct {
+		ID       string  `db:"id"`
+		Version  int64   `db:"version"`
+		BlobHash *string `db:"blob_hash"`
+	}
+	err = tx.SelectContext(ctx, &stale, `
+		select id, version, blob_hash from (
+			select id, version, blob_hash, row_number() over (partition by id order by version desc) as n
+			from record_versions
+			where user_login=$1
+		) v
+		where n > $2
+	`, login, keep)
+	if err != nil {
+		return fmt.Errorf("failed to find versions to purge for user %q: %w", login, err)
 	}
-	for _, r := range binaryRecords {
-		res = append(res, r)
+
+	for _, version := range stale {
+		if _, err := tx.ExecContext(ctx,
+			"delete from record_versions where user_login=$1 and id=$2 and version=$3",
+			login, version.ID, version.Version,
+		); err != nil {
+			return fmt.Errorf("failed to purge version %d of record %q: %w", version.Version, version.ID, err)
+		}
+
+		if version.BlobHash != nil {
+			if err := releaseBlob(ctx, tx, *version.BlobHash); err != nil {
+				return err
+			}
+		}
 	}
-	for _, r := range textRecords {
-		res = append(res, r)
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit purge: %w", err)
 	}
-	for _, r := range bankCardRecords {
-		res = append(res, r)
+
+	if len(stale) > 0 {
+		s.purgeBlobs(ctx)
 	}
 
-	return res, nil
+	return nil
 }
 
-func getRecords[T record.Record](ctx context.Context, db *sqlx.DB, login string, tableName tableNameT, recs *[]T, columns ...string) func() error {
-	return func() error {
-		columns := strings.Join(columns, ", ")
-		sqlexpr := fmt.Sprintf("select %s from %s where user_login=$1", columns, tableName)
-		err := db.SelectContext(
-			ctx, recs,
-			sqlexpr,
-			login,
-		)
+// PurgeRecords removes the records with all their versions and tombstones, the blobs they refer to are released.
+func (s *dbStore) PurgeRecords(ctx context.Context, login string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start a transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, id := range ids {
+		var hashes []string
+		err := tx.SelectContext(ctx, &hashes, `
+			select blob_hash from records where user_login=$1 and id=$2 and blob_hash is not null
+			union all
+			select blob_hash from record_versions where user_login=$1 and id=$2 and blob_hash is not null
+		`, login, id)
 		if err != nil {
-			return fmt.Errorf("failed to execute query %q for user %q, error: %v", sqlexpr, login, err)
+			return fmt.Errorf("failed to find the content of record %q: %w", id, err)
+		}
+
+		for _, query := range []string{
+			"delete from records where user_login=$1 and id=$2",
+			"delete from record_versions where user_login=$1 and id=$2",
+			"delete from tombstone where user_login=$1 and id=$2",
+		} {
+			if _, err := tx.ExecContext(ctx, query, login, id); err != nil {
+				return fmt.Errorf("failed to purge record %q: %w", id, err)
+			}
+		}
+
+		for _, hash := range hashes {
+			if err := releaseBlob(ctx, tx, hash); err != nil {
+				return err
+			}
 		}
-		return nil
 	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit purge: %w", err)
+	}
+
+	s.purgeBlobs(ctx)
+
+	return nil
 }
 
-func upsertLoginPasswordRecord(ctx context.Context, tx *sqlx.Tx, r *record.LoginPasswordRecord, userLogin string) error {
-	var old record.LoginPasswordRecord
-	err := tx.GetContext(ctx, &old, `
-		select id, last_update_date, login, password
-		from login_password_record
-		where id=$1
-	`, r.ID)
+// Snapshot reads the whole vault of the user in a single transaction.
+func (s *dbStore) Snapshot(ctx context.Context, login string) (record.Snapshot, error) {
+	var snapshot record.Snapshot
 
+	tx, err := s.db.BeginTxx(ctx, nil)
 	if err != nil {
-		_, err = tx.ExecContext(ctx,
-			"insert into login_password_record (id, last_update_date, login, password, user_login) values ($1, $2, $3, $4, $5)",
-			r.ID, r.LastUpdateDate, r.Login, r.Password, userLogin,
-		)
-	} else if r.LastUpdateDate.After(old.LastUpdateDate) {
-		_, err = tx.ExecContext(ctx,
-			"update login_password_record set login=$1, password=$2",
-			r.Login, r.Password,
-		)
+		return snapshot, fmt.Errorf("failed to start a transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	return err
+	var cursor struct {
+		Revision       int64 `db:"revision"`
+		PurgedRevision int64 `db:"purged_revision"`
+	}
+	err = tx.GetContext(ctx, &cursor,
+		"select revision, purged_revision from user_revision where user_login=$1",
+		login,
+	)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return snapshot, fmt.Errorf("failed to get revision of user %q: %w", login, err)
+	}
+	snapshot.Revision, snapshot.PurgedRevision = cursor.Revision, cursor.PurgedRevision
+
+	var records []storedRecord
+	err = tx.SelectContext(ctx, &records, `
+		select id, type, last_update_date, revision, payload, blob_hash, metadata, tags
+		from records
+		where user_login=$1
+		order by id
+	`, login)
+	if err != nil {
+		return snapshot, fmt.Errorf("failed to fetch records of user %q: %w", login, err)
+	}
+	for _, row := range records {
+		rec, err := s.toRecord(ctx, row)
+		if err != nil {
+			return snapshot, err
+		}
+		snapshot.Records = append(snapshot.Records, rec)
+	}
+
+	err = tx.SelectContext(ctx, &snapshot.Tombstones,
+		"select id, deletion_date, revision from tombstone where user_login=$1 order by id",
+		login,
+	)
+	if err != nil {
+		return snapshot, fmt.Errorf("failed to fetch tombstones of user %q: %w", login, err)
+	}
+
+	var versions []struct {
+		storedRecord
+		ReplacedAt time.Time `db:"replaced_at"`
+		Deleted    bool      `db:"deleted"`
+	}
+	err = tx.SelectContext(ctx, &versions, `
+		select id, type, last_update_date, version as revision, payload, blob_hash, metadata, tags, replaced_at, deleted
+		from record_versions
+		where user_login=$1
+		order by id, version
+	`, login)
+	if err != nil {
+		return snapshot, fmt.Errorf("failed to fetch history of user %q: %w", login, err)
+	}
+	for _, row := range versions {
+		rec, err := s.toRecord(ctx, row.storedRecord)
+		if err != nil {
+			return snapshot, err
+		}
+		snapshot.Versions = append(snapshot.Versions, record.ArchivedVersion{
+			Version: record.Version{
+				Version:        row.Revision,
+				LastUpdateDate: row.LastUpdateDate,
+				ReplacedAt:     row.ReplacedAt,
+				Deleted:        row.Deleted,
+			},
+			Record: rec,
+		})
+	}
+
+	return snapshot, nil
 }
 
-func upsertBankCardRecord(ctx context.Context, tx *sqlx.Tx, r *record.BankCardRecord, userLogin string) error {
-	var old record.BankCardRecord
-	err := tx.GetContext(ctx, &old, `
-		select id, last_update_date, card_number, month, day, code
-		from bank_card_record
-		where id=$1
-	`, r.ID)
+// RestoreSnapshot replaces the vault of the user in a single transaction,
+// the blobs of the replaced records are released.
+func (s *dbStore) RestoreSnapshot(ctx context.Context, login string, snapshot record.Snapshot) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start a transaction: %w", err)
+	}
+	defer tx.Rollback()
 
+	var hashes []string
+	err = tx.SelectContext(ctx, &hashes, `
+		select blob_hash from records where user_login=$1 and blob_hash is not null
+		union all
+		select blob_hash from record_versions where user_login=$1 and blob_hash is not null
+	`, login)
 	if err != nil {
-		_, err = tx.ExecContext(ctx,
-			"insert into bank_card_record (id, last_update_date, card_number, month, day, code, user_login) values ($1, $2, $3, $4, $5, $6, $7)",
-			r.ID, r.LastUpdateDate, r.CardNumber, r.Month, r.Day, r.Code, userLogin,
-		)
-	} else if r.LastUpdateDate.After(old.LastUpdateDate) {
-		_, err = tx.ExecContext(ctx,
-			"update bank_card_record set card_number=$1, month=$2, day=$3, code=$4",
-			r.CardNumber, r.Month, r.Day, r.Code,
-		)
+		return fmt.Errorf("failed to find blobs of user %q: %w", login, err)
+	}
+	for _, hash := range hashes {
+		if err := releaseBlob(ctx, tx, hash); err != nil {
+			return err
+		}
 	}
 
-	return err
+	for _, table := range []string{"record_versions", "records", "tombstone"} {
+		if _, err := tx.ExecContext(ctx, "delete from "+table+" where user_login=$1", login); err != nil {
+			return fmt.Errorf("failed to clear vault of user %q: %w", login, err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		insert into user_revision (user_login, revision, purged_revision) values ($1, $2, $3)
+		on conflict (user_login) do update
+		set revision=excluded.revision, purged_revision=excluded.purged_revision
+	`, login, snapshot.Revision, snapshot.PurgedRevision); err != nil {
+		return fmt.Errorf("failed to restore revision of user %q: %w", login, err)
+	}
+
+	for _, rec := range snapshot.Records {
+		if err := s.insertRecord(ctx, tx, login, rec, rec.GetRevision()); err != nil {
+			return err
+		}
+	}
+
+	for _, t := range snapshot.Tombstones {
+		if _, err := tx.ExecContext(ctx,
+			"insert into tombstone (id, deletion_date, user_login, revision) values ($1, $2, $3, $4)",
+			t.ID, s.timeArg(t.DeletionDate), login, t.Revision,
+		); err != nil {
+			return fmt.Errorf("failed to restore tombstone of record %q: %w", t.ID, err)
+		}
+	}
+
+	for _, v := range snapshot.Versions {
+		payload, blobHash, err := s.storePayload(ctx, tx, v.Record)
+		if err != nil {
+			return err
+		}
+
+		annotations := v.Record.GetAnnotations()
+		if _, err := tx.ExecContext(ctx, `
+			insert into record_versions (user_login, id, version, type, last_update_date, replaced_at, deleted, payload, blob_hash, metadata, tags)
+			values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		`, login, v.Record.GetId(), v.Version.Version, v.Record.Kind(), s.timeArg(v.LastUpdateDate), s.timeArg(v.ReplacedAt), v.Deleted,
+			payload, blobHash, annotations.Metadata, annotations.Tags,
+		); err != nil {
+			return fmt.Errorf("failed to restore version %d of record %q: %w", v.Version.Version, v.Record.GetId(), err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit restore: %w", err)
+	}
+
+	s.purgeBlobs(ctx)
+
+	return nil
 }
 
-func upsertBinaryRecord(ctx context.Context, tx *sqlx.Tx, r *record.BinaryRecord, userLogin string) error {
-	var old record.BinaryRecord
-	err := tx.GetContext(ctx, &old, "select * from binary_record where id=$1", r.ID)
+// addRecord stores the record of the user unless it was changed since the revision the record is based on.
+// The previous version is moved to the history, the binary payload is moved to the blob store.
+func (s *dbStore) addRecord(ctx context.Context, tx *sqlx.Tx, rec record.Record, userLogin string, revision int64) (conflict bool, err error) {
+	var oldRevision int64
+	err = tx.GetContext(ctx, &oldRevision,
+		"select revision from records where user_login=$1 and id=$2",
+		userLogin, rec.GetId(),
+	)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return false, fmt.Errorf("failed to find record %q: %w", rec.GetId(), err)
+	}
+	exists := err == nil
+
+	// the record was changed since the revision the client has seen
+	if exists && oldRevision != rec.GetRevision() {
+		return true, nil
+	}
+
+	if exists {
+		if err := s.archiveRecord(ctx, tx, userLogin, rec.GetId(), false); err != nil {
+			return false, err
+		}
+	}
+
+	return false, s.insertRecord(ctx, tx, userLogin, rec, revision)
+}
 
+// insertRecord stores the record with the revision overwriting the existing one.
+func (s *dbStore) insertRecord(ctx context.Context, tx *sqlx.Tx, userLogin string, rec record.Record, revision int64) error {
+	payload, blobHash, err := s.storePayload(ctx, tx, rec)
 	if err != nil {
-		_, err = tx.ExecContext(ctx,
-			"insert into binary_record (id, last_update_date, binary, user_login) values ($1, $2, $3, $4)",
-			r.ID, r.LastUpdateDate, r.Binary, userLogin,
-		)
-	} else if r.LastUpdateDate.After(old.LastUpdateDate) {
-		_, err = tx.ExecContext(ctx,
-			"update binary_record set binary=$1",
-			r.Binary,
-		)
+		return err
 	}
 
-	return err
+	annotations := rec.GetAnnotations()
+	_, err = tx.ExecContext(ctx, `
+		insert into records (user_login, id, type, revision, last_update_date, payload, blob_hash, metadata, tags, created_at, updated_at)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10)
+		on conflict (user_login, id) do update
+		set type=excluded.type, revision=excluded.revision, last_update_date=excluded.last_update_date,
+			payload=excluded.payload, blob_hash=excluded.blob_hash, metadata=excluded.metadata, tags=excluded.tags,
+			updated_at=excluded.updated_at
+	`, userLogin, rec.GetId(), rec.Kind(), revision, s.timeArg(rec.GetLastUpdateDate()), payload, blobHash, annotations.Metadata, annotations.Tags, s.timeArg(time.Now()))
+	if err != nil {
+		return fmt.Errorf("failed to store record %q: %w", rec.GetId(), err)
+	}
+
+	return nil
 }
 
-func upsertTextRecord(ctx context.Context, tx *sqlx.Tx, r *record.TextRecord, userLogin string) error {
-	var old record.TextRecord
-	err := tx.GetContext(ctx, &old, "select * from text_record where id=$1", r.ID)
+// storePayload returns the payload of the record to be kept in the database.
+// The binary payload is moved to the blob store and the payload is empty then.
+func (s *dbStore) storePayload(ctx context.Context, tx *sqlx.Tx, rec record.Record) ([]byte, *string, error) {
+	payload, err := record.Marshal(rec)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if rec.Kind() != record.KindFile && len(payload) <= minBlobSize {
+		return payload, nil, nil
+	}
 
+	hash, err := s.retainBlob(ctx, tx, payload)
 	if err != nil {
-		_, err = tx.ExecContext(ctx,
-			"insert into text_record (id, last_update_date, text, user_login) values ($1, $2, $3, $4)",
-			r.ID, r.LastUpdateDate, r.Text, userLogin,
-		)
-	} else if r.LastUpdateDate.After(old.LastUpdateDate) {
-		_, err = tx.ExecContext(ctx,
-			"update text_record set text=$1",
-			r.Text,
-		)
+		return nil, nil, err
 	}
 
+	return []byte{}, &hash, nil
+}
+
+// archiveRecord copies the stored record to the history, the reference to its blob is moved to the version.
+func (s *dbStore) archiveRecord(ctx context.Context, tx *sqlx.Tx, userLogin, id string, deleted bool) error {
+	_, err := tx.ExecContext(ctx, `
+		insert into record_versions (user_login, id, version, type, last_update_date, replaced_at, deleted, payload, blob_hash, metadata, tags)
+		select user_login, id, revision, type, last_update_date, $3, $4, payload, blob_hash, metadata, tags
+		from records
+		where user_login=$1 and id=$2
+	`, userLogin, id, s.timeArg(time.Now()), deleted)
+	if err != nil {
+		return fmt.Errorf("failed to keep the previous version of record %q: %w", id, err)
+	}
+
+	return nil
+}
+
+// deletedByTombstone reports whether the record was deleted after its last update.
+// The tombstone of the record updated after the deletion is removed.
+func deletedByTombstone(ctx context.Context, tx *sqlx.Tx, rec record.Record, userLogin string) (bool, error) {
+	var tombstone record.Tombstone
+	err := tx.GetContext(ctx, &tombstone,
+		"select id, deletion_date from tombstone where id=$1 and user_login=$2",
+		rec.GetId(), userLogin,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if tombstone.Deletes(rec) {
+		return true, nil
+	}
+
+	_, err = tx.ExecContext(ctx, "delete from tombstone where id=$1 and user_login=$2", rec.GetId(), userLogin)
+	return false, err
+}
+
+// deleteRecord moves the record to the history unless it was updated after the deletion and stores the tombstone.
+func (s *dbStore) deleteRecord(ctx context.Context, tx *sqlx.Tx, tombstone record.Tombstone, userLogin string, revision int64) error {
+	var newer bool
+	err := tx.GetContext(ctx, &newer,
+		"select exists(select 1 from records where user_login=$1 and id=$2 and last_update_date > $3)",
+		userLogin, tombstone.ID, s.timeArg(tombstone.DeletionDate),
+	)
+	if err != nil {
+		return err
+	}
+	if newer {
+		return nil
+	}
+
+	if err := s.archiveRecord(ctx, tx, userLogin, tombstone.ID, true); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "delete from records where user_login=$1 and id=$2", userLogin, tombstone.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		insert into tombstone (id, deletion_date, user_login, revision) values ($1, $2, $3, $4)
+		on conflict (user_login, id) do update
+		set deletion_date=excluded.deletion_date, revision=excluded.revision
+		where tombstone.deletion_date < excluded.deletion_date
+	`, tombstone.ID, s.timeArg(tombstone.DeletionDate), userLogin, revision)
+
 	return err
 }
+
+// nextRevision increments the revision of the user.
+// It locks the revision row, so the concurrent writes of the same user are serialized.
+func nextRevision(ctx context.Context, tx *sqlx.Tx, userLogin string) (int64, error) {
+	var revision int64
+	err := tx.GetContext(ctx, &revision, `
+		insert into user_revision (user_login, revision) values ($1, 1)
+		on conflict (user_login) do update
+		set revision=user_revision.revision + 1
+		returning revision
+	`, userLogin)
+
+	return revision, err
+}
+
+// retainBlob adds a reference to the blob with the payload, the payload is stored once for all the references.
+// The blob row stays locked until the transaction ends, so the blob could not be purged in the meantime.
+func (s *dbStore) retainBlob(ctx context.Context, tx *sqlx.Tx, payload []byte) (string, error) {
+	sum := sha256.Sum256(payload)
+	hash := hex.EncodeToString(sum[:])
+
+	var refCount int64
+	err := tx.GetContext(ctx, &refCount, `
+		insert into blobs (hash, size, ref_count, created_at) values ($1, $2, 1, $3)
+		on conflict (hash) do update
+		set ref_count=blobs.ref_count + 1
+		returning ref_count
+	`, hash, len(payload), s.timeArg(time.Now()))
+	if err != nil {
+		return "", fmt.Errorf("failed to reference blob %q: %w", hash, err)
+	}
+
+	// the blob is either new or about to be purged, so it is stored again
+	if refCount == 1 {
+		if err := s.blobs.Put(ctx, hash, payload); err != nil {
+			return "", err
+		}
+	}
+
+	return hash, nil
+}
+
+// releaseBlob removes a reference to the blob, the blob without references is purged after the transaction.
+func releaseBlob(ctx context.Context, tx *sqlx.Tx, hash string) error {
+	if _, err := tx.ExecContext(ctx, "update blobs set ref_count=ref_count - 1 where hash=$1", hash); err != nil {
+		return fmt.Errorf("failed to release blob %q: %w", hash, err)
+	}
+
+	return nil
+}
+
+// purgeBlobs removes the blobs no record refers to.
+// The purge is not a part of the change that released the blobs, the blobs left behind
+// because of an error are removed next time.
+func (s *dbStore) purgeBlobs(ctx context.Context) {
+	var hashes []string
+	if err := s.db.SelectContext(ctx, &hashes, "select hash from blobs where ref_count = 0"); err != nil {
+		return
+	}
+
+	for _, hash := range hashes {
+		if err := s.purgeBlob(ctx, hash); err != nil {
+			return
+		}
+	}
+}
+
+// purgeBlob removes the blob while its row is locked, so that nobody could start referring to it.
+func (s *dbStore) purgeBlob(ctx context.Context, hash string) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, "delete from blobs where hash=$1 and ref_count = 0", hash)
+	if err != nil {
+		return err
+	}
+
+	// the blob was referenced again in the meantime
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return err
+	}
+
+	if err := s.blobs.Delete(ctx, hash); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// storedRecord is a row of the records table.
+type storedRecord struct {
+	ID             string      `db:"id"`
+	Kind           record.Kind `db:"type"`
+	LastUpdateDate time.Time   `db:"last_update_date"`
+	Revision       int64       `db:"revision"`
+	Payload        []byte      `db:"payload"`
+	BlobHash       *string     `db:"blob_hash"`
+	record.Annotations
+}
+
+// getRecordsSince fetches all the records of the user changed after the revision.
+func (s *dbStore) getRecordsSince(ctx context.Context, login string, revision int64) ([]record.Record, error) {
+	var rows []storedRecord
+	err := s.db.SelectContext(ctx, &rows, `
+		select id, type, last_update_date, revision, payload, blob_hash, metadata, tags
+		from records
+		where user_login=$1 and revision > $2
+	`, login, revision)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch records of user %q: %w", login, err)
+	}
+
+	res := make([]record.Record, 0, len(rows))
+	for _, row := range rows {
+		rec, err := s.toRecord(ctx, row)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, rec)
+	}
+
+	return res, nil
+}
+
+// toRecord restores the record from the row, the payload is read from the blob store if it is kept there.
+func (s *dbStore) toRecord(ctx context.Context, row storedRecord) (record.Record, error) {
+	if row.BlobHash != nil {
+		payload, err := s.blobs.Get(ctx, *row.BlobHash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read payload of record %q: %w", row.ID, err)
+		}
+		row.Payload = payload
+	}
+
+	rec, err := record.Unmarshal(row.Kind, row.ID, row.LastUpdateDate, row.Payload)
+	if err != nil {
+		return nil, err
+	}
+	rec.SetRevision(row.Revision)
+	rec.SetAnnotations(row.Annotations)
+
+	return rec, nil
+}
@@ -0,0 +1,193 @@
+package record_store
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/denistakeda/mpass/internal/blob_store"
+	"github.com/denistakeda/mpass/internal/db/dbtest"
+	"github.com/denistakeda/mpass/internal/domain/record"
+	"github.com/denistakeda/mpass/internal/ports"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_dbStore_Postgres(t *testing.T) {
+	database := dbtest.Postgres(t)
+
+	testRecordStore(t, func(t *testing.T) ports.RecordStore {
+		dbtest.Reset(t, database, "login", "alice", "bob")
+		return NewWithDb(database, blob_store.NewInMemory())
+	})
+
+	testBlobs(t, func(t *testing.T, blobs ports.BlobStore) ports.RecordStore {
+		dbtest.Reset(t, database, "login", "alice", "bob")
+		return NewWithDb(database, blobs)
+	})
+}
+
+func Test_dbStore_SQLite(t *testing.T) {
+	newDatabase := func(t *testing.T) *sqlx.DB {
+		database := dbtest.SQLite(t)
+		dbtest.Reset(t, database, "login", "alice", "bob")
+		return database
+	}
+
+	testRecordStore(t, func(t *testing.T) ports.RecordStore {
+		return NewWithSQLite(newDatabase(t), blob_store.NewInMemory())
+	})
+
+	testBlobs(t, func(t *testing.T, blobs ports.BlobStore) ports.RecordStore {
+		return NewWithSQLite(newDatabase(t), blobs)
+	})
+}
+
+// testBlobs checks that the binary payloads are kept in the blob store once and removed when they are not needed.
+func testBlobs(t *testing.T, newStore func(t *testing.T, blobs ports.BlobStore) ports.RecordStore) {
+	ctx := context.Background()
+	now := time.Now()
+	content := []byte("the content of the file")
+	otherContent := []byte("another content of the file")
+
+	newBlobs := func(t *testing.T) (ports.BlobStore, func() []string) {
+		dir := t.TempDir()
+		blobs, err := blob_store.NewFS(dir)
+		require.NoError(t, err)
+
+		stored := func() []string {
+			paths, err := filepath.Glob(filepath.Join(dir, "*", "*"))
+			require.NoError(t, err)
+
+			names := make([]string, 0, len(paths))
+			for _, path := range paths {
+				names = append(names, filepath.Base(path))
+			}
+			return names
+		}
+
+		return blobs, stored
+	}
+
+	fileRecord := func(id string, data []byte, revision int64) *record.BinaryRecord {
+		return &record.BinaryRecord{ID: id, LastUpdateDate: now, Revision: revision, Binary: data}
+	}
+
+	t.Run("store the same content once", func(t *testing.T) {
+		blobs, stored := newBlobs(t)
+		s := newStore(t, blobs)
+
+		assert.Empty(t, addRecords(t, s, fileRecord("first", content, 0), fileRecord("second", content, 0)))
+		assert.Equal(t, []string{blob_store.Hash(content)}, stored())
+
+		recs, err := s.AllRecords(ctx, "login")
+		require.NoError(t, err)
+		require.Len(t, recs, 2)
+		for _, rec := range recs {
+			assert.Equal(t, content, rec.(*record.BinaryRecord).Binary)
+		}
+	})
+
+	t.Run("remove the content nobody refers to", func(t *testing.T) {
+		blobs, stored := newBlobs(t)
+		s := newStore(t, blobs)
+
+		assert.Empty(t, addRecords(t, s, fileRecord("first", content, 0), fileRecord("second", content, 0)))
+		first, err := s.GetRecord(ctx, "login", "first")
+		require.NoError(t, err)
+
+		assert.Empty(t, addRecords(t, s, fileRecord("first", otherContent, first.GetRevision())))
+		require.NoError(t, s.PurgeVersions(ctx, "login", 0))
+		assert.ElementsMatch(t, []string{blob_store.Hash(content), blob_store.Hash(otherContent)}, stored(),
+			"the content is still used by the second record")
+
+		require.NoError(t, s.DeleteRecords(ctx, "login", []record.Tombstone{{ID: "second", DeletionDate: now.Add(time.Second)}}))
+		require.NoError(t, s.PurgeVersions(ctx, "login", 0))
+		assert.Equal(t, []string{blob_store.Hash(otherContent)}, stored())
+
+		require.NoError(t, s.DeleteRecords(ctx, "login", []record.Tombstone{{ID: "first", DeletionDate: now.Add(time.Second)}}))
+		require.NoError(t, s.PurgeVersions(ctx, "login", 0))
+		assert.Empty(t, stored())
+
+		assert.Empty(t, addRecords(t, s, fileRecord("third", content, 0)))
+		assert.Equal(t, []string{blob_store.Hash(content)}, stored(), "the removed content could be stored again")
+	})
+
+	t.Run("versions keep their content", func(t *testing.T) {
+		blobs, stored := newBlobs(t)
+		s := newStore(t, blobs)
+
+		assert.Empty(t, addRecords(t, s, fileRecord("file", content, 0)))
+		first, err := s.GetRecord(ctx, "login", "file")
+		require.NoError(t, err)
+
+		assert.Empty(t, addRecords(t, s, fileRecord("file", otherContent, first.GetRevision())))
+		assert.ElementsMatch(t, []string{blob_store.Hash(content), blob_store.Hash(otherContent)}, stored(),
+			"the content is still used by the previous version")
+
+		rec, err := s.GetRecordVersion(ctx, "login", "file", first.GetRevision())
+		require.NoError(t, err)
+		assert.Equal(t, content, rec.(*record.BinaryRecord).Binary)
+
+		require.NoError(t, s.PurgeVersions(ctx, "login", 0))
+		assert.Equal(t, []string{blob_store.Hash(otherContent)}, stored())
+
+		require.NoError(t, s.DeleteRecords(ctx, "login", []record.Tombstone{{ID: "file", DeletionDate: now.Add(time.Second)}}))
+		assert.Equal(t, []string{blob_store.Hash(otherContent)}, stored(), "the content is used by the deleted version")
+
+		require.NoError(t, s.PurgeVersions(ctx, "login", 0))
+		assert.Empty(t, stored())
+	})
+
+	t.Run("purged records release their content", func(t *testing.T) {
+		blobs, stored := newBlobs(t)
+		s := newStore(t, blobs)
+
+		assert.Empty(t, addRecords(t, s, fileRecord("file", content, 0)))
+		first, err := s.GetRecord(ctx, "login", "file")
+		require.NoError(t, err)
+		assert.Empty(t, addRecords(t, s, fileRecord("file", otherContent, first.GetRevision())))
+
+		require.NoError(t, s.PurgeRecords(ctx, "login", []string{"file"}))
+		assert.Empty(t, stored())
+	})
+
+	t.Run("keep only big payloads of other records in the blob store", func(t *testing.T) {
+		blobs, stored := newBlobs(t)
+		s := newStore(t, blobs)
+
+		big := bytes.Repeat([]byte("x"), minBlobSize+1)
+		assert.Empty(t, addRecords(t, s,
+			&record.EncryptedRecord{ID: "small", LastUpdateDate: now, Payload: content},
+			&record.EncryptedRecord{ID: "big", LastUpdateDate: now, Payload: big},
+		))
+		assert.Equal(t, []string{blob_store.Hash(big)}, stored())
+
+		rec, err := s.GetRecord(ctx, "login", "big")
+		require.NoError(t, err)
+		assert.Equal(t, big, rec.(*record.EncryptedRecord).Payload)
+	})
+
+	t.Run("blobs are shared by the users", func(t *testing.T) {
+		blobs, stored := newBlobs(t)
+		s := newStore(t, blobs)
+
+		_, err := s.AddRecords(ctx, "alice", []record.Record{fileRecord("file", content, 0)})
+		require.NoError(t, err)
+		_, err = s.AddRecords(ctx, "bob", []record.Record{fileRecord("file", content, 0)})
+		require.NoError(t, err)
+
+		require.NoError(t, s.DeleteRecords(ctx, "alice", []record.Tombstone{{ID: "file", DeletionDate: now.Add(time.Second)}}))
+		require.NoError(t, s.PurgeVersions(ctx, "alice", 0))
+		assert.Equal(t, []string{blob_store.Hash(content)}, stored())
+
+		recs, err := s.AllRecords(ctx, "bob")
+		require.NoError(t, err)
+		if assert.Len(t, recs, 1) {
+			assert.Equal(t, content, recs[0].(*record.BinaryRecord).Binary)
+		}
+	})
+}
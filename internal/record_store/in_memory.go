@@ -2,7 +2,10 @@ package record_store
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/denistakeda/mpass/internal/domain/record"
 )
@@ -15,14 +18,69 @@ func NewInMemory() *inMemory {
 	return &inMemory{}
 }
 
-func (r *inMemory) AddRecords(ctx context.Context, login string, records []record.Record) error {
-	return r.getStore(login).addRecords(records)
+func (r *inMemory) AddRecords(ctx context.Context, login string, records []record.Record) ([]string, error) {
+	return r.getStore(login).addRecords(records), nil
 }
 
 func (r *inMemory) AllRecords(ctx context.Context, login string) ([]record.Record, error) {
 	return r.getStore(login).allRecords(), nil
 }
 
+func (r *inMemory) GetRecord(ctx context.Context, login, id string) (record.Record, error) {
+	rec, ok := r.getStore(login).getRecord(id)
+	if !ok {
+		return nil, fmt.Errorf("no record %q", id)
+	}
+
+	return rec, nil
+}
+
+func (r *inMemory) GetChangesSince(ctx context.Context, login string, revision int64) (record.Changes, error) {
+	return r.getStore(login).changesSince(revision), nil
+}
+
+func (r *inMemory) DeleteRecords(ctx context.Context, login string, tombstones []record.Tombstone) error {
+	r.getStore(login).deleteRecords(tombstones)
+	return nil
+}
+
+func (r *inMemory) PurgeTombstones(ctx context.Context, login string, before time.Time) error {
+	r.getStore(login).purgeTombstones(before)
+	return nil
+}
+
+func (r *inMemory) ListRecordVersions(ctx context.Context, login, id string) ([]record.Version, error) {
+	return r.getStore(login).listVersions(id), nil
+}
+
+func (r *inMemory) GetRecordVersion(ctx context.Context, login, id string, version int64) (record.Record, error) {
+	rec, ok := r.getStore(login).getVersion(id, version)
+	if !ok {
+		return nil, fmt.Errorf("no version %d of record %q", version, id)
+	}
+
+	return rec, nil
+}
+
+func (r *inMemory) PurgeVersions(ctx context.Context, login string, keep int) error {
+	r.getStore(login).purgeVersions(keep)
+	return nil
+}
+
+func (r *inMemory) PurgeRecords(ctx context.Context, login string, ids []string) error {
+	r.getStore(login).purgeRecords(ids)
+	return nil
+}
+
+func (r *inMemory) Snapshot(ctx context.Context, login string) (record.Snapshot, error) {
+	return r.getStore(login).snapshot(), nil
+}
+
+func (r *inMemory) RestoreSnapshot(ctx context.Context, login string, snapshot record.Snapshot) error {
+	r.getStore(login).restore(snapshot)
+	return nil
+}
+
 func (r *inMemory) getStore(login string) *store {
 	s, _ := r.stores.LoadOrStore(login, newStore())
 	return s.(*store)
@@ -30,27 +88,66 @@ func (r *inMemory) getStore(login string) *store {
 
 // -- Store --
 
-type store struct {
-	mx      sync.Mutex
-	records map[string]record.Record
-}
+type (
+	store struct {
+		mx             sync.Mutex
+		revision       int64
+		purgedRevision int64
+		records        map[string]record.Record
+		tombstones     map[string]storedTombstone
+		// history keeps the prior versions of every record, the oldest first
+		history map[string][]storedVersion
+	}
+
+	storedVersion struct {
+		version record.Version
+		rec     record.Record
+	}
+
+	storedTombstone struct {
+		tombstone record.Tombstone
+		revision  int64
+	}
+)
 
 func newStore() *store {
-	return &store{records: make(map[string]record.Record)}
+	return &store{
+		records:    make(map[string]record.Record),
+		tombstones: make(map[string]storedTombstone),
+		history:    make(map[string][]storedVersion),
+	}
 }
 
-func (s *store) addRecords(records []record.Record) error {
+func (s *store) addRecords(records []record.Record) []string {
 	s.mx.Lock()
 	defer s.mx.Unlock()
 
+	s.revision++
+
+	var conflicts []string
 	for _, rec := range records {
-		oldRec, ok := s.records[rec.GetId()]
-		if !ok || rec.GetLastUpdateDate().After((oldRec).GetLastUpdateDate()) {
-			s.records[rec.GetId()] = rec
+		if stored, ok := s.tombstones[rec.GetId()]; ok {
+			if stored.tombstone.Deletes(rec) {
+				continue
+			}
+			delete(s.tombstones, rec.GetId())
 		}
+
+		// the record was changed since the revision the client has seen
+		if oldRec, ok := s.records[rec.GetId()]; ok && oldRec.GetRevision() != rec.GetRevision() {
+			conflicts = append(conflicts, rec.GetId())
+			continue
+		}
+
+		if oldRec, ok := s.records[rec.GetId()]; ok {
+			s.archive(oldRec, false)
+		}
+
+		rec.SetRevision(s.revision)
+		s.records[rec.GetId()] = rec
 	}
 
-	return nil
+	return conflicts
 }
 
 func (s *store) allRecords() []record.Record {
@@ -64,3 +161,188 @@ func (s *store) allRecords() []record.Record {
 
 	return res
 }
+
+func (s *store) getRecord(id string) (record.Record, bool) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	rec, ok := s.records[id]
+	return rec, ok
+}
+
+func (s *store) changesSince(revision int64) record.Changes {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	changes := record.Changes{Revision: s.revision}
+
+	// some of the deletions the client has not seen yet are forgotten
+	// or the client has seen the revisions lost when the vault was restored from a backup
+	if revision < s.purgedRevision || revision > s.revision {
+		changes.Full = true
+		revision = 0
+	}
+
+	for _, rec := range s.records {
+		if rec.GetRevision() > revision {
+			changes.Records = append(changes.Records, rec)
+		}
+	}
+
+	if changes.Full {
+		return changes
+	}
+
+	for _, stored := range s.tombstones {
+		if stored.revision > revision {
+			changes.Tombstones = append(changes.Tombstones, stored.tombstone)
+		}
+	}
+
+	return changes
+}
+
+func (s *store) deleteRecords(tombstones []record.Tombstone) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.revision++
+
+	for _, tombstone := range tombstones {
+		if rec, ok := s.records[tombstone.ID]; ok {
+			if !tombstone.Deletes(rec) {
+				// the record was updated after the deletion
+				continue
+			}
+			s.archive(rec, true)
+			delete(s.records, tombstone.ID)
+		}
+
+		old, ok := s.tombstones[tombstone.ID]
+		if !ok || tombstone.DeletionDate.After(old.tombstone.DeletionDate) {
+			s.tombstones[tombstone.ID] = storedTombstone{tombstone: tombstone, revision: s.revision}
+		}
+	}
+}
+
+func (s *store) purgeTombstones(before time.Time) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	for id, stored := range s.tombstones {
+		if stored.tombstone.DeletionDate.Before(before) {
+			delete(s.tombstones, id)
+			if stored.revision > s.purgedRevision {
+				s.purgedRevision = stored.revision
+			}
+		}
+	}
+}
+
+// archive moves the replaced record to the history, the caller should hold the lock.
+func (s *store) archive(rec record.Record, deleted bool) {
+	s.history[rec.GetId()] = append(s.history[rec.GetId()], storedVersion{
+		version: record.Version{
+			Version:        rec.GetRevision(),
+			LastUpdateDate: rec.GetLastUpdateDate(),
+			ReplacedAt:     time.Now(),
+			Deleted:        deleted,
+		},
+		rec: rec,
+	})
+}
+
+func (s *store) listVersions(id string) []record.Version {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	history := s.history[id]
+	res := make([]record.Version, 0, len(history))
+	for i := len(history) - 1; i >= 0; i-- {
+		res = append(res, history[i].version)
+	}
+
+	return res
+}
+
+func (s *store) getVersion(id string, version int64) (record.Record, bool) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	for _, stored := range s.history[id] {
+		if stored.version.Version == version {
+			return stored.rec, true
+		}
+	}
+
+	return nil, false
+}
+
+func (s *store) purgeVersions(keep int) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	for id, history := range s.history {
+		if len(history) > keep {
+			s.history[id] = append([]storedVersion(nil), history[len(history)-keep:]...)
+		}
+	}
+}
+
+func (s *store) purgeRecords(ids []string) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	for _, id := range ids {
+		delete(s.records, id)
+		delete(s.history, id)
+		delete(s.tombstones, id)
+	}
+}
+
+func (s *store) snapshot() record.Snapshot {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	snapshot := record.Snapshot{
+		Revision:       s.revision,
+		PurgedRevision: s.purgedRevision,
+	}
+	for _, rec := range s.records {
+		snapshot.Records = append(snapshot.Records, rec)
+	}
+	for _, stored := range s.tombstones {
+		snapshot.Tombstones = append(snapshot.Tombstones, record.RevisedTombstone{Tombstone: stored.tombstone, Revision: stored.revision})
+	}
+	for _, history := range s.history {
+		for _, stored := range history {
+			snapshot.Versions = append(snapshot.Versions, record.ArchivedVersion{Version: stored.version, Record: stored.rec})
+		}
+	}
+
+	return snapshot
+}
+
+func (s *store) restore(snapshot record.Snapshot) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.revision = snapshot.Revision
+	s.purgedRevision = snapshot.PurgedRevision
+	s.records = make(map[string]record.Record, len(snapshot.Records))
+	s.tombstones = make(map[string]storedTombstone, len(snapshot.Tombstones))
+	s.history = make(map[string][]storedVersion)
+
+	for _, rec := range snapshot.Records {
+		s.records[rec.GetId()] = rec
+	}
+	for _, t := range snapshot.Tombstones {
+		s.tombstones[t.ID] = storedTombstone{tombstone: t.Tombstone, revision: t.Revision}
+	}
+
+	versions := append([]record.ArchivedVersion(nil), snapshot.Versions...)
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Version.Version < versions[j].Version.Version })
+	for _, v := range versions {
+		s.history[v.Record.GetId()] = append(s.history[v.Record.GetId()], storedVersion{version: v.Version, rec: v.Record})
+	}
+}
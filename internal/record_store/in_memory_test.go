@@ -0,0 +1,13 @@
+package record_store
+
+import (
+	"testing"
+
+	"github.com/denistakeda/mpass/internal/ports"
+)
+
+func Test_inMemory(t *testing.T) {
+	testRecordStore(t, func(t *testing.T) ports.RecordStore {
+		return NewInMemory()
+	})
+}
@@ -0,0 +1,541 @@
+package record_store
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/denistakeda/mpass/internal/domain/record"
+	"github.com/denistakeda/mpass/internal/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testRecordStore runs the tests every implementation of the record store should pass.
+// The store should know the users "login", "alice" and "bob".
+func testRecordStore(t *testing.T, newStore func(t *testing.T) ports.RecordStore) {
+	t.Run("AddRecords", func(t *testing.T) { testAddRecords(t, newStore) })
+	t.Run("GetRecord", func(t *testing.T) { testGetRecord(t, newStore) })
+	t.Run("DeleteRecords", func(t *testing.T) { testDeleteRecords(t, newStore) })
+	t.Run("GetChangesSince", func(t *testing.T) { testGetChangesSince(t, newStore) })
+	t.Run("TenantIsolation", func(t *testing.T) { testTenantIsolation(t, newStore) })
+	t.Run("History", func(t *testing.T) { testHistory(t, newStore) })
+	t.Run("Snapshot", func(t *testing.T) { testSnapshot(t, newStore) })
+}
+
+func testAddRecords(t *testing.T, newStore func(t *testing.T) ports.RecordStore) {
+	now := time.Now()
+
+	textRecord := func(text string, revision int64) *record.TextRecord {
+		return &record.TextRecord{ID: "key", LastUpdateDate: now, Revision: revision, Text: text}
+	}
+
+	t.Run("accept edits based on the latest revision", func(t *testing.T) {
+		s := newStore(t)
+
+		assert.Empty(t, addRecords(t, s, textRecord("first", 0)))
+		recs, err := s.AllRecords(context.Background(), "login")
+		require.NoError(t, err)
+		require.Len(t, recs, 1)
+
+		assert.Empty(t, addRecords(t, s, textRecord("second", recs[0].GetRevision())))
+		recs, err = s.AllRecords(context.Background(), "login")
+		require.NoError(t, err)
+		if assert.Len(t, recs, 1) {
+			assert.Equal(t, "second", recs[0].(*record.TextRecord).Text)
+		}
+	})
+
+	t.Run("keep annotations", func(t *testing.T) {
+		s := newStore(t)
+
+		rec := textRecord("first", 0)
+		rec.Annotations = record.Annotations{Metadata: record.Metadata{"url": "example.com"}, Tags: record.Tags{"work"}}
+		assert.Empty(t, addRecords(t, s, rec))
+
+		recs, err := s.AllRecords(context.Background(), "login")
+		require.NoError(t, err)
+		if assert.Len(t, recs, 1) {
+			assert.Equal(t, rec.Annotations, recs[0].GetAnnotations())
+		}
+	})
+
+	t.Run("reject edits based on a stale revision", func(t *testing.T) {
+		s := newStore(t)
+
+		assert.Empty(t, addRecords(t, s, textRecord("first", 0)))
+		assert.Empty(t, addRecords(t, s, textRecord("second", 1)))
+
+		// older clock, but also older revision
+		assert.Equal(t, []string{"key"}, addRecords(t, s, textRecord("third", 1)))
+		// newer record created concurrently on another device
+		assert.Equal(t, []string{"key"}, addRecords(t, s, textRecord("fourth", 0)))
+
+		recs, err := s.AllRecords(context.Background(), "login")
+		require.NoError(t, err)
+		if assert.Len(t, recs, 1) {
+			assert.Equal(t, "second", recs[0].(*record.TextRecord).Text, "conflicting edits should not be stored")
+		}
+	})
+}
+
+func testGetRecord(t *testing.T, newStore func(t *testing.T) ports.RecordStore) {
+	s := newStore(t)
+
+	rec := &record.EncryptedRecord{ID: "key", LastUpdateDate: time.Now(), Payload: []byte("ciphertext")}
+	assert.Empty(t, addRecords(t, s, rec))
+
+	got, err := s.GetRecord(context.Background(), "login", "key")
+	require.NoError(t, err)
+	if assert.IsType(t, &record.EncryptedRecord{}, got) {
+		assert.Equal(t, rec.Payload, got.(*record.EncryptedRecord).Payload)
+		assert.NotZero(t, got.GetRevision())
+	}
+
+	_, err = s.GetRecord(context.Background(), "login", "unknown")
+	assert.Error(t, err)
+	_, err = s.GetRecord(context.Background(), "alice", "key")
+	assert.Error(t, err, "records of other users should not be found")
+}
+
+func testDeleteRecords(t *testing.T, newStore func(t *testing.T) ports.RecordStore) {
+	now := time.Now()
+
+	textRecord := func(id string, lastUpdateDate time.Time) *record.TextRecord {
+		return &record.TextRecord{ID: id, LastUpdateDate: lastUpdateDate, Text: "text"}
+	}
+	tombstone := func(id string, deletionDate time.Time) record.Tombstone {
+		return record.Tombstone{ID: id, DeletionDate: deletionDate}
+	}
+
+	t.Run("delete existing record", func(t *testing.T) {
+		s := newStore(t)
+		ctx := context.Background()
+
+		addRecords(t, s, textRecord("key", now))
+		require.NoError(t, s.DeleteRecords(ctx, "login", []record.Tombstone{tombstone("key", now.Add(time.Second))}))
+
+		recs, err := s.AllRecords(ctx, "login")
+		assert.NoError(t, err)
+		assert.Empty(t, recs)
+	})
+
+	t.Run("deletion wins over older edits", func(t *testing.T) {
+		s := newStore(t)
+		ctx := context.Background()
+
+		require.NoError(t, s.DeleteRecords(ctx, "login", []record.Tombstone{tombstone("key", now)}))
+		addRecords(t, s, textRecord("key", now.Add(-time.Second)))
+
+		recs, err := s.AllRecords(ctx, "login")
+		assert.NoError(t, err)
+		assert.Empty(t, recs)
+	})
+
+	t.Run("newer edits win over deletion", func(t *testing.T) {
+		s := newStore(t)
+		ctx := context.Background()
+
+		addRecords(t, s, textRecord("key", now.Add(time.Second)))
+		require.NoError(t, s.DeleteRecords(ctx, "login", []record.Tombstone{tombstone("key", now)}))
+
+		recs, err := s.AllRecords(ctx, "login")
+		assert.NoError(t, err)
+		assert.Len(t, recs, 1)
+
+		require.NoError(t, s.DeleteRecords(ctx, "login", []record.Tombstone{tombstone("another-key", now)}))
+		addRecords(t, s, textRecord("another-key", now.Add(time.Second)))
+
+		recs, err = s.AllRecords(ctx, "login")
+		assert.NoError(t, err)
+		assert.Len(t, recs, 2)
+	})
+
+	t.Run("purged tombstones do not block edits", func(t *testing.T) {
+		s := newStore(t)
+		ctx := context.Background()
+
+		require.NoError(t, s.DeleteRecords(ctx, "login", []record.Tombstone{tombstone("key", now)}))
+		require.NoError(t, s.PurgeTombstones(ctx, "login", now.Add(time.Second)))
+		addRecords(t, s, textRecord("key", now.Add(-time.Second)))
+
+		recs, err := s.AllRecords(ctx, "login")
+		assert.NoError(t, err)
+		assert.Len(t, recs, 1)
+	})
+}
+
+func testGetChangesSince(t *testing.T, newStore func(t *testing.T) ports.RecordStore) {
+	now := time.Now()
+	ctx := context.Background()
+
+	s := newStore(t)
+
+	changes, err := s.GetChangesSince(ctx, "login", 0)
+	assert.NoError(t, err)
+	assert.Empty(t, changes.Records)
+	assert.Zero(t, changes.Revision)
+
+	addRecords(t, s,
+		&record.TextRecord{ID: "first", LastUpdateDate: now},
+		&record.TextRecord{ID: "second", LastUpdateDate: now},
+	)
+
+	changes, err = s.GetChangesSince(ctx, "login", 0)
+	assert.NoError(t, err)
+	assert.Len(t, changes.Records, 2)
+	revision := changes.Revision
+
+	t.Run("no changes", func(t *testing.T) {
+		changes, err := s.GetChangesSince(ctx, "login", revision)
+		assert.NoError(t, err)
+		assert.Empty(t, changes.Records)
+		assert.Empty(t, changes.Tombstones)
+		assert.Equal(t, revision, changes.Revision)
+	})
+
+	t.Run("only changed records", func(t *testing.T) {
+		addRecords(t, s, &record.TextRecord{ID: "second", LastUpdateDate: now.Add(time.Second), Revision: revision})
+		require.NoError(t, s.DeleteRecords(ctx, "login", []record.Tombstone{
+			{ID: "first", DeletionDate: now.Add(time.Second)},
+		}))
+
+		changes, err := s.GetChangesSince(ctx, "login", revision)
+		assert.NoError(t, err)
+		assert.False(t, changes.Full)
+		if assert.Len(t, changes.Records, 1) {
+			assert.Equal(t, "second", changes.Records[0].GetId())
+		}
+		if assert.Len(t, changes.Tombstones, 1) {
+			assert.Equal(t, "first", changes.Tombstones[0].ID)
+		}
+		assert.Greater(t, changes.Revision, revision)
+	})
+
+	t.Run("full resync when unseen tombstones are purged", func(t *testing.T) {
+		require.NoError(t, s.PurgeTombstones(ctx, "login", now.Add(time.Hour)))
+
+		changes, err := s.GetChangesSince(ctx, "login", revision)
+		assert.NoError(t, err)
+		assert.True(t, changes.Full)
+		assert.Len(t, changes.Records, 1)
+		assert.Empty(t, changes.Tombstones)
+
+		changes, err = s.GetChangesSince(ctx, "login", changes.Revision)
+		assert.NoError(t, err)
+		assert.False(t, changes.Full, "clients that have seen the tombstones should not resync")
+	})
+}
+
+// testHistory checks that the overwritten and deleted records are kept in the history.
+func testHistory(t *testing.T, newStore func(t *testing.T) ports.RecordStore) {
+	now := time.Now()
+	ctx := context.Background()
+
+	// update stores the new text of the record based on its latest revision
+	update := func(t *testing.T, s ports.RecordStore, text string) int64 {
+		var revision int64
+		if rec, err := s.GetRecord(ctx, "login", "key"); err == nil {
+			revision = rec.GetRevision()
+		}
+
+		assert.Empty(t, addRecords(t, s, &record.TextRecord{ID: "key", LastUpdateDate: now, Revision: revision, Text: text}))
+		return revision
+	}
+
+	t.Run("keep overwritten versions", func(t *testing.T) {
+		s := newStore(t)
+
+		versions, err := s.ListRecordVersions(ctx, "login", "key")
+		require.NoError(t, err)
+		assert.Empty(t, versions)
+
+		update(t, s, "first")
+		first := update(t, s, "second")
+		second := update(t, s, "third")
+
+		versions, err = s.ListRecordVersions(ctx, "login", "key")
+		require.NoError(t, err)
+		require.Len(t, versions, 2)
+		assert.Equal(t, second, versions[0].Version, "the latest version goes first")
+		assert.Equal(t, first, versions[1].Version)
+		assert.False(t, versions[0].Deleted)
+		assert.False(t, versions[0].ReplacedAt.IsZero())
+
+		rec, err := s.GetRecordVersion(ctx, "login", "key", first)
+		require.NoError(t, err)
+		assert.Equal(t, "first", rec.(*record.TextRecord).Text)
+		assert.Equal(t, first, rec.GetRevision())
+
+		_, err = s.GetRecordVersion(ctx, "login", "key", first+100)
+		assert.Error(t, err)
+		_, err = s.GetRecordVersion(ctx, "alice", "key", first)
+		assert.Error(t, err, "versions of other users should not be found")
+	})
+
+	t.Run("keep deleted records", func(t *testing.T) {
+		s := newStore(t)
+
+		update(t, s, "first")
+		require.NoError(t, s.DeleteRecords(ctx, "login", []record.Tombstone{{ID: "key", DeletionDate: now.Add(time.Second)}}))
+
+		versions, err := s.ListRecordVersions(ctx, "login", "key")
+		require.NoError(t, err)
+		require.Len(t, versions, 1)
+		assert.True(t, versions[0].Deleted)
+
+		rec, err := s.GetRecordVersion(ctx, "login", "key", versions[0].Version)
+		require.NoError(t, err)
+		assert.Equal(t, "first", rec.(*record.TextRecord).Text)
+	})
+
+	t.Run("rejected edits are not kept", func(t *testing.T) {
+		s := newStore(t)
+
+		update(t, s, "first")
+		assert.Equal(t, []string{"key"}, addRecords(t, s, &record.TextRecord{ID: "key", LastUpdateDate: now, Text: "stale"}))
+
+		versions, err := s.ListRecordVersions(ctx, "login", "key")
+		require.NoError(t, err)
+		assert.Empty(t, versions)
+	})
+
+	t.Run("purge old versions", func(t *testing.T) {
+		s := newStore(t)
+
+		update(t, s, "first")
+		update(t, s, "second")
+		update(t, s, "third")
+		third := update(t, s, "fourth")
+
+		require.NoError(t, s.PurgeVersions(ctx, "login", 1))
+
+		versions, err := s.ListRecordVersions(ctx, "login", "key")
+		require.NoError(t, err)
+		if assert.Len(t, versions, 1) {
+			assert.Equal(t, third, versions[0].Version, "the latest versions are kept")
+		}
+
+		require.NoError(t, s.PurgeVersions(ctx, "login", 0))
+		versions, err = s.ListRecordVersions(ctx, "login", "key")
+		require.NoError(t, err)
+		assert.Empty(t, versions)
+	})
+
+	t.Run("purge records with their history", func(t *testing.T) {
+		s := newStore(t)
+
+		update(t, s, "first")
+		update(t, s, "second")
+		assert.Empty(t, addRecords(t, s, &record.TextRecord{ID: "other", LastUpdateDate: now, Text: "other"}))
+		require.NoError(t, s.DeleteRecords(ctx, "login", []record.Tombstone{{ID: "other", DeletionDate: now.Add(time.Second)}}))
+
+		require.NoError(t, s.PurgeRecords(ctx, "login", []string{"key", "other"}))
+
+		changes, err := s.GetChangesSince(ctx, "login", 0)
+		require.NoError(t, err)
+		assert.Empty(t, changes.Records)
+		assert.Empty(t, changes.Tombstones, "the purged records leave no tombstones")
+
+		for _, id := range []string{"key", "other"} {
+			versions, err := s.ListRecordVersions(ctx, "login", id)
+			require.NoError(t, err)
+			assert.Empty(t, versions)
+		}
+	})
+}
+
+// testSnapshot checks that the vault restored from the snapshot is the same as it was when the snapshot was taken.
+func testSnapshot(t *testing.T, newStore func(t *testing.T) ports.RecordStore) {
+	now := time.Now()
+	ctx := context.Background()
+
+	snapshot := func(t *testing.T, s ports.RecordStore, login string) record.Snapshot {
+		snapshot, err := s.Snapshot(ctx, login)
+		require.NoError(t, err)
+
+		sort.Slice(snapshot.Records, func(i, j int) bool { return snapshot.Records[i].GetId() < snapshot.Records[j].GetId() })
+		sort.Slice(snapshot.Tombstones, func(i, j int) bool { return snapshot.Tombstones[i].ID < snapshot.Tombstones[j].ID })
+		sort.Slice(snapshot.Versions, func(i, j int) bool {
+			a, b := snapshot.Versions[i], snapshot.Versions[j]
+			if a.Record.GetId() != b.Record.GetId() {
+				return a.Record.GetId() < b.Record.GetId()
+			}
+			return a.Version.Version < b.Version.Version
+		})
+
+		return snapshot
+	}
+
+	t.Run("restore the vault as it was", func(t *testing.T) {
+		s := newStore(t)
+
+		addRecords(t, s,
+			&record.TextRecord{ID: "note", LastUpdateDate: now, Text: "first"},
+			&record.BinaryRecord{ID: "scan", LastUpdateDate: now, Binary: []byte("the content of the file")},
+			&record.TextRecord{ID: "gone", LastUpdateDate: now, Text: "deleted"},
+		)
+		note, err := s.GetRecord(ctx, "login", "note")
+		require.NoError(t, err)
+		note.(*record.TextRecord).Text = "second"
+		note.SetAnnotations(record.Annotations{Metadata: record.Metadata{"url": "example.com"}, Tags: record.Tags{"work"}})
+		assert.Empty(t, addRecords(t, s, note))
+		require.NoError(t, s.DeleteRecords(ctx, "login", []record.Tombstone{{ID: "gone", DeletionDate: now.Add(time.Second)}}))
+
+		before := snapshot(t, s, "login")
+		assert.Len(t, before.Records, 2)
+		assert.Len(t, before.Tombstones, 1)
+		assert.Len(t, before.Versions, 2)
+
+		// the changes made after the snapshot are lost
+		addRecords(t, s, &record.TextRecord{ID: "later", LastUpdateDate: now, Text: "later"})
+		require.NoError(t, s.DeleteRecords(ctx, "login", []record.Tombstone{{ID: "scan", DeletionDate: now.Add(time.Second)}}))
+		changes, err := s.GetChangesSince(ctx, "login", 0)
+		require.NoError(t, err)
+
+		require.NoError(t, s.RestoreSnapshot(ctx, "login", before))
+		assert.Equal(t, before, snapshot(t, s, "login"))
+
+		rec, err := s.GetRecord(ctx, "login", "scan")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("the content of the file"), rec.(*record.BinaryRecord).Binary)
+
+		changes, err = s.GetChangesSince(ctx, "login", changes.Revision)
+		require.NoError(t, err)
+		assert.True(t, changes.Full, "clients that have seen the lost revisions should resync")
+		assert.Len(t, changes.Records, 2)
+
+		changes, err = s.GetChangesSince(ctx, "login", before.Revision)
+		require.NoError(t, err)
+		assert.False(t, changes.Full)
+		assert.Empty(t, changes.Records)
+	})
+
+	t.Run("restore does not touch other accounts", func(t *testing.T) {
+		s := newStore(t)
+
+		addRecords(t, s, &record.TextRecord{ID: "note", LastUpdateDate: now, Text: "login notes"})
+		_, err := s.AddRecords(ctx, "alice", []record.Record{&record.TextRecord{ID: "note", LastUpdateDate: now, Text: "alice notes"}})
+		require.NoError(t, err)
+
+		require.NoError(t, s.RestoreSnapshot(ctx, "login", record.Snapshot{}))
+
+		recs, err := s.AllRecords(ctx, "login")
+		require.NoError(t, err)
+		assert.Empty(t, recs)
+
+		recs, err = s.AllRecords(ctx, "alice")
+		require.NoError(t, err)
+		assert.Len(t, recs, 1)
+	})
+}
+
+func addRecords(t *testing.T, s ports.RecordStore, records ...record.Record) []string {
+	conflicts, err := s.AddRecords(context.Background(), "login", records)
+	require.NoError(t, err)
+
+	return conflicts
+}
+
+// testTenantIsolation checks that the records of one user are never seen or changed by another one.
+func testTenantIsolation(t *testing.T, newStore func(t *testing.T) ports.RecordStore) {
+	now := time.Now()
+
+	textRecord := func(text string) *record.TextRecord {
+		return &record.TextRecord{ID: "notes", LastUpdateDate: now, Text: text}
+	}
+
+	texts := func(t *testing.T, s ports.RecordStore, login string) []string {
+		recs, err := s.AllRecords(context.Background(), login)
+		require.NoError(t, err)
+
+		var res []string
+		for _, rec := range recs {
+			res = append(res, rec.(*record.TextRecord).Text)
+		}
+
+		return res
+	}
+
+	add := func(t *testing.T, s ports.RecordStore, login string, recs ...record.Record) []string {
+		conflicts, err := s.AddRecords(context.Background(), login, recs)
+		require.NoError(t, err)
+
+		return conflicts
+	}
+
+	t.Run("same ids in different accounts", func(t *testing.T) {
+		s := newStore(t)
+
+		assert.Empty(t, add(t, s, "alice", textRecord("alice notes")))
+		assert.Empty(t, add(t, s, "bob", textRecord("bob notes")), "ids of other users should not conflict")
+
+		assert.Equal(t, []string{"alice notes"}, texts(t, s, "alice"))
+		assert.Equal(t, []string{"bob notes"}, texts(t, s, "bob"))
+	})
+
+	t.Run("update does not touch other accounts", func(t *testing.T) {
+		s := newStore(t)
+
+		add(t, s, "alice", textRecord("alice notes"))
+		add(t, s, "bob", textRecord("bob notes"))
+
+		recs, err := s.AllRecords(context.Background(), "alice")
+		require.NoError(t, err)
+		require.Len(t, recs, 1)
+
+		updated := textRecord("alice new notes")
+		updated.Revision = recs[0].GetRevision()
+		assert.Empty(t, add(t, s, "alice", updated))
+
+		assert.Equal(t, []string{"alice new notes"}, texts(t, s, "alice"))
+		assert.Equal(t, []string{"bob notes"}, texts(t, s, "bob"))
+	})
+
+	t.Run("deletion does not touch other accounts", func(t *testing.T) {
+		s := newStore(t)
+
+		add(t, s, "alice", textRecord("alice notes"))
+		add(t, s, "bob", textRecord("bob notes"))
+
+		tombstone := record.Tombstone{ID: "notes", DeletionDate: now.Add(time.Second)}
+		require.NoError(t, s.DeleteRecords(context.Background(), "alice", []record.Tombstone{tombstone}))
+
+		assert.Empty(t, texts(t, s, "alice"))
+		assert.Equal(t, []string{"bob notes"}, texts(t, s, "bob"))
+
+		changes, err := s.GetChangesSince(context.Background(), "bob", 0)
+		require.NoError(t, err)
+		assert.Empty(t, changes.Tombstones, "tombstones of other users should not be sent")
+		assert.Len(t, changes.Records, 1)
+	})
+
+	t.Run("changes of other accounts are not sent", func(t *testing.T) {
+		s := newStore(t)
+
+		add(t, s, "alice", textRecord("alice notes"))
+
+		changes, err := s.GetChangesSince(context.Background(), "bob", 0)
+		require.NoError(t, err)
+		assert.Empty(t, changes.Records)
+	})
+
+	t.Run("changed type replaces the record", func(t *testing.T) {
+		s := newStore(t)
+
+		add(t, s, "alice", textRecord("alice notes"))
+		recs, err := s.AllRecords(context.Background(), "alice")
+		require.NoError(t, err)
+		require.Len(t, recs, 1)
+
+		password := &record.LoginPasswordRecord{ID: "notes", LastUpdateDate: now, Revision: recs[0].GetRevision(), Login: "alice", Password: "secret"}
+		assert.Empty(t, add(t, s, "alice", password))
+
+		recs, err = s.AllRecords(context.Background(), "alice")
+		require.NoError(t, err)
+		if assert.Len(t, recs, 1) {
+			assert.Equal(t, record.KindPassword, recs[0].Kind())
+		}
+	})
+}
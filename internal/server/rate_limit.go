@@ -0,0 +1,86 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	pb "github.com/denistakeda/mpass/proto"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+type limiter interface {
+	// Allow reports whether the event of the key is allowed, the time to wait is returned otherwise.
+	Allow(key string) (bool, time.Duration)
+}
+
+// rateLimitInterceptor rejects the sign ins and the sign ups beyond the configured rates
+// before any password is checked or any account is created.
+func (s *server) rateLimitInterceptor(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	switch r := req.(type) {
+	case *pb.SignInRequest:
+		ip := peerIP(ctx)
+		if err := s.allow(s.signInIPLimiter, ip, "too many sign in attempts from your address"); err != nil {
+			s.logger.Warn().Str("ip", ip).Msg("sign in rate limit exceeded for the address")
+			return nil, err
+		}
+		if err := s.allow(s.signInLoginLimiter, r.Login, "too many sign in attempts for the login"); err != nil {
+			s.logger.Warn().Str("login", r.Login).Msg("sign in rate limit exceeded for the login")
+			return nil, err
+		}
+	case *pb.SignUpRequest:
+		ip := peerIP(ctx)
+		if err := s.allow(s.signUpIPLimiter, ip, "too many sign ups from your address"); err != nil {
+			s.logger.Warn().Str("ip", ip).Msg("sign up rate limit exceeded for the address")
+			return nil, err
+		}
+	}
+
+	return handler(ctx, req)
+}
+
+func (s *server) allow(l limiter, key, msg string) error {
+	if l == nil {
+		return nil
+	}
+
+	if ok, retryAfter := l.Allow(key); !ok {
+		return resourceExhausted(msg, retryAfter)
+	}
+
+	return nil
+}
+
+// resourceExhausted returns the ResourceExhausted status with the hint when the request could be retried.
+func resourceExhausted(msg string, retryAfter time.Duration) error {
+	// the hint is rounded up, so that the client retrying after it is not rejected again
+	rounded := (retryAfter + time.Second - 1).Truncate(time.Second)
+
+	st := status.New(codes.ResourceExhausted, fmt.Sprintf("%s, try again in %s", msg, rounded))
+	if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(rounded)}); err == nil {
+		st = detailed
+	}
+
+	return st.Err()
+}
+
+// peerIP returns the address of the client without the port.
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+
+	return host
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/denistakeda/mpass/internal/domain"
+	"github.com/denistakeda/mpass/internal/logging"
+	"github.com/denistakeda/mpass/internal/rate_limiter"
+	"github.com/denistakeda/mpass/mocks/server"
+	pb "github.com/denistakeda/mpass/proto"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func Test_server_rateLimitInterceptor(t *testing.T) {
+	withPeer := func(ip string) context.Context {
+		return peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 4000}})
+	}
+	handler := func(ctx context.Context, req any) (any, error) { return "ok", nil }
+	info := &grpc.UnaryServerInfo{}
+
+	t.Run("sign ins are limited per login", func(t *testing.T) {
+		s := New(NewServerParams{
+			LogService:         logging.New(),
+			SignInLoginLimiter: rate_limiter.New(2, time.Minute),
+		})
+
+		for i := 0; i < 2; i++ {
+			_, err := s.rateLimitInterceptor(withPeer("10.0.0.1"), &pb.SignInRequest{Login: "bob"}, info, handler)
+			require.NoError(t, err)
+		}
+
+		_, err := s.rateLimitInterceptor(withPeer("10.0.0.2"), &pb.SignInRequest{Login: "bob"}, info, handler)
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+		assert.Equal(t, 30*time.Second, retryDelay(t, err))
+
+		_, err = s.rateLimitInterceptor(withPeer("10.0.0.1"), &pb.SignInRequest{Login: "alice"}, info, handler)
+		assert.NoError(t, err, "other logins should not be limited")
+	})
+
+	t.Run("sign ins and sign ups are limited per address", func(t *testing.T) {
+		s := New(NewServerParams{
+			LogService:      logging.New(),
+			SignInIPLimiter: rate_limiter.New(1, time.Minute),
+			SignUpIPLimiter: rate_limiter.New(1, time.Hour),
+		})
+
+		_, err := s.rateLimitInterceptor(withPeer("10.0.0.1"), &pb.SignInRequest{Login: "bob"}, info, handler)
+		require.NoError(t, err)
+		_, err = s.rateLimitInterceptor(withPeer("10.0.0.1"), &pb.SignInRequest{Login: "alice"}, info, handler)
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+		_, err = s.rateLimitInterceptor(withPeer("10.0.0.1"), &pb.SignUpRequest{Login: "carol"}, info, handler)
+		require.NoError(t, err)
+		_, err = s.rateLimitInterceptor(withPeer("10.0.0.1"), &pb.SignUpRequest{Login: "dave"}, info, handler)
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+		_, err = s.rateLimitInterceptor(withPeer("10.0.0.2"), &pb.SignUpRequest{Login: "dave"}, info, handler)
+		assert.NoError(t, err, "other addresses should not be limited")
+	})
+
+	t.Run("other requests are not limited", func(t *testing.T) {
+		s := New(NewServerParams{
+			LogService:      logging.New(),
+			SignInIPLimiter: rate_limiter.New(1, time.Minute),
+		})
+
+		for i := 0; i < 3; i++ {
+			_, err := s.rateLimitInterceptor(withPeer("10.0.0.1"), &pb.RefreshTokenRequest{}, info, handler)
+			require.NoError(t, err)
+		}
+	})
+}
+
+func Test_server_SignIn_locked(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	authService := server_mock.NewMockauthService(ctrl)
+	authService.EXPECT().
+		SignIn(gomock.Any(), "login", "password", "laptop").
+		Return(domain.Tokens{}, &domain.LoginLockedError{RetryAfter: 90 * time.Second}).
+		Times(1)
+
+	s := New(NewServerParams{
+		LogService:  logging.New(),
+		AuthService: authService,
+	})
+
+	_, err := s.SignIn(context.Background(), &pb.SignInRequest{Login: "login", Password: "password", Device: "laptop"})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, 90*time.Second, retryDelay(t, err))
+}
+
+func retryDelay(t *testing.T, err error) time.Duration {
+	t.Helper()
+
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			return info.RetryDelay.AsDuration()
+		}
+	}
+
+	t.Fatalf("no retry info in %v", err)
+	return 0
+}
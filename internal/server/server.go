@@ -2,8 +2,13 @@ package server
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
+	"hash/crc32"
+	"io"
 	"net"
+	"runtime/debug"
+	"unicode/utf8"
 
 	"github.com/denistakeda/mpass/internal/domain"
 	"github.com/denistakeda/mpass/internal/domain/record"
@@ -11,10 +16,12 @@ import (
 	pb "github.com/denistakeda/mpass/proto"
 	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/recovery"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/status"
 )
 
@@ -26,38 +33,87 @@ type (
 		authService   authService
 		recordService recordService
 
-		host     string
-		usedHost string // provided host might differ from the actually used one
-		s        *grpc.Server
+		signInLoginLimiter limiter
+		signInIPLimiter    limiter
+		signUpIPLimiter    limiter
+
+		host        string
+		usedHost    string // provided host might differ from the actually used one
+		credentials credentials.TransportCredentials
+		s           *grpc.Server
 	}
 
 	authService interface {
-		SignUp(ctx context.Context, login, password string) (string, error)
-		SignIn(ctx context.Context, login, password string) (string, error)
+		SignUp(ctx context.Context, login, password, device string) (domain.Tokens, error)
+		SignIn(ctx context.Context, login, password, device string) (domain.Tokens, error)
+		RefreshToken(ctx context.Context, refreshToken string) (domain.Tokens, error)
+		Logout(ctx context.Context, refreshToken string) error
+		ChangePassword(ctx context.Context, login, oldPassword, newPassword string, wrappedKey []byte, device string) (domain.Tokens, error)
 		AuthenticateUser(ctx context.Context, token string) (domain.User, error)
+		InitVaultKey(ctx context.Context, login string, wrappedKey []byte) error
+		GetVaultKey(ctx context.Context, login string) ([]byte, error)
 	}
 
 	recordService interface {
-		AddRecords(ctx context.Context, login string, records []record.Record) error
+		AddRecords(ctx context.Context, login string, records []record.Record) ([]string, error)
 		AllRecords(ctx context.Context, login string) ([]record.Record, error)
+		GetChangesSince(ctx context.Context, login string, revision int64) (record.Changes, error)
+		DeleteRecords(ctx context.Context, login string, tombstones []record.Tombstone) error
+		PurgeRecords(ctx context.Context, login string, ids []string) error
+		GetRecord(ctx context.Context, login, id string) (record.Record, error)
+		StartUpload(ctx context.Context, login string, upload domain.Upload) (domain.Upload, error)
+		UploadChunk(ctx context.Context, login, uploadID string, offset int64, data []byte) error
+		FinishUpload(ctx context.Context, login, uploadID string) ([]string, error)
+		ListRecordVersions(ctx context.Context, login, id string) ([]record.Version, error)
+		GetRecordVersion(ctx context.Context, login, id string, version int64) (record.Record, error)
+		SetHistoryRetention(ctx context.Context, login string, keep int) error
 	}
 )
 
+const (
+	// the payloads of the encrypted records bigger than maxInlinePayload are never sent inline,
+	// the rest are sent inline until the total size of the payloads reaches maxInlineTotal,
+	// so that the message stays far below the default limit of gRPC
+	maxInlinePayload = 256 << 10
+	maxInlineTotal   = 2 << 20
+
+	downloadChunkSize = 1 << 20
+	maxUploadSize     = 1 << 30
+
+	// the ids are stored as varchar(255)
+	maxIDLength = 255
+)
+
+var crc32c = crc32.MakeTable(crc32.Castagnoli)
+
 var userKey struct{}
 
 type NewServerParams struct {
-	Host          string
+	Host string
+	// Credentials secure the transport, the connections are not encrypted if nil
+	Credentials   credentials.TransportCredentials
 	LogService    ports.LogService
 	AuthService   authService
 	RecordService recordService
+
+	// SignInLoginLimiter and SignInIPLimiter limit the sign ins per login and per address of the client,
+	// SignUpIPLimiter limits the sign ups per address. The rate is not limited if nil.
+	SignInLoginLimiter limiter
+	SignInIPLimiter    limiter
+	SignUpIPLimiter    limiter
 }
 
 func New(params NewServerParams) *server {
 	return &server{
 		host:          params.Host,
+		credentials:   params.Credentials,
 		logger:        params.LogService.ComponentLogger("server"),
 		authService:   params.AuthService,
 		recordService: params.RecordService,
+
+		signInLoginLimiter: params.SignInLoginLimiter,
+		signInIPLimiter:    params.SignInIPLimiter,
+		signUpIPLimiter:    params.SignUpIPLimiter,
 	}
 }
 
@@ -72,7 +128,25 @@ func (s *server) Start() <-chan error {
 
 	s.usedHost = listen.Addr().String()
 
-	s.s = grpc.NewServer(grpc.UnaryInterceptor(auth.UnaryServerInterceptor(s.authFunc)))
+	recoveryOpt := recovery.WithRecoveryHandler(s.recoveryHandler)
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(
+			recovery.UnaryServerInterceptor(recoveryOpt),
+			s.rateLimitInterceptor,
+			auth.UnaryServerInterceptor(s.authFunc),
+		),
+		grpc.ChainStreamInterceptor(
+			recovery.StreamServerInterceptor(recoveryOpt),
+			auth.StreamServerInterceptor(s.authFunc),
+		),
+	}
+	if s.credentials != nil {
+		opts = append(opts, grpc.Creds(s.credentials))
+	} else {
+		s.logger.Warn().Msg("TLS is not configured, the connections are not encrypted")
+	}
+
+	s.s = grpc.NewServer(opts...)
 	pb.RegisterMpassServiceServer(s.s, s)
 
 	s.logger.Info().Msg("gRPC server started")
@@ -98,13 +172,14 @@ func (s *server) Host() string {
 func (s *server) SignUp(ctx context.Context, req *pb.SignUpRequest) (*pb.SignUpResponse, error) {
 	var resp pb.SignUpResponse
 
-	token, err := s.authService.SignUp(ctx, req.Login, req.Password)
+	tokens, err := s.authService.SignUp(ctx, req.Login, req.Password, req.Device)
 	if err != nil {
 		s.logger.Error().Err(err).Str("login", req.Login).Msg("failed to sign up")
 		return nil, status.Error(codes.Internal, "failed to sign up")
 	}
 
-	resp.Token = token
+	resp.Token = tokens.AccessToken
+	resp.RefreshToken = tokens.RefreshToken
 
 	return &resp, nil
 }
@@ -112,30 +187,84 @@ func (s *server) SignUp(ctx context.Context, req *pb.SignUpRequest) (*pb.SignUpR
 func (s *server) SignIn(ctx context.Context, req *pb.SignInRequest) (*pb.SignInResponse, error) {
 	var resp pb.SignInResponse
 
-	token, err := s.authService.SignIn(ctx, req.Login, req.Password)
+	tokens, err := s.authService.SignIn(ctx, req.Login, req.Password, req.Device)
 	if err != nil {
 		s.logger.Error().Err(err).Str("login", req.Login).Msg("failed to sign in")
+
+		var locked *domain.LoginLockedError
+		if errors.As(err, &locked) {
+			return nil, resourceExhausted("the login is locked after too many failed sign ins", locked.RetryAfter)
+		}
+
 		return nil, status.Error(codes.Internal, "failed to sign in")
 	}
 
-	resp.Token = token
+	resp.Token = tokens.AccessToken
+	resp.RefreshToken = tokens.RefreshToken
 
 	return &resp, nil
 }
 
-func (s *server) AddRecords(ctx context.Context, req *pb.AddRecordsRequest) (*empty.Empty, error) {
+// RefreshToken does not require authentication, the refresh token itself proves the identity of the user.
+func (s *server) RefreshToken(ctx context.Context, req *pb.RefreshTokenRequest) (*pb.RefreshTokenResponse, error) {
+	tokens, err := s.authService.RefreshToken(ctx, req.RefreshToken)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("failed to refresh token")
+		return nil, status.Error(codes.Unauthenticated, "failed to refresh token, sign in again")
+	}
+
+	return &pb.RefreshTokenResponse{Token: tokens.AccessToken, RefreshToken: tokens.RefreshToken}, nil
+}
+
+func (s *server) Logout(ctx context.Context, req *pb.LogoutRequest) (*empty.Empty, error) {
+	if err := s.authService.Logout(ctx, req.RefreshToken); err != nil {
+		s.logger.Error().Err(err).Msg("failed to logout")
+		return nil, status.Error(codes.Unauthenticated, "failed to logout")
+	}
+
+	return &empty.Empty{}, nil
+}
+
+func (s *server) ChangePassword(ctx context.Context, req *pb.ChangePasswordRequest) (*pb.ChangePasswordResponse, error) {
+	user, ok := ctx.Value(userKey).(domain.User)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "user is not authenticated")
+	}
+
+	tokens, err := s.authService.ChangePassword(ctx, user.Login, req.OldPassword, req.NewPassword, req.WrappedKey, req.Device)
+	if err != nil {
+		s.logger.Error().Err(err).Str("login", user.Login).Msg("failed to change password")
+
+		var locked *domain.LoginLockedError
+		if errors.As(err, &locked) {
+			return nil, resourceExhausted("the login is locked after too many failed sign ins", locked.RetryAfter)
+		}
+
+		return nil, status.Error(codes.PermissionDenied, "failed to change password, check the current password")
+	}
+
+	return &pb.ChangePasswordResponse{Token: tokens.AccessToken, RefreshToken: tokens.RefreshToken}, nil
+}
+
+func (s *server) AddRecords(ctx context.Context, req *pb.AddRecordsRequest) (*pb.AddRecordsResponse, error) {
 	user, ok := ctx.Value(userKey).(domain.User)
 	if !ok {
 		return nil, status.Errorf(codes.Unauthenticated, "user is not authenticated")
 	}
 
-	if err := s.recordService.AddRecords(ctx, user.Login, toDomainRecords(req.Records)); err != nil {
+	recs, err := toDomainRecords(req.Records)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	conflicts, err := s.recordService.AddRecords(ctx, user.Login, recs)
+	if err != nil {
 		msg := fmt.Sprintf("failed to store records for user %q", user.Login)
 		s.logger.Error().Err(err).Msg(msg)
 		return nil, status.Errorf(codes.Internal, msg)
 	}
 
-	return &empty.Empty{}, nil
+	return &pb.AddRecordsResponse{Conflicts: conflicts}, nil
 }
 
 func (s *server) AllRecords(ctx context.Context, _ *empty.Empty) (*pb.AllRecordsResponse, error) {
@@ -152,7 +281,276 @@ func (s *server) AllRecords(ctx context.Context, _ *empty.Empty) (*pb.AllRecords
 		return nil, status.Errorf(codes.Internal, msg)
 	}
 
-	return &pb.AllRecordsResponse{Records: toProtoRecords(recs)}, nil
+	return &pb.AllRecordsResponse{Records: inlinePayloads(toProtoRecords(recs))}, nil
+}
+
+func (s *server) GetChangesSince(ctx context.Context, req *pb.GetChangesSinceRequest) (*pb.GetChangesSinceResponse, error) {
+	user, ok := ctx.Value(userKey).(domain.User)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "user is not authenticated")
+	}
+
+	changes, err := s.recordService.GetChangesSince(ctx, user.Login, req.Revision)
+	if err != nil {
+		msg := fmt.Sprintf("failed to get changes for user %q", user.Login)
+		s.logger.Error().Err(err).Msg(msg)
+		return nil, status.Errorf(codes.Internal, msg)
+	}
+
+	return &pb.GetChangesSinceResponse{
+		Records:    inlinePayloads(toProtoRecords(changes.Records)),
+		Tombstones: toProtoTombstones(changes.Tombstones),
+		Revision:   changes.Revision,
+		Full:       changes.Full,
+	}, nil
+}
+
+func (s *server) DeleteRecords(ctx context.Context, req *pb.DeleteRecordsRequest) (*empty.Empty, error) {
+	user, ok := ctx.Value(userKey).(domain.User)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "user is not authenticated")
+	}
+
+	tombstones, err := toDomainTombstones(req.Tombstones)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if req.Purge {
+		ids := make([]string, len(tombstones))
+		for idx, tombstone := range tombstones {
+			ids[idx] = tombstone.ID
+		}
+
+		if err := s.recordService.PurgeRecords(ctx, user.Login, ids); err != nil {
+			msg := fmt.Sprintf("failed to purge records for user %q", user.Login)
+			s.logger.Error().Err(err).Msg(msg)
+			return nil, status.Errorf(codes.Internal, msg)
+		}
+
+		return &empty.Empty{}, nil
+	}
+
+	if err := s.recordService.DeleteRecords(ctx, user.Login, tombstones); err != nil {
+		msg := fmt.Sprintf("failed to delete records for user %q", user.Login)
+		s.logger.Error().Err(err).Msg(msg)
+		return nil, status.Errorf(codes.Internal, msg)
+	}
+
+	return &empty.Empty{}, nil
+}
+
+func (s *server) ListRecordVersions(ctx context.Context, req *pb.ListRecordVersionsRequest) (*pb.ListRecordVersionsResponse, error) {
+	user, ok := ctx.Value(userKey).(domain.User)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "user is not authenticated")
+	}
+
+	versions, err := s.recordService.ListRecordVersions(ctx, user.Login, req.Id)
+	if err != nil {
+		msg := fmt.Sprintf("failed to list versions of record %q for user %q", req.Id, user.Login)
+		s.logger.Error().Err(err).Msg(msg)
+		return nil, status.Errorf(codes.Internal, msg)
+	}
+
+	res := &pb.ListRecordVersionsResponse{Versions: make([]*pb.RecordVersion, 0, len(versions))}
+	for _, version := range versions {
+		res.Versions = append(res.Versions, version.ToProto())
+	}
+
+	return res, nil
+}
+
+// GetRecordVersion returns the prior version of the record, the big payload should be downloaded separately.
+func (s *server) GetRecordVersion(ctx context.Context, req *pb.GetRecordVersionRequest) (*pb.Record, error) {
+	user, ok := ctx.Value(userKey).(domain.User)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "user is not authenticated")
+	}
+
+	rec, err := s.recordService.GetRecordVersion(ctx, user.Login, req.Id, req.Version)
+	if err != nil {
+		s.logger.Error().Err(err).Str("login", user.Login).Msg("failed to get record version")
+		return nil, status.Errorf(codes.NotFound, "version %d of record %q is not found", req.Version, req.Id)
+	}
+
+	return inlinePayloads([]*pb.Record{rec.ToProto()})[0], nil
+}
+
+// SetHistoryRetention sets how many prior versions of every record are kept for the user.
+func (s *server) SetHistoryRetention(ctx context.Context, req *pb.SetHistoryRetentionRequest) (*empty.Empty, error) {
+	user, ok := ctx.Value(userKey).(domain.User)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "user is not authenticated")
+	}
+
+	if req.Keep < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "history retention should not be negative, got %d", req.Keep)
+	}
+
+	if err := s.recordService.SetHistoryRetention(ctx, user.Login, int(req.Keep)); err != nil {
+		msg := fmt.Sprintf("failed to set history retention for user %q", user.Login)
+		s.logger.Error().Err(err).Msg(msg)
+		return nil, status.Errorf(codes.Internal, msg)
+	}
+
+	return &empty.Empty{}, nil
+}
+
+func (s *server) InitVaultKey(ctx context.Context, req *pb.VaultKey) (*empty.Empty, error) {
+	user, ok := ctx.Value(userKey).(domain.User)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "user is not authenticated")
+	}
+
+	if err := s.authService.InitVaultKey(ctx, user.Login, req.WrappedKey); err != nil {
+		msg := fmt.Sprintf("failed to init vault key for user %q", user.Login)
+		s.logger.Error().Err(err).Msg(msg)
+		return nil, status.Errorf(codes.Internal, msg)
+	}
+
+	return &empty.Empty{}, nil
+}
+
+func (s *server) GetVaultKey(ctx context.Context, _ *empty.Empty) (*pb.VaultKey, error) {
+	user, ok := ctx.Value(userKey).(domain.User)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "user is not authenticated")
+	}
+
+	wrappedKey, err := s.authService.GetVaultKey(ctx, user.Login)
+	if err != nil {
+		msg := fmt.Sprintf("failed to get vault key for user %q", user.Login)
+		s.logger.Error().Err(err).Msg(msg)
+		return nil, status.Errorf(codes.Internal, msg)
+	}
+
+	return &pb.VaultKey{WrappedKey: wrappedKey}, nil
+}
+
+func (s *server) StartUpload(ctx context.Context, req *pb.StartUploadRequest) (*pb.StartUploadResponse, error) {
+	user, ok := ctx.Value(userKey).(domain.User)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "user is not authenticated")
+	}
+
+	if err := validateID(req.Id); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid record id: %s", err)
+	}
+	if req.Size <= 0 || req.Size > maxUploadSize {
+		return nil, status.Errorf(codes.InvalidArgument, "the size of the upload should be between 1 and %d bytes", maxUploadSize)
+	}
+	if len(req.Checksum) != sha256.Size {
+		return nil, status.Errorf(codes.InvalidArgument, "the checksum of the upload should be SHA-256")
+	}
+
+	upload, err := s.recordService.StartUpload(ctx, user.Login, domain.Upload{
+		RecordID:       req.Id,
+		LastUpdateDate: req.LastUpdateDate.AsTime(),
+		Revision:       req.Revision,
+		Size:           req.Size,
+		Checksum:       req.Checksum,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("failed to start upload for user %q", user.Login)
+		s.logger.Error().Err(err).Msg(msg)
+		return nil, status.Errorf(codes.Internal, msg)
+	}
+
+	return &pb.StartUploadResponse{UploadId: upload.ID, Offset: upload.Received}, nil
+}
+
+// UploadRecord receives the chunks of the upload started with StartUpload.
+// The record is stored once the stream is closed and the whole payload is received.
+func (s *server) UploadRecord(stream pb.MpassService_UploadRecordServer) error {
+	ctx := stream.Context()
+	user, ok := ctx.Value(userKey).(domain.User)
+	if !ok {
+		return status.Errorf(codes.Unauthenticated, "user is not authenticated")
+	}
+
+	var uploadID string
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if crc32.Checksum(chunk.Data, crc32c) != chunk.Checksum {
+			return status.Errorf(codes.DataLoss, "checksum of the chunk at offset %d does not match", chunk.Offset)
+		}
+
+		if err := s.recordService.UploadChunk(ctx, user.Login, chunk.UploadId, chunk.Offset, chunk.Data); err != nil {
+			s.logger.Error().Err(err).Str("login", user.Login).Msg("failed to store chunk")
+			return status.Errorf(codes.FailedPrecondition, "failed to store the chunk at offset %d: %v", chunk.Offset, err)
+		}
+
+		uploadID = chunk.UploadId
+	}
+
+	if uploadID == "" {
+		return status.Errorf(codes.InvalidArgument, "no chunks were sent")
+	}
+
+	conflicts, err := s.recordService.FinishUpload(ctx, user.Login, uploadID)
+	if err != nil {
+		msg := fmt.Sprintf("failed to finish upload for user %q", user.Login)
+		s.logger.Error().Err(err).Msg(msg)
+		return status.Errorf(codes.FailedPrecondition, "%s: %v", msg, err)
+	}
+
+	return stream.SendAndClose(&pb.AddRecordsResponse{Conflicts: conflicts})
+}
+
+// DownloadRecord sends the payload of the encrypted record in chunks starting from the offset.
+// The payload of the prior version is sent if the version is set.
+func (s *server) DownloadRecord(req *pb.DownloadRecordRequest, stream pb.MpassService_DownloadRecordServer) error {
+	ctx := stream.Context()
+	user, ok := ctx.Value(userKey).(domain.User)
+	if !ok {
+		return status.Errorf(codes.Unauthenticated, "user is not authenticated")
+	}
+
+	var (
+		rec record.Record
+		err error
+	)
+	if req.Version > 0 {
+		rec, err = s.recordService.GetRecordVersion(ctx, user.Login, req.Id, req.Version)
+	} else {
+		rec, err = s.recordService.GetRecord(ctx, user.Login, req.Id)
+	}
+	if err != nil {
+		s.logger.Error().Err(err).Str("login", user.Login).Msg("failed to get record")
+		return status.Errorf(codes.NotFound, "record %q is not found", req.Id)
+	}
+
+	encrypted, ok := rec.(*record.EncryptedRecord)
+	if !ok {
+		return status.Errorf(codes.FailedPrecondition, "record %q is not encrypted", req.Id)
+	}
+
+	payload := encrypted.Payload
+	if req.Offset < 0 || req.Offset > int64(len(payload)) {
+		return status.Errorf(codes.OutOfRange, "offset %d is out of the payload of %d bytes", req.Offset, len(payload))
+	}
+
+	for offset := req.Offset; offset < int64(len(payload)); offset += downloadChunkSize {
+		end := offset + downloadChunkSize
+		if end > int64(len(payload)) {
+			end = int64(len(payload))
+		}
+
+		data := payload[offset:end]
+		if err := stream.Send(&pb.RecordChunk{Offset: offset, Data: data, Checksum: crc32.Checksum(data, crc32c)}); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // authFunc is used by a middleware to authenticate requests
@@ -170,14 +568,36 @@ func (s *server) authFunc(ctx context.Context) (context.Context, error) {
 	return context.WithValue(ctx, userKey, user), nil
 }
 
-func toDomainRecords(recs []*pb.Record) []record.Record {
+// recoveryHandler turns a panic in a handler into an error instead of crashing the whole server.
+func (s *server) recoveryHandler(p any) error {
+	s.logger.Error().Interface("panic", p).Bytes("stack", debug.Stack()).Msg("recovered from panic")
+	return status.Error(codes.Internal, "internal error")
+}
+
+// toDomainRecords converts the records sent by a client, the malformed ones are rejected.
+func toDomainRecords(recs []*pb.Record) ([]record.Record, error) {
 	res := make([]record.Record, len(recs))
 
 	for idx, rec := range recs {
+		if err := validateID(rec.GetId()); err != nil {
+			return nil, errors.Wrapf(err, "record #%d", idx)
+		}
+
 		res[idx] = record.FromProto(rec)
+		if res[idx] == nil {
+			return nil, errors.Errorf("record %q has an unknown type", rec.Id)
+		}
+
+		if rec.GetEncryptedRecord() == nil {
+			return nil, errors.Errorf("record %q is not encrypted", rec.Id)
+		}
+
+		if encrypted, ok := rec.Record.(*pb.Record_EncryptedRecord); ok && len(encrypted.EncryptedRecord.GetPayload()) == 0 {
+			return nil, errors.Errorf("record %q has an empty payload", rec.Id)
+		}
 	}
 
-	return res
+	return res, nil
 }
 
 func toProtoRecords(recs []record.Record) []*pb.Record {
@@ -189,3 +609,68 @@ func toProtoRecords(recs []record.Record) []*pb.Record {
 
 	return res
 }
+
+// toDomainTombstones converts the tombstones sent by a client, the malformed ones are rejected.
+func toDomainTombstones(tombstones []*pb.Tombstone) ([]record.Tombstone, error) {
+	res := make([]record.Tombstone, len(tombstones))
+
+	for idx, tombstone := range tombstones {
+		if err := validateID(tombstone.GetId()); err != nil {
+			return nil, errors.Wrapf(err, "tombstone #%d", idx)
+		}
+		if tombstone.GetDeletionDate() == nil {
+			return nil, errors.Errorf("tombstone of record %q has no deletion date", tombstone.Id)
+		}
+
+		res[idx] = record.TombstoneFromProto(tombstone)
+	}
+
+	return res, nil
+}
+
+// validateID checks the record id sent by a client.
+func validateID(id string) error {
+	switch {
+	case id == "":
+		return errors.New("id is empty")
+	case len(id) > maxIDLength:
+		return errors.Errorf("id is longer than %d bytes", maxIDLength)
+	case !utf8.ValidString(id):
+		return errors.New("id is not valid UTF-8")
+	}
+
+	return nil
+}
+
+func toProtoTombstones(tombstones []record.Tombstone) []*pb.Tombstone {
+	res := make([]*pb.Tombstone, len(tombstones))
+
+	for idx, tombstone := range tombstones {
+		res[idx] = tombstone.ToProto()
+	}
+
+	return res
+}
+
+// inlinePayloads strips the payloads of the encrypted records not fitting into the message,
+// such records carry the size of the payload to be downloaded separately.
+func inlinePayloads(recs []*pb.Record) []*pb.Record {
+	total := 0
+	for _, rec := range recs {
+		encrypted := rec.GetEncryptedRecord()
+		if encrypted == nil {
+			continue
+		}
+
+		size := len(encrypted.Payload)
+		if size <= maxInlinePayload && total+size <= maxInlineTotal {
+			total += size
+			continue
+		}
+
+		rec.Record = &pb.Record_EncryptedRecord{EncryptedRecord: &pb.EncryptedRecord{}}
+		rec.PayloadSize = int64(size)
+	}
+
+	return recs
+}
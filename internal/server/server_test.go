@@ -2,15 +2,21 @@ package server
 
 import (
 	"context"
+	"crypto/sha256"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/denistakeda/mpass/internal/domain"
 	"github.com/denistakeda/mpass/internal/logging"
 	"github.com/denistakeda/mpass/mocks/server"
 	pb "github.com/denistakeda/mpass/proto"
 	"github.com/golang/mock/gomock"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func Test_server_StartStop(t *testing.T) {
@@ -36,11 +42,11 @@ func Test_server_SignUp(t *testing.T) {
 	}{
 		{
 			name: "auth service returns an error",
-			req:  &pb.SignUpRequest{Login: "login", Password: "password"},
+			req:  &pb.SignUpRequest{Login: "login", Password: "password", Device: "laptop"},
 			authServiceExpectations: func(as *server_mock.MockauthService) {
 				as.EXPECT().
-					SignUp(gomock.Any(), "login", "password").
-					Return("", errors.New("mock error")).
+					SignUp(gomock.Any(), "login", "password", "laptop").
+					Return(domain.Tokens{}, errors.New("mock error")).
 					Times(1)
 			},
 			wantToken: false,
@@ -48,11 +54,11 @@ func Test_server_SignUp(t *testing.T) {
 		},
 		{
 			name: "should successfully return a token",
-			req:  &pb.SignUpRequest{Login: "login", Password: "password"},
+			req:  &pb.SignUpRequest{Login: "login", Password: "password", Device: "laptop"},
 			authServiceExpectations: func(as *server_mock.MockauthService) {
 				as.EXPECT().
-					SignUp(gomock.Any(), "login", "password").
-					Return("token", nil).
+					SignUp(gomock.Any(), "login", "password", "laptop").
+					Return(domain.Tokens{AccessToken: "token", RefreshToken: "refresh token"}, nil).
 					Times(1)
 			},
 			wantToken: true,
@@ -85,6 +91,7 @@ func Test_server_SignUp(t *testing.T) {
 				assert.NotNil(t, got)
 				if got != nil {
 					assert.NotEmpty(t, got.Token, "should have token")
+					assert.NotEmpty(t, got.RefreshToken, "should have refresh token")
 				}
 			}
 			if tt.wantErr {
@@ -106,11 +113,11 @@ func Test_server_SignIn(t *testing.T) {
 	}{
 		{
 			name: "auth service returns an error",
-			req:  &pb.SignInRequest{Login: "login", Password: "password"},
+			req:  &pb.SignInRequest{Login: "login", Password: "password", Device: "laptop"},
 			authServiceExpectations: func(as *server_mock.MockauthService) {
 				as.EXPECT().
-					SignIn(gomock.Any(), "login", "password").
-					Return("", errors.New("mock error")).
+					SignIn(gomock.Any(), "login", "password", "laptop").
+					Return(domain.Tokens{}, errors.New("mock error")).
 					Times(1)
 			},
 			wantToken: false,
@@ -118,11 +125,11 @@ func Test_server_SignIn(t *testing.T) {
 		},
 		{
 			name: "should successfully return a token",
-			req:  &pb.SignInRequest{Login: "login", Password: "password"},
+			req:  &pb.SignInRequest{Login: "login", Password: "password", Device: "laptop"},
 			authServiceExpectations: func(as *server_mock.MockauthService) {
 				as.EXPECT().
-					SignIn(gomock.Any(), "login", "password").
-					Return("token", nil).
+					SignIn(gomock.Any(), "login", "password", "laptop").
+					Return(domain.Tokens{AccessToken: "token", RefreshToken: "refresh token"}, nil).
 					Times(1)
 			},
 			wantToken: true,
@@ -155,6 +162,7 @@ func Test_server_SignIn(t *testing.T) {
 				assert.NotNil(t, got)
 				if got != nil {
 					assert.NotEmpty(t, got.Token, "should have token")
+					assert.NotEmpty(t, got.RefreshToken, "should have refresh token")
 				}
 			}
 			if tt.wantErr {
@@ -165,3 +173,318 @@ func Test_server_SignIn(t *testing.T) {
 		})
 	}
 }
+
+func Test_server_RefreshToken(t *testing.T) {
+	tests := []struct {
+		name                    string
+		authServiceExpectations func(as *server_mock.MockauthService)
+		wantErr                 bool
+	}{
+		{
+			name: "invalid refresh token",
+			authServiceExpectations: func(as *server_mock.MockauthService) {
+				as.EXPECT().
+					RefreshToken(gomock.Any(), "refresh token").
+					Return(domain.Tokens{}, errors.New("mock error")).
+					Times(1)
+			},
+			wantErr: true,
+		},
+		{
+			name: "refreshed successfully",
+			authServiceExpectations: func(as *server_mock.MockauthService) {
+				as.EXPECT().
+					RefreshToken(gomock.Any(), "refresh token").
+					Return(domain.Tokens{AccessToken: "new token", RefreshToken: "new refresh token"}, nil).
+					Times(1)
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			authService := server_mock.NewMockauthService(ctrl)
+			s := New(NewServerParams{
+				Host:        ":0",
+				LogService:  logging.New(),
+				AuthService: authService,
+			})
+
+			tt.authServiceExpectations(authService)
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			got, err := s.RefreshToken(ctx, &pb.RefreshTokenRequest{RefreshToken: "refresh token"})
+			if tt.wantErr {
+				assert.Equal(t, codes.Unauthenticated, status.Code(err))
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, "new token", got.Token)
+			assert.Equal(t, "new refresh token", got.RefreshToken)
+		})
+	}
+}
+
+func Test_server_ChangePassword(t *testing.T) {
+	req := &pb.ChangePasswordRequest{OldPassword: "old", NewPassword: "new", WrappedKey: []byte("key"), Device: "laptop"}
+
+	tests := []struct {
+		name                    string
+		authenticated           bool
+		authServiceExpectations func(as *server_mock.MockauthService)
+		wantCode                codes.Code
+	}{
+		{
+			name:     "user is not authenticated",
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:          "wrong old password",
+			authenticated: true,
+			authServiceExpectations: func(as *server_mock.MockauthService) {
+				as.EXPECT().
+					ChangePassword(gomock.Any(), "login", "old", "new", []byte("key"), "laptop").
+					Return(domain.Tokens{}, errors.New("mock error")).
+					Times(1)
+			},
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name:          "changed successfully",
+			authenticated: true,
+			authServiceExpectations: func(as *server_mock.MockauthService) {
+				as.EXPECT().
+					ChangePassword(gomock.Any(), "login", "old", "new", []byte("key"), "laptop").
+					Return(domain.Tokens{AccessToken: "new token", RefreshToken: "new refresh token"}, nil).
+					Times(1)
+			},
+			wantCode: codes.OK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			authService := server_mock.NewMockauthService(ctrl)
+			s := New(NewServerParams{
+				Host:        ":0",
+				LogService:  logging.New(),
+				AuthService: authService,
+			})
+
+			if tt.authServiceExpectations != nil {
+				tt.authServiceExpectations(authService)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			if tt.authenticated {
+				ctx = context.WithValue(ctx, userKey, domain.User{Login: "login"})
+			}
+
+			got, err := s.ChangePassword(ctx, req)
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			if tt.wantCode == codes.OK {
+				assert.Equal(t, "new token", got.Token)
+				assert.Equal(t, "new refresh token", got.RefreshToken)
+			}
+		})
+	}
+}
+
+func Test_server_AddRecords(t *testing.T) {
+	lastUpdate := timestamppb.Now()
+
+	tests := []struct {
+		name     string
+		records  []*pb.Record
+		wantCode codes.Code
+	}{
+		{
+			name:     "record without payload type",
+			records:  []*pb.Record{{Id: "key", LastUpdateDate: lastUpdate}},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name: "record without id",
+			records: []*pb.Record{{
+				LastUpdateDate: lastUpdate,
+				Record:         &pb.Record_EncryptedRecord{EncryptedRecord: &pb.EncryptedRecord{Payload: []byte("payload")}},
+			}},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name: "plaintext record",
+			records: []*pb.Record{{
+				Id:             "key",
+				LastUpdateDate: lastUpdate,
+				Record:         &pb.Record_TextRecord{TextRecord: &pb.TextRecord{Text: "text"}},
+			}},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name: "record with empty payload",
+			records: []*pb.Record{{
+				Id:             "key",
+				LastUpdateDate: lastUpdate,
+				Record:         &pb.Record_EncryptedRecord{EncryptedRecord: &pb.EncryptedRecord{}},
+			}},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name: "valid record",
+			records: []*pb.Record{{
+				Id:             "key",
+				LastUpdateDate: lastUpdate,
+				Record:         &pb.Record_EncryptedRecord{EncryptedRecord: &pb.EncryptedRecord{Payload: []byte("payload")}},
+			}},
+			wantCode: codes.OK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			recordService := server_mock.NewMockrecordService(ctrl)
+			s := New(NewServerParams{
+				Host:          ":0",
+				LogService:    logging.New(),
+				RecordService: recordService,
+			})
+
+			if tt.wantCode == codes.OK {
+				recordService.EXPECT().AddRecords(gomock.Any(), "login", gomock.Len(len(tt.records))).Return(nil, nil).Times(1)
+			}
+
+			ctx := context.WithValue(context.Background(), userKey, domain.User{Login: "login"})
+
+			_, err := s.AddRecords(ctx, &pb.AddRecordsRequest{Records: tt.records})
+			assert.Equal(t, tt.wantCode, status.Code(err))
+		})
+	}
+}
+
+func Test_server_DeleteRecords(t *testing.T) {
+	deletionDate := timestamppb.Now()
+
+	tests := []struct {
+		name       string
+		tombstones []*pb.Tombstone
+		purge      bool
+		wantCode   codes.Code
+	}{
+		{
+			name:       "tombstone without id",
+			tombstones: []*pb.Tombstone{{DeletionDate: deletionDate}},
+			wantCode:   codes.InvalidArgument,
+		},
+		{
+			name:       "tombstone with too long id",
+			tombstones: []*pb.Tombstone{{Id: strings.Repeat("k", maxIDLength+1), DeletionDate: deletionDate}},
+			wantCode:   codes.InvalidArgument,
+		},
+		{
+			name:       "tombstone with invalid id",
+			tombstones: []*pb.Tombstone{{Id: "\xff", DeletionDate: deletionDate}},
+			wantCode:   codes.InvalidArgument,
+		},
+		{
+			name:       "tombstone without deletion date",
+			tombstones: []*pb.Tombstone{{Id: "key"}},
+			wantCode:   codes.InvalidArgument,
+		},
+		{
+			name:       "valid tombstone",
+			tombstones: []*pb.Tombstone{{Id: "key", DeletionDate: deletionDate}},
+			wantCode:   codes.OK,
+		},
+		{
+			name:       "purge",
+			tombstones: []*pb.Tombstone{{Id: "key", DeletionDate: deletionDate}},
+			purge:      true,
+			wantCode:   codes.OK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			recordService := server_mock.NewMockrecordService(ctrl)
+			s := New(NewServerParams{
+				Host:          ":0",
+				LogService:    logging.New(),
+				RecordService: recordService,
+			})
+
+			if tt.wantCode == codes.OK && tt.purge {
+				recordService.EXPECT().PurgeRecords(gomock.Any(), "login", []string{"key"}).Return(nil).Times(1)
+			} else if tt.wantCode == codes.OK {
+				recordService.EXPECT().DeleteRecords(gomock.Any(), "login", gomock.Len(len(tt.tombstones))).Return(nil).Times(1)
+			}
+
+			ctx := context.WithValue(context.Background(), userKey, domain.User{Login: "login"})
+
+			_, err := s.DeleteRecords(ctx, &pb.DeleteRecordsRequest{Tombstones: tt.tombstones, Purge: tt.purge})
+			assert.Equal(t, tt.wantCode, status.Code(err))
+		})
+	}
+}
+
+func Test_server_StartUpload(t *testing.T) {
+	checksum := sha256.Sum256([]byte("payload"))
+
+	tests := []struct {
+		name     string
+		req      *pb.StartUploadRequest
+		wantCode codes.Code
+	}{
+		{
+			name:     "upload without id",
+			req:      &pb.StartUploadRequest{Size: 7, Checksum: checksum[:]},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "upload with too long id",
+			req:      &pb.StartUploadRequest{Id: strings.Repeat("k", maxIDLength+1), Size: 7, Checksum: checksum[:]},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "upload without checksum",
+			req:      &pb.StartUploadRequest{Id: "key", Size: 7},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "valid upload",
+			req:      &pb.StartUploadRequest{Id: "key", LastUpdateDate: timestamppb.Now(), Size: 7, Checksum: checksum[:]},
+			wantCode: codes.OK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			recordService := server_mock.NewMockrecordService(ctrl)
+			s := New(NewServerParams{
+				Host:          ":0",
+				LogService:    logging.New(),
+				RecordService: recordService,
+			})
+
+			if tt.wantCode == codes.OK {
+				recordService.EXPECT().StartUpload(gomock.Any(), "login", gomock.Any()).Return(domain.Upload{ID: "upload"}, nil).Times(1)
+			}
+
+			ctx := context.WithValue(context.Background(), userKey, domain.User{Login: "login"})
+
+			_, err := s.StartUpload(ctx, tt.req)
+			assert.Equal(t, tt.wantCode, status.Code(err))
+		})
+	}
+}
+
+func Test_server_recoveryHandler(t *testing.T) {
+	s := New(NewServerParams{Host: ":0", LogService: logging.New()})
+
+	err := s.recoveryHandler("boom")
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
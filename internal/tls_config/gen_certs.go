@@ -0,0 +1,150 @@
+package tls_config
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const certValidity = 365 * 24 * time.Hour
+
+// Certs are the paths of the generated files.
+type Certs struct {
+	CACert     string
+	ServerCert string
+	ServerKey  string
+	ClientCert string
+	ClientKey  string
+
+	// ServerFingerprint could be pinned by the clients
+	ServerFingerprint string
+}
+
+// GenerateCerts creates a self-signed CA and the server and client certificates signed by it.
+// The server certificate is valid for the hosts, which could be either DNS names or IP addresses.
+// It is meant for development setups only.
+func GenerateCerts(dir string, hosts []string) (Certs, error) {
+	certs := Certs{
+		CACert:     filepath.Join(dir, "ca.pem"),
+		ServerCert: filepath.Join(dir, "server.pem"),
+		ServerKey:  filepath.Join(dir, "server-key.pem"),
+		ClientCert: filepath.Join(dir, "client.pem"),
+		ClientKey:  filepath.Join(dir, "client-key.pem"),
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return certs, errors.Wrapf(err, "failed to create directory %q", dir)
+	}
+
+	caTemplate := template("mpass dev CA")
+	caTemplate.IsCA = true
+	caTemplate.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+	caTemplate.BasicConstraintsValid = true
+
+	caKey, caDER, err := createCert(caTemplate, nil, nil)
+	if err != nil {
+		return certs, errors.Wrap(err, "failed to create CA certificate")
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		return certs, err
+	}
+	if err := writePEM(certs.CACert, "CERTIFICATE", caDER); err != nil {
+		return certs, err
+	}
+
+	serverTemplate := template("mpass server")
+	serverTemplate.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			serverTemplate.IPAddresses = append(serverTemplate.IPAddresses, ip)
+		} else {
+			serverTemplate.DNSNames = append(serverTemplate.DNSNames, host)
+		}
+	}
+
+	serverKey, serverDER, err := createCert(serverTemplate, caCert, caKey)
+	if err != nil {
+		return certs, errors.Wrap(err, "failed to create server certificate")
+	}
+	if err := writeCert(certs.ServerCert, certs.ServerKey, serverDER, serverKey); err != nil {
+		return certs, err
+	}
+	certs.ServerFingerprint = Fingerprint(serverDER)
+
+	clientTemplate := template("mpass client")
+	clientTemplate.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+
+	clientKey, clientDER, err := createCert(clientTemplate, caCert, caKey)
+	if err != nil {
+		return certs, errors.Wrap(err, "failed to create client certificate")
+	}
+	if err := writeCert(certs.ClientCert, certs.ClientKey, clientDER, clientKey); err != nil {
+		return certs, err
+	}
+
+	return certs, nil
+}
+
+func template(commonName string) *x509.Certificate {
+	serial, _ := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName, Organization: []string{"mpass"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(certValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+}
+
+// createCert generates a key and a certificate signed by the parent, the certificate is self-signed without the parent.
+func createCert(tmpl, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*ecdsa.PrivateKey, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to generate key")
+	}
+
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return key, der, nil
+}
+
+func writeCert(certPath, keyPath string, der []byte, key *ecdsa.PrivateKey) error {
+	if err := writePEM(certPath, "CERTIFICATE", der); err != nil {
+		return err
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal key")
+	}
+
+	return writePEM(keyPath, "PRIVATE KEY", keyDER)
+}
+
+func writePEM(path, blockType string, der []byte) error {
+	content := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		return errors.Wrapf(err, "failed to write %q", path)
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+// package tls_config builds the TLS configuration of the gRPC transport
+// and generates self-signed certificates for development setups.
+package tls_config
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ServerParams are the files the server TLS configuration is loaded from.
+type ServerParams struct {
+	CertFile string
+	KeyFile  string
+
+	// ClientCAFile enables mutual TLS, only the clients with a certificate signed by the CA are accepted
+	ClientCAFile string
+}
+
+// ClientParams are the files the client TLS configuration is loaded from.
+type ClientParams struct {
+	// CAFile is the bundle of the CAs to verify the server, the system CAs are used if empty
+	CAFile string
+	// Fingerprint is the pinned SHA-256 fingerprint of the server certificate.
+	// With the fingerprint and without the CA the self-signed server certificates are accepted.
+	Fingerprint string
+	// ServerName overrides the name the server certificate is verified against
+	ServerName string
+
+	// CertFile and KeyFile are the client certificate for mutual TLS
+	CertFile string
+	KeyFile  string
+}
+
+// ServerConfig loads the server certificate and the optional client CA.
+func ServerConfig(params ServerParams) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(params.CertFile, params.KeyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load server certificate")
+	}
+
+	conf := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if params.ClientCAFile != "" {
+		pool, err := loadCertPool(params.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		conf.ClientCAs = pool
+		conf.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return conf, nil
+}
+
+// ClientConfig loads the CA bundle, the pinned fingerprint and the optional client certificate.
+func ClientConfig(params ClientParams) (*tls.Config, error) {
+	conf := &tls.Config{
+		ServerName: params.ServerName,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if params.CAFile != "" {
+		pool, err := loadCertPool(params.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		conf.RootCAs = pool
+	}
+
+	if params.Fingerprint != "" {
+		fingerprint, err := parseFingerprint(params.Fingerprint)
+		if err != nil {
+			return nil, err
+		}
+
+		if params.CAFile == "" {
+			// the pinned certificate is trusted as is, the chain is not verified
+			conf.InsecureSkipVerify = true
+		}
+		conf.VerifyPeerCertificate = verifyFingerprint(fingerprint)
+	}
+
+	if params.CertFile != "" || params.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(params.CertFile, params.KeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load client certificate")
+		}
+		conf.Certificates = []tls.Certificate{cert}
+	}
+
+	return conf, nil
+}
+
+// Fingerprint returns the SHA-256 fingerprint of the DER encoded certificate.
+func Fingerprint(cert []byte) string {
+	sum := sha256.Sum256(cert)
+	return hex.EncodeToString(sum[:])
+}
+
+func verifyFingerprint(fingerprint []byte) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("server has not provided a certificate")
+		}
+
+		sum := sha256.Sum256(rawCerts[0])
+		if subtle.ConstantTimeCompare(sum[:], fingerprint) != 1 {
+			return errors.Errorf("server certificate fingerprint %s does not match the pinned one", hex.EncodeToString(sum[:]))
+		}
+
+		return nil
+	}
+}
+
+// parseFingerprint accepts the hex encoded fingerprint with or without colons.
+func parseFingerprint(s string) ([]byte, error) {
+	fingerprint, err := hex.DecodeString(strings.ReplaceAll(s, ":", ""))
+	if err != nil || len(fingerprint) != sha256.Size {
+		return nil, errors.Errorf("invalid certificate fingerprint %q, expected hex encoded SHA-256", s)
+	}
+
+	return fingerprint, nil
+}
+
+func loadCertPool(path string) (*x509.CertPool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read CA file %q", path)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(content) {
+		return nil, errors.Errorf("no certificates found in CA file %q", path)
+	}
+
+	return pool, nil
+}
@@ -0,0 +1,131 @@
+package tls_config
+
+import (
+	"crypto/tls"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_TLSConfig(t *testing.T) {
+	certs, err := GenerateCerts(t.TempDir(), []string{"localhost", "127.0.0.1"})
+	require.NoError(t, err)
+
+	serverConf, err := ServerConfig(ServerParams{CertFile: certs.ServerCert, KeyFile: certs.ServerKey})
+	require.NoError(t, err)
+
+	mtlsServerConf, err := ServerConfig(ServerParams{CertFile: certs.ServerCert, KeyFile: certs.ServerKey, ClientCAFile: certs.CACert})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		server  *tls.Config
+		client  ClientParams
+		wantErr bool
+	}{
+		{
+			name:   "verify with CA",
+			server: serverConf,
+			client: ClientParams{CAFile: certs.CACert, ServerName: "localhost"},
+		},
+		{
+			name:   "pinned self-signed certificate",
+			server: serverConf,
+			client: ClientParams{Fingerprint: certs.ServerFingerprint},
+		},
+		{
+			name:   "pinned fingerprint with colons",
+			server: serverConf,
+			client: ClientParams{Fingerprint: withColons(certs.ServerFingerprint), ServerName: "localhost"},
+		},
+		{
+			name:    "wrong fingerprint",
+			server:  serverConf,
+			client:  ClientParams{CAFile: certs.CACert, ServerName: "localhost", Fingerprint: strings.Repeat("00", 32)},
+			wantErr: true,
+		},
+		{
+			name:    "unknown CA",
+			server:  serverConf,
+			client:  ClientParams{ServerName: "localhost"},
+			wantErr: true,
+		},
+		{
+			name:    "wrong server name",
+			server:  serverConf,
+			client:  ClientParams{CAFile: certs.CACert, ServerName: "example.com"},
+			wantErr: true,
+		},
+		{
+			name:   "mutual TLS",
+			server: mtlsServerConf,
+			client: ClientParams{CAFile: certs.CACert, ServerName: "localhost", CertFile: certs.ClientCert, KeyFile: certs.ClientKey},
+		},
+		{
+			name:    "mutual TLS without client certificate",
+			server:  mtlsServerConf,
+			client:  ClientParams{CAFile: certs.CACert, ServerName: "localhost"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientConf, err := ClientConfig(tt.client)
+			require.NoError(t, err)
+
+			err = handshake(tt.server, clientConf)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	t.Run("invalid fingerprint", func(t *testing.T) {
+		_, err := ClientConfig(ClientParams{Fingerprint: "not a fingerprint"})
+		assert.Error(t, err)
+	})
+}
+
+// handshake connects the client to the server and waits for the server to accept the connection.
+func handshake(serverConf, clientConf *tls.Config) error {
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", serverConf)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		if err := conn.(*tls.Conn).Handshake(); err == nil {
+			conn.Write([]byte{1})
+		}
+	}()
+
+	conn, err := tls.Dial("tcp", ln.Addr().String(), clientConf)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Read(make([]byte, 1))
+	return err
+}
+
+func withColons(fingerprint string) string {
+	var parts []string
+	for i := 0; i < len(fingerprint); i += 2 {
+		parts = append(parts, fingerprint[i:i+2])
+	}
+
+	return strings.Join(parts, ":")
+}
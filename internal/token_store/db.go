@@ -0,0 +1,59 @@
+package token_store
+
+import (
+	"context"
+	"time"
+
+	"github.com/denistakeda/mpass/internal/domain"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+// TokenStore keeps the data in the SQL database, both postgres and SQLite are supported.
+type TokenStore struct {
+	db *sqlx.DB
+}
+
+func NewWithDB(db *sqlx.DB) *TokenStore {
+	return &TokenStore{db: db}
+}
+
+// AddRefreshToken stores the refresh token replacing the previous token of the same device.
+func (s *TokenStore) AddRefreshToken(ctx context.Context, token domain.RefreshToken) error {
+	if _, err := s.db.ExecContext(ctx, `
+		insert into refresh_tokens(user_login, device, token_hash, created_at, expires_at)
+		values ($1, $2, $3, $4, $5)
+		on conflict (user_login, device) do update
+		set token_hash=excluded.token_hash, created_at=excluded.created_at, expires_at=excluded.expires_at
+	`, token.Login, token.Device, token.Hash, time.Now(), token.ExpiresAt); err != nil {
+		return errors.Wrapf(err, "failed to store refresh token of user %s", token.Login)
+	}
+
+	return nil
+}
+
+// TakeRefreshToken removes the refresh token and returns it, so that every token could be used only once.
+func (s *TokenStore) TakeRefreshToken(ctx context.Context, hash string) (domain.RefreshToken, error) {
+	var token domain.RefreshToken
+	if err := s.db.GetContext(ctx, &token, `
+		delete from refresh_tokens
+		where token_hash=$1
+		returning token_hash, user_login, device, expires_at
+	`, hash); err != nil {
+		return token, errors.Wrap(err, "no such refresh token")
+	}
+
+	return token, nil
+}
+
+// RevokeRefreshTokens removes the refresh tokens of all the devices of the user.
+func (s *TokenStore) RevokeRefreshTokens(ctx context.Context, login string) error {
+	if _, err := s.db.ExecContext(ctx, `
+		delete from refresh_tokens
+		where user_login=$1
+	`, login); err != nil {
+		return errors.Wrapf(err, "failed to revoke refresh tokens of user %s", login)
+	}
+
+	return nil
+}
@@ -0,0 +1,25 @@
+package token_store
+
+import (
+	"testing"
+
+	"github.com/denistakeda/mpass/internal/db/dbtest"
+	"github.com/denistakeda/mpass/internal/ports"
+)
+
+func Test_TokenStore_Postgres(t *testing.T) {
+	database := dbtest.Postgres(t)
+
+	testTokenStore(t, func(t *testing.T) ports.TokenStore {
+		dbtest.Reset(t, database, "login", "other")
+		return NewWithDB(database)
+	})
+}
+
+func Test_TokenStore_SQLite(t *testing.T) {
+	testTokenStore(t, func(t *testing.T) ports.TokenStore {
+		database := dbtest.SQLite(t)
+		dbtest.Reset(t, database, "login", "other")
+		return NewWithDB(database)
+	})
+}
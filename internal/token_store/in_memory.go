@@ -0,0 +1,77 @@
+package token_store
+
+import (
+	"context"
+	"sync"
+
+	"github.com/denistakeda/mpass/internal/domain"
+	"github.com/pkg/errors"
+)
+
+type (
+	inMemoryTokenStore struct {
+		mx     sync.Mutex
+		tokens map[string]domain.RefreshToken
+		// devices maps the device of a user to the hash of its token
+		devices map[device]string
+	}
+
+	device struct {
+		login string
+		name  string
+	}
+)
+
+func NewInMemory() *inMemoryTokenStore {
+	return &inMemoryTokenStore{
+		tokens:  make(map[string]domain.RefreshToken),
+		devices: make(map[device]string),
+	}
+}
+
+// AddRefreshToken stores the refresh token replacing the previous token of the same device.
+func (s *inMemoryTokenStore) AddRefreshToken(ctx context.Context, token domain.RefreshToken) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	d := device{login: token.Login, name: token.Device}
+	if old, ok := s.devices[d]; ok {
+		delete(s.tokens, old)
+	}
+
+	s.devices[d] = token.Hash
+	s.tokens[token.Hash] = token
+
+	return nil
+}
+
+// TakeRefreshToken removes the refresh token and returns it, so that every token could be used only once.
+func (s *inMemoryTokenStore) TakeRefreshToken(ctx context.Context, hash string) (domain.RefreshToken, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	token, ok := s.tokens[hash]
+	if !ok {
+		return domain.RefreshToken{}, errors.New("no such refresh token")
+	}
+
+	delete(s.tokens, hash)
+	delete(s.devices, device{login: token.Login, name: token.Device})
+
+	return token, nil
+}
+
+// RevokeRefreshTokens removes the refresh tokens of all the devices of the user.
+func (s *inMemoryTokenStore) RevokeRefreshTokens(ctx context.Context, login string) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	for d, hash := range s.devices {
+		if d.login == login {
+			delete(s.tokens, hash)
+			delete(s.devices, d)
+		}
+	}
+
+	return nil
+}
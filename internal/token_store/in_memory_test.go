@@ -0,0 +1,13 @@
+package token_store
+
+import (
+	"testing"
+
+	"github.com/denistakeda/mpass/internal/ports"
+)
+
+func Test_inMemoryTokenStore(t *testing.T) {
+	testTokenStore(t, func(t *testing.T) ports.TokenStore {
+		return NewInMemory()
+	})
+}
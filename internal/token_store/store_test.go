@@ -0,0 +1,99 @@
+package token_store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/denistakeda/mpass/internal/domain"
+	"github.com/denistakeda/mpass/internal/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testTokenStore runs the tests every implementation of the token store should pass.
+// The store should know the users "login" and "other".
+func testTokenStore(t *testing.T, newStore func(t *testing.T) ports.TokenStore) {
+	// the databases keep the time with microsecond precision
+	token := domain.RefreshToken{Hash: "hash", Login: "login", Device: "laptop", ExpiresAt: time.Now().Add(time.Hour).Truncate(time.Microsecond)}
+
+	t.Run("take unknown token", func(t *testing.T) {
+		s := newStore(t)
+
+		_, err := s.TakeRefreshToken(context.Background(), "hash")
+		assert.Error(t, err)
+	})
+
+	t.Run("token could be taken only once", func(t *testing.T) {
+		s := newStore(t)
+		require.NoError(t, s.AddRefreshToken(context.Background(), token))
+
+		got, err := s.TakeRefreshToken(context.Background(), "hash")
+		require.NoError(t, err)
+		assertToken(t, token, got)
+
+		_, err = s.TakeRefreshToken(context.Background(), "hash")
+		assert.Error(t, err)
+	})
+
+	t.Run("new token of the same device replaces the old one", func(t *testing.T) {
+		s := newStore(t)
+		require.NoError(t, s.AddRefreshToken(context.Background(), token))
+
+		newToken := token
+		newToken.Hash = "new hash"
+		require.NoError(t, s.AddRefreshToken(context.Background(), newToken))
+
+		_, err := s.TakeRefreshToken(context.Background(), "hash")
+		assert.Error(t, err)
+
+		got, err := s.TakeRefreshToken(context.Background(), "new hash")
+		require.NoError(t, err)
+		assertToken(t, newToken, got)
+	})
+
+	t.Run("tokens of different devices are kept", func(t *testing.T) {
+		s := newStore(t)
+		require.NoError(t, s.AddRefreshToken(context.Background(), token))
+
+		phone := token
+		phone.Hash, phone.Device = "phone hash", "phone"
+		require.NoError(t, s.AddRefreshToken(context.Background(), phone))
+
+		_, err := s.TakeRefreshToken(context.Background(), "hash")
+		assert.NoError(t, err)
+		_, err = s.TakeRefreshToken(context.Background(), "phone hash")
+		assert.NoError(t, err)
+	})
+
+	t.Run("tokens of all the devices are revoked", func(t *testing.T) {
+		s := newStore(t)
+		require.NoError(t, s.AddRefreshToken(context.Background(), token))
+
+		phone := token
+		phone.Hash, phone.Device = "phone hash", "phone"
+		require.NoError(t, s.AddRefreshToken(context.Background(), phone))
+
+		other := token
+		other.Hash, other.Login = "other hash", "other"
+		require.NoError(t, s.AddRefreshToken(context.Background(), other))
+
+		require.NoError(t, s.RevokeRefreshTokens(context.Background(), "login"))
+
+		_, err := s.TakeRefreshToken(context.Background(), "hash")
+		assert.Error(t, err)
+		_, err = s.TakeRefreshToken(context.Background(), "phone hash")
+		assert.Error(t, err)
+
+		got, err := s.TakeRefreshToken(context.Background(), "other hash")
+		require.NoError(t, err, "tokens of other users should be kept")
+		assertToken(t, other, got)
+	})
+}
+
+func assertToken(t *testing.T, want, got domain.RefreshToken) {
+	assert.True(t, want.ExpiresAt.Equal(got.ExpiresAt), "expected expiration time %v, got %v", want.ExpiresAt, got.ExpiresAt)
+
+	want.ExpiresAt, got.ExpiresAt = time.Time{}, time.Time{}
+	assert.Equal(t, want, got)
+}
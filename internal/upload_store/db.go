@@ -0,0 +1,146 @@
+package upload_store
+
+import (
+	"context"
+	"time"
+
+	"github.com/denistakeda/mpass/internal/domain"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+// UploadStore keeps the data in the SQL database, both postgres and SQLite are supported.
+// The chunks are stored separately and joined once the upload is finished.
+// The time is always stored in UTC to be compared the same way by both databases.
+type UploadStore struct {
+	db *sqlx.DB
+}
+
+func NewWithDB(db *sqlx.DB) *UploadStore {
+	return &UploadStore{db: db}
+}
+
+// StartUpload creates the upload or returns the unfinished upload of the same payload of the record.
+func (s *UploadStore) StartUpload(ctx context.Context, u domain.Upload) (domain.Upload, error) {
+	if _, err := s.db.ExecContext(ctx, `
+		insert into uploads(user_login, id, record_id, last_update_date, revision, size, checksum, created_at)
+		values ($1, $2, $3, $4, $5, $6, $7, $8)
+		on conflict (user_login, record_id, checksum) do update
+		set last_update_date=excluded.last_update_date, revision=excluded.revision
+	`, u.Login, u.ID, u.RecordID, u.LastUpdateDate.UTC(), u.Revision, u.Size, u.Checksum, u.CreatedAt.UTC()); err != nil {
+		return u, errors.Wrapf(err, "failed to start upload of record %q", u.RecordID)
+	}
+
+	var id string
+	if err := s.db.GetContext(ctx, &id, `
+		select id from uploads
+		where user_login=$1 and record_id=$2 and checksum=$3
+	`, u.Login, u.RecordID, u.Checksum); err != nil {
+		return u, errors.Wrapf(err, "failed to start upload of record %q", u.RecordID)
+	}
+
+	return s.GetUpload(ctx, u.Login, id)
+}
+
+func (s *UploadStore) GetUpload(ctx context.Context, login, id string) (domain.Upload, error) {
+	return getUpload(ctx, s.db, login, id)
+}
+
+// AddChunk appends the data to the upload, the offset should match the number of bytes received.
+func (s *UploadStore) AddChunk(ctx context.Context, login, id string, offset int64, data []byte) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to start a transaction")
+	}
+	defer tx.Rollback()
+
+	// the upload row is locked, so that the concurrent chunks of the same upload wait for each other
+	if _, err := tx.ExecContext(ctx, `
+		update uploads set size=size
+		where user_login=$1 and id=$2
+	`, login, id); err != nil {
+		return errors.Wrapf(err, "failed to lock upload %q", id)
+	}
+
+	u, err := getUpload(ctx, tx, login, id)
+	if err != nil {
+		return err
+	}
+
+	if err := checkChunk(u, offset, data); err != nil {
+		return err
+	}
+
+	if len(data) > 0 {
+		if _, err := tx.ExecContext(ctx, `
+			insert into upload_chunks(user_login, upload_id, chunk_offset, data)
+			values ($1, $2, $3, $4)
+		`, login, id, offset, data); err != nil {
+			return errors.Wrapf(err, "failed to store chunk of upload %q", id)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrapf(err, "failed to store chunk of upload %q", id)
+	}
+
+	return nil
+}
+
+func (s *UploadStore) GetPayload(ctx context.Context, login, id string) ([]byte, error) {
+	var chunks [][]byte
+	if err := s.db.SelectContext(ctx, &chunks, `
+		select data from upload_chunks
+		where user_login=$1 and upload_id=$2
+		order by chunk_offset
+	`, login, id); err != nil {
+		return nil, errors.Wrapf(err, "failed to get payload of upload %q", id)
+	}
+
+	var payload []byte
+	for _, chunk := range chunks {
+		payload = append(payload, chunk...)
+	}
+
+	return payload, nil
+}
+
+func (s *UploadStore) DeleteUpload(ctx context.Context, login, id string) error {
+	if _, err := s.db.ExecContext(ctx, `
+		delete from uploads
+		where user_login=$1 and id=$2
+	`, login, id); err != nil {
+		return errors.Wrapf(err, "failed to delete upload %q", id)
+	}
+
+	return nil
+}
+
+// PurgeUploads removes the uploads started before the time and never finished.
+func (s *UploadStore) PurgeUploads(ctx context.Context, before time.Time) error {
+	if _, err := s.db.ExecContext(ctx, `
+		delete from uploads
+		where created_at < $1
+	`, before.UTC()); err != nil {
+		return errors.Wrap(err, "failed to purge uploads")
+	}
+
+	return nil
+}
+
+func getUpload(ctx context.Context, q sqlx.QueryerContext, login, id string) (domain.Upload, error) {
+	var u domain.Upload
+	if err := sqlx.GetContext(ctx, q, &u, `
+		select u.id, u.user_login, u.record_id, u.last_update_date, u.revision, u.size, u.checksum, u.created_at,
+			coalesce((
+				select sum(length(c.data)) from upload_chunks c
+				where c.user_login=u.user_login and c.upload_id=u.id
+			), 0) as received
+		from uploads u
+		where u.user_login=$1 and u.id=$2
+	`, login, id); err != nil {
+		return u, errors.Wrapf(err, "failed to get upload %q", id)
+	}
+
+	return u, nil
+}
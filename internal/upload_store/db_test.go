@@ -0,0 +1,25 @@
+package upload_store
+
+import (
+	"testing"
+
+	"github.com/denistakeda/mpass/internal/db/dbtest"
+	"github.com/denistakeda/mpass/internal/ports"
+)
+
+func Test_UploadStore_Postgres(t *testing.T) {
+	database := dbtest.Postgres(t)
+
+	testUploadStore(t, func(t *testing.T) ports.UploadStore {
+		dbtest.Reset(t, database, "alice", "bob")
+		return NewWithDB(database)
+	})
+}
+
+func Test_UploadStore_SQLite(t *testing.T) {
+	testUploadStore(t, func(t *testing.T) ports.UploadStore {
+		database := dbtest.SQLite(t)
+		dbtest.Reset(t, database, "alice", "bob")
+		return NewWithDB(database)
+	})
+}
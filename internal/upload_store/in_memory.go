@@ -0,0 +1,130 @@
+package upload_store
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/denistakeda/mpass/internal/domain"
+	"github.com/pkg/errors"
+)
+
+type (
+	inMemoryUploadStore struct {
+		mx      sync.Mutex
+		uploads map[uploadKey]*upload
+	}
+
+	uploadKey struct {
+		login string
+		id    string
+	}
+
+	upload struct {
+		domain.Upload
+		payload []byte
+	}
+)
+
+func NewInMemory() *inMemoryUploadStore {
+	return &inMemoryUploadStore{uploads: make(map[uploadKey]*upload)}
+}
+
+// StartUpload creates the upload or returns the unfinished upload of the same payload of the record.
+func (s *inMemoryUploadStore) StartUpload(ctx context.Context, u domain.Upload) (domain.Upload, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	for key, stored := range s.uploads {
+		if key.login == u.Login && stored.RecordID == u.RecordID && bytes.Equal(stored.Checksum, u.Checksum) {
+			stored.LastUpdateDate, stored.Revision = u.LastUpdateDate, u.Revision
+			return stored.Upload, nil
+		}
+	}
+
+	u.Received = 0
+	s.uploads[uploadKey{login: u.Login, id: u.ID}] = &upload{Upload: u}
+
+	return u, nil
+}
+
+func (s *inMemoryUploadStore) GetUpload(ctx context.Context, login, id string) (domain.Upload, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	stored, ok := s.uploads[uploadKey{login: login, id: id}]
+	if !ok {
+		return domain.Upload{}, errors.Errorf("no upload %q", id)
+	}
+
+	return stored.Upload, nil
+}
+
+// AddChunk appends the data to the upload, the offset should match the number of bytes received.
+func (s *inMemoryUploadStore) AddChunk(ctx context.Context, login, id string, offset int64, data []byte) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	stored, ok := s.uploads[uploadKey{login: login, id: id}]
+	if !ok {
+		return errors.Errorf("no upload %q", id)
+	}
+
+	if err := checkChunk(stored.Upload, offset, data); err != nil {
+		return err
+	}
+
+	stored.payload = append(stored.payload, data...)
+	stored.Received += int64(len(data))
+
+	return nil
+}
+
+func (s *inMemoryUploadStore) GetPayload(ctx context.Context, login, id string) ([]byte, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	stored, ok := s.uploads[uploadKey{login: login, id: id}]
+	if !ok {
+		return nil, errors.Errorf("no upload %q", id)
+	}
+
+	return append([]byte(nil), stored.payload...), nil
+}
+
+func (s *inMemoryUploadStore) DeleteUpload(ctx context.Context, login, id string) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	delete(s.uploads, uploadKey{login: login, id: id})
+
+	return nil
+}
+
+// PurgeUploads removes the uploads started before the time and never finished.
+func (s *inMemoryUploadStore) PurgeUploads(ctx context.Context, before time.Time) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	for key, stored := range s.uploads {
+		if stored.CreatedAt.Before(before) {
+			delete(s.uploads, key)
+		}
+	}
+
+	return nil
+}
+
+// checkChunk makes sure that the chunk continues the upload and does not exceed its size.
+func checkChunk(u domain.Upload, offset int64, data []byte) error {
+	if offset != u.Received {
+		return errors.Errorf("upload %q expects offset %d, got %d", u.ID, u.Received, offset)
+	}
+
+	if offset+int64(len(data)) > u.Size {
+		return errors.Errorf("upload %q exceeds the announced size of %d bytes", u.ID, u.Size)
+	}
+
+	return nil
+}
@@ -0,0 +1,13 @@
+package upload_store
+
+import (
+	"testing"
+
+	"github.com/denistakeda/mpass/internal/ports"
+)
+
+func Test_inMemoryUploadStore(t *testing.T) {
+	testUploadStore(t, func(t *testing.T) ports.UploadStore {
+		return NewInMemory()
+	})
+}
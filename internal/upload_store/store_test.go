@@ -0,0 +1,130 @@
+package upload_store
+
+import (
+	"context"
+	"crypto/sha256"
+	"testing"
+	"time"
+
+	"github.com/denistakeda/mpass/internal/domain"
+	"github.com/denistakeda/mpass/internal/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testUploadStore runs the tests every implementation of the upload store should pass.
+// The store should know the users "alice" and "bob".
+func testUploadStore(t *testing.T, newStore func(t *testing.T) ports.UploadStore) {
+	ctx := context.Background()
+	payload := []byte("the payload of the record")
+	checksum := sha256.Sum256(payload)
+	newUpload := func(login, id string) domain.Upload {
+		return domain.Upload{
+			ID:             id,
+			Login:          login,
+			RecordID:       "record",
+			LastUpdateDate: time.Now(),
+			Size:           int64(len(payload)),
+			Checksum:       checksum[:],
+			CreatedAt:      time.Now(),
+		}
+	}
+
+	t.Run("upload in chunks", func(t *testing.T) {
+		s := newStore(t)
+
+		u, err := s.StartUpload(ctx, newUpload("alice", "upload"))
+		require.NoError(t, err)
+		assert.Equal(t, "upload", u.ID)
+		assert.Equal(t, int64(0), u.Received)
+
+		require.NoError(t, s.AddChunk(ctx, "alice", "upload", 0, payload[:10]))
+		require.NoError(t, s.AddChunk(ctx, "alice", "upload", 10, payload[10:]))
+
+		u, err = s.GetUpload(ctx, "alice", "upload")
+		require.NoError(t, err)
+		assert.Equal(t, u.Size, u.Received)
+
+		got, err := s.GetPayload(ctx, "alice", "upload")
+		require.NoError(t, err)
+		assert.Equal(t, payload, got)
+
+		require.NoError(t, s.DeleteUpload(ctx, "alice", "upload"))
+		_, err = s.GetUpload(ctx, "alice", "upload")
+		assert.Error(t, err)
+	})
+
+	t.Run("resume the upload of the same payload", func(t *testing.T) {
+		s := newStore(t)
+
+		_, err := s.StartUpload(ctx, newUpload("alice", "first"))
+		require.NoError(t, err)
+		require.NoError(t, s.AddChunk(ctx, "alice", "first", 0, payload[:10]))
+
+		u, err := s.StartUpload(ctx, newUpload("alice", "second"))
+		require.NoError(t, err)
+		assert.Equal(t, "first", u.ID, "should continue the started upload")
+		assert.Equal(t, int64(10), u.Received)
+
+		other := newUpload("alice", "other")
+		other.Checksum = make([]byte, sha256.Size)
+		u, err = s.StartUpload(ctx, other)
+		require.NoError(t, err)
+		assert.Equal(t, "other", u.ID, "another payload should be uploaded separately")
+	})
+
+	t.Run("reject unexpected chunks", func(t *testing.T) {
+		s := newStore(t)
+
+		_, err := s.StartUpload(ctx, newUpload("alice", "upload"))
+		require.NoError(t, err)
+		require.NoError(t, s.AddChunk(ctx, "alice", "upload", 0, payload[:10]))
+
+		assert.Error(t, s.AddChunk(ctx, "alice", "upload", 0, payload[:10]), "chunk is sent twice")
+		assert.Error(t, s.AddChunk(ctx, "alice", "upload", 20, payload[20:]), "chunk is missed")
+		assert.Error(t, s.AddChunk(ctx, "alice", "upload", 10, append(payload[10:], 'x')), "payload is too big")
+		assert.Error(t, s.AddChunk(ctx, "alice", "unknown", 0, payload))
+
+		u, err := s.GetUpload(ctx, "alice", "upload")
+		require.NoError(t, err)
+		assert.Equal(t, int64(10), u.Received, "rejected chunks should not be stored")
+	})
+
+	t.Run("uploads of different users are isolated", func(t *testing.T) {
+		s := newStore(t)
+
+		_, err := s.StartUpload(ctx, newUpload("alice", "alice-upload"))
+		require.NoError(t, err)
+		require.NoError(t, s.AddChunk(ctx, "alice", "alice-upload", 0, payload[:10]))
+
+		u, err := s.StartUpload(ctx, newUpload("bob", "bob-upload"))
+		require.NoError(t, err)
+		assert.Equal(t, "bob-upload", u.ID)
+		assert.Equal(t, int64(0), u.Received)
+
+		_, err = s.GetUpload(ctx, "bob", "alice-upload")
+		assert.Error(t, err)
+		assert.Error(t, s.AddChunk(ctx, "bob", "alice-upload", 10, payload[10:]))
+	})
+
+	t.Run("purge stale uploads", func(t *testing.T) {
+		s := newStore(t)
+
+		stale := newUpload("alice", "stale")
+		stale.CreatedAt = time.Now().Add(-2 * time.Hour)
+		_, err := s.StartUpload(ctx, stale)
+		require.NoError(t, err)
+		require.NoError(t, s.AddChunk(ctx, "alice", "stale", 0, payload[:10]))
+
+		fresh := newUpload("bob", "fresh")
+		_, err = s.StartUpload(ctx, fresh)
+		require.NoError(t, err)
+
+		require.NoError(t, s.PurgeUploads(ctx, time.Now().Add(-time.Hour)))
+
+		_, err = s.GetUpload(ctx, "alice", "stale")
+		assert.Error(t, err)
+		_, err = s.GetUpload(ctx, "bob", "fresh")
+		assert.NoError(t, err)
+	})
+}
@@ -2,6 +2,7 @@ package user_store
 
 import (
 	"context"
+	"database/sql"
 	"github.com/denistakeda/mpass/internal/domain"
 	"time"
 
@@ -9,6 +10,7 @@ import (
 	"github.com/pkg/errors"
 )
 
+// UserStore keeps the data in the SQL database, both postgres and SQLite are supported.
 type UserStore struct {
 	db *sqlx.DB
 }
@@ -31,7 +33,7 @@ func (u *UserStore) AddNewUser(ctx context.Context, login, passwordHash string)
 func (u *UserStore) GetUser(ctx context.Context, login string) (domain.User, error) {
 	var user domain.User
 	if err := u.db.GetContext(ctx, &user, `
-		select login, password from users
+		select login, password, history_retention, session_stamp from users
 		where login=$1
 	`, login); err != nil {
 		return user, errors.Wrapf(err, "failed to get user %s from the database", login)
@@ -39,3 +41,191 @@ func (u *UserStore) GetUser(ctx context.Context, login string) (domain.User, err
 
 	return user, nil
 }
+
+// InitVaultKey stores the wrapped vault key of the user. Once set, the key can not be overwritten.
+func (u *UserStore) InitVaultKey(ctx context.Context, login string, wrappedKey []byte) error {
+	res, err := u.db.ExecContext(ctx, `
+		update users set vault_key=$1
+		where login=$2 and vault_key is null
+	`, wrappedKey, login)
+	if err != nil {
+		return errors.Wrapf(err, "failed to store vault key of user %s", login)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "failed to store vault key of user %s", login)
+	}
+	if affected == 0 {
+		return errors.Errorf("vault key of user %s is already set", login)
+	}
+
+	return nil
+}
+
+// GetVaultKey returns the wrapped vault key of the user or nil if it was not set yet.
+func (u *UserStore) GetVaultKey(ctx context.Context, login string) ([]byte, error) {
+	var wrappedKey []byte
+	if err := u.db.GetContext(ctx, &wrappedKey, `
+		select vault_key from users
+		where login=$1
+	`, login); err != nil {
+		return nil, errors.Wrapf(err, "failed to get vault key of user %s from the database", login)
+	}
+
+	return wrappedKey, nil
+}
+
+// UpdateCredentials replaces the password hash, the session stamp and, if it is not empty, the wrapped vault key
+// of the user at once, only if the password hash is still the old one.
+func (u *UserStore) UpdateCredentials(ctx context.Context, login, oldHash, newHash, stamp string, wrappedKey []byte) error {
+	if len(wrappedKey) == 0 {
+		wrappedKey = nil // keeps the current key
+	}
+
+	res, err := u.db.ExecContext(ctx, `
+		update users set password=$1, vault_key=coalesce($2, vault_key), session_stamp=$3
+		where login=$4 and password=$5
+	`, newHash, wrappedKey, stamp, login, oldHash)
+	if err != nil {
+		return errors.Wrapf(err, "failed to update credentials of user %s", login)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "failed to update credentials of user %s", login)
+	}
+	if affected == 0 {
+		return errors.Errorf("password of user %s was changed concurrently", login)
+	}
+
+	return nil
+}
+
+// ListUsers returns all the users ordered by login.
+func (u *UserStore) ListUsers(ctx context.Context) ([]domain.User, error) {
+	var users []domain.User
+	if err := u.db.SelectContext(ctx, &users, `
+		select login, password, history_retention, session_stamp from users
+		order by login
+	`); err != nil {
+		return nil, errors.Wrap(err, "failed to list users")
+	}
+
+	return users, nil
+}
+
+// RestoreUser creates the user or overwrites the password hash, the vault key and the settings of the existing one.
+func (u *UserStore) RestoreUser(ctx context.Context, user domain.User, wrappedKey []byte) error {
+	if _, err := u.db.ExecContext(ctx, `
+		insert into users(login, password, created_at, vault_key, history_retention)
+		values ($1, $2, $3, $4, $5)
+		on conflict (login) do update
+		set password=excluded.password, vault_key=excluded.vault_key, history_retention=excluded.history_retention
+	`, user.Login, user.PasswordHash, time.Now(), wrappedKey, user.HistoryRetention); err != nil {
+		return errors.Wrapf(err, "failed to restore user %s", user.Login)
+	}
+
+	return nil
+}
+
+// UpdatePasswordHash replaces the password hash of the user only if it is still the old one.
+func (u *UserStore) UpdatePasswordHash(ctx context.Context, login, oldHash, newHash string) error {
+	res, err := u.db.ExecContext(ctx, `
+		update users set password=$1
+		where login=$2 and password=$3
+	`, newHash, login, oldHash)
+	if err != nil {
+		return errors.Wrapf(err, "failed to update password hash of user %s", login)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "failed to update password hash of user %s", login)
+	}
+	if affected == 0 {
+		return errors.Errorf("password of user %s was changed concurrently", login)
+	}
+
+	return nil
+}
+
+// SetHistoryRetention sets the number of prior versions of every record kept for the user.
+func (u *UserStore) SetHistoryRetention(ctx context.Context, login string, keep int) error {
+	res, err := u.db.ExecContext(ctx, `
+		update users set history_retention=$1
+		where login=$2
+	`, keep, login)
+	if err != nil {
+		return errors.Wrapf(err, "failed to set history retention of user %s", login)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrapf(err, "failed to set history retention of user %s", login)
+	}
+	if affected == 0 {
+		return errors.Errorf("no such user %s", login)
+	}
+
+	return nil
+}
+
+// GetLoginFailures returns the failed sign ins of the user in a row.
+func (u *UserStore) GetLoginFailures(ctx context.Context, login string) (domain.LoginFailures, error) {
+	var row struct {
+		Count       int          `db:"failed_logins"`
+		LockedUntil sql.NullTime `db:"locked_until"`
+	}
+	if err := u.db.GetContext(ctx, &row, `
+		select failed_logins, locked_until from users
+		where login=$1
+	`, login); err != nil {
+		return domain.LoginFailures{}, errors.Wrapf(err, "failed to get failed sign ins of user %s", login)
+	}
+
+	return domain.LoginFailures{Count: row.Count, LockedUntil: row.LockedUntil.Time}, nil
+}
+
+// AddLoginFailure counts the failed sign in and returns the number of the failed sign ins in a row.
+// The counter is incremented by the database, so the concurrent sign ins are all counted.
+func (u *UserStore) AddLoginFailure(ctx context.Context, login string) (int, error) {
+	var count int
+	err := u.db.GetContext(ctx, &count, `
+		update users set failed_logins=failed_logins+1
+		where login=$1
+		returning failed_logins
+	`, login)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to count failed sign in of user %s", login)
+	}
+
+	return count, nil
+}
+
+// LockLogin forbids the user to sign in until the time.
+func (u *UserStore) LockLogin(ctx context.Context, login string, until time.Time) error {
+	if _, err := u.db.ExecContext(ctx, `
+		update users set locked_until=$1
+		where login=$2
+	`, until.UTC(), login); err != nil {
+		return errors.Wrapf(err, "failed to lock user %s", login)
+	}
+
+	return nil
+}
+
+// ResetLoginFailures forgets the failed sign ins and unlocks the login.
+func (u *UserStore) ResetLoginFailures(ctx context.Context, login string) error {
+	if _, err := u.db.ExecContext(ctx, `
+		update users set failed_logins=0, locked_until=null
+		where login=$1
+	`, login); err != nil {
+		return errors.Wrapf(err, "failed to reset failed sign ins of user %s", login)
+	}
+
+	return nil
+}
@@ -0,0 +1,23 @@
+package user_store
+
+import (
+	"testing"
+
+	"github.com/denistakeda/mpass/internal/db/dbtest"
+	"github.com/denistakeda/mpass/internal/ports"
+)
+
+func Test_UserStore_Postgres(t *testing.T) {
+	database := dbtest.Postgres(t)
+
+	testUserStore(t, func(t *testing.T) ports.UserStore {
+		dbtest.Reset(t, database)
+		return NewWithDB(database)
+	})
+}
+
+func Test_UserStore_SQLite(t *testing.T) {
+	testUserStore(t, func(t *testing.T) ports.UserStore {
+		return NewWithDB(dbtest.SQLite(t))
+	})
+}
@@ -2,18 +2,24 @@ package user_store
 
 import (
 	"context"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/denistakeda/mpass/internal/domain"
 	"github.com/pkg/errors"
 )
 
 type inMemoryUserStore struct {
-	users sync.Map
+	users     sync.Map
+	vaultKeys sync.Map
+
+	mu       sync.Mutex
+	failures map[string]domain.LoginFailures
 }
 
 func NewInMemory() *inMemoryUserStore {
-	return &inMemoryUserStore{}
+	return &inMemoryUserStore{failures: make(map[string]domain.LoginFailures)}
 }
 
 func (s *inMemoryUserStore) AddNewUser(ctx context.Context, login, passwordHash string) error {
@@ -33,3 +39,156 @@ func (s *inMemoryUserStore) GetUser(ctx context.Context, login string) (domain.U
 
 	return user.(domain.User), nil
 }
+
+func (s *inMemoryUserStore) InitVaultKey(ctx context.Context, login string, wrappedKey []byte) error {
+	if _, err := s.GetUser(ctx, login); err != nil {
+		return err
+	}
+
+	_, loaded := s.vaultKeys.LoadOrStore(login, wrappedKey)
+	if loaded {
+		return errors.Errorf("vault key of user %q is already set", login)
+	}
+
+	return nil
+}
+
+func (s *inMemoryUserStore) GetVaultKey(ctx context.Context, login string) ([]byte, error) {
+	if _, err := s.GetUser(ctx, login); err != nil {
+		return nil, err
+	}
+
+	wrappedKey, ok := s.vaultKeys.Load(login)
+	if !ok {
+		return nil, nil
+	}
+
+	return wrappedKey.([]byte), nil
+}
+
+func (s *inMemoryUserStore) UpdateCredentials(ctx context.Context, login, oldHash, newHash, stamp string, wrappedKey []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, err := s.GetUser(ctx, login)
+	if err != nil {
+		return err
+	}
+	if user.PasswordHash != oldHash {
+		return errors.Errorf("password of user %q was changed concurrently", login)
+	}
+
+	user.PasswordHash = newHash
+	user.SessionStamp = stamp
+	s.users.Store(login, user)
+	if len(wrappedKey) > 0 {
+		s.vaultKeys.Store(login, wrappedKey)
+	}
+
+	return nil
+}
+
+func (s *inMemoryUserStore) ListUsers(ctx context.Context) ([]domain.User, error) {
+	var users []domain.User
+	s.users.Range(func(_, user any) bool {
+		users = append(users, user.(domain.User))
+		return true
+	})
+	sort.Slice(users, func(i, j int) bool { return users[i].Login < users[j].Login })
+
+	return users, nil
+}
+
+func (s *inMemoryUserStore) RestoreUser(ctx context.Context, user domain.User, wrappedKey []byte) error {
+	s.users.Store(user.Login, user)
+	if wrappedKey == nil {
+		s.vaultKeys.Delete(user.Login)
+	} else {
+		s.vaultKeys.Store(user.Login, wrappedKey)
+	}
+
+	return nil
+}
+
+func (s *inMemoryUserStore) UpdatePasswordHash(ctx context.Context, login, oldHash, newHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, err := s.GetUser(ctx, login)
+	if err != nil {
+		return err
+	}
+	if user.PasswordHash != oldHash {
+		return errors.Errorf("password of user %q was changed concurrently", login)
+	}
+
+	user.PasswordHash = newHash
+	s.users.Store(login, user)
+
+	return nil
+}
+
+func (s *inMemoryUserStore) SetHistoryRetention(ctx context.Context, login string, keep int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, err := s.GetUser(ctx, login)
+	if err != nil {
+		return err
+	}
+
+	user.HistoryRetention = &keep
+	s.users.Store(login, user)
+
+	return nil
+}
+
+func (s *inMemoryUserStore) GetLoginFailures(ctx context.Context, login string) (domain.LoginFailures, error) {
+	if _, err := s.GetUser(ctx, login); err != nil {
+		return domain.LoginFailures{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.failures[login], nil
+}
+
+func (s *inMemoryUserStore) AddLoginFailure(ctx context.Context, login string) (int, error) {
+	if _, err := s.GetUser(ctx, login); err != nil {
+		return 0, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	failures := s.failures[login]
+	failures.Count++
+	s.failures[login] = failures
+
+	return failures.Count, nil
+}
+
+func (s *inMemoryUserStore) LockLogin(ctx context.Context, login string, until time.Time) error {
+	if _, err := s.GetUser(ctx, login); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	failures := s.failures[login]
+	failures.LockedUntil = until
+	s.failures[login] = failures
+
+	return nil
+}
+
+func (s *inMemoryUserStore) ResetLoginFailures(ctx context.Context, login string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.failures, login)
+
+	return nil
+}
@@ -0,0 +1,262 @@
+package user_store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/denistakeda/mpass/internal/domain"
+	"github.com/denistakeda/mpass/internal/ports"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testUserStore runs the tests every implementation of the user store should pass.
+func testUserStore(t *testing.T, newStore func(t *testing.T) ports.UserStore) {
+	t.Run("AddNewUser", func(t *testing.T) { testAddNewUser(t, newStore) })
+	t.Run("GetUser", func(t *testing.T) { testGetUser(t, newStore) })
+	t.Run("VaultKey", func(t *testing.T) { testVaultKey(t, newStore) })
+	t.Run("RestoreUser", func(t *testing.T) { testRestoreUser(t, newStore) })
+	t.Run("UpdatePasswordHash", func(t *testing.T) { testUpdatePasswordHash(t, newStore) })
+	t.Run("HistoryRetention", func(t *testing.T) { testHistoryRetention(t, newStore) })
+	t.Run("LoginFailures", func(t *testing.T) { testLoginFailures(t, newStore) })
+}
+
+func testAddNewUser(t *testing.T, newStore func(t *testing.T) ports.UserStore) {
+	t.Run("add new user", func(t *testing.T) {
+		s := newStore(t)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		assert.NoError(t, s.AddNewUser(ctx, "login", "password"))
+	})
+
+	t.Run("add new user twice should fail", func(t *testing.T) {
+		s := newStore(t)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		assert.NoError(t, s.AddNewUser(ctx, "login", "password"))
+		assert.Error(t, s.AddNewUser(ctx, "login", "password"))
+	})
+}
+
+func testGetUser(t *testing.T, newStore func(t *testing.T) ports.UserStore) {
+	t.Run("request non-existed user", func(t *testing.T) {
+		s := newStore(t)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		_, err := s.GetUser(ctx, "login")
+
+		assert.Error(t, err)
+	})
+
+	t.Run("request existed user", func(t *testing.T) {
+		s := newStore(t)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		user := domain.User{Login: "login", PasswordHash: "password-hash"}
+
+		assert.NoError(t, s.AddNewUser(ctx, user.Login, user.PasswordHash))
+
+		got, err := s.GetUser(ctx, "login")
+
+		assert.NoError(t, err)
+		assert.Equal(t, user, got, "should return correct user")
+	})
+}
+
+func testVaultKey(t *testing.T, newStore func(t *testing.T) ports.UserStore) {
+	t.Run("key of unknown user", func(t *testing.T) {
+		s := newStore(t)
+
+		assert.Error(t, s.InitVaultKey(context.Background(), "login", []byte("key")))
+	})
+
+	t.Run("key is set only once", func(t *testing.T) {
+		s := newStore(t)
+		ctx := context.Background()
+
+		require.NoError(t, s.AddNewUser(ctx, "login", "password"))
+
+		key, err := s.GetVaultKey(ctx, "login")
+		require.NoError(t, err)
+		assert.Nil(t, key, "key should not be set yet")
+
+		require.NoError(t, s.InitVaultKey(ctx, "login", []byte("key")))
+		assert.Error(t, s.InitVaultKey(ctx, "login", []byte("another key")))
+
+		key, err = s.GetVaultKey(ctx, "login")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("key"), key)
+	})
+
+	t.Run("credentials are updated at once", func(t *testing.T) {
+		s := newStore(t)
+		ctx := context.Background()
+
+		require.NoError(t, s.AddNewUser(ctx, "login", "old-hash"))
+		require.NoError(t, s.InitVaultKey(ctx, "login", []byte("key")))
+		require.NoError(t, s.UpdateCredentials(ctx, "login", "old-hash", "new-hash", "stamp", []byte("rewrapped key")))
+
+		user, err := s.GetUser(ctx, "login")
+		require.NoError(t, err)
+		assert.Equal(t, "new-hash", user.PasswordHash)
+		assert.Equal(t, "stamp", user.SessionStamp)
+		key, err := s.GetVaultKey(ctx, "login")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("rewrapped key"), key)
+
+		require.NoError(t, s.UpdateCredentials(ctx, "login", "new-hash", "newer-hash", "new-stamp", nil))
+		key, err = s.GetVaultKey(ctx, "login")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("rewrapped key"), key, "empty key should keep the current one")
+
+		assert.Error(t, s.UpdateCredentials(ctx, "login", "old-hash", "another-hash", "another-stamp", []byte("another key")))
+		user, err = s.GetUser(ctx, "login")
+		require.NoError(t, err)
+		assert.Equal(t, "newer-hash", user.PasswordHash, "nothing should be changed if the password was changed concurrently")
+		assert.Equal(t, "new-stamp", user.SessionStamp, "nothing should be changed if the password was changed concurrently")
+		key, err = s.GetVaultKey(ctx, "login")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("rewrapped key"), key, "nothing should be changed if the password was changed concurrently")
+
+		assert.Error(t, s.UpdateCredentials(ctx, "unknown", "", "hash", "stamp", []byte("key")))
+	})
+}
+
+func testRestoreUser(t *testing.T, newStore func(t *testing.T) ports.UserStore) {
+	t.Run("restore new and existing users", func(t *testing.T) {
+		s := newStore(t)
+		ctx := context.Background()
+
+		require.NoError(t, s.AddNewUser(ctx, "bob", "old-hash"))
+		require.NoError(t, s.InitVaultKey(ctx, "bob", []byte("old key")))
+
+		retention := 3
+		require.NoError(t, s.RestoreUser(ctx, domain.User{Login: "bob", PasswordHash: "hash", HistoryRetention: &retention}, []byte("key")))
+		require.NoError(t, s.RestoreUser(ctx, domain.User{Login: "alice", PasswordHash: "alice-hash"}, nil))
+
+		users, err := s.ListUsers(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []domain.User{
+			{Login: "alice", PasswordHash: "alice-hash"},
+			{Login: "bob", PasswordHash: "hash", HistoryRetention: &retention},
+		}, users)
+
+		key, err := s.GetVaultKey(ctx, "bob")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("key"), key)
+
+		key, err = s.GetVaultKey(ctx, "alice")
+		require.NoError(t, err)
+		assert.Nil(t, key)
+	})
+}
+
+func testHistoryRetention(t *testing.T, newStore func(t *testing.T) ports.UserStore) {
+	t.Run("retention is set", func(t *testing.T) {
+		s := newStore(t)
+		ctx := context.Background()
+
+		require.NoError(t, s.AddNewUser(ctx, "login", "hash"))
+
+		user, err := s.GetUser(ctx, "login")
+		require.NoError(t, err)
+		assert.Nil(t, user.HistoryRetention, "the default should be used until the retention is set")
+
+		require.NoError(t, s.SetHistoryRetention(ctx, "login", 5))
+
+		user, err = s.GetUser(ctx, "login")
+		require.NoError(t, err)
+		if assert.NotNil(t, user.HistoryRetention) {
+			assert.Equal(t, 5, *user.HistoryRetention)
+		}
+	})
+
+	t.Run("retention of unknown user", func(t *testing.T) {
+		s := newStore(t)
+
+		assert.Error(t, s.SetHistoryRetention(context.Background(), "login", 5))
+	})
+}
+
+func testUpdatePasswordHash(t *testing.T, newStore func(t *testing.T) ports.UserStore) {
+	t.Run("hash is replaced", func(t *testing.T) {
+		s := newStore(t)
+		ctx := context.Background()
+
+		require.NoError(t, s.AddNewUser(ctx, "login", "old-hash"))
+		require.NoError(t, s.UpdatePasswordHash(ctx, "login", "old-hash", "new-hash"))
+
+		user, err := s.GetUser(ctx, "login")
+		require.NoError(t, err)
+		assert.Equal(t, "new-hash", user.PasswordHash)
+	})
+
+	t.Run("hash changed concurrently is not replaced", func(t *testing.T) {
+		s := newStore(t)
+		ctx := context.Background()
+
+		require.NoError(t, s.AddNewUser(ctx, "login", "changed-hash"))
+		assert.Error(t, s.UpdatePasswordHash(ctx, "login", "old-hash", "new-hash"))
+
+		user, err := s.GetUser(ctx, "login")
+		require.NoError(t, err)
+		assert.Equal(t, "changed-hash", user.PasswordHash)
+	})
+
+	t.Run("hash of unknown user", func(t *testing.T) {
+		s := newStore(t)
+
+		assert.Error(t, s.UpdatePasswordHash(context.Background(), "login", "old-hash", "new-hash"))
+	})
+}
+
+func testLoginFailures(t *testing.T, newStore func(t *testing.T) ports.UserStore) {
+	t.Run("failures are counted until reset", func(t *testing.T) {
+		s := newStore(t)
+		ctx := context.Background()
+
+		require.NoError(t, s.AddNewUser(ctx, "login", "password"))
+
+		failures, err := s.GetLoginFailures(ctx, "login")
+		require.NoError(t, err)
+		assert.Equal(t, domain.LoginFailures{}, failures)
+
+		for want := 1; want <= 3; want++ {
+			count, err := s.AddLoginFailure(ctx, "login")
+			require.NoError(t, err)
+			assert.Equal(t, want, count)
+		}
+
+		until := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+		require.NoError(t, s.LockLogin(ctx, "login", until))
+
+		failures, err = s.GetLoginFailures(ctx, "login")
+		require.NoError(t, err)
+		assert.Equal(t, 3, failures.Count)
+		assert.True(t, until.Equal(failures.LockedUntil), "expected %s, got %s", until, failures.LockedUntil)
+
+		require.NoError(t, s.ResetLoginFailures(ctx, "login"))
+
+		failures, err = s.GetLoginFailures(ctx, "login")
+		require.NoError(t, err)
+		assert.Equal(t, 0, failures.Count)
+		assert.True(t, failures.LockedUntil.IsZero())
+	})
+
+	t.Run("failures of unknown user are not counted", func(t *testing.T) {
+		s := newStore(t)
+
+		count, err := s.AddLoginFailure(context.Background(), "login")
+		require.NoError(t, err)
+		assert.Equal(t, 0, count)
+	})
+}
@@ -0,0 +1,2 @@
+drop table encrypted_record;
+alter table users drop column vault_key;
@@ -0,0 +1,15 @@
+alter table users add column vault_key bytea;
+
+create table encrypted_record (
+    id varchar(255) not null,
+    last_update_date timestamp not null,
+    user_login varchar(255) not null,
+
+    payload bytea not null,
+
+    primary key (user_login, id),
+
+    constraint fk_encrypted_record_user
+        foreign key(user_login)
+            references users(login)
+);
@@ -0,0 +1,11 @@
+create table tombstone (
+    id varchar(255) not null,
+    deletion_date timestamp not null,
+    user_login varchar(255) not null,
+
+    primary key (user_login, id),
+
+    constraint fk_tombstone_user
+        foreign key(user_login)
+            references users(login)
+);
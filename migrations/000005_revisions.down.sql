@@ -0,0 +1,8 @@
+alter table tombstone drop column revision;
+alter table encrypted_record drop column revision;
+alter table login_password_record drop column revision;
+alter table binary_record drop column revision;
+alter table bank_card_record drop column revision;
+alter table text_record drop column revision;
+
+drop table user_revision;
@@ -0,0 +1,20 @@
+create table user_revision (
+    user_login varchar(255) primary key,
+    revision bigint not null,
+    purged_revision bigint not null default 0,
+
+    constraint fk_user_revision_user
+        foreign key(user_login)
+            references users(login)
+);
+
+-- all the existing records belong to the very first revision
+insert into user_revision (user_login, revision)
+select login, 1 from users;
+
+alter table text_record add column revision bigint not null default 1;
+alter table bank_card_record add column revision bigint not null default 1;
+alter table binary_record add column revision bigint not null default 1;
+alter table login_password_record add column revision bigint not null default 1;
+alter table encrypted_record add column revision bigint not null default 1;
+alter table tombstone add column revision bigint not null default 1;
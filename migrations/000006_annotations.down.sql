@@ -0,0 +1,4 @@
+alter table login_password_record drop column tags, drop column metadata;
+alter table binary_record drop column tags, drop column metadata;
+alter table bank_card_record drop column tags, drop column metadata;
+alter table text_record drop column tags, drop column metadata;
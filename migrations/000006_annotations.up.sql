@@ -0,0 +1,4 @@
+alter table text_record add column metadata jsonb not null default '{}', add column tags jsonb not null default '[]';
+alter table bank_card_record add column metadata jsonb not null default '{}', add column tags jsonb not null default '[]';
+alter table binary_record add column metadata jsonb not null default '{}', add column tags jsonb not null default '[]';
+alter table login_password_record add column metadata jsonb not null default '{}', add column tags jsonb not null default '[]';
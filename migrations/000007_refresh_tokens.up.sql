@@ -0,0 +1,13 @@
+create table refresh_tokens (
+    user_login varchar(255) not null,
+    device varchar(255) not null,
+    token_hash varchar(64) not null unique,
+    created_at timestamp not null,
+    expires_at timestamp not null,
+
+    primary key (user_login, device),
+
+    constraint fk_refresh_tokens_user
+        foreign key(user_login)
+            references users(login)
+);
@@ -0,0 +1,17 @@
+-- fails if different users have records with the same id
+
+alter table text_record drop constraint text_record_pkey;
+alter table text_record add primary key (id);
+alter table text_record alter column user_login drop not null;
+
+alter table bank_card_record drop constraint bank_card_record_pkey;
+alter table bank_card_record add primary key (id);
+alter table bank_card_record alter column user_login drop not null;
+
+alter table binary_record drop constraint binary_record_pkey;
+alter table binary_record add primary key (id);
+alter table binary_record alter column user_login drop not null;
+
+alter table login_password_record drop constraint login_password_record_pkey;
+alter table login_password_record add primary key (id);
+alter table login_password_record alter column user_login drop not null;
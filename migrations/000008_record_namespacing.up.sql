@@ -0,0 +1,22 @@
+-- the records are identified by the id within the account of the user,
+-- the records without an owner could not be read by anyone and are removed
+
+delete from text_record where user_login is null;
+alter table text_record alter column user_login set not null;
+alter table text_record drop constraint text_record_pkey;
+alter table text_record add primary key (user_login, id);
+
+delete from bank_card_record where user_login is null;
+alter table bank_card_record alter column user_login set not null;
+alter table bank_card_record drop constraint bank_card_record_pkey;
+alter table bank_card_record add primary key (user_login, id);
+
+delete from binary_record where user_login is null;
+alter table binary_record alter column user_login set not null;
+alter table binary_record drop constraint binary_record_pkey;
+alter table binary_record add primary key (user_login, id);
+
+delete from login_password_record where user_login is null;
+alter table login_password_record alter column user_login set not null;
+alter table login_password_record drop constraint login_password_record_pkey;
+alter table login_password_record add primary key (user_login, id);
@@ -0,0 +1,113 @@
+create table text_record (
+    id varchar(255) not null,
+    last_update_date timestamp not null,
+    user_login varchar(255) not null,
+    revision bigint not null default 1,
+    metadata jsonb not null default '{}',
+    tags jsonb not null default '[]',
+
+    text text,
+
+    primary key (user_login, id),
+
+    constraint fk_text_record_user
+        foreign key(user_login)
+            references users(login)
+);
+
+create table bank_card_record (
+    id varchar(255) not null,
+    last_update_date timestamp not null,
+    user_login varchar(255) not null,
+    revision bigint not null default 1,
+    metadata jsonb not null default '{}',
+    tags jsonb not null default '[]',
+
+    card_number varchar(16) not null,
+    month int not null,
+    day int not null,
+    code int not null,
+
+    primary key (user_login, id),
+
+    constraint fk_bank_record_user
+        foreign key(user_login)
+            references users(login)
+);
+
+create table binary_record (
+    id varchar(255) not null,
+    last_update_date timestamp not null,
+    user_login varchar(255) not null,
+    revision bigint not null default 1,
+    metadata jsonb not null default '{}',
+    tags jsonb not null default '[]',
+
+    "binary" bytea,
+
+    primary key (user_login, id),
+
+    constraint fk_binary_record_user
+        foreign key(user_login)
+            references users(login)
+);
+
+create table login_password_record (
+    id varchar(255) not null,
+    last_update_date timestamp not null,
+    user_login varchar(255) not null,
+    revision bigint not null default 1,
+    metadata jsonb not null default '{}',
+    tags jsonb not null default '[]',
+
+    login varchar(255) not null,
+    password varchar(255) not null,
+
+    primary key (user_login, id),
+
+    constraint fk_login_password_record_user
+        foreign key(user_login)
+            references users(login)
+);
+
+create table encrypted_record (
+    id varchar(255) not null,
+    last_update_date timestamp not null,
+    user_login varchar(255) not null,
+    revision bigint not null default 1,
+
+    payload bytea not null,
+
+    primary key (user_login, id),
+
+    constraint fk_encrypted_record_user
+        foreign key(user_login)
+            references users(login)
+);
+
+insert into login_password_record (id, last_update_date, user_login, revision, metadata, tags, login, password)
+select id, last_update_date, user_login, revision, metadata, tags,
+    convert_from(payload, 'UTF8')::jsonb->>'login', convert_from(payload, 'UTF8')::jsonb->>'password'
+from records where type='password';
+
+insert into bank_card_record (id, last_update_date, user_login, revision, metadata, tags, card_number, month, day, code)
+select id, last_update_date, user_login, revision, metadata, tags,
+    convert_from(payload, 'UTF8')::jsonb->>'card_number',
+    (convert_from(payload, 'UTF8')::jsonb->>'month')::int,
+    (convert_from(payload, 'UTF8')::jsonb->>'day')::int,
+    (convert_from(payload, 'UTF8')::jsonb->>'code')::int
+from records where type='card';
+
+insert into text_record (id, last_update_date, user_login, revision, metadata, tags, text)
+select id, last_update_date, user_login, revision, metadata, tags, convert_from(payload, 'UTF8')
+from records where type='text';
+
+insert into binary_record (id, last_update_date, user_login, revision, metadata, tags, "binary")
+select id, last_update_date, user_login, revision, metadata, tags, payload
+from records where type='file';
+
+insert into encrypted_record (id, last_update_date, user_login, revision, payload)
+select id, last_update_date, user_login, revision, payload
+from records where type='encrypted';
+
+drop table records;
@@ -0,0 +1,62 @@
+-- all the kinds of records are kept in one table,
+-- the payload is encoded by the codec registered for the type of the record
+create table records (
+    user_login varchar(255) not null,
+    id varchar(255) not null,
+    type varchar(64) not null,
+    revision bigint not null,
+    last_update_date timestamp not null,
+    created_at timestamp not null default now(),
+    updated_at timestamp not null default now(),
+
+    payload bytea not null,
+    metadata jsonb not null default '{}',
+    tags jsonb not null default '[]',
+
+    primary key (user_login, id),
+
+    constraint fk_records_user
+        foreign key(user_login)
+            references users(login)
+);
+
+create index records_user_login_revision on records (user_login, revision);
+
+-- the same id could be used in several legacy tables, the latest version wins
+insert into records (user_login, id, type, revision, last_update_date, payload, metadata, tags)
+select distinct on (user_login, id) user_login, id, type, revision, last_update_date, payload, metadata, tags
+from (
+    select user_login, id, 'password' as type, revision, last_update_date,
+        convert_to(json_build_object('login', login, 'password', password)::text, 'UTF8') as payload,
+        metadata, tags
+    from login_password_record
+
+    union all
+
+    select user_login, id, 'card', revision, last_update_date,
+        convert_to(json_build_object('card_number', card_number, 'month', month, 'day', day, 'code', code)::text, 'UTF8'),
+        metadata, tags
+    from bank_card_record
+
+    union all
+
+    select user_login, id, 'text', revision, last_update_date, convert_to(coalesce(text, ''), 'UTF8'), metadata, tags
+    from text_record
+
+    union all
+
+    select user_login, id, 'file', revision, last_update_date, coalesce("binary", ''::bytea), metadata, tags
+    from binary_record
+
+    union all
+
+    select user_login, id, 'encrypted', revision, last_update_date, payload, '{}'::jsonb, '[]'::jsonb
+    from encrypted_record
+) legacy
+order by user_login, id, last_update_date desc;
+
+drop table login_password_record;
+drop table bank_card_record;
+drop table text_record;
+drop table binary_record;
+drop table encrypted_record;
@@ -0,0 +1,31 @@
+create table uploads (
+    user_login varchar(255) not null,
+    id varchar(64) not null,
+    record_id varchar(255) not null,
+    last_update_date timestamp not null,
+    revision bigint not null,
+    size bigint not null,
+    checksum bytea not null,
+    created_at timestamp not null,
+
+    primary key (user_login, id),
+    unique (user_login, record_id, checksum),
+
+    constraint fk_uploads_user
+        foreign key(user_login)
+            references users(login)
+);
+
+create table upload_chunks (
+    user_login varchar(255) not null,
+    upload_id varchar(64) not null,
+    chunk_offset bigint not null,
+    data bytea not null,
+
+    primary key (user_login, upload_id, chunk_offset),
+
+    constraint fk_upload_chunks_upload
+        foreign key(user_login, upload_id)
+            references uploads(user_login, id)
+            on delete cascade
+);
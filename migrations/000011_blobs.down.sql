@@ -0,0 +1,13 @@
+-- the payloads kept in the blob store can not be moved back to the records by SQL,
+-- so the rollback is refused until no record keeps its payload there
+do $$
+begin
+    if exists (select 1 from records where blob_hash is not null) then
+        raise exception 'records keep their payloads in the blob store, move them back to records.payload before the rollback';
+    end if;
+end
+$$;
+
+alter table records drop column blob_hash;
+
+drop table blobs;
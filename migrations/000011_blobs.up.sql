@@ -0,0 +1,14 @@
+-- the binary payloads are kept in the blob store by the SHA-256 hash of their content,
+-- the blob is removed once no record refers to it
+create table blobs (
+    hash varchar(64) primary key,
+    size bigint not null,
+    ref_count bigint not null,
+    created_at timestamp not null default now()
+);
+
+create index blobs_unreferenced on blobs (hash) where ref_count = 0;
+
+-- the payload of the record is empty when it is kept in the blob store
+alter table records add column blob_hash varchar(64)
+    constraint fk_records_blob references blobs(hash);
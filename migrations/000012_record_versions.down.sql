@@ -0,0 +1,10 @@
+-- the blobs referred only by the versions are left in the blob store
+update blobs set ref_count = ref_count - v.refs
+from (
+    select blob_hash, count(*) as refs from record_versions
+    where blob_hash is not null
+    group by blob_hash
+) v
+where blobs.hash = v.blob_hash;
+
+drop table record_versions;
@@ -0,0 +1,23 @@
+-- the prior versions of the records are kept when they are overwritten or deleted,
+-- the version is the revision the record was stored with
+create table record_versions (
+    user_login varchar(255) not null,
+    id varchar(255) not null,
+    version bigint not null,
+    type varchar(64) not null,
+    last_update_date timestamp not null,
+    replaced_at timestamp not null,
+    deleted boolean not null default false,
+
+    payload bytea not null,
+    blob_hash varchar(64)
+        constraint fk_record_versions_blob references blobs(hash),
+    metadata jsonb not null default '{}',
+    tags jsonb not null default '[]',
+
+    primary key (user_login, id, version),
+
+    constraint fk_record_versions_user
+        foreign key(user_login)
+            references users(login)
+);
@@ -0,0 +1,3 @@
+-- the failed sign ins in a row, the login is locked until locked_until after too many of them
+alter table users add column failed_logins integer not null default 0;
+alter table users add column locked_until timestamp;
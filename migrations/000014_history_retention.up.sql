@@ -0,0 +1,2 @@
+-- the number of prior versions of every record kept for the user, the default of the server is used if null
+alter table users add column history_retention integer;
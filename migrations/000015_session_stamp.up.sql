@@ -0,0 +1,3 @@
+-- the access tokens carry the stamp and are rejected once it changes, the existing tokens are rejected too
+alter table users add column session_stamp varchar(64) not null default '';
+update users set session_stamp = md5(random()::text);
@@ -0,0 +1,5 @@
+drop table user_revision;
+drop table tombstone;
+drop table records;
+drop table refresh_tokens;
+drop table users;
@@ -0,0 +1,68 @@
+-- the schema matches the postgres one after all of its migrations
+
+create table users (
+    id integer primary key autoincrement,
+    login varchar(255) unique not null,
+    password varchar(255) not null,
+    created_at timestamp not null,
+    vault_key blob
+);
+
+create table refresh_tokens (
+    user_login varchar(255) not null,
+    device varchar(255) not null,
+    token_hash varchar(64) not null unique,
+    created_at timestamp not null,
+    expires_at timestamp not null,
+
+    primary key (user_login, device),
+
+    constraint fk_refresh_tokens_user
+        foreign key(user_login)
+            references users(login)
+);
+
+create table records (
+    user_login varchar(255) not null,
+    id varchar(255) not null,
+    type varchar(64) not null,
+    revision bigint not null,
+    last_update_date timestamp not null,
+    created_at timestamp not null default current_timestamp,
+    updated_at timestamp not null default current_timestamp,
+
+    payload blob not null,
+    metadata text not null default '{}',
+    tags text not null default '[]',
+
+    primary key (user_login, id),
+
+    constraint fk_records_user
+        foreign key(user_login)
+            references users(login)
+);
+
+create index records_user_login_revision on records (user_login, revision);
+
+create table tombstone (
+    id varchar(255) not null,
+    deletion_date timestamp not null,
+    user_login varchar(255) not null,
+    revision bigint not null default 1,
+
+    primary key (user_login, id),
+
+    constraint fk_tombstone_user
+        foreign key(user_login)
+            references users(login)
+);
+
+create table user_revision (
+    user_login varchar(255) primary key,
+    revision bigint not null,
+    purged_revision bigint not null default 0,
+
+    constraint fk_user_revision_user
+        foreign key(user_login)
+            references users(login)
+);
@@ -0,0 +1,2 @@
+drop table upload_chunks;
+drop table uploads;
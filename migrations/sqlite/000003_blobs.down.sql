@@ -0,0 +1,13 @@
+-- the payloads kept in the blob store can not be moved back to the records by SQL,
+-- so the rollback is refused until no record keeps its payload there
+create temp table blob_check (
+    blob_records integer
+        constraint "records keep their payloads in the blob store, move them back to records.payload before the rollback"
+        check (blob_records = 0)
+);
+insert into blob_check select count(*) from records where blob_hash is not null;
+drop table blob_check;
+
+alter table records drop column blob_hash;
+
+drop table blobs;
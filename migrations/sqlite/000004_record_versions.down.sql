@@ -0,0 +1,6 @@
+-- the blobs referred only by the versions are left in the blob store
+update blobs set ref_count = ref_count - (
+    select count(*) from record_versions v where v.blob_hash = blobs.hash
+);
+
+drop table record_versions;
@@ -0,0 +1,2 @@
+alter table users drop column locked_until;
+alter table users drop column failed_logins;
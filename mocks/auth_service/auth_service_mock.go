@@ -7,6 +7,7 @@ package auth_service_mock
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	domain "github.com/denistakeda/mpass/internal/domain"
 	gomock "github.com/golang/mock/gomock"
@@ -35,6 +36,21 @@ func (m *MockuserStore) EXPECT() *MockuserStoreMockRecorder {
 	return m.recorder
 }
 
+// AddLoginFailure mocks base method.
+func (m *MockuserStore) AddLoginFailure(ctx context.Context, login string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddLoginFailure", ctx, login)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddLoginFailure indicates an expected call of AddLoginFailure.
+func (mr *MockuserStoreMockRecorder) AddLoginFailure(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddLoginFailure", reflect.TypeOf((*MockuserStore)(nil).AddLoginFailure), ctx, login)
+}
+
 // AddNewUser mocks base method.
 func (m *MockuserStore) AddNewUser(ctx context.Context, login, passwordHash string) error {
 	m.ctrl.T.Helper()
@@ -49,6 +65,21 @@ func (mr *MockuserStoreMockRecorder) AddNewUser(ctx, login, passwordHash interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddNewUser", reflect.TypeOf((*MockuserStore)(nil).AddNewUser), ctx, login, passwordHash)
 }
 
+// GetLoginFailures mocks base method.
+func (m *MockuserStore) GetLoginFailures(ctx context.Context, login string) (domain.LoginFailures, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginFailures", ctx, login)
+	ret0, _ := ret[0].(domain.LoginFailures)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoginFailures indicates an expected call of GetLoginFailures.
+func (mr *MockuserStoreMockRecorder) GetLoginFailures(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginFailures", reflect.TypeOf((*MockuserStore)(nil).GetLoginFailures), ctx, login)
+}
+
 // GetUser mocks base method.
 func (m *MockuserStore) GetUser(ctx context.Context, login string) (domain.User, error) {
 	m.ctrl.T.Helper()
@@ -63,3 +94,208 @@ func (mr *MockuserStoreMockRecorder) GetUser(ctx, login interface{}) *gomock.Cal
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockuserStore)(nil).GetUser), ctx, login)
 }
+
+// GetVaultKey mocks base method.
+func (m *MockuserStore) GetVaultKey(ctx context.Context, login string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVaultKey", ctx, login)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVaultKey indicates an expected call of GetVaultKey.
+func (mr *MockuserStoreMockRecorder) GetVaultKey(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVaultKey", reflect.TypeOf((*MockuserStore)(nil).GetVaultKey), ctx, login)
+}
+
+// InitVaultKey mocks base method.
+func (m *MockuserStore) InitVaultKey(ctx context.Context, login string, wrappedKey []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InitVaultKey", ctx, login, wrappedKey)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InitVaultKey indicates an expected call of InitVaultKey.
+func (mr *MockuserStoreMockRecorder) InitVaultKey(ctx, login, wrappedKey interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InitVaultKey", reflect.TypeOf((*MockuserStore)(nil).InitVaultKey), ctx, login, wrappedKey)
+}
+
+// LockLogin mocks base method.
+func (m *MockuserStore) LockLogin(ctx context.Context, login string, until time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockLogin", ctx, login, until)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockLogin indicates an expected call of LockLogin.
+func (mr *MockuserStoreMockRecorder) LockLogin(ctx, login, until interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockLogin", reflect.TypeOf((*MockuserStore)(nil).LockLogin), ctx, login, until)
+}
+
+// ResetLoginFailures mocks base method.
+func (m *MockuserStore) ResetLoginFailures(ctx context.Context, login string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetLoginFailures", ctx, login)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetLoginFailures indicates an expected call of ResetLoginFailures.
+func (mr *MockuserStoreMockRecorder) ResetLoginFailures(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetLoginFailures", reflect.TypeOf((*MockuserStore)(nil).ResetLoginFailures), ctx, login)
+}
+
+// UpdateCredentials mocks base method.
+func (m *MockuserStore) UpdateCredentials(ctx context.Context, login, oldHash, newHash, stamp string, wrappedKey []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCredentials", ctx, login, oldHash, newHash, stamp, wrappedKey)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateCredentials indicates an expected call of UpdateCredentials.
+func (mr *MockuserStoreMockRecorder) UpdateCredentials(ctx, login, oldHash, newHash, stamp, wrappedKey interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCredentials", reflect.TypeOf((*MockuserStore)(nil).UpdateCredentials), ctx, login, oldHash, newHash, stamp, wrappedKey)
+}
+
+// UpdatePasswordHash mocks base method.
+func (m *MockuserStore) UpdatePasswordHash(ctx context.Context, login, oldHash, newHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePasswordHash", ctx, login, oldHash, newHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePasswordHash indicates an expected call of UpdatePasswordHash.
+func (mr *MockuserStoreMockRecorder) UpdatePasswordHash(ctx, login, oldHash, newHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePasswordHash", reflect.TypeOf((*MockuserStore)(nil).UpdatePasswordHash), ctx, login, oldHash, newHash)
+}
+
+// MocktokenStore is a mock of tokenStore interface.
+type MocktokenStore struct {
+	ctrl     *gomock.Controller
+	recorder *MocktokenStoreMockRecorder
+}
+
+// MocktokenStoreMockRecorder is the mock recorder for MocktokenStore.
+type MocktokenStoreMockRecorder struct {
+	mock *MocktokenStore
+}
+
+// NewMocktokenStore creates a new mock instance.
+func NewMocktokenStore(ctrl *gomock.Controller) *MocktokenStore {
+	mock := &MocktokenStore{ctrl: ctrl}
+	mock.recorder = &MocktokenStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MocktokenStore) EXPECT() *MocktokenStoreMockRecorder {
+	return m.recorder
+}
+
+// AddRefreshToken mocks base method.
+func (m *MocktokenStore) AddRefreshToken(ctx context.Context, token domain.RefreshToken) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddRefreshToken", ctx, token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddRefreshToken indicates an expected call of AddRefreshToken.
+func (mr *MocktokenStoreMockRecorder) AddRefreshToken(ctx, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRefreshToken", reflect.TypeOf((*MocktokenStore)(nil).AddRefreshToken), ctx, token)
+}
+
+// RevokeRefreshTokens mocks base method.
+func (m *MocktokenStore) RevokeRefreshTokens(ctx context.Context, login string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRefreshTokens", ctx, login)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeRefreshTokens indicates an expected call of RevokeRefreshTokens.
+func (mr *MocktokenStoreMockRecorder) RevokeRefreshTokens(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRefreshTokens", reflect.TypeOf((*MocktokenStore)(nil).RevokeRefreshTokens), ctx, login)
+}
+
+// TakeRefreshToken mocks base method.
+func (m *MocktokenStore) TakeRefreshToken(ctx context.Context, hash string) (domain.RefreshToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakeRefreshToken", ctx, hash)
+	ret0, _ := ret[0].(domain.RefreshToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TakeRefreshToken indicates an expected call of TakeRefreshToken.
+func (mr *MocktokenStoreMockRecorder) TakeRefreshToken(ctx, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeRefreshToken", reflect.TypeOf((*MocktokenStore)(nil).TakeRefreshToken), ctx, hash)
+}
+
+// MockpasswordHasher is a mock of passwordHasher interface.
+type MockpasswordHasher struct {
+	ctrl     *gomock.Controller
+	recorder *MockpasswordHasherMockRecorder
+}
+
+// MockpasswordHasherMockRecorder is the mock recorder for MockpasswordHasher.
+type MockpasswordHasherMockRecorder struct {
+	mock *MockpasswordHasher
+}
+
+// NewMockpasswordHasher creates a new mock instance.
+func NewMockpasswordHasher(ctrl *gomock.Controller) *MockpasswordHasher {
+	mock := &MockpasswordHasher{ctrl: ctrl}
+	mock.recorder = &MockpasswordHasherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockpasswordHasher) EXPECT() *MockpasswordHasherMockRecorder {
+	return m.recorder
+}
+
+// Hash mocks base method.
+func (m *MockpasswordHasher) Hash(password string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Hash", password)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Hash indicates an expected call of Hash.
+func (mr *MockpasswordHasherMockRecorder) Hash(password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Hash", reflect.TypeOf((*MockpasswordHasher)(nil).Hash), password)
+}
+
+// Verify mocks base method.
+func (m *MockpasswordHasher) Verify(hash, password string) (bool, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Verify", hash, password)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Verify indicates an expected call of Verify.
+func (mr *MockpasswordHasherMockRecorder) Verify(hash, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verify", reflect.TypeOf((*MockpasswordHasher)(nil).Verify), hash, password)
+}
@@ -9,6 +9,7 @@ import (
 	reflect "reflect"
 
 	domain "github.com/denistakeda/mpass/internal/domain"
+	record "github.com/denistakeda/mpass/internal/domain/record"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -50,32 +51,304 @@ func (mr *MockauthServiceMockRecorder) AuthenticateUser(ctx, token interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthenticateUser", reflect.TypeOf((*MockauthService)(nil).AuthenticateUser), ctx, token)
 }
 
+// ChangePassword mocks base method.
+func (m *MockauthService) ChangePassword(ctx context.Context, login, oldPassword, newPassword string, wrappedKey []byte, device string) (domain.Tokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", ctx, login, oldPassword, newPassword, wrappedKey, device)
+	ret0, _ := ret[0].(domain.Tokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockauthServiceMockRecorder) ChangePassword(ctx, login, oldPassword, newPassword, wrappedKey, device interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockauthService)(nil).ChangePassword), ctx, login, oldPassword, newPassword, wrappedKey, device)
+}
+
+// GetVaultKey mocks base method.
+func (m *MockauthService) GetVaultKey(ctx context.Context, login string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVaultKey", ctx, login)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVaultKey indicates an expected call of GetVaultKey.
+func (mr *MockauthServiceMockRecorder) GetVaultKey(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVaultKey", reflect.TypeOf((*MockauthService)(nil).GetVaultKey), ctx, login)
+}
+
+// InitVaultKey mocks base method.
+func (m *MockauthService) InitVaultKey(ctx context.Context, login string, wrappedKey []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InitVaultKey", ctx, login, wrappedKey)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InitVaultKey indicates an expected call of InitVaultKey.
+func (mr *MockauthServiceMockRecorder) InitVaultKey(ctx, login, wrappedKey interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InitVaultKey", reflect.TypeOf((*MockauthService)(nil).InitVaultKey), ctx, login, wrappedKey)
+}
+
+// Logout mocks base method.
+func (m *MockauthService) Logout(ctx context.Context, refreshToken string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Logout", ctx, refreshToken)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Logout indicates an expected call of Logout.
+func (mr *MockauthServiceMockRecorder) Logout(ctx, refreshToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockauthService)(nil).Logout), ctx, refreshToken)
+}
+
+// RefreshToken mocks base method.
+func (m *MockauthService) RefreshToken(ctx context.Context, refreshToken string) (domain.Tokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshToken", ctx, refreshToken)
+	ret0, _ := ret[0].(domain.Tokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefreshToken indicates an expected call of RefreshToken.
+func (mr *MockauthServiceMockRecorder) RefreshToken(ctx, refreshToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshToken", reflect.TypeOf((*MockauthService)(nil).RefreshToken), ctx, refreshToken)
+}
+
 // SignIn mocks base method.
-func (m *MockauthService) SignIn(ctx context.Context, login, password string) (string, error) {
+func (m *MockauthService) SignIn(ctx context.Context, login, password, device string) (domain.Tokens, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SignIn", ctx, login, password)
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "SignIn", ctx, login, password, device)
+	ret0, _ := ret[0].(domain.Tokens)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // SignIn indicates an expected call of SignIn.
-func (mr *MockauthServiceMockRecorder) SignIn(ctx, login, password interface{}) *gomock.Call {
+func (mr *MockauthServiceMockRecorder) SignIn(ctx, login, password, device interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignIn", reflect.TypeOf((*MockauthService)(nil).SignIn), ctx, login, password)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignIn", reflect.TypeOf((*MockauthService)(nil).SignIn), ctx, login, password, device)
 }
 
 // SignUp mocks base method.
-func (m *MockauthService) SignUp(ctx context.Context, login, password string) (string, error) {
+func (m *MockauthService) SignUp(ctx context.Context, login, password, device string) (domain.Tokens, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SignUp", ctx, login, password)
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "SignUp", ctx, login, password, device)
+	ret0, _ := ret[0].(domain.Tokens)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // SignUp indicates an expected call of SignUp.
-func (mr *MockauthServiceMockRecorder) SignUp(ctx, login, password interface{}) *gomock.Call {
+func (mr *MockauthServiceMockRecorder) SignUp(ctx, login, password, device interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignUp", reflect.TypeOf((*MockauthService)(nil).SignUp), ctx, login, password, device)
+}
+
+// MockrecordService is a mock of recordService interface.
+type MockrecordService struct {
+	ctrl     *gomock.Controller
+	recorder *MockrecordServiceMockRecorder
+}
+
+// MockrecordServiceMockRecorder is the mock recorder for MockrecordService.
+type MockrecordServiceMockRecorder struct {
+	mock *MockrecordService
+}
+
+// NewMockrecordService creates a new mock instance.
+func NewMockrecordService(ctrl *gomock.Controller) *MockrecordService {
+	mock := &MockrecordService{ctrl: ctrl}
+	mock.recorder = &MockrecordServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockrecordService) EXPECT() *MockrecordServiceMockRecorder {
+	return m.recorder
+}
+
+// AddRecords mocks base method.
+func (m *MockrecordService) AddRecords(ctx context.Context, login string, records []record.Record) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddRecords", ctx, login, records)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddRecords indicates an expected call of AddRecords.
+func (mr *MockrecordServiceMockRecorder) AddRecords(ctx, login, records interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRecords", reflect.TypeOf((*MockrecordService)(nil).AddRecords), ctx, login, records)
+}
+
+// AllRecords mocks base method.
+func (m *MockrecordService) AllRecords(ctx context.Context, login string) ([]record.Record, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AllRecords", ctx, login)
+	ret0, _ := ret[0].([]record.Record)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AllRecords indicates an expected call of AllRecords.
+func (mr *MockrecordServiceMockRecorder) AllRecords(ctx, login interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllRecords", reflect.TypeOf((*MockrecordService)(nil).AllRecords), ctx, login)
+}
+
+// DeleteRecords mocks base method.
+func (m *MockrecordService) DeleteRecords(ctx context.Context, login string, tombstones []record.Tombstone) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRecords", ctx, login, tombstones)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRecords indicates an expected call of DeleteRecords.
+func (mr *MockrecordServiceMockRecorder) DeleteRecords(ctx, login, tombstones interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRecords", reflect.TypeOf((*MockrecordService)(nil).DeleteRecords), ctx, login, tombstones)
+}
+
+// FinishUpload mocks base method.
+func (m *MockrecordService) FinishUpload(ctx context.Context, login, uploadID string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FinishUpload", ctx, login, uploadID)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FinishUpload indicates an expected call of FinishUpload.
+func (mr *MockrecordServiceMockRecorder) FinishUpload(ctx, login, uploadID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FinishUpload", reflect.TypeOf((*MockrecordService)(nil).FinishUpload), ctx, login, uploadID)
+}
+
+// GetChangesSince mocks base method.
+func (m *MockrecordService) GetChangesSince(ctx context.Context, login string, revision int64) (record.Changes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChangesSince", ctx, login, revision)
+	ret0, _ := ret[0].(record.Changes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChangesSince indicates an expected call of GetChangesSince.
+func (mr *MockrecordServiceMockRecorder) GetChangesSince(ctx, login, revision interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChangesSince", reflect.TypeOf((*MockrecordService)(nil).GetChangesSince), ctx, login, revision)
+}
+
+// GetRecord mocks base method.
+func (m *MockrecordService) GetRecord(ctx context.Context, login, id string) (record.Record, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRecord", ctx, login, id)
+	ret0, _ := ret[0].(record.Record)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRecord indicates an expected call of GetRecord.
+func (mr *MockrecordServiceMockRecorder) GetRecord(ctx, login, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecord", reflect.TypeOf((*MockrecordService)(nil).GetRecord), ctx, login, id)
+}
+
+// GetRecordVersion mocks base method.
+func (m *MockrecordService) GetRecordVersion(ctx context.Context, login, id string, version int64) (record.Record, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRecordVersion", ctx, login, id, version)
+	ret0, _ := ret[0].(record.Record)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRecordVersion indicates an expected call of GetRecordVersion.
+func (mr *MockrecordServiceMockRecorder) GetRecordVersion(ctx, login, id, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecordVersion", reflect.TypeOf((*MockrecordService)(nil).GetRecordVersion), ctx, login, id, version)
+}
+
+// ListRecordVersions mocks base method.
+func (m *MockrecordService) ListRecordVersions(ctx context.Context, login, id string) ([]record.Version, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRecordVersions", ctx, login, id)
+	ret0, _ := ret[0].([]record.Version)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRecordVersions indicates an expected call of ListRecordVersions.
+func (mr *MockrecordServiceMockRecorder) ListRecordVersions(ctx, login, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRecordVersions", reflect.TypeOf((*MockrecordService)(nil).ListRecordVersions), ctx, login, id)
+}
+
+// PurgeRecords mocks base method.
+func (m *MockrecordService) PurgeRecords(ctx context.Context, login string, ids []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeRecords", ctx, login, ids)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PurgeRecords indicates an expected call of PurgeRecords.
+func (mr *MockrecordServiceMockRecorder) PurgeRecords(ctx, login, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeRecords", reflect.TypeOf((*MockrecordService)(nil).PurgeRecords), ctx, login, ids)
+}
+
+// SetHistoryRetention mocks base method.
+func (m *MockrecordService) SetHistoryRetention(ctx context.Context, login string, keep int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetHistoryRetention", ctx, login, keep)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetHistoryRetention indicates an expected call of SetHistoryRetention.
+func (mr *MockrecordServiceMockRecorder) SetHistoryRetention(ctx, login, keep interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetHistoryRetention", reflect.TypeOf((*MockrecordService)(nil).SetHistoryRetention), ctx, login, keep)
+}
+
+// StartUpload mocks base method.
+func (m *MockrecordService) StartUpload(ctx context.Context, login string, upload domain.Upload) (domain.Upload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartUpload", ctx, login, upload)
+	ret0, _ := ret[0].(domain.Upload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StartUpload indicates an expected call of StartUpload.
+func (mr *MockrecordServiceMockRecorder) StartUpload(ctx, login, upload interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartUpload", reflect.TypeOf((*MockrecordService)(nil).StartUpload), ctx, login, upload)
+}
+
+// UploadChunk mocks base method.
+func (m *MockrecordService) UploadChunk(ctx context.Context, login, uploadID string, offset int64, data []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadChunk", ctx, login, uploadID, offset, data)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UploadChunk indicates an expected call of UploadChunk.
+func (mr *MockrecordServiceMockRecorder) UploadChunk(ctx, login, uploadID, offset, data interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignUp", reflect.TypeOf((*MockauthService)(nil).SignUp), ctx, login, password)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadChunk", reflect.TypeOf((*MockrecordService)(nil).UploadChunk), ctx, login, uploadID, offset, data)
 }
@@ -20,10 +20,23 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	MpassService_SignUp_FullMethodName     = "/pb.MpassService/SignUp"
-	MpassService_SignIn_FullMethodName     = "/pb.MpassService/SignIn"
-	MpassService_AddRecords_FullMethodName = "/pb.MpassService/AddRecords"
-	MpassService_AllRecords_FullMethodName = "/pb.MpassService/AllRecords"
+	MpassService_SignUp_FullMethodName              = "/pb.MpassService/SignUp"
+	MpassService_SignIn_FullMethodName              = "/pb.MpassService/SignIn"
+	MpassService_AddRecords_FullMethodName          = "/pb.MpassService/AddRecords"
+	MpassService_AllRecords_FullMethodName          = "/pb.MpassService/AllRecords"
+	MpassService_DeleteRecords_FullMethodName       = "/pb.MpassService/DeleteRecords"
+	MpassService_GetChangesSince_FullMethodName     = "/pb.MpassService/GetChangesSince"
+	MpassService_InitVaultKey_FullMethodName        = "/pb.MpassService/InitVaultKey"
+	MpassService_GetVaultKey_FullMethodName         = "/pb.MpassService/GetVaultKey"
+	MpassService_RefreshToken_FullMethodName        = "/pb.MpassService/RefreshToken"
+	MpassService_Logout_FullMethodName              = "/pb.MpassService/Logout"
+	MpassService_ChangePassword_FullMethodName      = "/pb.MpassService/ChangePassword"
+	MpassService_StartUpload_FullMethodName         = "/pb.MpassService/StartUpload"
+	MpassService_UploadRecord_FullMethodName        = "/pb.MpassService/UploadRecord"
+	MpassService_DownloadRecord_FullMethodName      = "/pb.MpassService/DownloadRecord"
+	MpassService_ListRecordVersions_FullMethodName  = "/pb.MpassService/ListRecordVersions"
+	MpassService_GetRecordVersion_FullMethodName    = "/pb.MpassService/GetRecordVersion"
+	MpassService_SetHistoryRetention_FullMethodName = "/pb.MpassService/SetHistoryRetention"
 )
 
 // MpassServiceClient is the client API for MpassService service.
@@ -32,8 +45,21 @@ const (
 type MpassServiceClient interface {
 	SignUp(ctx context.Context, in *SignUpRequest, opts ...grpc.CallOption) (*SignUpResponse, error)
 	SignIn(ctx context.Context, in *SignInRequest, opts ...grpc.CallOption) (*SignInResponse, error)
-	AddRecords(ctx context.Context, in *AddRecordsRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	AddRecords(ctx context.Context, in *AddRecordsRequest, opts ...grpc.CallOption) (*AddRecordsResponse, error)
 	AllRecords(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*AllRecordsResponse, error)
+	DeleteRecords(ctx context.Context, in *DeleteRecordsRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	GetChangesSince(ctx context.Context, in *GetChangesSinceRequest, opts ...grpc.CallOption) (*GetChangesSinceResponse, error)
+	InitVaultKey(ctx context.Context, in *VaultKey, opts ...grpc.CallOption) (*empty.Empty, error)
+	GetVaultKey(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*VaultKey, error)
+	RefreshToken(ctx context.Context, in *RefreshTokenRequest, opts ...grpc.CallOption) (*RefreshTokenResponse, error)
+	Logout(ctx context.Context, in *LogoutRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	ChangePassword(ctx context.Context, in *ChangePasswordRequest, opts ...grpc.CallOption) (*ChangePasswordResponse, error)
+	StartUpload(ctx context.Context, in *StartUploadRequest, opts ...grpc.CallOption) (*StartUploadResponse, error)
+	UploadRecord(ctx context.Context, opts ...grpc.CallOption) (MpassService_UploadRecordClient, error)
+	DownloadRecord(ctx context.Context, in *DownloadRecordRequest, opts ...grpc.CallOption) (MpassService_DownloadRecordClient, error)
+	ListRecordVersions(ctx context.Context, in *ListRecordVersionsRequest, opts ...grpc.CallOption) (*ListRecordVersionsResponse, error)
+	GetRecordVersion(ctx context.Context, in *GetRecordVersionRequest, opts ...grpc.CallOption) (*Record, error)
+	SetHistoryRetention(ctx context.Context, in *SetHistoryRetentionRequest, opts ...grpc.CallOption) (*empty.Empty, error)
 }
 
 type mpassServiceClient struct {
@@ -62,8 +88,8 @@ func (c *mpassServiceClient) SignIn(ctx context.Context, in *SignInRequest, opts
 	return out, nil
 }
 
-func (c *mpassServiceClient) AddRecords(ctx context.Context, in *AddRecordsRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
-	out := new(empty.Empty)
+func (c *mpassServiceClient) AddRecords(ctx context.Context, in *AddRecordsRequest, opts ...grpc.CallOption) (*AddRecordsResponse, error) {
+	out := new(AddRecordsResponse)
 	err := c.cc.Invoke(ctx, MpassService_AddRecords_FullMethodName, in, out, opts...)
 	if err != nil {
 		return nil, err
@@ -80,14 +106,192 @@ func (c *mpassServiceClient) AllRecords(ctx context.Context, in *empty.Empty, op
 	return out, nil
 }
 
+func (c *mpassServiceClient) DeleteRecords(ctx context.Context, in *DeleteRecordsRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, MpassService_DeleteRecords_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mpassServiceClient) GetChangesSince(ctx context.Context, in *GetChangesSinceRequest, opts ...grpc.CallOption) (*GetChangesSinceResponse, error) {
+	out := new(GetChangesSinceResponse)
+	err := c.cc.Invoke(ctx, MpassService_GetChangesSince_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mpassServiceClient) InitVaultKey(ctx context.Context, in *VaultKey, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, MpassService_InitVaultKey_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mpassServiceClient) GetVaultKey(ctx context.Context, in *empty.Empty, opts ...grpc.CallOption) (*VaultKey, error) {
+	out := new(VaultKey)
+	err := c.cc.Invoke(ctx, MpassService_GetVaultKey_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mpassServiceClient) RefreshToken(ctx context.Context, in *RefreshTokenRequest, opts ...grpc.CallOption) (*RefreshTokenResponse, error) {
+	out := new(RefreshTokenResponse)
+	err := c.cc.Invoke(ctx, MpassService_RefreshToken_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mpassServiceClient) Logout(ctx context.Context, in *LogoutRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, MpassService_Logout_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mpassServiceClient) ChangePassword(ctx context.Context, in *ChangePasswordRequest, opts ...grpc.CallOption) (*ChangePasswordResponse, error) {
+	out := new(ChangePasswordResponse)
+	err := c.cc.Invoke(ctx, MpassService_ChangePassword_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mpassServiceClient) StartUpload(ctx context.Context, in *StartUploadRequest, opts ...grpc.CallOption) (*StartUploadResponse, error) {
+	out := new(StartUploadResponse)
+	err := c.cc.Invoke(ctx, MpassService_StartUpload_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mpassServiceClient) UploadRecord(ctx context.Context, opts ...grpc.CallOption) (MpassService_UploadRecordClient, error) {
+	stream, err := c.cc.NewStream(ctx, &MpassService_ServiceDesc.Streams[0], MpassService_UploadRecord_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &mpassServiceUploadRecordClient{stream}
+	return x, nil
+}
+
+type MpassService_UploadRecordClient interface {
+	Send(*RecordChunk) error
+	CloseAndRecv() (*AddRecordsResponse, error)
+	grpc.ClientStream
+}
+
+type mpassServiceUploadRecordClient struct {
+	grpc.ClientStream
+}
+
+func (x *mpassServiceUploadRecordClient) Send(m *RecordChunk) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *mpassServiceUploadRecordClient) CloseAndRecv() (*AddRecordsResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(AddRecordsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *mpassServiceClient) DownloadRecord(ctx context.Context, in *DownloadRecordRequest, opts ...grpc.CallOption) (MpassService_DownloadRecordClient, error) {
+	stream, err := c.cc.NewStream(ctx, &MpassService_ServiceDesc.Streams[1], MpassService_DownloadRecord_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &mpassServiceDownloadRecordClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type MpassService_DownloadRecordClient interface {
+	Recv() (*RecordChunk, error)
+	grpc.ClientStream
+}
+
+type mpassServiceDownloadRecordClient struct {
+	grpc.ClientStream
+}
+
+func (x *mpassServiceDownloadRecordClient) Recv() (*RecordChunk, error) {
+	m := new(RecordChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *mpassServiceClient) ListRecordVersions(ctx context.Context, in *ListRecordVersionsRequest, opts ...grpc.CallOption) (*ListRecordVersionsResponse, error) {
+	out := new(ListRecordVersionsResponse)
+	err := c.cc.Invoke(ctx, MpassService_ListRecordVersions_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mpassServiceClient) GetRecordVersion(ctx context.Context, in *GetRecordVersionRequest, opts ...grpc.CallOption) (*Record, error) {
+	out := new(Record)
+	err := c.cc.Invoke(ctx, MpassService_GetRecordVersion_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mpassServiceClient) SetHistoryRetention(ctx context.Context, in *SetHistoryRetentionRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, MpassService_SetHistoryRetention_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // MpassServiceServer is the server API for MpassService service.
 // All implementations must embed UnimplementedMpassServiceServer
 // for forward compatibility
 type MpassServiceServer interface {
 	SignUp(context.Context, *SignUpRequest) (*SignUpResponse, error)
 	SignIn(context.Context, *SignInRequest) (*SignInResponse, error)
-	AddRecords(context.Context, *AddRecordsRequest) (*empty.Empty, error)
+	AddRecords(context.Context, *AddRecordsRequest) (*AddRecordsResponse, error)
 	AllRecords(context.Context, *empty.Empty) (*AllRecordsResponse, error)
+	DeleteRecords(context.Context, *DeleteRecordsRequest) (*empty.Empty, error)
+	GetChangesSince(context.Context, *GetChangesSinceRequest) (*GetChangesSinceResponse, error)
+	InitVaultKey(context.Context, *VaultKey) (*empty.Empty, error)
+	GetVaultKey(context.Context, *empty.Empty) (*VaultKey, error)
+	RefreshToken(context.Context, *RefreshTokenRequest) (*RefreshTokenResponse, error)
+	Logout(context.Context, *LogoutRequest) (*empty.Empty, error)
+	ChangePassword(context.Context, *ChangePasswordRequest) (*ChangePasswordResponse, error)
+	StartUpload(context.Context, *StartUploadRequest) (*StartUploadResponse, error)
+	UploadRecord(MpassService_UploadRecordServer) error
+	DownloadRecord(*DownloadRecordRequest, MpassService_DownloadRecordServer) error
+	ListRecordVersions(context.Context, *ListRecordVersionsRequest) (*ListRecordVersionsResponse, error)
+	GetRecordVersion(context.Context, *GetRecordVersionRequest) (*Record, error)
+	SetHistoryRetention(context.Context, *SetHistoryRetentionRequest) (*empty.Empty, error)
 	mustEmbedUnimplementedMpassServiceServer()
 }
 
@@ -101,12 +305,51 @@ func (UnimplementedMpassServiceServer) SignUp(context.Context, *SignUpRequest) (
 func (UnimplementedMpassServiceServer) SignIn(context.Context, *SignInRequest) (*SignInResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SignIn not implemented")
 }
-func (UnimplementedMpassServiceServer) AddRecords(context.Context, *AddRecordsRequest) (*empty.Empty, error) {
+func (UnimplementedMpassServiceServer) AddRecords(context.Context, *AddRecordsRequest) (*AddRecordsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AddRecords not implemented")
 }
 func (UnimplementedMpassServiceServer) AllRecords(context.Context, *empty.Empty) (*AllRecordsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AllRecords not implemented")
 }
+func (UnimplementedMpassServiceServer) DeleteRecords(context.Context, *DeleteRecordsRequest) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteRecords not implemented")
+}
+func (UnimplementedMpassServiceServer) GetChangesSince(context.Context, *GetChangesSinceRequest) (*GetChangesSinceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetChangesSince not implemented")
+}
+func (UnimplementedMpassServiceServer) InitVaultKey(context.Context, *VaultKey) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method InitVaultKey not implemented")
+}
+func (UnimplementedMpassServiceServer) GetVaultKey(context.Context, *empty.Empty) (*VaultKey, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetVaultKey not implemented")
+}
+func (UnimplementedMpassServiceServer) RefreshToken(context.Context, *RefreshTokenRequest) (*RefreshTokenResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RefreshToken not implemented")
+}
+func (UnimplementedMpassServiceServer) Logout(context.Context, *LogoutRequest) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Logout not implemented")
+}
+func (UnimplementedMpassServiceServer) ChangePassword(context.Context, *ChangePasswordRequest) (*ChangePasswordResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ChangePassword not implemented")
+}
+func (UnimplementedMpassServiceServer) StartUpload(context.Context, *StartUploadRequest) (*StartUploadResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartUpload not implemented")
+}
+func (UnimplementedMpassServiceServer) UploadRecord(MpassService_UploadRecordServer) error {
+	return status.Errorf(codes.Unimplemented, "method UploadRecord not implemented")
+}
+func (UnimplementedMpassServiceServer) DownloadRecord(*DownloadRecordRequest, MpassService_DownloadRecordServer) error {
+	return status.Errorf(codes.Unimplemented, "method DownloadRecord not implemented")
+}
+func (UnimplementedMpassServiceServer) ListRecordVersions(context.Context, *ListRecordVersionsRequest) (*ListRecordVersionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRecordVersions not implemented")
+}
+func (UnimplementedMpassServiceServer) GetRecordVersion(context.Context, *GetRecordVersionRequest) (*Record, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRecordVersion not implemented")
+}
+func (UnimplementedMpassServiceServer) SetHistoryRetention(context.Context, *SetHistoryRetentionRequest) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetHistoryRetention not implemented")
+}
 func (UnimplementedMpassServiceServer) mustEmbedUnimplementedMpassServiceServer() {}
 
 // UnsafeMpassServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -192,6 +435,251 @@ func _MpassService_AllRecords_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _MpassService_DeleteRecords_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRecordsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MpassServiceServer).DeleteRecords(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MpassService_DeleteRecords_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MpassServiceServer).DeleteRecords(ctx, req.(*DeleteRecordsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MpassService_GetChangesSince_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetChangesSinceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MpassServiceServer).GetChangesSince(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MpassService_GetChangesSince_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MpassServiceServer).GetChangesSince(ctx, req.(*GetChangesSinceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MpassService_InitVaultKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VaultKey)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MpassServiceServer).InitVaultKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MpassService_InitVaultKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MpassServiceServer).InitVaultKey(ctx, req.(*VaultKey))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MpassService_GetVaultKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(empty.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MpassServiceServer).GetVaultKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MpassService_GetVaultKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MpassServiceServer).GetVaultKey(ctx, req.(*empty.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MpassService_RefreshToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RefreshTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MpassServiceServer).RefreshToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MpassService_RefreshToken_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MpassServiceServer).RefreshToken(ctx, req.(*RefreshTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MpassService_Logout_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LogoutRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MpassServiceServer).Logout(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MpassService_Logout_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MpassServiceServer).Logout(ctx, req.(*LogoutRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MpassService_ChangePassword_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChangePasswordRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MpassServiceServer).ChangePassword(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MpassService_ChangePassword_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MpassServiceServer).ChangePassword(ctx, req.(*ChangePasswordRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MpassService_StartUpload_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StartUploadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MpassServiceServer).StartUpload(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MpassService_StartUpload_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MpassServiceServer).StartUpload(ctx, req.(*StartUploadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MpassService_UploadRecord_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(MpassServiceServer).UploadRecord(&mpassServiceUploadRecordServer{stream})
+}
+
+type MpassService_UploadRecordServer interface {
+	SendAndClose(*AddRecordsResponse) error
+	Recv() (*RecordChunk, error)
+	grpc.ServerStream
+}
+
+type mpassServiceUploadRecordServer struct {
+	grpc.ServerStream
+}
+
+func (x *mpassServiceUploadRecordServer) SendAndClose(m *AddRecordsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *mpassServiceUploadRecordServer) Recv() (*RecordChunk, error) {
+	m := new(RecordChunk)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _MpassService_DownloadRecord_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DownloadRecordRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MpassServiceServer).DownloadRecord(m, &mpassServiceDownloadRecordServer{stream})
+}
+
+type MpassService_DownloadRecordServer interface {
+	Send(*RecordChunk) error
+	grpc.ServerStream
+}
+
+type mpassServiceDownloadRecordServer struct {
+	grpc.ServerStream
+}
+
+func (x *mpassServiceDownloadRecordServer) Send(m *RecordChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _MpassService_ListRecordVersions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRecordVersionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MpassServiceServer).ListRecordVersions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MpassService_ListRecordVersions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MpassServiceServer).ListRecordVersions(ctx, req.(*ListRecordVersionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MpassService_GetRecordVersion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRecordVersionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MpassServiceServer).GetRecordVersion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MpassService_GetRecordVersion_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MpassServiceServer).GetRecordVersion(ctx, req.(*GetRecordVersionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MpassService_SetHistoryRetention_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetHistoryRetentionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MpassServiceServer).SetHistoryRetention(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MpassService_SetHistoryRetention_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MpassServiceServer).SetHistoryRetention(ctx, req.(*SetHistoryRetentionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // MpassService_ServiceDesc is the grpc.ServiceDesc for MpassService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -215,7 +703,62 @@ var MpassService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "AllRecords",
 			Handler:    _MpassService_AllRecords_Handler,
 		},
+		{
+			MethodName: "DeleteRecords",
+			Handler:    _MpassService_DeleteRecords_Handler,
+		},
+		{
+			MethodName: "GetChangesSince",
+			Handler:    _MpassService_GetChangesSince_Handler,
+		},
+		{
+			MethodName: "InitVaultKey",
+			Handler:    _MpassService_InitVaultKey_Handler,
+		},
+		{
+			MethodName: "GetVaultKey",
+			Handler:    _MpassService_GetVaultKey_Handler,
+		},
+		{
+			MethodName: "RefreshToken",
+			Handler:    _MpassService_RefreshToken_Handler,
+		},
+		{
+			MethodName: "Logout",
+			Handler:    _MpassService_Logout_Handler,
+		},
+		{
+			MethodName: "ChangePassword",
+			Handler:    _MpassService_ChangePassword_Handler,
+		},
+		{
+			MethodName: "StartUpload",
+			Handler:    _MpassService_StartUpload_Handler,
+		},
+		{
+			MethodName: "ListRecordVersions",
+			Handler:    _MpassService_ListRecordVersions_Handler,
+		},
+		{
+			MethodName: "GetRecordVersion",
+			Handler:    _MpassService_GetRecordVersion_Handler,
+		},
+		{
+			MethodName: "SetHistoryRetention",
+			Handler:    _MpassService_SetHistoryRetention_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "UploadRecord",
+			Handler:       _MpassService_UploadRecord_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "DownloadRecord",
+			Handler:       _MpassService_DownloadRecord_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/mpass.proto",
 }